@@ -0,0 +1,375 @@
+// Package breakpad writes Breakpad symbol files (.sym) from PDB files.
+//
+// The output has the records of the Windows dump_syms tool: MODULE,
+// INFO CODE_ID, FILE, FUNC with line records, PUBLIC, STACK WIN (x86 frame
+// data) and STACK CFI (x64 unwind information, which requires the
+// executable). It is not byte-for-byte identical to dump_syms, and has not
+// been compared with it on real PDBs:
+//
+//   - FILE records are numbered in name order, where dump_syms uses DIA's
+//     internal file ids. Processors only use the numbers to match line
+//     records to FILE records, so this does not change symbolication.
+//   - Function names are formatted from the PDB's types in the style of the
+//     MSVC undecorator, where dump_syms asks DIA for them; names with types
+//     the formatting does not cover may be spelled differently.
+package breakpad
+
+import (
+	"bufio"
+	"debug/pe"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// Options controls symbol file generation.
+type Options struct {
+	// DebugFile is the PDB file name written on the MODULE line.
+	DebugFile string
+
+	// PE is the executable matching the PDB. It provides the INFO CODE_ID
+	// record and the x64 unwind information. Optional.
+	PE *pe.File
+
+	// CodeFile is the executable file name written on the INFO CODE_ID line.
+	CodeFile string
+}
+
+// function is a FUNC record with its line records.
+type function struct {
+	rva       uint32
+	size      uint32
+	paramSize uint32
+	name      string
+	multiple  bool
+	module    int
+	lines     []line
+}
+
+type line struct {
+	rva    uint32
+	size   uint32
+	number uint32
+	file   string
+}
+
+type public struct {
+	rva       uint32
+	paramSize uint32
+	name      string
+	multiple  bool
+}
+
+// Write writes the Breakpad symbol file for a PDB.
+func Write(w io.Writer, f *pdb.File, opts Options) error {
+	info, err := f.Info()
+	if err != nil {
+		return err
+	}
+	machine, err := f.Machine()
+	if err != nil {
+		return err
+	}
+	symTable, err := f.Symbols()
+	if err != nil {
+		return err
+	}
+	// Parameter types are optional; without them C++ names have no parameters
+	types, _ := f.Types()
+
+	var img *image
+	if opts.PE != nil {
+		if img, err = newImage(opts.PE); err != nil {
+			return err
+		}
+	}
+
+	// Addresses are computed from the PDB's copy of the section headers,
+	// falling back to the executable's
+	sections, err := f.Sections()
+	if err != nil {
+		if img == nil {
+			return fmt.Errorf("breakpad: section headers are required for addresses: %w", err)
+		}
+		sections = img.sectionHeaders()
+	}
+
+	frameData, err := f.FrameData()
+	if err != nil {
+		return err
+	}
+	fpoData, err := f.FPOData()
+	if err != nil {
+		return err
+	}
+
+	// x86 functions report their parameter size from the frame data
+	paramSizes := make(map[uint32]uint32)
+	if machine == pdb.MachineI386 {
+		for _, fd := range fpoData {
+			paramSizes[fd.RVA] = uint32(fd.Params) * 4
+		}
+		for _, fd := range frameData {
+			paramSizes[fd.RVA] = fd.ParamsSize
+		}
+	}
+
+	// Code publics by address; the decorated names tell C++ from C functions
+	publicsByRVA := make(map[uint32][]string)
+	for sym := range symTable.Public() {
+		if !sym.IsCode() {
+			continue
+		}
+		rva := sections.ToRVA(sym.Section(), sym.Offset())
+		if rva == 0 {
+			continue
+		}
+		publicsByRVA[rva] = append(publicsByRVA[rva], sym.Name())
+	}
+
+	functions, err := collectFunctions(f, sections, types, publicsByRVA, paramSizes)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "MODULE windows %s %s %s\n", arch(machine), debugID(info), opts.DebugFile)
+	if img != nil {
+		fmt.Fprintf(bw, "INFO CODE_ID %s %s\n", img.codeID(), opts.CodeFile)
+	}
+
+	// The files used by line records are numbered in name order, as
+	// Breakpad's Module writer does, rather than with the DIA file ids of
+	// dump_syms on Windows, which cannot be derived from the PDB.
+	var files []string
+	seen := make(map[string]bool)
+	for _, fn := range functions {
+		for _, l := range fn.lines {
+			if !seen[l.file] {
+				seen[l.file] = true
+				files = append(files, l.file)
+			}
+		}
+	}
+	slices.Sort(files)
+	fileIDs := make(map[string]int, len(files))
+	for i, name := range files {
+		fileIDs[name] = i
+		fmt.Fprintf(bw, "FILE %d %s\n", i, name)
+	}
+
+	funcStarts := make(map[uint32]bool, len(functions))
+	for _, fn := range functions {
+		funcStarts[fn.rva] = true
+		fmt.Fprintf(bw, "FUNC %s%x %x %x %s\n", multipleMarker(fn.multiple), fn.rva, fn.size, fn.paramSize, fn.name)
+		for _, l := range fn.lines {
+			fmt.Fprintf(bw, "%x %x %d %d\n", l.rva, l.size, l.number, fileIDs[l.file])
+		}
+	}
+
+	for _, p := range collectPublics(publicsByRVA, funcStarts, paramSizes) {
+		fmt.Fprintf(bw, "PUBLIC %s%x %x %s\n", multipleMarker(p.multiple), p.rva, p.paramSize, p.name)
+	}
+
+	writeStackWin(bw, frameData, fpoData)
+	if img != nil && machine == pdb.MachineAMD64 {
+		writeStackCFI(bw, img)
+	}
+
+	return bw.Flush()
+}
+
+func collectFunctions(f *pdb.File, sections *pdb.SectionHeaders, types *pdb.TypeTable,
+	publicsByRVA map[uint32][]string, paramSizes map[uint32]uint32) ([]*function, error) {
+	modules, err := f.Modules()
+	if err != nil {
+		return nil, err
+	}
+
+	byRVA := make(map[uint32]*function)
+	var functions []*function
+
+	for _, mod := range modules {
+		var modFuncs []*function
+		for sym := range mod.Symbols() {
+			fn, ok := sym.(*pdb.FunctionSymbol)
+			if !ok || fn.Length() == 0 {
+				continue
+			}
+			rva := sections.ToRVA(fn.Section(), fn.Offset())
+			if rva == 0 {
+				continue
+			}
+
+			// Identical code folding leaves several functions at one address
+			if existing, ok := byRVA[rva]; ok {
+				existing.multiple = true
+				continue
+			}
+
+			var decorated string
+			if names := publicsByRVA[rva]; len(names) > 0 {
+				decorated = names[0]
+			}
+
+			rec := &function{
+				rva:       rva,
+				size:      fn.Length(),
+				paramSize: paramSizes[rva],
+				name:      functionName(types, fn, decorated),
+				module:    mod.Index(),
+			}
+			byRVA[rva] = rec
+			functions = append(functions, rec)
+			modFuncs = append(modFuncs, rec)
+		}
+
+		if len(modFuncs) == 0 {
+			continue
+		}
+
+		blocks, err := mod.Lines()
+		if err != nil {
+			return nil, err
+		}
+		assignLines(modFuncs, blocks, sections)
+	}
+
+	sort.Slice(functions, func(i, j int) bool { return functions[i].rva < functions[j].rva })
+	return functions, nil
+}
+
+// assignLines attaches the line records of a module to its functions.
+func assignLines(funcs []*function, blocks []pdb.LineBlock, sections *pdb.SectionHeaders) {
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].rva < funcs[j].rva })
+
+	for _, b := range blocks {
+		for _, l := range b.Lines {
+			if l.IsHidden() || l.Length == 0 {
+				continue
+			}
+			rva := sections.ToRVA(b.Section, l.Offset)
+
+			i := sort.Search(len(funcs), func(i int) bool { return funcs[i].rva+funcs[i].size > rva })
+			if i == len(funcs) || funcs[i].rva > rva {
+				continue
+			}
+
+			fn := funcs[i]
+			size := min(l.Length, fn.rva+fn.size-rva)
+			fn.lines = append(fn.lines, line{rva: rva, size: size, number: l.LineStart, file: b.FileName})
+		}
+	}
+
+	for _, fn := range funcs {
+		sort.SliceStable(fn.lines, func(i, j int) bool { return fn.lines[i].rva < fn.lines[j].rva })
+	}
+}
+
+// collectPublics returns the code publics that do not start a function.
+func collectPublics(publicsByRVA map[uint32][]string, funcStarts map[uint32]bool,
+	paramSizes map[uint32]uint32) []public {
+	var publics []public
+	for rva, names := range publicsByRVA {
+		if funcStarts[rva] {
+			continue
+		}
+		// The decoration of a C name gives the parameter size
+		name, paramSize := publicName(slices.Min(names))
+		if paramSize < 0 {
+			paramSize = int(paramSizes[rva])
+		}
+		publics = append(publics, public{
+			rva:       rva,
+			paramSize: uint32(paramSize),
+			name:      name,
+			multiple:  len(names) > 1,
+		})
+	}
+	sort.Slice(publics, func(i, j int) bool { return publics[i].rva < publics[j].rva })
+	return publics
+}
+
+// writeStackWin writes STACK WIN records for x86 frame data and FPO data.
+func writeStackWin(w io.Writer, frameData []pdb.FrameData, fpoData []pdb.FPOData) {
+	const frameTypeFrameData = 4
+
+	sorted := slices.Clone(frameData)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].RVA < sorted[j].RVA })
+	for i, fd := range sorted {
+		if i > 0 && sorted[i-1].RVA == fd.RVA {
+			continue
+		}
+		fmt.Fprintf(w, "STACK WIN %x %x %x %x %x %x %x %x %x ",
+			frameTypeFrameData, fd.RVA, fd.CodeSize, fd.PrologSize, 0,
+			fd.ParamsSize, fd.SavedRegsSize, fd.LocalSize, fd.MaxStackSize)
+		if fd.Program != "" {
+			fmt.Fprintf(w, "1 %s\n", fd.Program)
+		} else {
+			fmt.Fprintf(w, "0 0\n")
+		}
+	}
+
+	sortedFPO := slices.Clone(fpoData)
+	sort.SliceStable(sortedFPO, func(i, j int) bool { return sortedFPO[i].RVA < sortedFPO[j].RVA })
+	for i, fd := range sortedFPO {
+		if i > 0 && sortedFPO[i-1].RVA == fd.RVA {
+			continue
+		}
+		usesBP := 0
+		if fd.UsesBP {
+			usesBP = 1
+		}
+		fmt.Fprintf(w, "STACK WIN %x %x %x %x %x %x %x %x %x 0 %d\n",
+			fd.FrameType, fd.RVA, fd.CodeSize, fd.PrologSize, 0,
+			uint32(fd.Params)*4, uint32(fd.SavedRegs)*4, fd.Locals*4, 0, usesBP)
+	}
+}
+
+// writeStackCFI writes STACK CFI records for the x64 runtime functions of
+// an executable.
+func writeStackCFI(w io.Writer, img *image) {
+	for _, fn := range img.runtimeFunctions() {
+		if fn.end <= fn.begin {
+			continue
+		}
+		stackSize, raOffset := img.frameSize(fn)
+		fmt.Fprintf(w, "STACK CFI INIT %x %x .cfa: $rsp .ra: .cfa %d - ^\n", fn.begin, fn.end-fn.begin, raOffset)
+		fmt.Fprintf(w, "STACK CFI %x .cfa: $rsp %d +\n", fn.begin, stackSize)
+	}
+}
+
+func multipleMarker(multiple bool) string {
+	if multiple {
+		return "m "
+	}
+	return ""
+}
+
+// arch returns the Breakpad CPU name for a machine type.
+func arch(machine uint16) string {
+	switch machine {
+	case pdb.MachineI386:
+		return "x86"
+	case pdb.MachineAMD64:
+		return "x86_64"
+	case pdb.MachineARM64:
+		return "arm64"
+	case pdb.MachineARMNT:
+		return "arm"
+	default:
+		return "unknown"
+	}
+}
+
+// debugID formats the PDB GUID and age as a Breakpad debug identifier.
+func debugID(info *pdb.PDBInfo) string {
+	g := info.GUID
+	return fmt.Sprintf("%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%x",
+		g[3], g[2], g[1], g[0], g[5], g[4], g[7], g[6],
+		g[8], g[9], g[10], g[11], g[12], g[13], g[14], g[15], info.Age)
+}
@@ -0,0 +1,115 @@
+package breakpad
+
+import (
+	"bytes"
+	"debug/pe"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// The golden files were written by this package with -update and checked
+// by hand against the record formats. They are not dump_syms output, which
+// numbers FILE records differently (see the package documentation): the
+// test catches changes in the output, not differences from dump_syms.
+func TestWriteGolden(t *testing.T) {
+	tests := []struct {
+		pdb, exe, golden string
+	}{
+		{"x86.pdb", "", "x86.sym"},
+		{"x64.pdb", "x64.exe", "x64.sym"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			f, err := pdb.Open(filepath.Join("..", "testdata", tt.pdb))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			opts := Options{DebugFile: tt.pdb}
+			if tt.exe != "" {
+				img, err := pe.Open(filepath.Join("..", "testdata", tt.exe))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer img.Close()
+				opts.PE, opts.CodeFile = img, tt.exe
+			}
+
+			var buf bytes.Buffer
+			if err := Write(&buf, f, opts); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.Bytes(); !bytes.Equal(got, want) {
+				t.Errorf("output differs from %s:\n%s", golden, diffLines(string(want), string(got)))
+			}
+		})
+	}
+}
+
+func TestPublicName(t *testing.T) {
+	tests := []struct {
+		decorated string
+		name      string
+		paramSize int
+	}{
+		{"_main", "main", -1},
+		{"_Add@8", "Add", 8},
+		{"@Fast@12", "Fast", 12},
+		{"main", "main", -1},
+		{"Vector@@16", "Vector@@16", -1},
+		{"_", "_", -1},
+		{"?Get@?$Holder@$0A@@@QEBAHXZ", "Holder<0>::Get()", -1},
+		{"?Log@@YAXPEBDZZ", "Log(char const *,...)", -1},
+		{"?push_back@?$vector@HV?$allocator@H@std@@@std@@QEAAXAEBH@Z",
+			"std::vector<int,std::allocator<int> >::push_back(int const &)", -1},
+		{"?Sort@@YAXPEAHP6A_NHH@Z@Z", "Sort(int *,bool (*)(int,int))", -1},
+		{"?g_count@@3HA", "g_count", -1},
+	}
+
+	for _, tt := range tests {
+		name, paramSize := publicName(tt.decorated)
+		if name != tt.name || paramSize != tt.paramSize {
+			t.Errorf("publicName(%q) = %q, %d; want %q, %d", tt.decorated, name, paramSize, tt.name, tt.paramSize)
+		}
+	}
+}
+
+// diffLines lists the lines of want and got that differ.
+func diffLines(want, got string) string {
+	w := bytes.Split([]byte(want), []byte("\n"))
+	g := bytes.Split([]byte(got), []byte("\n"))
+
+	var out bytes.Buffer
+	for i := range max(len(w), len(g)) {
+		var wl, gl []byte
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if !bytes.Equal(wl, gl) {
+			out.WriteString("-" + string(wl) + "\n+" + string(gl) + "\n")
+		}
+	}
+	return out.String()
+}
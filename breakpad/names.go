@@ -0,0 +1,193 @@
+package breakpad
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/skdltmxn/pdb-go/demangle"
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// maxTypeDepth bounds recursion when formatting self-referencing types.
+const maxTypeDepth = 16
+
+// Primitive type names as printed by the MSVC undecorator.
+var undnamePrimitives = map[string]string{
+	"int8_t":   "signed char",
+	"uint8_t":  "unsigned char",
+	"int64_t":  "__int64",
+	"uint64_t": "unsigned __int64",
+}
+
+// functionName returns the name of a function as dump_syms prints it:
+// the qualified name followed by the parameter types, without return type,
+// calling convention or access specifier. C functions keep their plain name.
+func functionName(types *pdb.TypeTable, fn *pdb.FunctionSymbol, decorated string) string {
+	name := fn.Name()
+	if !isCxxName(name, decorated) || types == nil {
+		return name
+	}
+
+	sig, err := fn.Signature()
+	if err != nil {
+		return name
+	}
+
+	params := make([]string, 0, len(sig.Parameters)+1)
+	for _, p := range sig.Parameters {
+		params = append(params, formatType(types, p.Type, 0))
+	}
+	if sig.IsVariadic {
+		params = append(params, "...")
+	}
+
+	// dump_syms turns the "(void)" of functions without parameters into "()"
+	return name + "(" + strings.Join(params, ",") + ")"
+}
+
+// isCxxName reports whether a function has C++ linkage: either its public
+// symbol has a C++ decorated name, or it has no public symbol and a
+// qualified name.
+func isCxxName(name, decorated string) bool {
+	if decorated != "" {
+		return demangle.IsMangled(decorated)
+	}
+	return strings.Contains(name, "::") || strings.Contains(name, "`")
+}
+
+// publicName undecorates a public symbol name the way dump_syms does. The
+// second result is the size of the stack parameters recorded in the
+// decoration of a C name, or -1.
+func publicName(decorated string) (string, int) {
+	if !demangle.IsMangled(decorated) {
+		return undecorateCName(decorated)
+	}
+
+	node, err := demangle.DemangleToNode(decorated)
+	if err != nil {
+		return decorated, -1
+	}
+
+	// dump_syms passes UNDNAME_NO_ECSU among others, separates parameters
+	// with a bare comma and writes "()" for an empty parameter list
+	opts := demangle.NoTagKeywords | demangle.NoPtr64 | demangle.NoCallingConvention | demangle.MSVCSpacing
+	fn, ok := node.(*demangle.FunctionSymbol)
+	if !ok {
+		return demangle.Format(node, opts|demangle.NameOnly), -1
+	}
+
+	var params []string
+	if fn.Signature != nil {
+		for _, p := range fn.Signature.Parameters {
+			params = append(params, demangle.Format(p, opts))
+		}
+		if fn.Signature.IsVariadic {
+			params = append(params, "...")
+		}
+	}
+	return demangle.Format(fn.Name, opts) + "(" + strings.Join(params, ",") + ")", -1
+}
+
+// undecorateCName removes the decoration of __cdecl, __stdcall and
+// __fastcall names as dump_syms does, on every architecture: the leading '_'
+// or '@', and the trailing '@' followed by the size of the stack parameters,
+// which is returned (-1 if absent).
+func undecorateCName(name string) (string, int) {
+	if len(name) < 2 || (name[0] != '_' && name[0] != '@') {
+		return name, -1
+	}
+	if at := strings.LastIndexByte(name[1:], '@') + 1; at > 0 {
+		digits := name[at+1:]
+		n := 0
+		for n < len(digits) && digits[n] >= '0' && digits[n] <= '9' {
+			n++
+		}
+		if size, err := strconv.Atoi(digits[:n]); err == nil {
+			return name[1:at], size
+		}
+	}
+	if name[0] == '_' {
+		return name[1:], -1
+	}
+	return name, -1
+}
+
+// formatType renders a type in MSVC undecorator style, e.g. "char const *".
+func formatType(tt *pdb.TypeTable, index pdb.TypeIndex, depth int) string {
+	if depth > maxTypeDepth {
+		return "..."
+	}
+
+	typ, err := tt.ByIndex(index)
+	if err != nil {
+		return "?"
+	}
+
+	switch t := typ.(type) {
+	case *pdb.PrimitiveType:
+		name := t.Name()
+		if mapped, ok := undnamePrimitives[name]; ok {
+			name = mapped
+		}
+		if t.IsPointer() {
+			return name + " *"
+		}
+		return name
+
+	case *pdb.PointerType:
+		suffix := " *"
+		if t.IsReference() {
+			suffix = " &"
+		} else if t.IsRValueRef() {
+			suffix = " &&"
+		}
+
+		// Pointers to functions are written around the declarator
+		if sig, err := tt.Signature(t.ReferentType()); err == nil {
+			return formatFunctionPointer(tt, sig, strings.TrimSpace(suffix), depth)
+		}
+
+		s := formatType(tt, t.ReferentType(), depth+1) + suffix
+		if t.IsConst() {
+			s += " const"
+		}
+		if t.IsVolatile() {
+			s += " volatile"
+		}
+		return s
+
+	case *pdb.ModifierType:
+		s := formatType(tt, t.ModifiedType(), depth+1)
+		if t.IsConst() {
+			s += " const"
+		}
+		if t.IsVolatile() {
+			s += " volatile"
+		}
+		return s
+
+	case *pdb.ArrayType:
+		return formatType(tt, t.ElementType(), depth+1) + " *"
+
+	default:
+		if typ.Name() != "" {
+			return typ.Name()
+		}
+		return "?"
+	}
+}
+
+func formatFunctionPointer(tt *pdb.TypeTable, sig *pdb.Signature, declarator string, depth int) string {
+	params := make([]string, 0, len(sig.Parameters)+1)
+	for _, p := range sig.Parameters {
+		params = append(params, formatType(tt, p.Type, depth+1))
+	}
+	if sig.IsVariadic {
+		params = append(params, "...")
+	}
+	if len(params) == 0 {
+		params = append(params, "void")
+	}
+
+	return formatType(tt, sig.ReturnType, depth+1) + " (" + declarator + ")(" + strings.Join(params, ",") + ")"
+}
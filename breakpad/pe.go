@@ -0,0 +1,210 @@
+package breakpad
+
+import (
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// x64 unwind operation codes (UWOP_*)
+const (
+	uwopPushNonvol    = 0
+	uwopAllocLarge    = 1
+	uwopAllocSmall    = 2
+	uwopSetFPReg      = 3
+	uwopSaveNonvol    = 4
+	uwopSaveNonvolFar = 5
+	uwopSaveXMM       = 6 // UWOP_EPILOG in version 2
+	uwopSaveXMMFar    = 7
+	uwopSaveXMM128    = 8
+	uwopSaveXMM128Far = 9
+	uwopPushMachFrame = 10
+)
+
+// unwFlagChainInfo marks UNWIND_INFO that continues in a chained entry.
+const unwFlagChainInfo = 0x4
+
+// imageDirectoryEntryException is the index of the exception directory.
+const imageDirectoryEntryException = 3
+
+// runtimeFunction is an x64 RUNTIME_FUNCTION entry of the exception
+// directory.
+type runtimeFunction struct {
+	begin      uint32
+	end        uint32
+	unwindInfo uint32
+}
+
+// image gives RVA-based access to the contents of a PE file.
+type image struct {
+	file        *pe.File
+	timestamp   uint32
+	sizeOfImage uint32
+	sectionData map[*pe.Section][]byte
+}
+
+func newImage(f *pe.File) (*image, error) {
+	img := &image{
+		file:        f,
+		timestamp:   f.FileHeader.TimeDateStamp,
+		sectionData: make(map[*pe.Section][]byte),
+	}
+
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		img.sizeOfImage = oh.SizeOfImage
+	case *pe.OptionalHeader64:
+		img.sizeOfImage = oh.SizeOfImage
+	default:
+		return nil, fmt.Errorf("breakpad: PE file has no optional header")
+	}
+
+	return img, nil
+}
+
+// codeID returns the identifier symbol servers use for the executable.
+func (img *image) codeID() string {
+	return fmt.Sprintf("%08X%x", img.timestamp, img.sizeOfImage)
+}
+
+// read returns n bytes at the given RVA.
+func (img *image) read(rva, n uint32) ([]byte, bool) {
+	for _, s := range img.file.Sections {
+		if rva < s.VirtualAddress || rva >= s.VirtualAddress+max(s.VirtualSize, s.Size) {
+			continue
+		}
+
+		data, ok := img.sectionData[s]
+		if !ok {
+			data, _ = s.Data()
+			img.sectionData[s] = data
+		}
+
+		off := uint64(rva - s.VirtualAddress)
+		if off+uint64(n) > uint64(len(data)) {
+			return nil, false
+		}
+		return data[off : off+uint64(n)], true
+	}
+	return nil, false
+}
+
+// sectionHeaders returns the executable's section headers.
+func (img *image) sectionHeaders() *pdb.SectionHeaders {
+	headers := make([]pdb.SectionHeader, len(img.file.Sections))
+	for i, s := range img.file.Sections {
+		h := &headers[i]
+		copy(h.Name[:], s.Name)
+		h.VirtualSize = s.VirtualSize
+		h.VirtualAddress = s.VirtualAddress
+		h.SizeOfRawData = s.Size
+		h.PointerToRawData = s.Offset
+		h.Characteristics = s.Characteristics
+	}
+	return pdb.NewSectionHeaders(headers)
+}
+
+// runtimeFunctions returns the entries of the x64 exception directory.
+func (img *image) runtimeFunctions() []runtimeFunction {
+	oh, ok := img.file.OptionalHeader.(*pe.OptionalHeader64)
+	if !ok || oh.NumberOfRvaAndSizes <= imageDirectoryEntryException {
+		return nil
+	}
+
+	dir := oh.DataDirectory[imageDirectoryEntryException]
+	data, ok := img.read(dir.VirtualAddress, dir.Size)
+	if !ok {
+		return nil
+	}
+
+	funcs := make([]runtimeFunction, len(data)/12)
+	for i := range funcs {
+		funcs[i] = runtimeFunction{
+			begin:      binary.LittleEndian.Uint32(data[i*12:]),
+			end:        binary.LittleEndian.Uint32(data[i*12+4:]),
+			unwindInfo: binary.LittleEndian.Uint32(data[i*12+8:]),
+		}
+	}
+	return funcs
+}
+
+// frameSize walks the unwind codes of a function, following chained unwind
+// information, and returns the size of its fixed stack frame including the
+// return address, and the offset of the return address from the CFA.
+func (img *image) frameSize(fn runtimeFunction) (stackSize, raOffset uint32) {
+	stackSize, raOffset = 8, 8
+
+	unwindRVA := fn.unwindInfo
+	for visited := 0; visited < 32; visited++ {
+		header, ok := img.read(unwindRVA, 4)
+		if !ok {
+			break
+		}
+		version := header[0] & 0x7
+		flags := header[0] >> 3
+		count := uint32(header[2])
+
+		codes, ok := img.read(unwindRVA+4, count*2)
+		if !ok {
+			break
+		}
+		slot := func(i uint32) uint32 { return uint32(binary.LittleEndian.Uint16(codes[i*2:])) }
+
+		for i := uint32(0); i < count; i++ {
+			op := codes[i*2+1] & 0xf
+			info := uint32(codes[i*2+1] >> 4)
+
+			switch op {
+			case uwopPushNonvol:
+				stackSize += 8
+			case uwopAllocLarge:
+				if info == 0 {
+					if i+1 < count {
+						stackSize += slot(i+1) * 8
+					}
+					i++
+				} else {
+					if i+2 < count {
+						stackSize += slot(i+1) | slot(i+2)<<16
+					}
+					i += 2
+				}
+			case uwopAllocSmall:
+				stackSize += info*8 + 8
+			case uwopSaveNonvol, uwopSaveXMM128:
+				i++
+			case uwopSaveNonvolFar, uwopSaveXMM128Far:
+				i += 2
+			case uwopSaveXMM:
+				// Epilog codes take a single slot
+				if version < 2 {
+					i++
+				}
+			case uwopSaveXMMFar:
+				i += 2
+			case uwopPushMachFrame:
+				if info != 0 {
+					stackSize += 88
+				} else {
+					stackSize += 80
+				}
+				raOffset += 80
+			}
+		}
+
+		if flags&unwFlagChainInfo == 0 {
+			break
+		}
+
+		// The chained RUNTIME_FUNCTION follows the codes, padded to an even count
+		chained, ok := img.read(unwindRVA+4+((count+1)&^1)*2, 12)
+		if !ok {
+			break
+		}
+		unwindRVA = binary.LittleEndian.Uint32(chained[8:])
+	}
+
+	return stackSize, raOffset
+}
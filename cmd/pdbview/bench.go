@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchWorkload string
+	benchMmap     bool
+	benchWindowed bool
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench <pdb-file>",
+	Short: "Measure the time and peak memory of reading a PDB file",
+	Long: `Run a workload over a PDB file and report the time it took and the peak
+resident set size of the process.
+
+The peak is that of the whole process, so run one workload per invocation
+to compare open modes, e.g. --windowed against the default to see the
+memory saved by reading streams through a bounded window. With --mmap,
+pages of the memory-mapped file count towards the resident set size while
+they are in the page cache.
+
+Workloads:
+  - publics: iterate the public symbols (default)
+  - modules: parse the symbols of every module
+  - types:   iterate all types
+  - all:     all of the above`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBench,
+}
+
+func init() {
+	benchCmd.Flags().StringVarP(&benchWorkload, "workload", "w", "publics", "workload to run (publics, modules, types, all)")
+	benchCmd.Flags().BoolVar(&benchMmap, "mmap", false, "memory-map the file instead of reading it")
+	benchCmd.Flags().BoolVar(&benchWindowed, "windowed", false, "read streams through a bounded window instead of whole before parsing them")
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	workloads := map[string]func(*pdb.File) (int, error){
+		"publics": benchPublics,
+		"modules": benchModules,
+		"types":   benchTypes,
+	}
+	var run []string
+	switch benchWorkload {
+	case "all":
+		run = []string{"publics", "modules", "types"}
+	case "publics", "modules", "types":
+		run = []string{benchWorkload}
+	default:
+		return fmt.Errorf("unknown workload: %s", benchWorkload)
+	}
+
+	start := time.Now()
+	f, err := pdb.OpenWithOptions(args[0], pdb.OpenOptions{
+		IndexCacheDir:   indexCache,
+		Mmap:            benchMmap,
+		WindowedStreams: benchWindowed,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open PDB: %w", err)
+	}
+	defer f.Close()
+
+	mode, others := "read", "streams"
+	if f.Mapped() {
+		mode, others = "memory-mapped", "contiguous streams used in place, others"
+	}
+	if benchWindowed {
+		others += " read through a window"
+	} else {
+		others += " copied"
+	}
+	fmt.Fprintf(output, "Mode: %s; %s\n", mode, others)
+
+	for _, name := range run {
+		workloadStart := time.Now()
+		n, err := workloads[name](f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Fprintf(output, "%-8s %10d items  %v\n", name+":", n, time.Since(workloadStart).Round(time.Millisecond))
+	}
+
+	fmt.Fprintf(output, "Total time: %v\n", time.Since(start).Round(time.Millisecond))
+	if rss, ok := peakRSS(); ok {
+		fmt.Fprintf(output, "Peak RSS: %.1f MiB\n", float64(rss)/(1<<20))
+	} else {
+		fmt.Fprintf(output, "Peak RSS: not available on this platform\n")
+	}
+	return nil
+}
+
+func benchPublics(f *pdb.File) (int, error) {
+	st, err := f.Symbols()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for range st.Public() {
+		n++
+	}
+	return n, nil
+}
+
+func benchModules(f *pdb.File) (int, error) {
+	modules, err := f.Modules()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, mod := range modules {
+		for range mod.Symbols() {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func benchTypes(f *pdb.File) (int, error) {
+	tt, err := f.Types()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for range tt.All() {
+		n++
+	}
+	return n, nil
+}
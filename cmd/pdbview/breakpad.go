@@ -0,0 +1,55 @@
+package main
+
+import (
+	"debug/pe"
+	"fmt"
+	"path/filepath"
+
+	"github.com/skdltmxn/pdb-go/breakpad"
+	"github.com/spf13/cobra"
+)
+
+var (
+	breakpadPE string
+)
+
+var breakpadCmd = &cobra.Command{
+	Use:   "breakpad <pdb-file>",
+	Short: "Write a Breakpad symbol file (.sym)",
+	Long: `Write a Breakpad symbol file for a PDB file, as produced by dump_syms.
+
+The output contains the MODULE, FILE, FUNC, line, PUBLIC and STACK WIN
+records from the PDB. With --pe, the INFO CODE_ID record and, for x64
+images, STACK CFI records from the executable's unwind information are
+written as well.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBreakpad,
+}
+
+func init() {
+	breakpadCmd.Flags().StringVar(&breakpadPE, "pe", "", "executable matching the PDB (for code id and x64 unwind info)")
+}
+
+func runBreakpad(cmd *cobra.Command, args []string) error {
+	pdbPath := args[0]
+
+	f, err := openPDB(pdbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open PDB: %w", err)
+	}
+	defer f.Close()
+
+	opts := breakpad.Options{DebugFile: filepath.Base(pdbPath)}
+	if breakpadPE != "" {
+		peFile, err := pe.Open(breakpadPE)
+		if err != nil {
+			return fmt.Errorf("failed to open PE file: %w", err)
+		}
+		defer peFile.Close()
+
+		opts.PE = peFile
+		opts.CodeFile = filepath.Base(breakpadPE)
+	}
+
+	return breakpad.Write(output, f, opts)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/skdltmxn/pdb-go/pdbcheck"
+	"github.com/spf13/cobra"
+)
+
+var checkFormat string
+
+var checkCmd = &cobra.Command{
+	Use:   "check <pdb-file>",
+	Short: "Check a PDB file for corruption",
+	Long: `Check the structure of a PDB file and report each problem with its
+severity and location.
+
+The MSF container is checked for blocks used by two streams, blocks in use
+that the free block map marks free, and directory sizes that do not match
+the block lists. The DBI, type and symbol streams are checked for records
+that overrun their stream, unterminated strings and references to type
+indexes that are not defined.
+
+The file is read directly rather than opened as a PDB, so truncated files
+are checked as far as they go. The command exits with status 1 if any
+error is found.
+
+Supported formats:
+  - text: One line per problem (default)
+  - json: JSON format`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCheck,
+}
+
+func init() {
+	checkCmd.Flags().StringVarP(&checkFormat, "format", "f", "text", "output format (text, json)")
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	if checkFormat != "text" && checkFormat != "json" {
+		return fmt.Errorf("unknown format: %s", checkFormat)
+	}
+
+	report, err := pdbcheck.Check(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to check PDB: %w", err)
+	}
+
+	if checkFormat == "json" {
+		err = report.WriteJSON(output)
+	} else {
+		err = report.WriteText(output)
+	}
+	if err != nil {
+		return err
+	}
+
+	if report.HasErrors() {
+		cmd.SilenceUsage = true
+		return &exitError{code: 1, msg: fmt.Sprintf("%d error(s) found", report.Count(pdbcheck.Error))}
+	}
+	return nil
+}
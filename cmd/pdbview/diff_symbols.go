@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/skdltmxn/pdb-go/pdbdiff"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffSymbolsFormat string
+	diffSymbolsSort   string
+	diffSymbolsLimit  int
+)
+
+var diffSymbolsCmd = &cobra.Command{
+	Use:   "diff-symbols <old.pdb> <new.pdb>",
+	Short: "Compare the symbols and code size of two PDB files",
+	Long: `Compare functions, global data and public symbols between two PDB files
+and report added and removed symbols, functions and data whose size changed,
+and per-module code size changes from section contributions.
+
+Sort orders:
+  - name:      Alphabetically by name (default)
+  - delta:     Largest growth first
+  - abs-delta: Largest change in either direction first
+
+Supported formats:
+  - text: Human-readable text (default)
+  - json: JSON format`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiffSymbols,
+}
+
+func init() {
+	diffSymbolsCmd.Flags().StringVarP(&diffSymbolsFormat, "format", "f", "text", "output format (text, json)")
+	diffSymbolsCmd.Flags().StringVarP(&diffSymbolsSort, "sort", "s", "name", "sort order (name, delta, abs-delta)")
+	diffSymbolsCmd.Flags().IntVarP(&diffSymbolsLimit, "limit", "n", 0, "limit symbol and module changes shown (0 = no limit)")
+}
+
+func runDiffSymbols(cmd *cobra.Command, args []string) error {
+	if diffSymbolsFormat != "text" && diffSymbolsFormat != "json" {
+		return fmt.Errorf("unknown format: %s", diffSymbolsFormat)
+	}
+
+	var order pdbdiff.SortOrder
+	switch diffSymbolsSort {
+	case "name":
+		order = pdbdiff.SortByName
+	case "delta":
+		order = pdbdiff.SortByDelta
+	case "abs-delta":
+		order = pdbdiff.SortByAbsDelta
+	default:
+		return fmt.Errorf("unknown sort order: %s", diffSymbolsSort)
+	}
+
+	oldFile, err := openPDB(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open PDB %s: %w", args[0], err)
+	}
+	defer oldFile.Close()
+
+	newFile, err := openPDB(args[1])
+	if err != nil {
+		return fmt.Errorf("failed to open PDB %s: %w", args[1], err)
+	}
+	defer newFile.Close()
+
+	report, err := pdbdiff.DiffSymbols(oldFile, newFile)
+	if err != nil {
+		return err
+	}
+
+	report.Sort(order)
+	if diffSymbolsLimit > 0 {
+		report.Symbols = report.Symbols[:min(diffSymbolsLimit, len(report.Symbols))]
+		report.Modules = report.Modules[:min(diffSymbolsLimit, len(report.Modules))]
+	}
+
+	switch diffSymbolsFormat {
+	case "json":
+		return report.WriteJSON(output)
+	default:
+		return report.WriteText(output)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+	"github.com/skdltmxn/pdb-go/pdbdiff"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffTypesFormat       string
+	diffTypesBreakingOnly bool
+)
+
+var diffTypesCmd = &cobra.Command{
+	Use:   "diff-types <old.pdb> <new.pdb>",
+	Short: "Compare the type layouts of two PDB files",
+	Long: `Compare user-defined types between two PDB files and report
+layout changes: added and removed types and fields, size, offset, type and
+access changes, base class and vtable slot changes, and enum value changes.
+
+Types are matched by unique name, or by name if they have none.
+
+Exit status is 0 if no breaking change was found, 2 if at least one
+breaking change was found, and 1 on error.
+
+Supported formats:
+  - text: Human-readable text, breaking changes marked with '!' (default)
+  - json: JSON format`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiffTypes,
+}
+
+func init() {
+	diffTypesCmd.Flags().StringVarP(&diffTypesFormat, "format", "f", "text", "output format (text, json)")
+	diffTypesCmd.Flags().BoolVar(&diffTypesBreakingOnly, "breaking-only", false, "only report breaking changes")
+}
+
+func runDiffTypes(cmd *cobra.Command, args []string) error {
+	if diffTypesFormat != "text" && diffTypesFormat != "json" {
+		return fmt.Errorf("unknown format: %s", diffTypesFormat)
+	}
+
+	oldTypes, closeOld, err := openTypes(args[0])
+	if err != nil {
+		return err
+	}
+	defer closeOld()
+
+	newTypes, closeNew, err := openTypes(args[1])
+	if err != nil {
+		return err
+	}
+	defer closeNew()
+
+	report := pdbdiff.DiffTypes(oldTypes, newTypes)
+	if diffTypesBreakingOnly {
+		changes := report.Changes[:0]
+		for _, c := range report.Changes {
+			if c.Breaking {
+				changes = append(changes, c)
+			}
+		}
+		report.Changes = changes
+	}
+
+	switch diffTypesFormat {
+	case "json":
+		err = report.WriteJSON(output)
+	default:
+		err = report.WriteText(output)
+	}
+	if err != nil {
+		return err
+	}
+
+	if report.HasBreaking() {
+		cmd.SilenceUsage = true
+		return &exitError{code: 2, msg: fmt.Sprintf("%d breaking change(s) found", report.BreakingCount())}
+	}
+	return nil
+}
+
+func openTypes(path string) (*pdb.TypeTable, func(), error) {
+	f, err := openPDB(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open PDB %s: %w", path, err)
+	}
+
+	types, err := f.Types()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to get types from %s: %w", path, err)
+	}
+
+	return types, func() { f.Close() }, nil
+}
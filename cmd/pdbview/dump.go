@@ -31,7 +31,7 @@ func init() {
 func runDump(cmd *cobra.Command, args []string) error {
 	pdbPath := args[0]
 
-	f, err := pdb.Open(pdbPath)
+	f, err := openPDB(pdbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open PDB: %w", err)
 	}
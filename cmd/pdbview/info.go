@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 
-	"github.com/skdltmxn/pdb-go/pdb"
 	"github.com/spf13/cobra"
 )
 
@@ -18,7 +17,7 @@ var infoCmd = &cobra.Command{
 func runInfo(cmd *cobra.Command, args []string) error {
 	pdbPath := args[0]
 
-	f, err := pdb.Open(pdbPath)
+	f, err := openPDB(pdbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open PDB: %w", err)
 	}
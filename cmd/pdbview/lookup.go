@@ -10,8 +10,12 @@ import (
 )
 
 var (
-	lookupDemangled bool
-	lookupShowRVA   bool
+	lookupDemangled  bool
+	lookupShowRVA    bool
+	lookupGlob       bool
+	lookupRegexp     bool
+	lookupIgnoreCase bool
+	lookupLimit      int
 )
 
 var lookupCmd = &cobra.Command{
@@ -22,12 +26,17 @@ var lookupCmd = &cobra.Command{
 Query can be:
   - Symbol/member name: lookup file.pdb myFunction
     (searches both symbols and class/struct members)
-  - Qualified member: lookup file.pdb MyClass::m_value
-    (searches specific class member)
+  - Qualified name: lookup file.pdb MyClass::Run
+    (matches undecorated symbol names and class members)
   - Address: lookup file.pdb 0x1234
     (searches for symbols at that offset)
   - Type index: lookup file.pdb type:0x1000
-    (looks up type by index)`,
+    (looks up type by index)
+
+Symbols are matched by their raw and undecorated names and listed exact
+matches first, then prefix matches, then substring matches, and symbols at
+the same address once. Use --glob (matching whole names) or --regex to read
+the name as a pattern and -i to ignore case.`,
 	Args: cobra.ExactArgs(2),
 	RunE: runLookup,
 }
@@ -35,13 +44,17 @@ Query can be:
 func init() {
 	lookupCmd.Flags().BoolVarP(&lookupDemangled, "demangle", "d", false, "show demangled names")
 	lookupCmd.Flags().BoolVarP(&lookupShowRVA, "rva", "r", false, "show RVA (Relative Virtual Address)")
+	lookupCmd.Flags().BoolVarP(&lookupGlob, "glob", "g", false, "read the name as a glob pattern (*, ?, [...])")
+	lookupCmd.Flags().BoolVarP(&lookupRegexp, "regex", "e", false, "read the name as a regular expression")
+	lookupCmd.Flags().BoolVarP(&lookupIgnoreCase, "ignore-case", "i", false, "ignore case when matching names")
+	lookupCmd.Flags().IntVarP(&lookupLimit, "limit", "n", 50, "limit number of symbols shown (0 = unlimited)")
 }
 
 func runLookup(cmd *cobra.Command, args []string) error {
 	pdbPath := args[0]
 	query := args[1]
 
-	f, err := pdb.Open(pdbPath)
+	f, err := openPDB(pdbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open PDB: %w", err)
 	}
@@ -79,28 +92,24 @@ func lookupName(f *pdb.File, name string) error {
 
 	symbolCount := 0
 	memberCount := 0
+	enumCount := 0
 
-	// Check if this is a qualified name (Class::member)
-	isQualified := strings.Contains(name, "::")
-
-	// Search symbols first (only if not a qualified member name)
-	if !isQualified {
-		for sym := range symbols.ByName(name) {
-			printSymbolDetail(sym, sections)
-			symbolCount++
-		}
-
-		// Also search by demangled name if no exact match
-		if symbolCount == 0 {
-			for sym := range symbols.All() {
-				demangled := sym.DemangledName()
-				if strings.Contains(demangled, name) || strings.Contains(sym.Name(), name) {
-					printSymbolDetail(sym, sections)
-					symbolCount++
-				}
-			}
+	opts, err := searchOptions(lookupGlob, lookupRegexp, lookupIgnoreCase)
+	if err != nil {
+		return err
+	}
+	matches, err := symbols.ByPattern(name, opts)
+	if err != nil {
+		return err
+	}
+	for i, m := range matches {
+		if lookupLimit > 0 && i >= lookupLimit {
+			fmt.Fprintf(output, "(%d more symbol(s) not shown, use --limit 0 to show all)\n\n", len(matches)-i)
+			break
 		}
+		printSymbolDetail(m.Symbol, sections)
 	}
+	symbolCount = len(matches)
 
 	// Search class/struct members
 	for result := range types.FindMembers(name) {
@@ -108,22 +117,47 @@ func lookupName(f *pdb.File, name string) error {
 		memberCount++
 	}
 
-	totalFound := symbolCount + memberCount
+	// Search enumerators
+	for e := range types.FindEnumerator(name) {
+		printEnumeratorDetail(e)
+		enumCount++
+	}
+
+	totalFound := symbolCount + memberCount + enumCount
 	if totalFound == 0 {
 		fmt.Fprintf(output, "No results found matching '%s'\n", name)
 	} else {
-		if symbolCount > 0 && memberCount > 0 {
-			fmt.Fprintf(output, "\nFound %d symbol(s) and %d member(s)\n", symbolCount, memberCount)
-		} else if symbolCount > 0 {
-			fmt.Fprintf(output, "\nFound %d symbol(s)\n", symbolCount)
-		} else {
-			fmt.Fprintf(output, "\nFound %d member(s)\n", memberCount)
+		var parts []string
+		if symbolCount > 0 {
+			parts = append(parts, fmt.Sprintf("%d symbol(s)", symbolCount))
+		}
+		if memberCount > 0 {
+			parts = append(parts, fmt.Sprintf("%d member(s)", memberCount))
+		}
+		if enumCount > 0 {
+			parts = append(parts, fmt.Sprintf("%d enumerator(s)", enumCount))
 		}
+		fmt.Fprintf(output, "\nFound %s\n", strings.Join(parts, " and "))
 	}
 
 	return nil
 }
 
+// searchOptions returns the name search options selected by the --glob,
+// --regex and --ignore-case flags of a command.
+func searchOptions(glob, regex, ignoreCase bool) (pdb.SearchOptions, error) {
+	opts := pdb.SearchOptions{IgnoreCase: ignoreCase}
+	switch {
+	case glob && regex:
+		return opts, fmt.Errorf("--glob and --regex cannot be used together")
+	case glob:
+		opts.Syntax = pdb.PatternGlob
+	case regex:
+		opts.Syntax = pdb.PatternRegexp
+	}
+	return opts, nil
+}
+
 func lookupAddress(f *pdb.File, addrStr string) error {
 	addr, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(addrStr, "0x"), "0X"), 16, 32)
 	if err != nil {
@@ -274,3 +308,11 @@ func printMemberDetail(result *pdb.MemberSearchResult) {
 	}
 	fmt.Fprintln(output)
 }
+
+func printEnumeratorDetail(e *pdb.Enumerator) {
+	fmt.Fprintf(output, "Enumerator:\n")
+	fmt.Fprintf(output, "  Name: %s::%s\n", e.EnumName, e.Name)
+	fmt.Fprintf(output, "  Value: %s (0x%X)\n", e.ValueString(), e.Value)
+	fmt.Fprintf(output, "  EnumType: %s (0x%04X)\n", e.EnumName, e.EnumType)
+	fmt.Fprintln(output)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/skdltmxn/pdb-go/demangle"
+	"github.com/skdltmxn/pdb-go/pdb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mangleType  string
+	mangleCheck bool
+)
+
+var mangleCmd = &cobra.Command{
+	Use:   "mangle <pdb-file> [name...]",
+	Short: "Generate decorated names from declarations in the type table",
+	Long: `Print MSVC-decorated names generated from names and types in a PDB file.
+
+Each function and global variable with a given qualified name is mangled
+from its type, with C decorations if it has C linkage. With --type, a name is mangled with the given type index
+instead, whether or not the PDB has a symbol of that name.
+
+With --check, every decorated public symbol is demangled and mangled again,
+and the names that do not come back unchanged are printed.
+
+Examples:
+  pdbview mangle app.pdb ui::Widget::resize
+  pdbview mangle app.pdb --type 0x1005 ui::Widget::resize
+  pdbview mangle app.pdb --check`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runMangle,
+}
+
+func init() {
+	mangleCmd.Flags().StringVarP(&mangleType, "type", "t", "", "type index of the declaration (hex)")
+	mangleCmd.Flags().BoolVar(&mangleCheck, "check", false, "check that public symbol names round-trip")
+}
+
+func runMangle(cmd *cobra.Command, args []string) error {
+	f, err := openPDB(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open PDB: %w", err)
+	}
+	defer f.Close()
+
+	if mangleCheck {
+		return checkMangle(f)
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("no names given")
+	}
+
+	types, err := f.Types()
+	if err != nil {
+		return fmt.Errorf("failed to get types: %w", err)
+	}
+
+	if mangleType != "" {
+		ti, err := strconv.ParseUint(mangleType, 0, 32)
+		if err != nil {
+			return fmt.Errorf("invalid type index: %s", mangleType)
+		}
+		for _, name := range args[1:] {
+			mangled, err := types.MangledName(name, pdb.TypeIndex(ti))
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			fmt.Fprintf(output, "%s  %s\n", mangled, name)
+		}
+		return nil
+	}
+
+	symbols, err := f.Symbols()
+	if err != nil {
+		return fmt.Errorf("failed to get symbols: %w", err)
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range args[1:] {
+		wanted[name] = true
+	}
+
+	found := 0
+	for sym := range symbols.All() {
+		if !wanted[sym.Name()] {
+			continue
+		}
+
+		var mangled string
+		switch s := sym.(type) {
+		case *pdb.FunctionSymbol:
+			mangled, err = s.MangledName()
+		case *pdb.DataSymbol:
+			mangled, err = s.MangledName()
+		default:
+			continue
+		}
+
+		found++
+		if err != nil {
+			fmt.Fprintf(output, "%s: %v\n", sym.Name(), err)
+			continue
+		}
+		fmt.Fprintf(output, "%s  %s\n", mangled, sym.Name())
+	}
+
+	if found == 0 {
+		fmt.Fprintln(output, "No functions or variables found")
+	}
+	return nil
+}
+
+// checkMangle demangles and mangles every decorated public symbol and
+// prints the names that change.
+func checkMangle(f *pdb.File) error {
+	symbols, err := f.Symbols()
+	if err != nil {
+		return fmt.Errorf("failed to get symbols: %w", err)
+	}
+
+	var total, unparsed, unsupported, mismatched int
+	for pub := range symbols.Public() {
+		name := pub.Name()
+		if !demangle.IsMangled(name) {
+			continue
+		}
+		total++
+
+		node, err := demangle.DemangleToNode(name)
+		if err != nil {
+			unparsed++
+			continue
+		}
+		mangled, err := demangle.Mangle(node)
+		if err != nil {
+			unsupported++
+			continue
+		}
+		if mangled != name {
+			mismatched++
+			fmt.Fprintf(output, "%s\n  -> %s\n", name, mangled)
+		}
+	}
+
+	fmt.Fprintf(output, "%d decorated public symbols: %d round-trip, %d differ, %d cannot be mangled, %d cannot be parsed\n",
+		total, total-unparsed-unsupported-mismatched, mismatched, unsupported, unparsed)
+	return nil
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/skdltmxn/pdb-go/pdb"
 	"github.com/spf13/cobra"
 )
 
@@ -27,7 +26,7 @@ func init() {
 func runModules(cmd *cobra.Command, args []string) error {
 	pdbPath := args[0]
 
-	f, err := pdb.Open(pdbPath)
+	f, err := openPDB(pdbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open PDB: %w", err)
 	}
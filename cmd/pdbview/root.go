@@ -5,12 +5,15 @@ import (
 	"io"
 	"os"
 
+	"github.com/skdltmxn/pdb-go/pdb"
 	"github.com/spf13/cobra"
 )
 
 var (
 	outputFile string
 	output     io.Writer
+	indexCache string
+	strict     bool
 )
 
 var rootCmd = &cobra.Command{
@@ -42,6 +45,8 @@ stored in PDB files.`,
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&outputFile, "output", "o", "", "write output to file instead of stdout")
+	rootCmd.PersistentFlags().StringVar(&indexCache, "index-cache", os.Getenv("PDBVIEW_INDEX_CACHE"), "directory for cached lookup indexes (default $PDBVIEW_INDEX_CACHE)")
+	rootCmd.PersistentFlags().BoolVar(&strict, "strict", false, "report records that cannot be decoded instead of skipping them")
 
 	rootCmd.AddCommand(infoCmd)
 	rootCmd.AddCommand(symbolsCmd)
@@ -49,4 +54,39 @@ func init() {
 	rootCmd.AddCommand(modulesCmd)
 	rootCmd.AddCommand(lookupCmd)
 	rootCmd.AddCommand(dumpCmd)
+	rootCmd.AddCommand(diffTypesCmd)
+	rootCmd.AddCommand(diffSymbolsCmd)
+	rootCmd.AddCommand(sizeCmd)
+	rootCmd.AddCommand(breakpadCmd)
+	rootCmd.AddCommand(symbolizeDumpCmd)
+	rootCmd.AddCommand(symbolizeCmd)
+	rootCmd.AddCommand(mangleCmd)
+	rootCmd.AddCommand(benchCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(streamsCmd)
+}
+
+// openPDB opens a PDB file with the options given by global flags.
+func openPDB(path string) (*pdb.File, error) {
+	return pdb.OpenWithOptions(path, pdb.OpenOptions{IndexCacheDir: indexCache, Strict: strict})
+}
+
+// parseErrors reports the parse errors met while listing symbols or types
+// on standard error, and fails the command if there were any.
+type parseErrors struct {
+	cmd   *cobra.Command
+	count int
+}
+
+func (p *parseErrors) report(err error) {
+	p.count++
+	fmt.Fprintf(p.cmd.ErrOrStderr(), "pdbview: %v\n", err)
+}
+
+func (p *parseErrors) err() error {
+	if p.count == 0 {
+		return nil
+	}
+	p.cmd.SilenceUsage = true
+	return fmt.Errorf("%d parse error(s)", p.count)
 }
@@ -0,0 +1,13 @@
+package main
+
+import "syscall"
+
+// peakRSS returns the peak resident set size of the process in bytes.
+func peakRSS() (uint64, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	// ru_maxrss is in kilobytes on Linux
+	return uint64(usage.Maxrss) * 1024, true
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+// peakRSS reports that the peak resident set size is not available.
+func peakRSS() (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/skdltmxn/pdb-go/pdbsize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sizeFormat        string
+	sizeDepth         int
+	sizeFoldTemplates bool
+	sizeMaxChildren   int
+)
+
+var sizeCmd = &cobra.Command{
+	Use:   "size <pdb-file>",
+	Short: "Break down the image size by section, library, object and function",
+	Long: `Attribute the size of the image described by a PDB file to sections,
+libraries, object files and functions, using the section contributions and
+function symbols of each module.
+
+Bytes of a contribution not covered by a function are shown as [other].
+Objects that were not linked from a static library are grouped under
+[no library].
+
+Depth levels:
+  1: section
+  2: section, library
+  3: section, library, object
+  4: section, library, object, function (default)
+
+Supported formats:
+  - text: Indented tree (default)
+  - json: JSON format
+  - csv:  One row per leaf with a column per level`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSize,
+}
+
+func init() {
+	sizeCmd.Flags().StringVarP(&sizeFormat, "format", "f", "text", "output format (text, json, csv)")
+	sizeCmd.Flags().IntVarP(&sizeDepth, "depth", "d", pdbsize.LevelSymbol, "number of levels to break down (1-4)")
+	sizeCmd.Flags().BoolVarP(&sizeFoldTemplates, "fold-templates", "t", false, "merge instantiations of the same template")
+	sizeCmd.Flags().IntVarP(&sizeMaxChildren, "max-children", "n", 20, "largest children shown per node, rest collapsed (0 = no limit)")
+}
+
+func runSize(cmd *cobra.Command, args []string) error {
+	if sizeFormat != "text" && sizeFormat != "json" && sizeFormat != "csv" {
+		return fmt.Errorf("unknown format: %s", sizeFormat)
+	}
+	if sizeDepth < pdbsize.LevelSection || sizeDepth > pdbsize.LevelSymbol {
+		return fmt.Errorf("depth must be between %d and %d", pdbsize.LevelSection, pdbsize.LevelSymbol)
+	}
+
+	f, err := openPDB(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open PDB: %w", err)
+	}
+	defer f.Close()
+
+	report, err := pdbsize.Analyze(f, pdbsize.Options{
+		Depth:         sizeDepth,
+		FoldTemplates: sizeFoldTemplates,
+		MaxChildren:   sizeMaxChildren,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch sizeFormat {
+	case "json":
+		return report.WriteJSON(output)
+	case "csv":
+		return report.WriteCSV(output)
+	default:
+		return report.WriteText(output)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/skdltmxn/pdb-go/msf"
+	"github.com/spf13/cobra"
+)
+
+var (
+	streamsBlocks bool
+)
+
+// Blocks shown per row of the block map
+const blockMapWidth = 64
+
+var streamsCmd = &cobra.Command{
+	Use:   "streams <pdb-file>",
+	Short: "List the streams of a PDB file and how its blocks are allocated",
+	Long: `List the streams of a PDB file with their sizes, block counts and, where
+known, what they hold.
+
+With --blocks, also show the extents (runs of consecutive blocks) of each
+stream, the free blocks of the active and inactive free block maps, and a
+map of the file with one character per block:
+
+  S  superblock           F  free block map
+  M  directory block map  D  directory
+  .  free                 ?  neither free nor in use
+  0-9, a-z                stream index modulo 36
+
+Identical rows of the map are shown once, followed by '*'. Free blocks and
+fragmented streams are what incremental linking leaves behind as a PDB grows.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStreams,
+}
+
+func init() {
+	streamsCmd.Flags().BoolVarP(&streamsBlocks, "blocks", "b", false, "show extents, free blocks and a block map")
+}
+
+func runStreams(cmd *cobra.Command, args []string) error {
+	f, err := openPDB(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open PDB: %w", err)
+	}
+	defer f.Close()
+
+	m := f.MSF()
+	dir, err := m.Directory()
+	if err != nil {
+		return fmt.Errorf("failed to read stream directory: %w", err)
+	}
+	names := f.StreamNames()
+
+	var frag *msf.Fragmentation
+	extents := make(map[uint32][]msf.Extent)
+	if streamsBlocks {
+		frag, err = m.Fragmentation()
+		if err != nil {
+			return fmt.Errorf("failed to read block allocation: %w", err)
+		}
+		for _, s := range frag.Streams {
+			extents[s.Stream] = s.Extents
+		}
+	}
+
+	if streamsBlocks {
+		fmt.Fprintf(output, "%-6s %-10s %-7s %-7s %s\n", "STREAM", "SIZE", "BLOCKS", "EXTENTS", "NAME")
+	} else {
+		fmt.Fprintf(output, "%-6s %-10s %-7s %s\n", "STREAM", "SIZE", "BLOCKS", "NAME")
+	}
+	fmt.Fprintf(output, "%s\n", strings.Repeat("-", 80))
+
+	for i := range dir.NumStreams {
+		size := "nil"
+		if dir.StreamSizes[i] != msf.NilStreamSize {
+			size = strconv.FormatUint(uint64(dir.StreamSizes[i]), 10)
+		}
+		if streamsBlocks {
+			fmt.Fprintf(output, "%-6d %-10s %-7d %-7d %s\n", i, size, len(dir.StreamBlocks[i]), len(extents[i]), names[i])
+		} else {
+			fmt.Fprintf(output, "%-6d %-10s %-7d %s\n", i, size, len(dir.StreamBlocks[i]), names[i])
+		}
+	}
+	fmt.Fprintf(output, "\nTotal: %d streams\n", dir.NumStreams)
+
+	if !streamsBlocks {
+		return nil
+	}
+
+	inactive, err := m.InactiveFreeBlockMap()
+	if err != nil {
+		return fmt.Errorf("failed to read inactive free block map: %w", err)
+	}
+	largest := frag.LargestFreeExtent()
+	fmt.Fprintf(output, "\nBlocks: %d of %d bytes, %d free (%d bytes), %d neither free nor in use\n",
+		frag.NumBlocks, m.BlockSize(), frag.FreeBlocks, uint64(frag.FreeBlocks)*uint64(m.BlockSize()), frag.UnusedBlocks)
+	fmt.Fprintf(output, "Free extents: %d, largest %d blocks\n", len(frag.FreeExtents), largest.Count)
+	fmt.Fprintf(output, "Fragmented streams: %d of %d\n", frag.FragmentedStreams(), len(frag.Streams))
+	fmt.Fprintf(output, "Inactive free block map (block %d): %d free\n",
+		3-m.SuperBlock().FreeBlockMapBlock, len(inactive.FreeBlocks()))
+
+	if frag.FragmentedStreams() > 0 {
+		fmt.Fprintf(output, "\nExtents of fragmented streams:\n")
+		for _, s := range frag.Streams {
+			if len(s.Extents) < 2 {
+				continue
+			}
+			fmt.Fprintf(output, "  %d", s.Stream)
+			if name := names[s.Stream]; name != "" {
+				fmt.Fprintf(output, " (%s)", name)
+			}
+			fmt.Fprintf(output, ": %s\n", formatExtents(s.Extents))
+		}
+	}
+
+	fmt.Fprintf(output, "\nBlock map:\n")
+	return printBlockMap(m)
+}
+
+// formatExtents formats extents as comma-separated block ranges.
+func formatExtents(extents []msf.Extent) string {
+	parts := make([]string, len(extents))
+	for i, e := range extents {
+		if e.Count == 1 {
+			parts[i] = strconv.FormatUint(uint64(e.First), 10)
+		} else {
+			parts[i] = fmt.Sprintf("%d-%d", e.First, e.First+e.Count-1)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printBlockMap prints one character per block, collapsing repeated rows.
+func printBlockMap(m *msf.File) error {
+	fpm, err := m.FreeBlockMap()
+	if err != nil {
+		return fmt.Errorf("failed to read free block map: %w", err)
+	}
+
+	const streamChars = "0123456789abcdefghijklmnopqrstuvwxyz"
+	row := make([]byte, 0, blockMapWidth)
+	prev, repeated := "", false
+	flush := func(first uint32) {
+		if s := string(row); s == prev {
+			if !repeated {
+				fmt.Fprintf(output, "%10s\n", "*")
+				repeated = true
+			}
+		} else {
+			fmt.Fprintf(output, "%10d  %s\n", first, s)
+			prev, repeated = s, false
+		}
+		row = row[:0]
+	}
+
+	for block := uint32(0); block < m.NumBlocks(); block++ {
+		owner, err := m.BlockOwner(block)
+		if err != nil {
+			return err
+		}
+
+		c := byte('?')
+		switch owner.Kind {
+		case msf.BlockNone:
+			if fpm.IsFree(block) {
+				c = '.'
+			}
+		case msf.BlockSuperBlock:
+			c = 'S'
+		case msf.BlockFreeBlockMap:
+			c = 'F'
+		case msf.BlockDirectoryMap:
+			c = 'M'
+		case msf.BlockDirectory:
+			c = 'D'
+		case msf.BlockStream:
+			c = streamChars[owner.Stream%uint32(len(streamChars))]
+		}
+
+		row = append(row, c)
+		if len(row) == blockMapWidth {
+			flush(block + 1 - blockMapWidth)
+		}
+	}
+	if len(row) > 0 {
+		flush(m.NumBlocks() - uint32(len(row)))
+	}
+	return nil
+}
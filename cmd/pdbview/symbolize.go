@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/skdltmxn/pdb-go/demangle"
+	"github.com/skdltmxn/pdb-go/pdb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	symbolizeSymbols      []string
+	symbolizeFormat       string
+	symbolizePrintAddress bool
+	symbolizeDemangle     bool
+)
+
+var symbolizeCmd = &cobra.Command{
+	Use:   "symbolize [pdb-file...]",
+	Short: "Symbolize addresses read from standard input",
+	Long: `Read one address per line from standard input and write the function,
+source file and line for each, including inlined frames (innermost first).
+
+Addresses are given as:
+  - module+0xRVA:   an RVA within a module, e.g. app.exe+0x1a2b
+  - section:offset: a PDB address in hex, e.g. 0001:00000a2b
+  - 0xRVA:          an RVA within the first PDB given
+
+A module's PDB is one of the PDB files given as arguments with the same base
+name, or <name>.pdb found in a --symbols directory. PDBs stay open between
+queries.
+
+Supported formats (matching llvm-symbolizer's output styles):
+  - text: Function and file:line:column lines per frame, then a blank line (default)
+  - json: One JSON object per line`,
+	RunE: runSymbolize,
+}
+
+func init() {
+	symbolizeCmd.Flags().StringSliceVar(&symbolizeSymbols, "symbols", nil, "directories to search for PDB files (repeatable)")
+	symbolizeCmd.Flags().StringVarP(&symbolizeFormat, "format", "f", "text", "output format (text, json)")
+	symbolizeCmd.Flags().BoolVarP(&symbolizePrintAddress, "print-address", "a", false, "print the address before the frames (text format)")
+	symbolizeCmd.Flags().BoolVar(&symbolizeDemangle, "demangle", true, "print function names; with --demangle=false print decorated names")
+}
+
+// symbolizeQuery is a parsed input line.
+type symbolizeQuery struct {
+	module    string // Empty for the default PDB
+	address   string // Address as written in the input
+	bySection bool
+	section   uint16
+	offset    uint32 // Section offset, or RVA unless bySection
+}
+
+// symbolizeResult follows llvm-symbolizer's JSON output.
+type symbolizeResult struct {
+	Address    string            `json:"Address"`
+	Error      *symbolizeError   `json:"Error,omitempty"`
+	ModuleName string            `json:"ModuleName"`
+	Symbol     []symbolizeSymbol `json:"Symbol,omitempty"`
+}
+
+type symbolizeError struct {
+	Message string `json:"Message"`
+}
+
+type symbolizeSymbol struct {
+	Column        uint32 `json:"Column"`
+	DemangledName string `json:"DemangledName,omitempty"`
+	Discriminator uint32 `json:"Discriminator"`
+	FileName      string `json:"FileName"`
+	FunctionName  string `json:"FunctionName"`
+	Line          uint32 `json:"Line"`
+	LinkageName   string `json:"LinkageName,omitempty"`
+	StartAddress  string `json:"StartAddress"`
+	StartFileName string `json:"StartFileName"`
+	StartLine     uint32 `json:"StartLine"`
+}
+
+func runSymbolize(cmd *cobra.Command, args []string) error {
+	if symbolizeFormat != "text" && symbolizeFormat != "json" {
+		return fmt.Errorf("unknown format: %s", symbolizeFormat)
+	}
+
+	store := newSymbolStore(symbolizeSymbols)
+	defer store.Close()
+
+	var defaultPDB *pdb.File
+	var defaultName string
+	for _, path := range args {
+		f, err := openPDB(path)
+		if err != nil {
+			return fmt.Errorf("failed to open PDB %s: %w", path, err)
+		}
+		store.add(path, f)
+		if defaultPDB == nil {
+			defaultPDB, defaultName = f, path
+		}
+	}
+
+	w := bufio.NewWriter(output)
+	scanner := bufio.NewScanner(cmd.InOrStdin())
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		result := symbolizeLine(store, defaultPDB, defaultName, line)
+		if symbolizeFormat == "json" {
+			data, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
+			w.Write(data)
+			w.WriteByte('\n')
+		} else {
+			if result.Error != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "pdbview: %s: %s\n", line, result.Error.Message)
+			}
+			writeSymbolizeText(w, result)
+		}
+
+		// Flush each answer so interactive callers are not kept waiting
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func symbolizeLine(store *symbolStore, defaultPDB *pdb.File, defaultName, line string) *symbolizeResult {
+	q, err := parseSymbolizeQuery(line)
+	if err != nil {
+		return &symbolizeResult{Address: line, Error: &symbolizeError{Message: err.Error()}}
+	}
+
+	result := &symbolizeResult{Address: q.address, ModuleName: q.module}
+
+	f := defaultPDB
+	if q.module != "" {
+		if f, err = store.openName(q.module); err != nil {
+			result.Error = &symbolizeError{Message: fmt.Sprintf("%s: %v", q.module, err)}
+			return result
+		}
+	} else if f == nil {
+		result.Error = &symbolizeError{Message: "no PDB given for addresses without a module"}
+		return result
+	} else {
+		result.ModuleName = defaultName
+	}
+
+	var frames []pdb.SourceFrame
+	if q.bySection {
+		frames, err = f.Symbolize(q.section, q.offset)
+	} else {
+		frames, err = f.SymbolizeRVA(q.offset)
+	}
+	if err != nil {
+		// Unknown addresses are not errors; llvm-symbolizer prints "??"
+		if err != pdb.ErrSymbolNotFound {
+			result.Error = &symbolizeError{Message: err.Error()}
+		}
+		return result
+	}
+
+	for _, fr := range frames {
+		sym := symbolizeSymbol{
+			FileName:     fr.File,
+			FunctionName: fr.Function,
+			Line:         fr.Line,
+			LinkageName:  fr.LinkageName,
+			StartLine:    fr.StartLine,
+		}
+		if fr.StartLine != 0 {
+			sym.StartFileName = fr.File
+		}
+		if demangle.IsMangled(fr.LinkageName) || demangle.IsRustMangled(fr.LinkageName) {
+			sym.DemangledName = demangle.DemangleSimple(fr.LinkageName)
+		}
+		if !fr.Inlined {
+			if q.bySection {
+				sym.StartAddress = fmt.Sprintf("%04X:%08X", q.section, q.offset-fr.Offset)
+			} else {
+				sym.StartAddress = fmt.Sprintf("0x%x", q.offset-fr.Offset)
+			}
+		}
+		result.Symbol = append(result.Symbol, sym)
+	}
+	return result
+}
+
+// parseSymbolizeQuery parses "module+0xRVA", "section:offset" or "0xRVA".
+func parseSymbolizeQuery(line string) (*symbolizeQuery, error) {
+	if i := strings.LastIndex(line, "+"); i > 0 {
+		rva, err := parseHex32(line[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid RVA %q", line[i+1:])
+		}
+		return &symbolizeQuery{module: strings.TrimSpace(line[:i]), address: line[i+1:], offset: rva}, nil
+	}
+
+	if section, offset, ok := strings.Cut(line, ":"); ok {
+		sec, err := strconv.ParseUint(section, 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid section %q", section)
+		}
+		off, err := parseHex32(offset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset %q", offset)
+		}
+		return &symbolizeQuery{address: line, bySection: true, section: uint16(sec), offset: off}, nil
+	}
+
+	rva, err := parseHex32(line)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q", line)
+	}
+	return &symbolizeQuery{address: line, offset: rva}, nil
+}
+
+// parseHex32 parses a hexadecimal number with an optional 0x prefix.
+func parseHex32(s string) (uint32, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	v, err := strconv.ParseUint(s, 16, 32)
+	return uint32(v), err
+}
+
+// writeSymbolizeText writes a result in llvm-symbolizer's LLVM style.
+func writeSymbolizeText(w io.Writer, r *symbolizeResult) {
+	if symbolizePrintAddress {
+		fmt.Fprintln(w, r.Address)
+	}
+
+	if len(r.Symbol) == 0 {
+		fmt.Fprint(w, "??\n??:0:0\n\n")
+		return
+	}
+
+	for _, s := range r.Symbol {
+		name := s.FunctionName
+		if !symbolizeDemangle && s.LinkageName != "" {
+			name = s.LinkageName
+		}
+		file := s.FileName
+		if file == "" {
+			file = "??"
+		}
+		fmt.Fprintf(w, "%s\n%s:%d:%d\n", name, file, s.Line, s.Column)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/skdltmxn/pdb-go/minidump"
+	"github.com/skdltmxn/pdb-go/pdb"
+	"github.com/skdltmxn/pdb-go/unwind"
+	"github.com/spf13/cobra"
+)
+
+var (
+	symbolizeDumpSymbols   []string
+	symbolizeDumpFormat    string
+	symbolizeDumpMaxFrames int
+)
+
+var symbolizeDumpCmd = &cobra.Command{
+	Use:   "symbolize-dump <dmp-file>",
+	Short: "Symbolize the thread stacks of a minidump",
+	Long: `Symbolize the threads of a Windows minidump using PDB files.
+
+Each loaded module is matched to its PDB by the GUID and age of its CodeView
+(RSDS) record. PDBs are looked up in the --symbols directories, either
+directly (<dir>/<name>.pdb) or in symbol server layout
+(<dir>/<name>.pdb/<id>/<name>.pdb).
+
+The first frame of each thread is its instruction pointer; the crashing
+thread uses the context of the exception. x86 stacks are unwound with the
+frame data (FPO) of the PDBs; x64 stacks with the unwind codes of the
+images, read from the dump when captured or from <name>.exe/.dll files in
+the --symbols directories. Frames without unwind information are found by
+following frame pointers or scanning the stack for return addresses.
+Inlined calls are reported as separate frames.
+
+Supported formats:
+  - text: Human-readable text (default)
+  - json: JSON format`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSymbolizeDump,
+}
+
+func init() {
+	symbolizeDumpCmd.Flags().StringSliceVar(&symbolizeDumpSymbols, "symbols", nil, "directories to search for PDB files (repeatable)")
+	symbolizeDumpCmd.Flags().StringVarP(&symbolizeDumpFormat, "format", "f", "text", "output format (text, json)")
+	symbolizeDumpCmd.Flags().IntVarP(&symbolizeDumpMaxFrames, "max-frames", "n", 64, "maximum frames per thread")
+	symbolizeDumpCmd.MarkFlagRequired("symbols")
+}
+
+// Well-known exception codes.
+var exceptionNames = map[uint32]string{
+	0x80000003: "EXCEPTION_BREAKPOINT",
+	0x80000004: "EXCEPTION_SINGLE_STEP",
+	0xc0000005: "EXCEPTION_ACCESS_VIOLATION",
+	0xc000001d: "EXCEPTION_ILLEGAL_INSTRUCTION",
+	0xc0000094: "EXCEPTION_INT_DIVIDE_BY_ZERO",
+	0xc0000096: "EXCEPTION_PRIV_INSTRUCTION",
+	0xc00000fd: "EXCEPTION_STACK_OVERFLOW",
+	0xc0000374: "STATUS_HEAP_CORRUPTION",
+	0xc0000409: "STATUS_STACK_BUFFER_OVERRUN",
+	0xc0000602: "STATUS_FAIL_FAST_EXCEPTION",
+	0xe06d7363: "Microsoft C++ Exception",
+}
+
+// foundBy describes how frames of each trust level were found.
+var foundBy = map[string]string{
+	"context":       "instruction pointer in context",
+	"cfi":           "call frame info",
+	"frame pointer": "previous frame's frame pointer",
+	"scan":          "stack scanning",
+}
+
+type dumpReport struct {
+	Crash   *dumpCrash   `json:"crash,omitempty"`
+	Threads []dumpThread `json:"threads"`
+	Modules []dumpModule `json:"modules"`
+}
+
+type dumpCrash struct {
+	Reason  string `json:"reason"`
+	Code    string `json:"code"`
+	Address string `json:"address"`
+	Thread  uint32 `json:"thread"`
+}
+
+type dumpThread struct {
+	ID      uint32      `json:"id"`
+	Crashed bool        `json:"crashed,omitempty"`
+	Frames  []dumpFrame `json:"frames"`
+}
+
+type dumpFrame struct {
+	Index        int            `json:"index"`
+	Address      string         `json:"address"`
+	Module       string         `json:"module,omitempty"`
+	ModuleOffset string         `json:"module_offset,omitempty"`
+	Trust        string         `json:"trust"` // "context", "cfi", "frame pointer" or "scan"
+	Locations    []dumpLocation `json:"locations,omitempty"`
+}
+
+type dumpLocation struct {
+	Function       string `json:"function"`
+	FunctionOffset string `json:"function_offset"`
+	File           string `json:"file,omitempty"`
+	Line           uint32 `json:"line,omitempty"`
+	Inlined        bool   `json:"inlined,omitempty"`
+}
+
+type dumpModule struct {
+	Base    string `json:"base"`
+	Size    uint32 `json:"size"`
+	Name    string `json:"name"`
+	PDB     string `json:"pdb,omitempty"`
+	Symbols string `json:"symbols"` // "loaded", "missing", "mismatch" or "none"
+}
+
+// dumpSymbolizer resolves addresses of a minidump to source frames.
+type dumpSymbolizer struct {
+	dump  *minidump.File
+	store *symbolStore
+	pdbs  map[*minidump.Module]*pdb.File
+
+	modules []*unwind.Module // Built on first use by unwindModules
+}
+
+func runSymbolizeDump(cmd *cobra.Command, args []string) error {
+	if symbolizeDumpFormat != "text" && symbolizeDumpFormat != "json" {
+		return fmt.Errorf("unknown format: %s", symbolizeDumpFormat)
+	}
+
+	dump, err := minidump.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open minidump: %w", err)
+	}
+	defer dump.Close()
+
+	store := newSymbolStore(symbolizeDumpSymbols)
+	defer store.Close()
+
+	s := &dumpSymbolizer{dump: dump, store: store, pdbs: make(map[*minidump.Module]*pdb.File)}
+	report := &dumpReport{Threads: []dumpThread{}}
+
+	for i := range dump.Modules {
+		report.Modules = append(report.Modules, s.loadModule(&dump.Modules[i]))
+	}
+
+	var crashed uint32
+	if e := dump.Exception; e != nil {
+		crashed = e.ThreadID
+		report.Crash = &dumpCrash{
+			Reason:  exceptionNames[e.Code],
+			Code:    fmt.Sprintf("0x%08x", e.Code),
+			Address: fmt.Sprintf("0x%x", e.Address),
+			Thread:  e.ThreadID,
+		}
+	}
+
+	// The crashing thread goes first, with the context of the exception
+	if dump.Exception != nil {
+		if ctx, err := dump.ExceptionContext(); err == nil {
+			report.Threads = append(report.Threads, dumpThread{
+				ID:      crashed,
+				Crashed: true,
+				Frames:  s.walk(dump.Thread(crashed), ctx),
+			})
+		}
+	}
+	for i := range dump.Threads {
+		t := &dump.Threads[i]
+		if dump.Exception != nil && t.ID == crashed {
+			continue
+		}
+		ctx, err := dump.ThreadContext(t)
+		if err != nil {
+			continue
+		}
+		report.Threads = append(report.Threads, dumpThread{ID: t.ID, Frames: s.walk(t, ctx)})
+	}
+
+	if symbolizeDumpFormat == "json" {
+		enc := json.NewEncoder(output)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	writeDumpReport(report)
+	return nil
+}
+
+// loadModule opens the PDB of a loaded module.
+func (s *dumpSymbolizer) loadModule(m *minidump.Module) dumpModule {
+	dm := dumpModule{
+		Base:    fmt.Sprintf("0x%x", m.BaseOfImage),
+		Size:    m.SizeOfImage,
+		Name:    windowsBase(m.Name),
+		Symbols: "none",
+	}
+	if m.CodeView == nil {
+		return dm
+	}
+
+	dm.PDB = windowsBase(m.CodeView.PDBName)
+	f, err := s.store.open(m.CodeView)
+	switch err {
+	case nil:
+		s.pdbs[m] = f
+		dm.Symbols = "loaded"
+	case errPDBMismatch:
+		dm.Symbols = "mismatch"
+	default:
+		dm.Symbols = "missing"
+	}
+	return dm
+}
+
+// walk returns the frames of a thread. x86 and x64 stacks are unwound with
+// frame data and unwind codes; other architectures get the instruction
+// pointer followed by return addresses found on the stack.
+func (s *dumpSymbolizer) walk(t *minidump.Thread, ctx *minidump.Context) []dumpFrame {
+	var arch unwind.Arch
+	switch ctx.Arch {
+	case minidump.ArchX86:
+		arch = unwind.ArchX86
+	case minidump.ArchAMD64:
+		arch = unwind.ArchAMD64
+	default:
+		return s.scanStack(t, ctx)
+	}
+
+	walker := &unwind.Walker{
+		Memory:    s.dump,
+		Modules:   s.unwindModules(),
+		MaxFrames: symbolizeDumpMaxFrames,
+	}
+
+	var frames []dumpFrame
+	for i, fr := range walker.Walk(unwind.NewContext(arch, ctx.IP(), ctx.GPRs())) {
+		frames = append(frames, s.frame(i, fr.Context.IP, fr.Trust.String()))
+	}
+	return frames
+}
+
+// unwindModules returns the modules of the dump with their unwind
+// information: x86 frame data from the PDBs, and x64 function tables from
+// the images captured in the dump or found next to the PDBs.
+func (s *dumpSymbolizer) unwindModules() []*unwind.Module {
+	if s.modules != nil {
+		return s.modules
+	}
+
+	x64 := s.dump.Arch() == minidump.ArchAMD64
+	for i := range s.dump.Modules {
+		m := &s.dump.Modules[i]
+		name := windowsBase(m.Name)
+		base, size := m.BaseOfImage, uint64(m.SizeOfImage)
+
+		var um *unwind.Module
+		if x64 {
+			if img, err := s.store.openImage(m); err == nil {
+				um, _ = unwind.NewPEModule(name, base, img)
+			}
+			if um == nil {
+				um, _ = unwind.NewMemoryModule(name, base, size, s.dump)
+			}
+		}
+		if um == nil {
+			um = &unwind.Module{Name: name, Base: base, Size: size}
+		}
+
+		if f, ok := s.pdbs[m]; ok {
+			um.LoadPDB(f)
+		}
+		s.modules = append(s.modules, um)
+	}
+	return s.modules
+}
+
+// scanStack returns the instruction pointer of a thread, then return
+// addresses into symbolized code found on its stack.
+func (s *dumpSymbolizer) scanStack(t *minidump.Thread, ctx *minidump.Context) []dumpFrame {
+	frames := []dumpFrame{s.frame(0, ctx.IP(), "context")}
+	if t == nil {
+		return frames
+	}
+
+	stack, err := s.dump.StackMemory(t)
+	if err != nil {
+		return frames
+	}
+
+	ptrSize := ctx.PointerSize()
+	sp := ctx.SP()
+	if sp < t.Stack.Start || sp-t.Stack.Start >= uint64(len(stack)) {
+		return frames
+	}
+
+	for off := int(sp - t.Stack.Start); off+ptrSize <= len(stack) && len(frames) < symbolizeDumpMaxFrames; off += ptrSize {
+		var addr uint64
+		if ptrSize == 4 {
+			addr = uint64(binary.LittleEndian.Uint32(stack[off:]))
+		} else {
+			addr = binary.LittleEndian.Uint64(stack[off:])
+		}
+
+		// Only return addresses into code with symbols are trusted
+		if _, ok := s.symbolize(addr, true); !ok {
+			continue
+		}
+		frames = append(frames, s.frame(len(frames), addr, "scan"))
+	}
+
+	return frames
+}
+
+// frame symbolizes one frame. Return addresses are looked up one byte back
+// so that they resolve to the call instruction.
+func (s *dumpSymbolizer) frame(index int, addr uint64, trust string) dumpFrame {
+	f := dumpFrame{
+		Index:   index,
+		Address: fmt.Sprintf("0x%x", addr),
+		Trust:   trust,
+	}
+
+	mod := s.dump.ModuleAt(addr)
+	if mod == nil {
+		return f
+	}
+	f.Module = windowsBase(mod.Name)
+	f.ModuleOffset = fmt.Sprintf("0x%x", addr-mod.BaseOfImage)
+
+	frames, ok := s.symbolize(addr, trust != "context")
+	if !ok {
+		return f
+	}
+
+	for _, sf := range frames {
+		offset := sf.Offset
+		if trust != "context" {
+			offset++
+		}
+		f.Locations = append(f.Locations, dumpLocation{
+			Function:       sf.Function,
+			FunctionOffset: fmt.Sprintf("0x%x", offset),
+			File:           sf.File,
+			Line:           sf.Line,
+			Inlined:        sf.Inlined,
+		})
+	}
+	return f
+}
+
+func (s *dumpSymbolizer) symbolize(addr uint64, returnAddress bool) ([]pdb.SourceFrame, bool) {
+	mod := s.dump.ModuleAt(addr)
+	if mod == nil {
+		return nil, false
+	}
+	f, ok := s.pdbs[mod]
+	if !ok {
+		return nil, false
+	}
+
+	rva := addr - mod.BaseOfImage
+	if returnAddress {
+		if rva == 0 {
+			return nil, false
+		}
+		rva--
+	}
+
+	frames, err := f.SymbolizeRVA(uint32(rva))
+	if err != nil || len(frames) == 0 {
+		return nil, false
+	}
+	return frames, true
+}
+
+func writeDumpReport(report *dumpReport) {
+	if c := report.Crash; c != nil {
+		reason := c.Reason
+		if reason == "" {
+			reason = c.Code
+		} else {
+			reason += " (" + c.Code + ")"
+		}
+		fmt.Fprintf(output, "Crash reason:    %s\n", reason)
+		fmt.Fprintf(output, "Crash address:   %s\n", c.Address)
+		fmt.Fprintf(output, "Crashing thread: %d\n\n", c.Thread)
+	}
+
+	for _, t := range report.Threads {
+		if t.Crashed {
+			fmt.Fprintf(output, "Thread %d (crashed)\n", t.ID)
+		} else {
+			fmt.Fprintf(output, "Thread %d\n", t.ID)
+		}
+
+		for _, f := range t.Frames {
+			prefix := fmt.Sprintf("%3d  ", f.Index)
+			switch {
+			case len(f.Locations) > 0:
+				for _, loc := range f.Locations {
+					fmt.Fprintf(output, "%s%s!%s + %s", prefix, f.Module, loc.Function, loc.FunctionOffset)
+					if loc.File != "" {
+						fmt.Fprintf(output, " [%s : %d]", loc.File, loc.Line)
+					}
+					if loc.Inlined {
+						fmt.Fprint(output, " (inlined)")
+					}
+					fmt.Fprintln(output)
+					prefix = strings.Repeat(" ", len(prefix))
+				}
+			case f.Module != "":
+				fmt.Fprintf(output, "%s%s + %s\n", prefix, f.Module, f.ModuleOffset)
+			default:
+				fmt.Fprintf(output, "%s%s\n", prefix, f.Address)
+			}
+
+			fmt.Fprintf(output, "     Found by: %s\n", foundBy[f.Trust])
+		}
+		fmt.Fprintln(output)
+	}
+
+	fmt.Fprintln(output, "Loaded modules:")
+	for _, m := range report.Modules {
+		fmt.Fprintf(output, "%18s  %08x  %-24s %-24s %s\n", m.Base, m.Size, m.Name, m.PDB, m.Symbols)
+	}
+}
@@ -4,16 +4,23 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/skdltmxn/pdb-go/demangle"
 	"github.com/skdltmxn/pdb-go/pdb"
 	"github.com/spf13/cobra"
 )
 
 var (
-	symbolsAll       bool
-	symbolsKind      string
-	symbolsDemangled bool
-	symbolsLimit     int
-	symbolsShowRVA   bool
+	symbolsAll        bool
+	symbolsKind       string
+	symbolsDemangled  bool
+	symbolsLimit      int
+	symbolsShowRVA    bool
+	symbolsClass      string
+	symbolsNoCompGen  bool
+	symbolsFilter     string
+	symbolsGlob       bool
+	symbolsRegexp     bool
+	symbolsIgnoreCase bool
 )
 
 var symbolsCmd = &cobra.Command{
@@ -22,7 +29,17 @@ var symbolsCmd = &cobra.Command{
 	Long: `List symbols from a PDB file.
 
 By default, only public symbols are shown. Use --all to include module symbols.
-Use --kind to filter by symbol kind (public, function, data, udt, constant).`,
+Use --kind to filter by symbol kind (public, function, data, udt, constant).
+
+Use --class to select decorated names by what they refer to (function,
+variable, compiler-function, string, vftable, vbtable, rtti, initializer,
+atexit, guard, hashed, constant), and --hide-generated to drop string
+literals, vftables, RTTI descriptors and other compiler-generated symbols.
+
+Use --filter to show only symbols whose raw or undecorated name contains the
+given text (or matches it with --glob or --regex; -i ignores case). A glob
+matches whole names. Filtered symbols are listed exact matches first, then
+prefix matches, then substring matches, and symbols at the same address once.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSymbols,
 }
@@ -33,12 +50,18 @@ func init() {
 	symbolsCmd.Flags().BoolVarP(&symbolsDemangled, "demangle", "d", false, "show demangled names")
 	symbolsCmd.Flags().IntVarP(&symbolsLimit, "limit", "n", 0, "limit number of symbols shown (0 = unlimited)")
 	symbolsCmd.Flags().BoolVarP(&symbolsShowRVA, "rva", "r", false, "show RVA (Relative Virtual Address)")
+	symbolsCmd.Flags().StringVar(&symbolsClass, "class", "", "filter by decorated name class (comma-separated, e.g. function,variable)")
+	symbolsCmd.Flags().BoolVar(&symbolsNoCompGen, "hide-generated", false, "hide compiler-generated symbols (string literals, vftables, RTTI, ...)")
+	symbolsCmd.Flags().StringVarP(&symbolsFilter, "filter", "f", "", "show only symbols whose name matches this text or pattern")
+	symbolsCmd.Flags().BoolVarP(&symbolsGlob, "glob", "g", false, "read --filter as a glob pattern (*, ?, [...])")
+	symbolsCmd.Flags().BoolVarP(&symbolsRegexp, "regex", "e", false, "read --filter as a regular expression")
+	symbolsCmd.Flags().BoolVarP(&symbolsIgnoreCase, "ignore-case", "i", false, "ignore case when matching --filter")
 }
 
 func runSymbols(cmd *cobra.Command, args []string) error {
 	pdbPath := args[0]
 
-	f, err := pdb.Open(pdbPath)
+	f, err := openPDB(pdbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open PDB: %w", err)
 	}
@@ -83,6 +106,29 @@ func runSymbols(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Determine which name classes to show
+	var classFilter map[demangle.Class]bool
+	if symbolsClass != "" {
+		classFilter = make(map[demangle.Class]bool)
+		for _, name := range strings.Split(symbolsClass, ",") {
+			c, ok := demangle.ParseClass(strings.TrimSpace(strings.ToLower(name)))
+			if !ok {
+				return fmt.Errorf("unknown name class: %s", name)
+			}
+			classFilter[c] = true
+		}
+	}
+	matchesClass := func(sym pdb.Symbol) bool {
+		if classFilter == nil && !symbolsNoCompGen {
+			return true
+		}
+		c := demangle.Classify(sym.Name())
+		if symbolsNoCompGen && c.IsCompilerGenerated() {
+			return false
+		}
+		return classFilter == nil || classFilter[c]
+	}
+
 	// Print header
 	if symbolsShowRVA {
 		fmt.Fprintf(output, "%-10s %-8s %-10s %-10s %s\n", "KIND", "SECTION", "OFFSET", "RVA", "NAME")
@@ -92,35 +138,63 @@ func runSymbols(cmd *cobra.Command, args []string) error {
 	fmt.Fprintf(output, "%s\n", strings.Repeat("-", 90))
 
 	count := 0
+	errs := &parseErrors{cmd: cmd}
+	show := func(sym pdb.Symbol) bool {
+		if hasKindFilter && sym.Kind() != kindFilter {
+			return true
+		}
+		if !matchesClass(sym) {
+			return true
+		}
+		printSymbol(sym, sections)
+		count++
+		return symbolsLimit <= 0 || count < symbolsLimit
+	}
 
-	if symbolsAll {
-		// Iterate all symbols
-		for sym := range symbols.All() {
-			if hasKindFilter && sym.Kind() != kindFilter {
-				continue
+	switch {
+	case symbolsFilter != "":
+		matches, err := filterSymbols(symbols)
+		if err != nil {
+			return err
+		}
+		for _, m := range matches {
+			if !show(m.Symbol) {
+				break
 			}
-			printSymbol(sym, sections)
-			count++
-			if symbolsLimit > 0 && count >= symbolsLimit {
+		}
+	case symbolsAll:
+		// Iterate all symbols
+		for sym, err := range symbols.AllChecked() {
+			if err != nil {
+				errs.report(err)
+			} else if !show(sym) {
 				break
 			}
 		}
-	} else {
+	default:
 		// Only public symbols
-		for sym := range symbols.Public() {
-			if hasKindFilter && sym.Kind() != kindFilter {
-				continue
-			}
-			printSymbol(sym, sections)
-			count++
-			if symbolsLimit > 0 && count >= symbolsLimit {
+		for sym, err := range symbols.PublicChecked() {
+			if err != nil {
+				errs.report(err)
+			} else if !show(sym) {
 				break
 			}
 		}
 	}
 
 	fmt.Fprintf(output, "\nTotal: %d symbols\n", count)
-	return nil
+	return errs.err()
+}
+
+// filterSymbols returns the symbols matching --filter, ranked. Without
+// --all, only public symbols are kept.
+func filterSymbols(symbols *pdb.SymbolTable) ([]pdb.SymbolMatch, error) {
+	opts, err := searchOptions(symbolsGlob, symbolsRegexp, symbolsIgnoreCase)
+	if err != nil {
+		return nil, err
+	}
+	opts.PublicOnly = !symbolsAll
+	return symbols.ByPattern(symbolsFilter, opts)
 }
 
 func printSymbol(sym pdb.Symbol, sections *pdb.SectionHeaders) {
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"debug/pe"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/skdltmxn/pdb-go/minidump"
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// Reasons a module's PDB could not be loaded.
+var (
+	errPDBNotFound = errors.New("PDB not found")
+	errPDBMismatch = errors.New("PDB does not match the module")
+	errPENotFound  = errors.New("executable not found")
+)
+
+// symbolStore locates PDBs in local symbol directories and keeps them open
+// for repeated lookups. A directory may hold PDBs directly or use the
+// symbol server layout <dir>/<name>/<id>/<name>.
+type symbolStore struct {
+	dirs    []string
+	files   map[string]*pdb.File
+	images  []*pe.File
+	missing map[string]error // Failed lookups, so they are not repeated
+}
+
+func newSymbolStore(dirs []string) *symbolStore {
+	return &symbolStore{
+		dirs:    dirs,
+		files:   make(map[string]*pdb.File),
+		missing: make(map[string]error),
+	}
+}
+
+// open returns the PDB matching a module's CodeView record.
+func (s *symbolStore) open(cv *minidump.CodeViewInfo) (*pdb.File, error) {
+	key := cv.DebugID() + "/" + strings.ToLower(cv.PDBName)
+	if f, ok := s.files[key]; ok {
+		return f, nil
+	}
+
+	name := windowsBase(cv.PDBName)
+	mismatch := false
+
+	for _, dir := range s.dirs {
+		for _, path := range []string{
+			filepath.Join(dir, name, cv.DebugID(), name),
+			filepath.Join(dir, name),
+			filepath.Join(dir, strings.ToLower(name)),
+		} {
+			if !isFile(path) {
+				continue
+			}
+
+			f, err := openPDB(path)
+			if err != nil {
+				continue
+			}
+			info, err := f.Info()
+			if err != nil || info.GUID != cv.GUID || info.Age != cv.Age {
+				f.Close()
+				mismatch = true
+				continue
+			}
+
+			s.files[key] = f
+			return f, nil
+		}
+	}
+
+	if mismatch {
+		return nil, errPDBMismatch
+	}
+	return nil, errPDBNotFound
+}
+
+// openName returns the PDB of a module by name alone, without checking its
+// identity. "app.exe", "app" and "app.pdb" all look for app.pdb.
+func (s *symbolStore) openName(module string) (*pdb.File, error) {
+	name := windowsBase(module)
+	if !strings.EqualFold(filepath.Ext(name), ".pdb") {
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + ".pdb"
+	}
+
+	key := "name/" + strings.ToLower(name)
+	if f, ok := s.files[key]; ok {
+		return f, nil
+	}
+	if err, ok := s.missing[key]; ok {
+		return nil, err
+	}
+
+	for _, dir := range s.dirs {
+		candidates := []string{filepath.Join(dir, name), filepath.Join(dir, strings.ToLower(name))}
+		if matches, _ := filepath.Glob(filepath.Join(dir, name, "*", name)); len(matches) > 0 {
+			candidates = append(candidates, matches...)
+		}
+
+		for _, path := range candidates {
+			if !isFile(path) {
+				continue
+			}
+			f, err := openPDB(path)
+			if err != nil {
+				s.missing[key] = err
+				return nil, err
+			}
+			s.files[key] = f
+			return f, nil
+		}
+	}
+
+	s.missing[key] = errPDBNotFound
+	return nil, errPDBNotFound
+}
+
+// openImage returns the executable of a module, stored next to the PDBs
+// either directly or in symbol server layout <dir>/<name>/<timestamp><size>/<name>.
+// Its timestamp and image size must match the module.
+func (s *symbolStore) openImage(m *minidump.Module) (*pe.File, error) {
+	name := windowsBase(m.Name)
+	id := fmt.Sprintf("%08X%x", m.TimeDateStamp, m.SizeOfImage)
+
+	for _, dir := range s.dirs {
+		for _, path := range []string{
+			filepath.Join(dir, name, id, name),
+			filepath.Join(dir, name),
+			filepath.Join(dir, strings.ToLower(name)),
+		} {
+			if !isFile(path) {
+				continue
+			}
+
+			f, err := pe.Open(path)
+			if err != nil {
+				continue
+			}
+			var size uint32
+			switch oh := f.OptionalHeader.(type) {
+			case *pe.OptionalHeader32:
+				size = oh.SizeOfImage
+			case *pe.OptionalHeader64:
+				size = oh.SizeOfImage
+			}
+			if f.TimeDateStamp != m.TimeDateStamp || size != m.SizeOfImage {
+				f.Close()
+				continue
+			}
+
+			s.images = append(s.images, f)
+			return f, nil
+		}
+	}
+	return nil, errPENotFound
+}
+
+// add registers an opened PDB under a module name for openName.
+func (s *symbolStore) add(name string, f *pdb.File) {
+	name = windowsBase(name)
+	name = strings.TrimSuffix(name, filepath.Ext(name)) + ".pdb"
+	s.files["name/"+strings.ToLower(name)] = f
+}
+
+// Close closes all opened PDBs and executables.
+func (s *symbolStore) Close() {
+	for _, f := range s.files {
+		f.Close()
+	}
+	for _, f := range s.images {
+		f.Close()
+	}
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// windowsBase returns the last element of a path that may use Windows
+// separators.
+func windowsBase(path string) string {
+	if i := strings.LastIndexAny(path, `\/`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
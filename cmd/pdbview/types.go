@@ -31,7 +31,7 @@ func init() {
 func runTypes(cmd *cobra.Command, args []string) error {
 	pdbPath := args[0]
 
-	f, err := pdb.Open(pdbPath)
+	f, err := openPDB(pdbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open PDB: %w", err)
 	}
@@ -74,8 +74,13 @@ func runTypes(cmd *cobra.Command, args []string) error {
 	fmt.Fprintf(output, "%s\n", strings.Repeat("-", 80))
 
 	count := 0
+	errs := &parseErrors{cmd: cmd}
 
-	for typ := range types.All() {
+	for typ, err := range types.AllChecked() {
+		if err != nil {
+			errs.report(err)
+			continue
+		}
 		if hasKindFilter && typ.Kind() != kindFilter {
 			continue
 		}
@@ -88,7 +93,7 @@ func runTypes(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Fprintf(output, "\nTotal: %d types\n", count)
-	return nil
+	return errs.err()
 }
 
 func printType(typ pdb.Type) {
@@ -0,0 +1,169 @@
+package demangle
+
+import "strings"
+
+// Class is the kind of entity a decorated name refers to.
+type Class int
+
+const (
+	ClassUnknown            Class = iota // Not a recognized decorated name
+	ClassFunction                        // Function written in the source
+	ClassVariable                        // Variable written in the source
+	ClassCompilerFunction                // Deleting destructor, closure, vector iterator or thunk
+	ClassStringLiteral                   // ??_C@_ string literal
+	ClassVFTable                         // vftable or local vftable
+	ClassVBTable                         // vbtable
+	ClassRTTI                            // RTTI descriptor or complete object locator
+	ClassDynamicInitializer              // ??__E initializer of a global
+	ClassAtExitDestructor                // ??__F destructor of a global
+	ClassLocalStaticGuard                // Guard of function-local statics
+	ClassHashedName                      // ??@ name shortened to its MD5 hash
+	ClassConstant                        // __real@, __xmm@ and similar constants
+)
+
+var classNames = map[Class]string{
+	ClassUnknown:            "unknown",
+	ClassFunction:           "function",
+	ClassVariable:           "variable",
+	ClassCompilerFunction:   "compiler-function",
+	ClassStringLiteral:      "string",
+	ClassVFTable:            "vftable",
+	ClassVBTable:            "vbtable",
+	ClassRTTI:               "rtti",
+	ClassDynamicInitializer: "initializer",
+	ClassAtExitDestructor:   "atexit",
+	ClassLocalStaticGuard:   "guard",
+	ClassHashedName:         "hashed",
+	ClassConstant:           "constant",
+}
+
+func (c Class) String() string {
+	if name, ok := classNames[c]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseClass returns the class with a name as returned by String.
+func ParseClass(name string) (Class, bool) {
+	for c, n := range classNames {
+		if n == name {
+			return c, true
+		}
+	}
+	return ClassUnknown, false
+}
+
+// IsCompilerGenerated reports whether symbols of the class are emitted by
+// the compiler rather than declared in the source.
+func (c Class) IsCompilerGenerated() bool {
+	switch c {
+	case ClassUnknown, ClassFunction, ClassVariable, ClassHashedName:
+		return false
+	}
+	return true
+}
+
+// Prefixes of floating-point and vector constants
+var constantPrefixes = []string{"__real@", "__xmm@", "__ymm@", "__zmm@", "__mask@"}
+
+// Operators naming functions the compiler generates
+var compilerOperators = map[OperatorKind]bool{
+	OpVCall:                     true,
+	OpVBaseDtor:                 true,
+	OpVectorDeletingDtor:        true,
+	OpDefaultCtorClosure:        true,
+	OpScalarDeletingDtor:        true,
+	OpVectorCtorIterator:        true,
+	OpVectorDtorIterator:        true,
+	OpVectorVbaseCtorIterator:   true,
+	OpVirtualDisplacementMap:    true,
+	OpEHVectorCtorIterator:      true,
+	OpEHVectorDtorIterator:      true,
+	OpEHVectorVbaseCtorIterator: true,
+	OpCopyCtorClosure:           true,
+	OpLocalVFTableCtorClosure:   true,
+}
+
+// Classify returns the class of a decorated name.
+func Classify(name string) Class {
+	for _, prefix := range constantPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return ClassConstant
+		}
+	}
+	if !strings.HasPrefix(name, "?") {
+		return ClassUnknown
+	}
+
+	node, err := DemangleToNode(name)
+	if err != nil {
+		return ClassUnknown
+	}
+
+	switch n := node.(type) {
+	case *StringLiteral:
+		return ClassStringLiteral
+
+	case *SpecialTableSymbol:
+		if op, ok := n.Name.BaseName().(*Operator); ok {
+			switch op.Op {
+			case OpVBTable:
+				return ClassVBTable
+			case OpRTTICompleteObjectLocator:
+				return ClassRTTI
+			}
+		}
+		return ClassVFTable
+
+	case *FunctionSymbol:
+		switch base := n.Name.BaseName().(type) {
+		case *DynamicStructor:
+			if base.IsDestructor {
+				return ClassAtExitDestructor
+			}
+			return ClassDynamicInitializer
+		case *Operator:
+			if compilerOperators[base.Op] {
+				return ClassCompilerFunction
+			}
+		}
+		if n.IsThunk {
+			return ClassCompilerFunction
+		}
+		return ClassFunction
+
+	case *VariableSymbol:
+		switch base := n.Name.BaseName().(type) {
+		case *Operator:
+			if base.Op == OpRTTITypeDescriptor {
+				return ClassRTTI
+			}
+		case *Identifier:
+			// Guards of thread-safe statics
+			if strings.HasPrefix(base.Name, "$TSS") {
+				return ClassLocalStaticGuard
+			}
+		}
+		return ClassVariable
+
+	case *QualifiedName:
+		switch base := n.BaseName().(type) {
+		case *HashedName:
+			return ClassHashedName
+		case *RTTIBaseClassDescriptor:
+			return ClassRTTI
+		case *Operator:
+			switch base.Op {
+			case OpLocalStaticGuard:
+				return ClassLocalStaticGuard
+			case OpRTTIBaseClassArray, OpRTTIClassHierarchyDescriptor:
+				return ClassRTTI
+			}
+			if compilerOperators[base.Op] {
+				return ClassCompilerFunction
+			}
+		}
+	}
+	return ClassUnknown
+}
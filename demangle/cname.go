@@ -0,0 +1,112 @@
+package demangle
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CName is a C function name with the decoration of its x86 calling
+// convention removed:
+//
+//	_name      __cdecl (with the X86 option)
+//	_name@N    __stdcall
+//	@name@N    __fastcall
+//	name@@N    __vectorcall
+//
+// N is the number of bytes of arguments passed on the stack.
+type CName struct {
+	Name        string
+	CallingConv CallingConvention
+	ArgBytes    int  // -1 for __cdecl, whose decoration does not record it
+	IsImport    bool // __imp_ pointer to the function in the import address table
+}
+
+// ParseCName parses a decorated C name. Only the X86 option is used: a
+// leading underscore marks __cdecl names on 32-bit x86 but is part of the
+// name elsewhere. The second result is false if the name has no
+// decoration.
+func ParseCName(decorated string, options ...Option) (CName, bool) {
+	var opts Option
+	for _, o := range options {
+		opts |= o
+	}
+
+	c := CName{ArgBytes: -1}
+	name := decorated
+	if rest, ok := strings.CutPrefix(name, "__imp_"); ok {
+		c.IsImport = true
+		name = rest
+	}
+	if name == "" || name[0] == '?' {
+		return CName{}, false
+	}
+
+	at := strings.LastIndexByte(name, '@')
+	if at < 0 {
+		if opts&X86 != 0 && len(name) > 1 && name[0] == '_' {
+			c.Name, c.CallingConv = name[1:], CallingConvCdecl
+			return c, true
+		}
+		return CName{}, false
+	}
+
+	n, err := strconv.Atoi(name[at+1:])
+	if err != nil || n < 0 || name[at+1] == '+' {
+		return CName{}, false
+	}
+	c.ArgBytes = n
+
+	base := name[:at]
+	switch {
+	case strings.HasSuffix(base, "@"):
+		c.Name, c.CallingConv = base[:len(base)-1], CallingConvVectorcall
+	case strings.HasPrefix(base, "@"):
+		c.Name, c.CallingConv = base[1:], CallingConvFastcall
+	case strings.HasPrefix(base, "_"):
+		c.Name, c.CallingConv = base[1:], CallingConvStdcall
+	default:
+		return CName{}, false
+	}
+
+	if c.Name == "" || strings.ContainsRune(c.Name, '@') {
+		return CName{}, false
+	}
+	return c, true
+}
+
+// Decorated returns the decorated name, as ParseCName reads it with the
+// same options. Without the X86 option, only __vectorcall names are
+// decorated: the other conventions are not distinguished off 32-bit x86.
+func (c CName) Decorated(options ...Option) string {
+	var opts Option
+	for _, o := range options {
+		opts |= o
+	}
+
+	argBytes := strconv.Itoa(max(c.ArgBytes, 0))
+	name := c.Name
+	switch {
+	case c.CallingConv == CallingConvVectorcall:
+		name += "@@" + argBytes
+	case opts&X86 == 0:
+	case c.CallingConv == CallingConvStdcall:
+		name = "_" + name + "@" + argBytes
+	case c.CallingConv == CallingConvFastcall:
+		name = "@" + name + "@" + argBytes
+	default:
+		name = "_" + name
+	}
+	if c.IsImport {
+		return "__imp_" + name
+	}
+	return name
+}
+
+// String returns the undecorated name, keeping the __imp_ prefix of
+// imports.
+func (c CName) String() string {
+	if c.IsImport {
+		return "__imp_" + c.Name
+	}
+	return c.Name
+}
@@ -0,0 +1,44 @@
+package demangle_test
+
+import (
+	"testing"
+
+	"github.com/skdltmxn/pdb-go/demangle"
+)
+
+func TestCNameDecorated(t *testing.T) {
+	tests := []struct {
+		decorated string
+		x86       bool
+	}{
+		{"_main", true},
+		{"_Add@8", true},
+		{"@Fast@8", true},
+		{"Blend@@32", true},
+		{"__imp__Add@8", true},
+		{"Blend@@32", false},
+	}
+
+	for _, tt := range tests {
+		var opts []demangle.Option
+		if tt.x86 {
+			opts = append(opts, demangle.X86)
+		}
+		c, ok := demangle.ParseCName(tt.decorated, opts...)
+		if !ok {
+			t.Errorf("ParseCName(%q) failed", tt.decorated)
+			continue
+		}
+		if got := c.Decorated(opts...); got != tt.decorated {
+			t.Errorf("ParseCName(%q).Decorated() = %q", tt.decorated, got)
+		}
+	}
+
+	// Off x86, only __vectorcall names are decorated
+	for _, cc := range []demangle.CallingConvention{demangle.CallingConvCdecl, demangle.CallingConvStdcall, demangle.CallingConvFastcall} {
+		c := demangle.CName{Name: "f", CallingConv: cc, ArgBytes: 8}
+		if got := c.Decorated(); got != "f" {
+			t.Errorf("%v name decorated to %q off x86", cc, got)
+		}
+	}
+}
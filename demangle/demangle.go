@@ -0,0 +1,1414 @@
+package demangle
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode/utf16"
+)
+
+// Errors
+var (
+	ErrEmptyInput      = errors.New("demangle: empty input")
+	ErrInvalidMangled  = errors.New("demangle: invalid mangled name")
+	ErrUnexpectedEnd   = errors.New("demangle: unexpected end of input")
+	ErrInvalidBackref  = errors.New("demangle: invalid back-reference")
+	ErrUnknownOperator = errors.New("demangle: unknown operator")
+	ErrUnknownType     = errors.New("demangle: unknown type")
+)
+
+// Demangle converts an MSVC decorated name to readable form. Options leave
+// parts of the declaration out, e.g.
+//
+//	Demangle("?Run@MyClass@@QEAAXXZ")                         // public: void __cdecl MyClass::Run(void) __ptr64
+//	Demangle("?Run@MyClass@@QEAAXXZ", NoAccessSpecifiers|NoPtr64) // void __cdecl MyClass::Run(void)
+//	Demangle("?Run@MyClass@@QEAAXXZ", NameOnly)               // MyClass::Run
+//
+// Rust names are demangled with DemangleRust and decorated C names are
+// undecorated with ParseCName. If the name is not mangled, it is returned
+// unchanged.
+func Demangle(decorated string, options ...Option) (string, error) {
+	if len(decorated) == 0 {
+		return "", ErrEmptyInput
+	}
+
+	if IsRustMangled(decorated) {
+		if s, err := DemangleRust(decorated, options...); err == nil {
+			return s, nil
+		}
+	}
+
+	// Check if this is a mangled C++ name
+	if decorated[0] != '?' {
+		// Not a C++ mangled name - might be a decorated C name
+		if c, ok := ParseCName(decorated, options...); ok {
+			return c.String(), nil
+		}
+		return decorated, nil
+	}
+
+	// Parse the mangled name
+	d := newDemangler(decorated)
+	node, err := d.parse()
+	if err != nil {
+		// On error, return the original name
+		return decorated, err
+	}
+
+	return Format(node, options...), nil
+}
+
+// DemangleToNode parses a mangled name and returns the AST. Rust names are
+// returned as a single Identifier holding the demangled path.
+func DemangleToNode(decorated string) (Node, error) {
+	if len(decorated) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	if IsRustMangled(decorated) {
+		if s, err := DemangleRust(decorated); err == nil {
+			return &Identifier{Name: s}, nil
+		}
+	}
+
+	if decorated[0] != '?' {
+		return &Identifier{Name: decorated}, nil
+	}
+
+	d := newDemangler(decorated)
+	return d.parse()
+}
+
+// DemangleType parses a decorated type, such as the unique name of a class
+// in the type table (.?AVWidget@ui@@) or the type of an RTTI type
+// descriptor.
+func DemangleType(decorated string) (Node, error) {
+	if len(decorated) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	d := newDemangler(strings.TrimPrefix(decorated, "."))
+	typ, err := d.parseResultType()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.input) {
+		return nil, ErrInvalidMangled
+	}
+	return typ, nil
+}
+
+// demangler holds parser state.
+type demangler struct {
+	input string
+	pos   int
+
+	// Back-reference tables: names (0-9 in names) and function parameter
+	// or template argument types (0-9 in types). Template instantiations
+	// start new tables.
+	backrefs backrefs
+}
+
+type backrefs struct {
+	names  []Node
+	params []Node
+}
+
+const maxBackrefs = 10
+
+func newDemangler(input string) *demangler {
+	return &demangler{
+		input: input,
+	}
+}
+
+// parse parses a complete symbol: '?' <name> <encoding>.
+func (d *demangler) parse() (Node, error) {
+	if !d.consumeByte('?') {
+		return nil, ErrInvalidMangled
+	}
+
+	// Compiler-generated names with encodings of their own
+	switch {
+	case d.consumePrefix("?@"):
+		return d.parseHashedName()
+	case d.consumePrefix("?_C@_"):
+		return d.parseStringLiteral()
+	case d.consumePrefix("?_7"):
+		return d.parseSpecialTable(OpVFTable)
+	case d.consumePrefix("?_8"):
+		return d.parseSpecialTable(OpVBTable)
+	case d.consumePrefix("?_S"):
+		return d.parseSpecialTable(OpLocalVFTable)
+	case d.consumePrefix("?_B"):
+		return d.parseLocalStaticGuard()
+	case d.consumePrefix("?_R"):
+		return d.parseRTTI()
+	case d.consumePrefix("?__E"):
+		return d.parseDynamicStructor(false)
+	case d.consumePrefix("?__F"):
+		return d.parseDynamicStructor(true)
+	}
+
+	name, err := d.parseFullyQualifiedName(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.parseEncoding(name)
+}
+
+// parseHashedName parses <md5 hash> '@' of a name shortened to its hash.
+// The complete object locator of a class with a hashed name follows it.
+func (d *demangler) parseHashedName() (Node, error) {
+	end := strings.IndexByte(d.input[d.pos:], '@')
+	if end <= 0 {
+		return nil, ErrInvalidMangled
+	}
+	name := &QualifiedName{Components: []Node{&HashedName{Hash: d.input[d.pos : d.pos+end]}}}
+	d.pos += end + 1
+
+	if d.consumePrefix("??_R4@") {
+		name.Components = append(name.Components, &Operator{Op: OpRTTICompleteObjectLocator})
+		return &SpecialTableSymbol{Name: name, Quals: Qualifiers{IsConst: true}}, nil
+	}
+	return name, nil
+}
+
+// parseStringLiteral parses <char kind> <length> <crc> '@' <chars> '@'
+// following ??_C@_.
+func (d *demangler) parseStringLiteral() (Node, error) {
+	var wide bool
+	switch d.consume() {
+	case '0':
+	case '1':
+		wide = true
+	default:
+		return nil, ErrInvalidMangled
+	}
+
+	size, err := d.parseNumber()
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		return nil, ErrInvalidMangled
+	}
+
+	// Skip the CRC of the string
+	end := strings.IndexByte(d.input[d.pos:], '@')
+	if end < 0 {
+		return nil, ErrUnexpectedEnd
+	}
+	d.pos += end + 1
+
+	var data []byte
+	for !d.consumeByte('@') {
+		if d.pos >= len(d.input) {
+			return nil, ErrUnexpectedEnd
+		}
+		b, err := d.parseStringChar()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b)
+	}
+
+	lit := &StringLiteral{
+		CharType:  PrimChar,
+		CharWidth: 1,
+		Truncated: int64(len(data)) < size,
+	}
+	switch {
+	case wide:
+		// wchar_t characters are encoded big-endian
+		for i := 0; i+1 < len(data); i += 2 {
+			data[i], data[i+1] = data[i+1], data[i]
+		}
+		lit.CharType, lit.CharWidth = PrimWChar, 2
+	default:
+		// char16_t and char32_t literals share the kind of narrow ones
+		switch guessCharWidth(data, size, lit.Truncated) {
+		case 2:
+			lit.CharType, lit.CharWidth = PrimChar16, 2
+		case 4:
+			lit.CharType, lit.CharWidth = PrimChar32, 4
+		}
+	}
+	lit.Length = int(size) / lit.CharWidth
+	lit.Prefix = decodeChars(data, lit.CharWidth, !lit.Truncated)
+	return lit, nil
+}
+
+// parseStringChar parses one byte of a string literal.
+func (d *demangler) parseStringChar() (byte, error) {
+	c := d.consume()
+	if c != '?' {
+		return c, nil
+	}
+
+	c = d.consume()
+	switch {
+	case c == '$':
+		hi, lo := d.consume(), d.consume()
+		if hi < 'A' || hi > 'P' || lo < 'A' || lo > 'P' {
+			return 0, ErrInvalidMangled
+		}
+		return (hi-'A')<<4 | (lo - 'A'), nil
+	case c >= '0' && c <= '9':
+		return ",/\\:. \n\t'-"[c-'0'], nil
+	case c >= 'a' && c <= 'z':
+		return 0xE1 + c - 'a', nil
+	case c >= 'A' && c <= 'Z':
+		return 0xC1 + c - 'A', nil
+	}
+	return 0, ErrInvalidMangled
+}
+
+// guessCharWidth infers the character size of a string literal from the
+// width of its NUL terminator, or from the zero bytes of ASCII characters
+// if the string is truncated.
+func guessCharWidth(data []byte, size int64, truncated bool) int {
+	if !truncated {
+		zeros := 0
+		for i := len(data) - 1; i >= 0 && data[i] == 0; i-- {
+			zeros++
+		}
+		switch {
+		case zeros >= 4 && size%4 == 0:
+			return 4
+		case zeros >= 2 && size%2 == 0:
+			return 2
+		}
+		return 1
+	}
+
+	zeroAt := func(stride int, offsets ...int) bool {
+		for i := 0; i+stride <= len(data); i += stride {
+			for _, o := range offsets {
+				if data[i+o] != 0 {
+					return false
+				}
+			}
+		}
+		return len(data) >= stride
+	}
+	switch {
+	case size%4 == 0 && zeroAt(4, 2, 3):
+		return 4
+	case size%2 == 0 && zeroAt(2, 1):
+		return 2
+	}
+	return 1
+}
+
+// decodeChars converts little-endian characters of a width to text,
+// dropping the terminating NUL of a complete string.
+func decodeChars(data []byte, width int, complete bool) string {
+	var runes []rune
+	switch width {
+	case 2:
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			units = append(units, binary.LittleEndian.Uint16(data[i:]))
+		}
+		runes = utf16.Decode(units)
+	case 4:
+		for i := 0; i+3 < len(data); i += 4 {
+			runes = append(runes, rune(binary.LittleEndian.Uint32(data[i:])))
+		}
+	default:
+		if complete && len(data) > 0 && data[len(data)-1] == 0 {
+			data = data[:len(data)-1]
+		}
+		return string(data)
+	}
+
+	if complete && len(runes) > 0 && runes[len(runes)-1] == 0 {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
+
+// parseSpecialTable parses the scope of a vftable, vbtable or complete
+// object locator, then '6' or '7', its qualifiers and the base classes it
+// is for.
+func (d *demangler) parseSpecialTable(op OperatorKind) (Node, error) {
+	name, err := d.parseScopeChain(&Operator{Op: op})
+	if err != nil {
+		return nil, err
+	}
+
+	if c := d.consume(); c != '6' && c != '7' {
+		return nil, ErrInvalidMangled
+	}
+	quals, _ := d.parseQualifiers()
+	table := &SpecialTableSymbol{Name: name, Quals: quals}
+
+	for d.pos < len(d.input) && !d.consumeByte('@') {
+		target, err := d.parseFullyQualifiedName(false)
+		if err != nil {
+			return nil, err
+		}
+		table.Targets = append(table.Targets, target)
+	}
+	return table, nil
+}
+
+// parseLocalStaticGuard parses the scope of a guard for function-local
+// statics, followed by its visibility and index.
+func (d *demangler) parseLocalStaticGuard() (Node, error) {
+	name, err := d.parseScopeChain(&Operator{Op: OpLocalStaticGuard})
+	if err != nil {
+		return nil, err
+	}
+
+	if d.consumePrefix("4IA") || d.consumeByte('5') {
+		if d.pos < len(d.input) {
+			if _, err := d.parseNumber(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return name, nil
+}
+
+func (d *demangler) parseRTTI() (Node, error) {
+	if d.pos >= len(d.input) {
+		return nil, ErrUnexpectedEnd
+	}
+
+	switch d.consume() {
+	case '0':
+		// Type descriptors name a type, usually with result qualifiers
+		typ, err := d.parseResultType()
+		if err != nil {
+			return nil, err
+		}
+		if !d.consumePrefix("@8") {
+			return nil, ErrInvalidMangled
+		}
+		return &VariableSymbol{
+			Name: &QualifiedName{Components: []Node{&Operator{Op: OpRTTITypeDescriptor}}},
+			Type: typ,
+		}, nil
+
+	case '1':
+		var desc RTTIBaseClassDescriptor
+		for _, v := range []*int64{&desc.NVOffset, &desc.VBPtrOffset, &desc.VBTableOffset, &desc.Flags} {
+			n, err := d.parseNumber()
+			if err != nil {
+				return nil, err
+			}
+			*v = n
+		}
+		return d.parseRTTIName(&desc)
+
+	case '2':
+		return d.parseRTTIName(&Operator{Op: OpRTTIBaseClassArray})
+	case '3':
+		return d.parseRTTIName(&Operator{Op: OpRTTIClassHierarchyDescriptor})
+	case '4':
+		return d.parseSpecialTable(OpRTTICompleteObjectLocator)
+	}
+
+	d.pos--
+	return nil, ErrUnknownOperator
+}
+
+// parseRTTIName parses the class of an RTTI descriptor, terminated by '8'.
+func (d *demangler) parseRTTIName(first Node) (Node, error) {
+	name, err := d.parseScopeChain(first)
+	if err != nil {
+		return nil, err
+	}
+	if !d.consumeByte('8') {
+		return nil, ErrInvalidMangled
+	}
+	return name, nil
+}
+
+// parseDynamicStructor parses a dynamic initializer or atexit destructor:
+// the name of the global it is for, or the complete symbol of a static data
+// member, then the function encoding.
+func (d *demangler) parseDynamicStructor(isDestructor bool) (Node, error) {
+	ds := &DynamicStructor{IsDestructor: isDestructor}
+
+	if d.peek() == '?' {
+		sym, err := d.parse()
+		if err != nil {
+			return nil, err
+		}
+		v, ok := sym.(*VariableSymbol)
+		if !ok {
+			return nil, ErrInvalidMangled
+		}
+		ds.Variable = v
+		for d.consumeByte('@') {
+		}
+	} else {
+		name, err := d.parseFullyQualifiedName(false)
+		if err != nil {
+			return nil, err
+		}
+		ds.Variable = name
+	}
+
+	if d.pos >= len(d.input) {
+		return nil, ErrUnexpectedEnd
+	}
+	return d.parseFunctionEncoding(&QualifiedName{Components: []Node{ds}})
+}
+
+// Operators encoded as ?<code>
+var operatorCodes = map[byte]OperatorKind{
+	'2': OpNew, '3': OpDelete, '4': OpAssign, '5': OpRightShift,
+	'6': OpLeftShift, '7': OpLogicalNot, '8': OpEqual, '9': OpNotEqual,
+	'A': OpSubscript, 'C': OpArrow, 'D': OpDereference, 'E': OpIncrement,
+	'F': OpDecrement, 'G': OpMinus, 'H': OpPlus, 'I': OpAddressOf,
+	'J': OpArrowDeref, 'K': OpDivide, 'L': OpModulo, 'M': OpLess,
+	'N': OpLessEqual, 'O': OpGreater, 'P': OpGreaterEqual, 'Q': OpComma,
+	'R': OpCall, 'S': OpComplement, 'T': OpXor, 'U': OpBitwiseOr,
+	'V': OpLogicalAnd, 'W': OpLogicalOr, 'X': OpMultiplyAssign,
+	'Y': OpPlusAssign, 'Z': OpMinusAssign,
+}
+
+// Operators encoded as ?_<code>
+var extendedOperatorCodes = map[byte]OperatorKind{
+	'0': OpDivideAssign, '1': OpModuloAssign, '2': OpRightShiftAssign,
+	'3': OpLeftShiftAssign, '4': OpAndAssign, '5': OpOrAssign,
+	'6': OpXorAssign, '9': OpVCall, 'A': OpTypeof, 'D': OpVBaseDtor,
+	'E': OpVectorDeletingDtor, 'F': OpDefaultCtorClosure,
+	'G': OpScalarDeletingDtor, 'H': OpVectorCtorIterator,
+	'I': OpVectorDtorIterator, 'J': OpVectorVbaseCtorIterator,
+	'K': OpVirtualDisplacementMap, 'L': OpEHVectorCtorIterator,
+	'M': OpEHVectorDtorIterator, 'N': OpEHVectorVbaseCtorIterator,
+	'O': OpCopyCtorClosure, 'T': OpLocalVFTableCtorClosure,
+	'U': OpNewArray, 'V': OpDeleteArray,
+}
+
+// Operators encoded as ?__<code>
+var extendedOperatorCodes2 = map[byte]OperatorKind{
+	'L': OpCoAwait, 'M': OpSpaceship,
+}
+
+// parseOperatorName parses the operator following a '?' in the first
+// component of a name.
+func (d *demangler) parseOperatorName() (Node, error) {
+	if d.pos >= len(d.input) {
+		return nil, ErrUnexpectedEnd
+	}
+
+	c := d.consume()
+	switch c {
+	case '0':
+		return &Structor{}, nil
+	case '1':
+		return &Structor{IsDestructor: true}, nil
+	case 'B':
+		// The target type is the function's return type
+		return &ConversionOperator{}, nil
+	case '_':
+		table := extendedOperatorCodes
+		if d.consumeByte('_') {
+			table = extendedOperatorCodes2
+		}
+		if op, ok := table[d.peek()]; ok {
+			d.pos++
+			return &Operator{Op: op}, nil
+		}
+	default:
+		if op, ok := operatorCodes[c]; ok {
+			return &Operator{Op: op}, nil
+		}
+	}
+
+	return nil, ErrUnknownOperator
+}
+
+// parseFullyQualifiedName parses a name and its enclosing scopes up to the
+// terminating '@'. Operators are only allowed as the first component of a
+// symbol's name.
+func (d *demangler) parseFullyQualifiedName(allowOperator bool) (*QualifiedName, error) {
+	first, err := d.parseUnqualifiedName(allowOperator)
+	if err != nil {
+		return nil, err
+	}
+	return d.parseScopeChain(first)
+}
+
+// parseScopeChain parses the scopes enclosing a name up to the terminating
+// '@'.
+func (d *demangler) parseScopeChain(first Node) (*QualifiedName, error) {
+	components := []Node{first}
+
+	for !d.consumeByte('@') {
+		if d.pos >= len(d.input) {
+			return nil, ErrUnexpectedEnd
+		}
+
+		parts, err := d.parseScopeFragment()
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, parts...)
+	}
+
+	// Reverse to get natural C++ order
+	for i, j := 0, len(components)-1; i < j; i, j = i+1, j-1 {
+		components[i], components[j] = components[j], components[i]
+	}
+
+	// Constructors and destructors are named after their class
+	if s, ok := first.(*Structor); ok && len(components) > 1 {
+		s.Class = components[len(components)-2]
+	}
+
+	return &QualifiedName{Components: components}, nil
+}
+
+func (d *demangler) parseUnqualifiedName(allowOperator bool) (Node, error) {
+	c := d.peek()
+	switch {
+	case c >= '0' && c <= '9':
+		return d.parseNameBackref()
+	case d.startsWith("?$"):
+		// Function templates are not memorized; they are the only templates
+		// naming a symbol
+		return d.parseTemplateInstantiation(!allowOperator)
+	case c == '?' && allowOperator:
+		d.pos++
+		return d.parseOperatorName()
+	}
+	return d.parseSimpleName()
+}
+
+// parseScopeFragment parses a namespace or class component. Locally scoped
+// names yield two components: the enclosing function and the scope number.
+func (d *demangler) parseScopeFragment() ([]Node, error) {
+	c := d.peek()
+	switch {
+	case c >= '0' && c <= '9':
+		n, err := d.parseNameBackref()
+		return []Node{n}, err
+
+	case d.startsWith("?$"):
+		n, err := d.parseTemplateInstantiation(true)
+		return []Node{n}, err
+
+	case d.startsWith("?A"):
+		// Anonymous namespace: ?A0x<hash>@
+		end := strings.IndexByte(d.input[d.pos:], '@')
+		if end < 0 {
+			return nil, ErrUnexpectedEnd
+		}
+		n := &AnonymousNamespace{Hash: d.input[d.pos+2 : d.pos+end]}
+		d.pos += end + 1
+		d.memorizeName(n)
+		return []Node{n}, nil
+
+	case c == '?':
+		// Local scope: ?<number>?<symbol>
+		d.pos++
+		num, err := d.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		scope := &Identifier{Name: "`" + formatInt(num) + "'"}
+		if !d.consumeByte('?') {
+			return []Node{scope}, nil
+		}
+
+		// The enclosing function is a complete symbol with its own
+		// back-references
+		saved := d.backrefs
+		d.backrefs = backrefs{}
+		fn, err := d.parse()
+		d.backrefs = saved
+		if err != nil {
+			return nil, err
+		}
+		return []Node{scope, &Identifier{Name: "`" + fn.String() + "'"}}, nil
+	}
+
+	n, err := d.parseSimpleName()
+	return []Node{n}, err
+}
+
+func (d *demangler) parseNameBackref() (Node, error) {
+	idx := int(d.consume() - '0')
+	if idx >= len(d.backrefs.names) {
+		return nil, ErrInvalidBackref
+	}
+	return d.backrefs.names[idx], nil
+}
+
+func (d *demangler) parseSimpleName() (Node, error) {
+	end := strings.IndexByte(d.input[d.pos:], '@')
+	if end <= 0 {
+		return nil, ErrInvalidMangled
+	}
+
+	n := &Identifier{Name: d.input[d.pos : d.pos+end]}
+	d.pos += end + 1
+	d.memorizeName(n)
+	return n, nil
+}
+
+func (d *demangler) parseTemplateInstantiation(memorize bool) (Node, error) {
+	// Skip ?$
+	d.pos += 2
+
+	// Template arguments have their own back-references
+	saved := d.backrefs
+	d.backrefs = backrefs{}
+
+	nameNode, err := d.parseUnqualifiedName(true)
+	if err != nil {
+		d.backrefs = saved
+		return nil, err
+	}
+
+	var args []Node
+	for !d.consumeByte('@') {
+		if d.pos >= len(d.input) {
+			d.backrefs = saved
+			return nil, ErrUnexpectedEnd
+		}
+		arg, err := d.parseTemplateArg()
+		if err != nil {
+			d.backrefs = saved
+			return nil, err
+		}
+		if arg != nil {
+			args = append(args, arg)
+		}
+	}
+
+	d.backrefs = saved
+	t := &TemplateInstantiation{
+		Name:      nameNode,
+		Arguments: args,
+	}
+	if memorize {
+		d.memorizeName(t)
+	}
+	return t, nil
+}
+
+// parseTemplateArg parses a template argument. Empty parameter packs yield
+// nil.
+func (d *demangler) parseTemplateArg() (Node, error) {
+	switch {
+	case d.consumePrefix("$$V"), d.consumePrefix("$$Z"), d.consumePrefix("$$$V"), d.consumePrefix("$S"):
+		return nil, nil
+
+	case d.consumePrefix("$0"):
+		val, err := d.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		return &IntegerLiteral{Value: val, Negative: val < 0}, nil
+
+	case d.consumePrefix("$1"), d.consumePrefix("$E"):
+		// Address of or reference to a symbol
+		ref := d.input[d.pos-1] == 'E'
+		saved := d.backrefs
+		sym, err := d.parse()
+		d.backrefs = saved
+		if err != nil {
+			return nil, err
+		}
+		name := NameOf(sym).String()
+		if !ref {
+			name = "&" + name
+		}
+		return &Identifier{Name: name}, nil
+
+	case d.consumePrefix("$D"):
+		val, err := d.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		return &Identifier{Name: "`template-parameter" + formatInt(val) + "'"}, nil
+	}
+
+	return d.parseMemorizedType()
+}
+
+func (d *demangler) parseEncoding(name *QualifiedName) (Node, error) {
+	// Managed and extern "C" markers
+	for d.consumePrefix("$$F") || d.consumePrefix("$$H") || d.consumePrefix("$$J0") {
+	}
+
+	if d.pos >= len(d.input) {
+		return name, nil
+	}
+
+	c := d.peek()
+	switch {
+	case c >= 'A' && c <= 'Z':
+		return d.parseFunctionEncoding(name)
+	case c >= '0' && c <= '4':
+		return d.parseVariableEncoding(name)
+	}
+	return name, nil
+}
+
+func (d *demangler) parseFunctionEncoding(name *QualifiedName) (Node, error) {
+	c := d.consume()
+	fn := &FunctionSymbol{Name: name}
+
+	// The class letters come in pairs (near and far); the pair selects the
+	// access and kind of function
+	hasThis := true
+	switch (c - 'A') / 2 {
+	case 0:
+		fn.AccessSpec = AccessPrivate
+	case 1:
+		fn.AccessSpec, fn.IsStatic = AccessPrivate, true
+	case 2:
+		fn.AccessSpec, fn.IsVirtual = AccessPrivate, true
+	case 3:
+		fn.AccessSpec, fn.IsVirtual, fn.IsThunk = AccessPrivate, true, true
+	case 4:
+		fn.AccessSpec = AccessProtected
+	case 5:
+		fn.AccessSpec, fn.IsStatic = AccessProtected, true
+	case 6:
+		fn.AccessSpec, fn.IsVirtual = AccessProtected, true
+	case 7:
+		fn.AccessSpec, fn.IsVirtual, fn.IsThunk = AccessProtected, true, true
+	case 8:
+		fn.AccessSpec = AccessPublic
+	case 9:
+		fn.AccessSpec, fn.IsStatic = AccessPublic, true
+	case 10:
+		fn.AccessSpec, fn.IsVirtual = AccessPublic, true
+	case 11:
+		fn.AccessSpec, fn.IsVirtual, fn.IsThunk = AccessPublic, true, true
+	case 12:
+		// Global function
+	}
+	if fn.IsStatic || fn.AccessSpec == AccessNone {
+		hasThis = false
+	}
+
+	if fn.IsThunk {
+		adj, err := d.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		fn.Adjustment = adj
+	}
+
+	sig, err := d.parseFunctionType(hasThis)
+	if err != nil {
+		return nil, err
+	}
+	fn.Signature = sig
+
+	if conv, ok := name.BaseName().(*ConversionOperator); ok {
+		conv.TargetType = sig.ReturnType
+	}
+	return fn, nil
+}
+
+func (d *demangler) parseVariableEncoding(name *QualifiedName) (Node, error) {
+	v := &VariableSymbol{Name: name}
+
+	switch d.consume() {
+	case '0':
+		v.AccessSpec, v.IsStatic = AccessPrivate, true
+	case '1':
+		v.AccessSpec, v.IsStatic = AccessProtected, true
+	case '2':
+		v.AccessSpec, v.IsStatic = AccessPublic, true
+	}
+
+	typ, err := d.parseType()
+	if err != nil {
+		return nil, err
+	}
+
+	// The storage class qualifies the variable itself
+	switch t := typ.(type) {
+	case *PointerType:
+		t.Is64Bit, t.Quals = d.parsePointerExtQualifiers(t.Quals)
+		q, _ := d.parseQualifiers()
+		t.Quals.IsConst, t.Quals.IsVolatile = q.IsConst, q.IsVolatile
+	case *MemberPointerType:
+		// Member qualifiers Q-T followed by the class
+		t.Is64Bit, t.Quals = d.parsePointerExtQualifiers(t.Quals)
+		if q, ok := d.parseMemberQualifiers(); ok {
+			t.Quals.IsConst, t.Quals.IsVolatile = q.IsConst, q.IsVolatile
+			if _, err := d.parseFullyQualifiedName(false); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		if q, ok := d.parseQualifiers(); ok && !q.IsEmpty() {
+			typ = &QualifiedType{Type: typ, Quals: q}
+		}
+	}
+	v.Type = typ
+
+	return v, nil
+}
+
+// parseFunctionType parses [<this-quals>] <calling-conv> <return-type>
+// <params> <throw-spec>.
+func (d *demangler) parseFunctionType(hasThis bool) (*FunctionType, error) {
+	ft := &FunctionType{}
+
+	if hasThis {
+		ft.Is64Bit, ft.Quals = d.parsePointerExtQualifiers(ft.Quals)
+		switch {
+		case d.consumeByte('G'):
+			ft.RefQualifier = RefQualifierLValue
+		case d.consumeByte('H'):
+			ft.RefQualifier = RefQualifierRValue
+		}
+		q, _ := d.parseQualifiers()
+		ft.Quals.IsConst, ft.Quals.IsVolatile = q.IsConst, q.IsVolatile
+	}
+
+	cc, err := d.parseCallingConvention()
+	if err != nil {
+		return nil, err
+	}
+	ft.CallingConv = cc
+
+	// '@' marks constructors and destructors, which return nothing.
+	// Qualified return types are prefixed with '?'.
+	if !d.consumeByte('@') {
+		ret, err := d.parseResultType()
+		if err != nil {
+			return nil, err
+		}
+		ft.ReturnType = ret
+	}
+
+	params, isVariadic, err := d.parseParameters()
+	if err != nil {
+		return nil, err
+	}
+	ft.Parameters = params
+	ft.IsVariadic = isVariadic
+
+	// Throw specification: Z for none, _E for noexcept
+	if !d.consumeByte('Z') {
+		d.consumePrefix("_E")
+	}
+
+	return ft, nil
+}
+
+// parseResultType parses a return type or the type of an RTTI type
+// descriptor: a type, or '?' <quals> <type>.
+func (d *demangler) parseResultType() (Node, error) {
+	if !d.consumeByte('?') {
+		return d.parseType()
+	}
+	q, _ := d.parseQualifiers()
+	typ, err := d.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if !q.IsEmpty() {
+		typ = &QualifiedType{Type: typ, Quals: q}
+	}
+	return typ, nil
+}
+
+func (d *demangler) parseCallingConvention() (CallingConvention, error) {
+	if d.pos >= len(d.input) {
+		return CallingConvCdecl, ErrUnexpectedEnd
+	}
+
+	switch d.consume() {
+	case 'A', 'B':
+		return CallingConvCdecl, nil
+	case 'C', 'D':
+		return CallingConvPascal, nil
+	case 'E', 'F':
+		return CallingConvThiscall, nil
+	case 'G', 'H':
+		return CallingConvStdcall, nil
+	case 'I', 'J':
+		return CallingConvFastcall, nil
+	case 'M', 'N':
+		return CallingConvClrcall, nil
+	case 'O', 'P':
+		return CallingConvEabi, nil
+	case 'Q':
+		return CallingConvVectorcall, nil
+	case 'S':
+		return CallingConvSwift, nil
+	case 'W':
+		return CallingConvSwiftAsync, nil
+	}
+
+	d.pos--
+	return CallingConvCdecl, ErrInvalidMangled
+}
+
+// parseParameters parses a parameter list: X for (void), or types ended by
+// '@', or by 'Z' for a variadic function.
+func (d *demangler) parseParameters() ([]Node, bool, error) {
+	if d.consumeByte('X') {
+		return nil, false, nil
+	}
+
+	var params []Node
+	for {
+		if d.pos >= len(d.input) {
+			return nil, false, ErrUnexpectedEnd
+		}
+		if d.consumeByte('@') {
+			return params, false, nil
+		}
+		if d.consumeByte('Z') {
+			return params, true, nil
+		}
+
+		param, err := d.parseMemorizedType()
+		if err != nil {
+			return nil, false, err
+		}
+		params = append(params, param)
+	}
+}
+
+// parseMemorizedType parses a parameter or template argument type. Types
+// longer than one character can be referred to later by a digit.
+func (d *demangler) parseMemorizedType() (Node, error) {
+	c := d.peek()
+	if c >= '0' && c <= '9' {
+		d.pos++
+		idx := int(c - '0')
+		if idx >= len(d.backrefs.params) {
+			return nil, ErrInvalidBackref
+		}
+		return d.backrefs.params[idx], nil
+	}
+
+	start := d.pos
+	t, err := d.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos-start > 1 && len(d.backrefs.params) < maxBackrefs {
+		d.backrefs.params = append(d.backrefs.params, t)
+	}
+	return t, nil
+}
+
+func (d *demangler) parseType() (Node, error) {
+	if d.pos >= len(d.input) {
+		return nil, ErrUnexpectedEnd
+	}
+
+	if d.isMemberPointer() {
+		return d.parseMemberPointerType()
+	}
+
+	switch {
+	case d.consumePrefix("$$Q"):
+		return d.parsePointerType(AffinityRValueReference, Qualifiers{})
+	case d.consumePrefix("$$R"):
+		return d.parsePointerType(AffinityRValueReference, Qualifiers{IsVolatile: true})
+	case d.consumePrefix("$$A6"):
+		return d.parseFunctionType(false)
+	case d.consumePrefix("$$A8@@"):
+		return d.parseFunctionType(true)
+	case d.consumePrefix("$$B"):
+		return d.parseType()
+	case d.consumePrefix("$$C"):
+		q, _ := d.parseQualifiers()
+		t, err := d.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if q.IsEmpty() {
+			return t, nil
+		}
+		return &QualifiedType{Type: t, Quals: q}, nil
+	case d.consumePrefix("$$T"):
+		return &PrimitiveType{Type: PrimNullptr}, nil
+	}
+
+	c := d.consume()
+	if prim, ok := primitiveCodes[c]; ok {
+		return &PrimitiveType{Type: prim}, nil
+	}
+
+	switch c {
+	case '_':
+		prim, ok := extendedPrimitiveCodes[d.consume()]
+		if !ok {
+			return nil, ErrUnknownType
+		}
+		return &PrimitiveType{Type: prim}, nil
+
+	case 'P':
+		return d.parsePointerType(AffinityPointer, Qualifiers{})
+	case 'Q':
+		return d.parsePointerType(AffinityPointer, Qualifiers{IsConst: true})
+	case 'R':
+		return d.parsePointerType(AffinityPointer, Qualifiers{IsVolatile: true})
+	case 'S':
+		return d.parsePointerType(AffinityPointer, Qualifiers{IsConst: true, IsVolatile: true})
+	case 'A':
+		return d.parsePointerType(AffinityReference, Qualifiers{})
+	case 'B':
+		return d.parsePointerType(AffinityReference, Qualifiers{IsVolatile: true})
+
+	case 'T':
+		return d.parseTagType(TagUnion)
+	case 'U':
+		return d.parseTagType(TagStruct)
+	case 'V':
+		return d.parseTagType(TagClass)
+	case 'W':
+		// The digit gives the underlying type; 4 is int
+		d.pos++
+		return d.parseTagType(TagEnum)
+
+	case 'Y':
+		return d.parseArrayType()
+	}
+
+	d.pos--
+	return nil, ErrUnknownType
+}
+
+// Single-character primitive type codes
+var primitiveCodes = map[byte]PrimitiveKind{
+	'X': PrimVoid, 'C': PrimSignedChar, 'D': PrimChar, 'E': PrimUnsignedChar,
+	'F': PrimShort, 'G': PrimUnsignedShort, 'H': PrimInt, 'I': PrimUnsignedInt,
+	'J': PrimLong, 'K': PrimUnsignedLong, 'M': PrimFloat, 'N': PrimDouble,
+	'O': PrimLongDouble,
+}
+
+// Primitive type codes following '_'
+var extendedPrimitiveCodes = map[byte]PrimitiveKind{
+	'N': PrimBool, 'J': PrimInt64, 'K': PrimUnsignedInt64, 'W': PrimWChar,
+	'Q': PrimChar8, 'S': PrimChar16, 'U': PrimChar32, 'L': PrimInt128,
+	'M': PrimUnsignedInt128,
+}
+
+// isMemberPointer reports whether a pointer to member follows.
+func (d *demangler) isMemberPointer() bool {
+	rest := d.input[d.pos:]
+	if len(rest) < 2 || !strings.ContainsRune("PQRS", rune(rest[0])) {
+		return false
+	}
+	rest = rest[1:]
+
+	if rest[0] >= '0' && rest[0] <= '9' {
+		return rest[0] == '8'
+	}
+	rest = strings.TrimLeft(rest, "EIF")
+	return len(rest) > 0 && rest[0] >= 'Q' && rest[0] <= 'T'
+}
+
+// parsePointerType parses the rest of a pointer or reference:
+// <ext-quals> (6 <function-type> | <quals> <type>).
+func (d *demangler) parsePointerType(affinity PointerAffinity, quals Qualifiers) (Node, error) {
+	ptr := &PointerType{Affinity: affinity, Quals: quals}
+
+	if d.consumeByte('6') {
+		fn, err := d.parseFunctionType(false)
+		if err != nil {
+			return nil, err
+		}
+		ptr.Pointee = fn
+		return ptr, nil
+	}
+
+	ptr.Is64Bit, ptr.Quals = d.parsePointerExtQualifiers(ptr.Quals)
+
+	pq, _ := d.parseQualifiers()
+	pointee, err := d.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if !pq.IsEmpty() {
+		pointee = &QualifiedType{Type: pointee, Quals: pq}
+	}
+	ptr.Pointee = pointee
+	return ptr, nil
+}
+
+// parseMemberPointerType parses a pointer to a data member (quals Q-T) or
+// to a member function (8).
+func (d *demangler) parseMemberPointerType() (Node, error) {
+	var quals Qualifiers
+	switch d.consume() {
+	case 'Q':
+		quals.IsConst = true
+	case 'R':
+		quals.IsVolatile = true
+	case 'S':
+		quals.IsConst, quals.IsVolatile = true, true
+	}
+
+	mp := &MemberPointerType{Quals: quals}
+
+	if d.consumeByte('8') {
+		class, err := d.parseFullyQualifiedName(false)
+		if err != nil {
+			return nil, err
+		}
+		fn, err := d.parseFunctionType(true)
+		if err != nil {
+			return nil, err
+		}
+		mp.ClassType, mp.MemberType = class, fn
+		return mp, nil
+	}
+
+	mp.Is64Bit, mp.Quals = d.parsePointerExtQualifiers(mp.Quals)
+
+	mq, _ := d.parseMemberQualifiers()
+
+	class, err := d.parseFullyQualifiedName(false)
+	if err != nil {
+		return nil, err
+	}
+	member, err := d.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if !mq.IsEmpty() {
+		member = &QualifiedType{Type: member, Quals: mq}
+	}
+	mp.ClassType, mp.MemberType = class, member
+	return mp, nil
+}
+
+// parsePointerExtQualifiers parses E (__ptr64), I (__restrict) and
+// F (__unaligned).
+func (d *demangler) parsePointerExtQualifiers(q Qualifiers) (bool, Qualifiers) {
+	is64Bit := false
+	for {
+		switch {
+		case d.consumeByte('E'):
+			is64Bit = true
+		case d.consumeByte('I'):
+			q.IsRestrict = true
+		case d.consumeByte('F'):
+			q.IsUnaligned = true
+		default:
+			return is64Bit, q
+		}
+	}
+}
+
+// parseQualifiers parses a cv-qualifier letter A-D. The second result is
+// false if none follows.
+func (d *demangler) parseQualifiers() (Qualifiers, bool) {
+	var quals Qualifiers
+	switch d.peek() {
+	case 'A':
+	case 'B':
+		quals.IsConst = true
+	case 'C':
+		quals.IsVolatile = true
+	case 'D':
+		quals.IsConst = true
+		quals.IsVolatile = true
+	default:
+		return quals, false
+	}
+	d.pos++
+	return quals, true
+}
+
+// parseMemberQualifiers parses a cv-qualifier letter Q-T of a class
+// member, which correspond to A-D.
+func (d *demangler) parseMemberQualifiers() (Qualifiers, bool) {
+	c := d.peek()
+	if c < 'Q' || c > 'T' {
+		return Qualifiers{}, false
+	}
+	d.pos++
+	return Qualifiers{IsConst: (c-'Q')&1 != 0, IsVolatile: (c-'Q')&2 != 0}, true
+}
+
+func (d *demangler) parseTagType(tag TagKind) (Node, error) {
+	name, err := d.parseFullyQualifiedName(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TagType{
+		Tag:  tag,
+		Name: name,
+	}, nil
+}
+
+// parseArrayType parses <rank> <dimension>... <element-type>.
+func (d *demangler) parseArrayType() (Node, error) {
+	rank, err := d.parseNumber()
+	if err != nil {
+		return nil, err
+	}
+	if rank <= 0 {
+		return nil, ErrInvalidMangled
+	}
+
+	dims := make([]uint64, 0, rank)
+	for range rank {
+		dim, err := d.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		dims = append(dims, uint64(dim))
+	}
+
+	elemType, err := d.parseType()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArrayType{
+		ElementType: elemType,
+		Dimensions:  dims,
+	}, nil
+}
+
+// parseNumber parses an encoded number: an optional '?' for negative
+// values, then a digit for 1-10, or hex digits A-P terminated by '@'.
+func (d *demangler) parseNumber() (int64, error) {
+	negative := d.consumeByte('?')
+
+	c := d.peek()
+	if c >= '0' && c <= '9' {
+		d.pos++
+		val := int64(c-'0') + 1
+		if negative {
+			val = -val
+		}
+		return val, nil
+	}
+
+	var val int64
+	for {
+		if d.pos >= len(d.input) {
+			return 0, ErrUnexpectedEnd
+		}
+		c = d.consume()
+		if c == '@' {
+			break
+		}
+		if c < 'A' || c > 'P' {
+			d.pos--
+			return 0, ErrInvalidMangled
+		}
+		val = val*16 + int64(c-'A')
+	}
+
+	if negative {
+		val = -val
+	}
+
+	return val, nil
+}
+
+// Helper methods
+
+func (d *demangler) peek() byte {
+	if d.pos >= len(d.input) {
+		return 0
+	}
+	return d.input[d.pos]
+}
+
+func (d *demangler) consume() byte {
+	if d.pos >= len(d.input) {
+		return 0
+	}
+	c := d.input[d.pos]
+	d.pos++
+	return c
+}
+
+func (d *demangler) consumeByte(c byte) bool {
+	if d.peek() == c && d.pos < len(d.input) {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+func (d *demangler) startsWith(prefix string) bool {
+	return strings.HasPrefix(d.input[d.pos:], prefix)
+}
+
+func (d *demangler) consumePrefix(prefix string) bool {
+	if d.startsWith(prefix) {
+		d.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (d *demangler) memorizeName(n Node) {
+	if len(d.backrefs.names) >= maxBackrefs {
+		return
+	}
+	s := n.String()
+	for _, b := range d.backrefs.names {
+		if b.String() == s {
+			return
+		}
+	}
+	d.backrefs.names = append(d.backrefs.names, n)
+}
+
+func formatInt(v int64) string {
+	return (&IntegerLiteral{Value: v}).String()
+}
+
+// DemangleSimple demangles a name, returning it unchanged if it cannot be
+// parsed.
+func DemangleSimple(decorated string, options ...Option) string {
+	result, err := Demangle(decorated, options...)
+	if err != nil {
+		return decorated
+	}
+	return result
+}
+
+// IsMangled returns true if the name appears to be an MSVC mangled name.
+func IsMangled(name string) bool {
+	return len(name) > 0 && (name[0] == '?' || strings.HasPrefix(name, "@?"))
+}
+
+// FoldTemplates parses a decorated name and returns its qualified name with
+// the arguments of every template instantiation replaced by "...", so that
+// all instantiations of a template map to the same name. The second result
+// is false if the name is not a function or variable that can be parsed.
+func FoldTemplates(decorated string) (string, bool) {
+	node, err := DemangleToNode(decorated)
+	if err != nil {
+		return "", false
+	}
+
+	name := NameOf(node)
+	if name == nil {
+		return "", false
+	}
+
+	parts := make([]string, 0, len(name.Components))
+	for _, c := range name.Components {
+		if t, ok := c.(*TemplateInstantiation); ok {
+			parts = append(parts, t.Name.String()+"<...>")
+			continue
+		}
+		parts = append(parts, c.String())
+	}
+	return strings.Join(parts, "::"), true
+}
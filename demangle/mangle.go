@@ -0,0 +1,728 @@
+package demangle
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrNotMangleable is returned by Mangle for nodes whose decoration cannot
+// be rebuilt from the tree, such as string literals, whose decoration holds
+// a checksum of the string, and anonymous namespaces built by the caller,
+// whose decoration holds a hash of the file.
+var ErrNotMangleable = errors.New("demangle: node cannot be mangled")
+
+// Mangle returns the MSVC-decorated name of a function, variable or table
+// symbol, the inverse of DemangleToNode. Names and parameter types that
+// repeat are written as back-references, as the compiler does, so that
+//
+//	node, _ := DemangleToNode(name)
+//	Mangle(node) // name
+//
+// for the names the compiler emits. Mangle also accepts trees built by the
+// caller, e.g. from the declarations in a header.
+func Mangle(n Node) (string, error) {
+	m := &mangler{}
+	if err := m.symbol(n); err != nil {
+		return "", err
+	}
+	return m.b.String(), nil
+}
+
+// mangler writes a decorated name, keeping the same back-reference tables
+// the demangler builds while reading it back.
+type mangler struct {
+	b        strings.Builder
+	backrefs mangleBackrefs
+}
+
+// mangleBackrefs holds the String of memorized names and the Format of
+// memorized parameter types.
+type mangleBackrefs struct {
+	names  []string
+	params []string
+}
+
+// Decorations of operator names, the inverse of the parser's code tables
+var operatorDecorations = func() map[OperatorKind]string {
+	m := make(map[OperatorKind]string)
+	for c, op := range operatorCodes {
+		m[op] = "?" + string(c)
+	}
+	for c, op := range extendedOperatorCodes {
+		m[op] = "?_" + string(c)
+	}
+	for c, op := range extendedOperatorCodes2 {
+		m[op] = "?__" + string(c)
+	}
+	return m
+}()
+
+// Codes of primitive types, the inverse of the parser's code tables
+var primitiveDecorations = func() map[PrimitiveKind]string {
+	m := map[PrimitiveKind]string{PrimNullptr: "$$T"}
+	for c, prim := range primitiveCodes {
+		m[prim] = string(c)
+	}
+	for c, prim := range extendedPrimitiveCodes {
+		m[prim] = "_" + string(c)
+	}
+	return m
+}()
+
+var callingConvCodes = map[CallingConvention]byte{
+	CallingConvCdecl:      'A',
+	CallingConvPascal:     'C',
+	CallingConvThiscall:   'E',
+	CallingConvStdcall:    'G',
+	CallingConvFastcall:   'I',
+	CallingConvClrcall:    'M',
+	CallingConvEabi:       'O',
+	CallingConvVectorcall: 'Q',
+	CallingConvSwift:      'S',
+	CallingConvSwiftAsync: 'W',
+}
+
+var tagCodes = map[TagKind]string{
+	TagUnion:  "T",
+	TagStruct: "U",
+	TagClass:  "V",
+	TagEnum:   "W4",
+}
+
+// Codes of special tables following ??_
+var tableCodes = map[OperatorKind]string{
+	OpVFTable:                   "7",
+	OpVBTable:                   "8",
+	OpLocalVFTable:              "S",
+	OpRTTICompleteObjectLocator: "R4",
+}
+
+func (m *mangler) symbol(n Node) error {
+	switch n := n.(type) {
+	case *FunctionSymbol:
+		return m.function(n)
+	case *VariableSymbol:
+		return m.variable(n)
+	case *SpecialTableSymbol:
+		return m.table(n)
+	case *QualifiedName:
+		return m.rttiName(n)
+	}
+	return ErrNotMangleable
+}
+
+func (m *mangler) function(fn *FunctionSymbol) error {
+	if fn.Name == nil || fn.Signature == nil {
+		return ErrNotMangleable
+	}
+
+	m.b.WriteByte('?')
+	if ds, ok := fn.Name.BaseName().(*DynamicStructor); ok {
+		if err := m.dynamicStructor(ds); err != nil {
+			return err
+		}
+	} else if err := m.fullName(fn.Name, true); err != nil {
+		return err
+	}
+
+	// The class letters come in pairs; the first of each pair is written
+	class := 12
+	if fn.AccessSpec != AccessNone {
+		class = 4 * int(fn.AccessSpec-AccessPrivate)
+		switch {
+		case fn.IsThunk:
+			class += 3
+		case fn.IsVirtual:
+			class += 2
+		case fn.IsStatic:
+			class++
+		}
+	}
+	m.b.WriteByte(byte('A' + 2*class))
+	if fn.IsThunk {
+		m.number(fn.Adjustment)
+	}
+
+	hasThis := !fn.IsStatic && fn.AccessSpec != AccessNone
+	return m.functionType(fn.Signature, hasThis)
+}
+
+// dynamicStructor writes the name of a dynamic initializer or atexit
+// destructor up to its function encoding.
+func (m *mangler) dynamicStructor(ds *DynamicStructor) error {
+	if ds.IsDestructor {
+		m.b.WriteString("?__F")
+	} else {
+		m.b.WriteString("?__E")
+	}
+
+	switch v := ds.Variable.(type) {
+	case *VariableSymbol:
+		if err := m.variable(v); err != nil {
+			return err
+		}
+		m.b.WriteString("@@")
+		return nil
+	case *QualifiedName:
+		return m.fullName(v, false)
+	}
+	return ErrNotMangleable
+}
+
+func (m *mangler) variable(v *VariableSymbol) error {
+	if v.Name == nil || v.Type == nil {
+		return ErrNotMangleable
+	}
+
+	if op, ok := v.Name.BaseName().(*Operator); ok && op.Op == OpRTTITypeDescriptor {
+		m.b.WriteString("??_R0")
+		if err := m.resultType(v.Type); err != nil {
+			return err
+		}
+		m.b.WriteString("@8")
+		return nil
+	}
+
+	m.b.WriteByte('?')
+	if err := m.fullName(v.Name, true); err != nil {
+		return err
+	}
+
+	// Static data members by access, or a global
+	if v.AccessSpec == AccessNone {
+		m.b.WriteByte('3')
+	} else {
+		m.b.WriteByte(byte('0' + v.AccessSpec - AccessPrivate))
+	}
+
+	// The storage class qualifies the variable itself; on pointers it is
+	// also written as the pointer's qualifiers
+	typ, quals := v.Type, Qualifiers{}
+	if q, ok := typ.(*QualifiedType); ok {
+		typ, quals = q.Type, q.Quals
+	}
+	switch t := typ.(type) {
+	case *PointerType:
+		p := *t
+		p.Quals.IsConst = p.Quals.IsConst || quals.IsConst
+		p.Quals.IsVolatile = p.Quals.IsVolatile || quals.IsVolatile
+		if err := m.typ(&p); err != nil {
+			return err
+		}
+		m.pointerExtQualifiers(p.Is64Bit, Qualifiers{})
+		m.qualifiers(p.Quals)
+	case *MemberPointerType:
+		p := *t
+		p.Quals.IsConst = p.Quals.IsConst || quals.IsConst
+		p.Quals.IsVolatile = p.Quals.IsVolatile || quals.IsVolatile
+		if err := m.typ(&p); err != nil {
+			return err
+		}
+		m.pointerExtQualifiers(p.Is64Bit, Qualifiers{})
+		m.b.WriteByte(memberQualifierCode(p.Quals))
+		return m.className(p.ClassType)
+	default:
+		if err := m.typ(typ); err != nil {
+			return err
+		}
+		m.qualifiers(quals)
+	}
+	return nil
+}
+
+func (m *mangler) table(t *SpecialTableSymbol) error {
+	if t.Name == nil {
+		return ErrNotMangleable
+	}
+	op, ok := t.Name.BaseName().(*Operator)
+	if !ok {
+		return ErrNotMangleable
+	}
+
+	// Complete object locators of classes with hashed names
+	if scope := t.Name.Scope(); len(scope) == 1 && op.Op == OpRTTICompleteObjectLocator {
+		if h, ok := scope[0].(*HashedName); ok {
+			m.b.WriteString("??@" + h.Hash + "@??_R4@")
+			return nil
+		}
+	}
+
+	code, ok := tableCodes[op.Op]
+	if !ok {
+		return ErrNotMangleable
+	}
+	m.b.WriteString("??_" + code)
+	if err := m.scopeChain(t.Name); err != nil {
+		return err
+	}
+	m.b.WriteByte('6')
+	m.qualifiers(t.Quals)
+	for _, target := range t.Targets {
+		if err := m.fullName(target, false); err != nil {
+			return err
+		}
+	}
+	m.b.WriteByte('@')
+	return nil
+}
+
+// rttiName writes a hashed name or an RTTI descriptor named after its
+// class.
+func (m *mangler) rttiName(q *QualifiedName) error {
+	switch base := q.BaseName().(type) {
+	case *HashedName:
+		if len(q.Components) != 1 {
+			return ErrNotMangleable
+		}
+		m.b.WriteString("??@" + base.Hash + "@")
+		return nil
+
+	case *RTTIBaseClassDescriptor:
+		m.b.WriteString("??_R1")
+		for _, v := range []int64{base.NVOffset, base.VBPtrOffset, base.VBTableOffset, base.Flags} {
+			m.number(v)
+		}
+
+	case *Operator:
+		switch base.Op {
+		case OpRTTIBaseClassArray:
+			m.b.WriteString("??_R2")
+		case OpRTTIClassHierarchyDescriptor:
+			m.b.WriteString("??_R3")
+		default:
+			return ErrNotMangleable
+		}
+
+	default:
+		return ErrNotMangleable
+	}
+
+	if err := m.scopeChain(q); err != nil {
+		return err
+	}
+	m.b.WriteByte('8')
+	return nil
+}
+
+// fullName writes a name's components innermost first, then '@'.
+// Operators are only allowed as the base name of a symbol.
+func (m *mangler) fullName(q *QualifiedName, allowOperator bool) error {
+	if q == nil || len(q.Components) == 0 {
+		return ErrNotMangleable
+	}
+	if err := m.unqualifiedName(q.BaseName(), allowOperator); err != nil {
+		return err
+	}
+	return m.scopeChain(q)
+}
+
+// scopeChain writes the scopes enclosing a name, innermost first, then
+// '@'.
+func (m *mangler) scopeChain(q *QualifiedName) error {
+	scope := q.Scope()
+	for i := len(scope) - 1; i >= 0; i-- {
+		if err := m.unqualifiedName(scope[i], false); err != nil {
+			return err
+		}
+	}
+	m.b.WriteByte('@')
+	return nil
+}
+
+// className writes the name of the class of a pointer to member.
+func (m *mangler) className(n Node) error {
+	switch n := n.(type) {
+	case *QualifiedName:
+		return m.fullName(n, false)
+	case *TagType:
+		return m.fullName(n.Name, false)
+	}
+	return ErrNotMangleable
+}
+
+func (m *mangler) unqualifiedName(n Node, allowOperator bool) error {
+	switch n := n.(type) {
+	case *Identifier:
+		if m.nameBackref(n) {
+			return nil
+		}
+		if n.Name == "" || strings.ContainsAny(n.Name, "@`?") || (n.Name[0] >= '0' && n.Name[0] <= '9') {
+			return ErrNotMangleable
+		}
+		m.b.WriteString(n.Name)
+		m.b.WriteByte('@')
+		m.memorizeName(n)
+		return nil
+
+	case *TemplateInstantiation:
+		// Function templates are not memorized
+		if allowOperator {
+			return m.templateInstantiation(n, false)
+		}
+		if m.nameBackref(n) {
+			return nil
+		}
+		return m.templateInstantiation(n, true)
+
+	case *AnonymousNamespace:
+		if m.nameBackref(n) {
+			return nil
+		}
+		if n.Hash == "" {
+			return ErrNotMangleable
+		}
+		m.b.WriteString("?A" + n.Hash + "@")
+		m.memorizeName(n)
+		return nil
+	}
+
+	if !allowOperator {
+		return ErrNotMangleable
+	}
+	switch n := n.(type) {
+	case *Structor:
+		if n.IsDestructor {
+			m.b.WriteString("?1")
+		} else {
+			m.b.WriteString("?0")
+		}
+		return nil
+	case *ConversionOperator:
+		// The target type is the function's return type
+		m.b.WriteString("?B")
+		return nil
+	case *Operator:
+		if code, ok := operatorDecorations[n.Op]; ok {
+			m.b.WriteString(code)
+			return nil
+		}
+	}
+	return ErrNotMangleable
+}
+
+// nameBackref writes the back-reference to a memorized name, if there is
+// one.
+func (m *mangler) nameBackref(n Node) bool {
+	s := n.String()
+	for i, b := range m.backrefs.names {
+		if b == s {
+			m.b.WriteByte(byte('0' + i))
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mangler) memorizeName(n Node) {
+	if len(m.backrefs.names) < maxBackrefs {
+		m.backrefs.names = append(m.backrefs.names, n.String())
+	}
+}
+
+func (m *mangler) templateInstantiation(t *TemplateInstantiation, memorize bool) error {
+	m.b.WriteString("?$")
+
+	// Template arguments have their own back-references
+	saved := m.backrefs
+	m.backrefs = mangleBackrefs{}
+	err := m.templateArgs(t)
+	m.backrefs = saved
+	if err != nil {
+		return err
+	}
+
+	if memorize {
+		m.memorizeName(t)
+	}
+	return nil
+}
+
+func (m *mangler) templateArgs(t *TemplateInstantiation) error {
+	if err := m.unqualifiedName(t.Name, true); err != nil {
+		return err
+	}
+
+	if len(t.Arguments) == 0 {
+		// Empty parameter pack
+		m.b.WriteString("$$V")
+	}
+	for _, arg := range t.Arguments {
+		switch a := arg.(type) {
+		case *IntegerLiteral:
+			m.b.WriteString("$0")
+			m.number(a.Value)
+		case *Identifier:
+			// Only template parameters; symbol references are kept as text
+			num, ok := strings.CutPrefix(a.Name, "`template-parameter")
+			if !ok || !strings.HasSuffix(num, "'") {
+				return ErrNotMangleable
+			}
+			v, err := strconv.ParseInt(strings.TrimSuffix(num, "'"), 10, 64)
+			if err != nil {
+				return ErrNotMangleable
+			}
+			m.b.WriteString("$D")
+			m.number(v)
+		default:
+			// Type arguments are written in full, never as back-references
+			if err := m.typ(arg); err != nil {
+				return err
+			}
+		}
+	}
+	m.b.WriteByte('@')
+	return nil
+}
+
+// functionType writes [<this-quals>] <calling-conv> <return-type>
+// <params> <throw-spec>.
+func (m *mangler) functionType(ft *FunctionType, hasThis bool) error {
+	if hasThis {
+		m.pointerExtQualifiers(ft.Is64Bit, ft.Quals)
+		switch ft.RefQualifier {
+		case RefQualifierLValue:
+			m.b.WriteByte('G')
+		case RefQualifierRValue:
+			m.b.WriteByte('H')
+		}
+		m.qualifiers(ft.Quals)
+	}
+
+	cc, ok := callingConvCodes[ft.CallingConv]
+	if !ok {
+		return ErrNotMangleable
+	}
+	m.b.WriteByte(cc)
+
+	// Constructors and destructors return nothing
+	if ft.ReturnType == nil {
+		m.b.WriteByte('@')
+	} else if err := m.resultType(ft.ReturnType); err != nil {
+		return err
+	}
+
+	if len(ft.Parameters) == 0 && !ft.IsVariadic {
+		m.b.WriteByte('X')
+	} else {
+		for _, param := range ft.Parameters {
+			if err := m.memorizedType(param); err != nil {
+				return err
+			}
+		}
+		if ft.IsVariadic {
+			m.b.WriteByte('Z')
+		} else {
+			m.b.WriteByte('@')
+		}
+	}
+
+	// No throw specification
+	m.b.WriteByte('Z')
+	return nil
+}
+
+// resultType writes a return type or the type of an RTTI type descriptor.
+// Qualified and class types are prefixed with '?' and their qualifiers.
+func (m *mangler) resultType(n Node) error {
+	switch t := n.(type) {
+	case *QualifiedType:
+		m.b.WriteByte('?')
+		m.qualifiers(t.Quals)
+		return m.typ(t.Type)
+	case *TagType:
+		m.b.WriteString("?A")
+	}
+	return m.typ(n)
+}
+
+// memorizedType writes a parameter type, or the back-reference to an
+// earlier one. Types longer than one character are
+// memorized.
+func (m *mangler) memorizedType(n Node) error {
+	key := Format(n)
+	for i, b := range m.backrefs.params {
+		if b == key {
+			m.b.WriteByte(byte('0' + i))
+			return nil
+		}
+	}
+
+	start := m.b.Len()
+	if err := m.typ(n); err != nil {
+		return err
+	}
+	if m.b.Len()-start > 1 && len(m.backrefs.params) < maxBackrefs {
+		m.backrefs.params = append(m.backrefs.params, key)
+	}
+	return nil
+}
+
+func (m *mangler) typ(n Node) error {
+	switch t := n.(type) {
+	case *PrimitiveType:
+		code, ok := primitiveDecorations[t.Type]
+		if !ok {
+			return ErrNotMangleable
+		}
+		m.b.WriteString(code)
+		return nil
+
+	case *PointerType:
+		return m.pointerType(t)
+
+	case *MemberPointerType:
+		return m.memberPointerType(t)
+
+	case *TagType:
+		code, ok := tagCodes[t.Tag]
+		if !ok {
+			return ErrNotMangleable
+		}
+		m.b.WriteString(code)
+		return m.fullName(t.Name, false)
+
+	case *ArrayType:
+		if len(t.Dimensions) == 0 {
+			return ErrNotMangleable
+		}
+		m.b.WriteByte('Y')
+		m.number(int64(len(t.Dimensions)))
+		for _, dim := range t.Dimensions {
+			m.number(int64(dim))
+		}
+		return m.typ(t.ElementType)
+
+	case *FunctionType:
+		m.b.WriteString("$$A6")
+		return m.functionType(t, false)
+
+	case *QualifiedType:
+		m.b.WriteString("$$C")
+		m.qualifiers(t.Quals)
+		return m.typ(t.Type)
+	}
+	return ErrNotMangleable
+}
+
+// pointerType writes a pointer or reference: its kind and qualifiers, then
+// 6 <function-type> or <ext-quals> <pointee-quals> <pointee>.
+func (m *mangler) pointerType(p *PointerType) error {
+	switch p.Affinity {
+	case AffinityPointer:
+		m.b.WriteByte("PQRS"[cvIndex(p.Quals)])
+	case AffinityReference:
+		if p.Quals.IsVolatile {
+			m.b.WriteByte('B')
+		} else {
+			m.b.WriteByte('A')
+		}
+	case AffinityRValueReference:
+		if p.Quals.IsVolatile {
+			m.b.WriteString("$$R")
+		} else {
+			m.b.WriteString("$$Q")
+		}
+	}
+
+	if fn, ok := p.Pointee.(*FunctionType); ok {
+		m.b.WriteByte('6')
+		return m.functionType(fn, false)
+	}
+
+	m.pointerExtQualifiers(p.Is64Bit, p.Quals)
+	pointee := p.Pointee
+	if q, ok := pointee.(*QualifiedType); ok {
+		m.qualifiers(q.Quals)
+		pointee = q.Type
+	} else {
+		m.b.WriteByte('A')
+	}
+	return m.typ(pointee)
+}
+
+// memberPointerType writes a pointer to a member function (8) or to a data
+// member (member quals Q-T).
+func (m *mangler) memberPointerType(p *MemberPointerType) error {
+	m.b.WriteByte("PQRS"[cvIndex(p.Quals)])
+
+	if fn, ok := p.MemberType.(*FunctionType); ok {
+		m.b.WriteByte('8')
+		if err := m.className(p.ClassType); err != nil {
+			return err
+		}
+		return m.functionType(fn, true)
+	}
+
+	m.pointerExtQualifiers(p.Is64Bit, p.Quals)
+	member := p.MemberType
+	var mq Qualifiers
+	if q, ok := member.(*QualifiedType); ok {
+		mq, member = q.Quals, q.Type
+	}
+	m.b.WriteByte(memberQualifierCode(mq))
+	if err := m.className(p.ClassType); err != nil {
+		return err
+	}
+	return m.typ(member)
+}
+
+// pointerExtQualifiers writes E (__ptr64), I (__restrict) and
+// F (__unaligned).
+func (m *mangler) pointerExtQualifiers(is64Bit bool, q Qualifiers) {
+	if is64Bit {
+		m.b.WriteByte('E')
+	}
+	if q.IsRestrict {
+		m.b.WriteByte('I')
+	}
+	if q.IsUnaligned {
+		m.b.WriteByte('F')
+	}
+}
+
+// qualifiers writes the cv-qualifier letter A-D.
+func (m *mangler) qualifiers(q Qualifiers) {
+	m.b.WriteByte("ABCD"[cvIndex(q)])
+}
+
+// memberQualifierCode returns the cv-qualifier letter Q-T of a member.
+func memberQualifierCode(q Qualifiers) byte {
+	return "QRST"[cvIndex(q)]
+}
+
+func cvIndex(q Qualifiers) int {
+	i := 0
+	if q.IsConst {
+		i |= 1
+	}
+	if q.IsVolatile {
+		i |= 2
+	}
+	return i
+}
+
+// number writes an encoded number: '?' for negative values, then a digit
+// for 1-10, or hex digits A-P terminated by '@'.
+func (m *mangler) number(v int64) {
+	if v < 0 {
+		m.b.WriteByte('?')
+		v = -v
+	}
+	if v >= 1 && v <= 10 {
+		m.b.WriteByte(byte('0' + v - 1))
+		return
+	}
+
+	var digits []byte
+	for ; v > 0; v /= 16 {
+		digits = append(digits, byte('A'+v%16))
+	}
+	for i := len(digits) - 1; i >= 0; i-- {
+		m.b.WriteByte(digits[i])
+	}
+	if len(digits) == 0 {
+		m.b.WriteByte('A')
+	}
+	m.b.WriteByte('@')
+}
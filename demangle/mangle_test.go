@@ -0,0 +1,129 @@
+package demangle_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/skdltmxn/pdb-go/demangle"
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// TestMangleRoundTrip mangles back the tree of every decorated public of
+// the fixture PDBs.
+func TestMangleRoundTrip(t *testing.T) {
+	for _, name := range []string{"x86.pdb", "x64.pdb"} {
+		t.Run(name, func(t *testing.T) {
+			f, err := pdb.Open(filepath.Join("..", "testdata", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			symbols, err := f.Symbols()
+			if err != nil {
+				t.Fatal(err)
+			}
+			count := 0
+			for sym := range symbols.Public() {
+				if demangle.IsMangled(sym.Name()) {
+					roundTrip(t, sym.Name())
+					count++
+				}
+			}
+			if count == 0 {
+				t.Error("no decorated publics")
+			}
+		})
+	}
+}
+
+func TestMangleBackrefs(t *testing.T) {
+	tests := []struct {
+		decorated string
+		demangled string
+	}{
+		// Parameter types longer than one character
+		{"?Swap@@YAXAEAVWidget@@0@Z", "void __cdecl Swap(class Widget &, class Widget &)"},
+		{"?f@@YAXPEAH0PEAD1@Z", "void __cdecl f(int *, int *, char *, char *)"},
+		{"?g@@YAXHHH@Z", "void __cdecl g(int, int, int)"},
+
+		// Names, including those of the symbol itself
+		{"?Copy@Widget@@QEAAXAEBV1@@Z", "public: void __cdecl Widget::Copy(class Widget const &)"},
+		{"?Get@Inner@Outer@@SAPEAV12@XZ", "public: static class Outer::Inner * __cdecl Outer::Inner::Get(void)"},
+		{"?Use@?A0x1234abcd@@YAXPEAVWidget@1@@Z",
+			"void __cdecl `anonymous namespace'::Use(class `anonymous namespace'::Widget *)"},
+
+		// Class template instantiations are memorized as names, and both
+		// tables carry on past their arguments; function templates are not
+		{"?f@@YAXV?$A@H@@0@Z", "void __cdecl f(class A<int>, class A<int>)"},
+		{"??$forward@AEAVWidget@@@std@@YAAEAVWidget@@AEAV1@@Z",
+			"class Widget & __cdecl std::forward<class Widget &>(class Widget &)"},
+		{"??$Max@V?$vector@H@std@@@@YA?AV?$vector@H@std@@AEBV01@0@Z",
+			"class std::vector<int> __cdecl Max<class std::vector<int>>(class std::vector<int> const &, class std::vector<int> const &)"},
+	}
+
+	for _, tt := range tests {
+		node := roundTrip(t, tt.decorated)
+		if node == nil {
+			continue
+		}
+		if got := demangle.Format(node, demangle.NoPtr64); got != tt.demangled {
+			t.Errorf("%s demangles to %q, want %q", tt.decorated, got, tt.demangled)
+		}
+	}
+}
+
+func TestMangleTemplateArgs(t *testing.T) {
+	tests := []struct {
+		decorated string
+		demangled string
+	}{
+		{"??$Max@H@@YAHHH@Z", "int __cdecl Max<int>(int, int)"},
+		{"?f@?$tuple@HM_N@std@@QEAAXXZ", "public: void __cdecl std::tuple<int, float, bool>::f(void)"},
+		{"?f@?$A@$$CBH@@QEAAXXZ", "public: void __cdecl A<int const>::f(void)"},
+		{"??$g@$$V@@YAXXZ", "void __cdecl g<>(void)"},
+
+		// Integer arguments
+		{"?Get@?$Holder@$0A@@@QEBAHXZ", "public: int __cdecl Holder<0>::Get(void) const"},
+		{"?Get@?$Holder@$0BA@@@QEBAHXZ", "public: int __cdecl Holder<16>::Get(void) const"},
+		{"?Get@?$Holder@$0?0@@QEBAHXZ", "public: int __cdecl Holder<-1>::Get(void) const"},
+
+		// Arguments have their own name table, and repeated argument types
+		// are written in full
+		{"?f@?$A@V?$A@H@@@@QEAAXXZ", "public: void __cdecl A<class A<int>>::f(void)"},
+		{"?f@?$pair@PEAHPEAH@std@@QEAAXXZ", "public: void __cdecl std::pair<int *, int *>::f(void)"},
+		{"?f@?$A@PEAUNode@@PEAU1@@@QEAAXXZ", "public: void __cdecl A<struct Node *, struct Node *>::f(void)"},
+		{"?push_back@?$vector@HV?$allocator@H@std@@@std@@QEAAXAEBH@Z",
+			"public: void __cdecl std::vector<int, class std::allocator<int>>::push_back(int const &)"},
+	}
+
+	for _, tt := range tests {
+		node := roundTrip(t, tt.decorated)
+		if node == nil {
+			continue
+		}
+		if got := demangle.Format(node, demangle.NoPtr64); got != tt.demangled {
+			t.Errorf("%s demangles to %q, want %q", tt.decorated, got, tt.demangled)
+		}
+	}
+}
+
+// roundTrip checks that a decorated name mangles back to itself and returns
+// its tree.
+func roundTrip(t *testing.T, decorated string) demangle.Node {
+	t.Helper()
+	node, err := demangle.DemangleToNode(decorated)
+	if err != nil {
+		t.Errorf("DemangleToNode(%q): %v", decorated, err)
+		return nil
+	}
+	got, err := demangle.Mangle(node)
+	if err != nil {
+		t.Errorf("Mangle(DemangleToNode(%q)): %v", decorated, err)
+		return nil
+	}
+	if got != decorated {
+		t.Errorf("Mangle(DemangleToNode(%q)) = %q", decorated, got)
+	}
+	return node
+}
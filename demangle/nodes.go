@@ -0,0 +1,673 @@
+// Package demangle undecorates MSVC C++ symbol names.
+//
+// Demangle renders a decorated name as text in the style of undname, with
+// options to leave out parts of the declaration. DemangleToNode returns the
+// parsed name as a tree of nodes, from which callers can take the scope,
+// base name, template arguments and parameter types of a symbol. Mangle
+// turns such a tree, parsed or built by the caller, back into a decorated
+// name.
+package demangle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NodeKind identifies the type of AST node.
+type NodeKind int
+
+const (
+	NodeKindUnknown NodeKind = iota
+	// Identifier nodes
+	NodeKindIdentifier
+	NodeKindOperator
+	NodeKindConversionOperator
+	NodeKindConstructor
+	NodeKindDestructor
+	NodeKindVTable
+	NodeKindVBTable
+	NodeKindRTTI
+	NodeKindLocalStaticGuard
+	NodeKindStringLiteral
+	NodeKindTemplateInstantiation
+	// Type nodes
+	NodeKindPrimitiveType
+	NodeKindPointerType
+	NodeKindReferenceType
+	NodeKindRValueReferenceType
+	NodeKindArrayType
+	NodeKindFunctionType
+	NodeKindMemberPointerType
+	NodeKindTagType
+	NodeKindQualifiedType
+	// Symbol nodes
+	NodeKindFunctionSymbol
+	NodeKindVariableSymbol
+	NodeKindSpecialTableSymbol
+	// Container nodes
+	NodeKindQualifiedName
+	NodeKindTemplateArgs
+	NodeKindIntegerLiteral
+)
+
+// Node is the interface implemented by all AST nodes.
+type Node interface {
+	Kind() NodeKind
+	fmt.Stringer
+}
+
+// QualifiedName represents a fully-qualified C++ name.
+type QualifiedName struct {
+	Components []Node
+}
+
+func (n *QualifiedName) Kind() NodeKind { return NodeKindQualifiedName }
+func (n *QualifiedName) String() string { return Format(n) }
+
+// BaseName returns the last component of the name: the identifier,
+// operator, constructor or template instantiation being named.
+func (n *QualifiedName) BaseName() Node {
+	if len(n.Components) == 0 {
+		return nil
+	}
+	return n.Components[len(n.Components)-1]
+}
+
+// Scope returns the enclosing namespaces and classes, outermost first.
+func (n *QualifiedName) Scope() []Node {
+	if len(n.Components) == 0 {
+		return nil
+	}
+	return n.Components[:len(n.Components)-1]
+}
+
+// TemplateArgs returns the template arguments of the base name, or nil if
+// it is not a template instantiation.
+func (n *QualifiedName) TemplateArgs() []Node {
+	if t, ok := n.BaseName().(*TemplateInstantiation); ok {
+		return t.Arguments
+	}
+	return nil
+}
+
+// Identifier represents a simple name.
+type Identifier struct {
+	Name string
+}
+
+func (n *Identifier) Kind() NodeKind { return NodeKindIdentifier }
+func (n *Identifier) String() string { return n.Name }
+
+// OperatorKind identifies operator types.
+type OperatorKind int
+
+const (
+	OpUnknown OperatorKind = iota
+	OpConstructor
+	OpDestructor
+	OpNew
+	OpDelete
+	OpAssign
+	OpRightShift
+	OpLeftShift
+	OpLogicalNot
+	OpEqual
+	OpNotEqual
+	OpSubscript
+	OpConversion
+	OpArrow
+	OpDereference
+	OpIncrement
+	OpDecrement
+	OpMinus
+	OpPlus
+	OpAddressOf
+	OpArrowDeref
+	OpDivide
+	OpModulo
+	OpLess
+	OpLessEqual
+	OpGreater
+	OpGreaterEqual
+	OpComma
+	OpCall
+	OpComplement
+	OpXor
+	OpBitwiseOr
+	OpLogicalAnd
+	OpLogicalOr
+	OpMultiplyAssign
+	OpPlusAssign
+	OpMinusAssign
+	OpDivideAssign
+	OpModuloAssign
+	OpRightShiftAssign
+	OpLeftShiftAssign
+	OpAndAssign
+	OpOrAssign
+	OpXorAssign
+	OpNewArray
+	OpDeleteArray
+	OpVFTable
+	OpVBTable
+	OpVCall
+	OpTypeof
+	OpLocalStaticGuard
+	OpStringLiteral
+	OpVBaseDtor
+	OpVectorDeletingDtor
+	OpDefaultCtorClosure
+	OpScalarDeletingDtor
+	OpVectorCtorIterator
+	OpVectorDtorIterator
+	OpVectorVbaseCtorIterator
+	OpVirtualDisplacementMap
+	OpEHVectorCtorIterator
+	OpEHVectorDtorIterator
+	OpEHVectorVbaseCtorIterator
+	OpCopyCtorClosure
+	OpLocalVFTable
+	OpLocalVFTableCtorClosure
+	OpRTTITypeDescriptor
+	OpRTTIBaseClassArray
+	OpRTTIBaseClassDescriptor
+	OpRTTIClassHierarchyDescriptor
+	OpRTTICompleteObjectLocator
+	OpSpaceship
+	OpCoAwait
+)
+
+var operatorNames = map[OperatorKind]string{
+	OpNew:                          "operator new",
+	OpDelete:                       "operator delete",
+	OpAssign:                       "operator=",
+	OpRightShift:                   "operator>>",
+	OpLeftShift:                    "operator<<",
+	OpLogicalNot:                   "operator!",
+	OpEqual:                        "operator==",
+	OpNotEqual:                     "operator!=",
+	OpSubscript:                    "operator[]",
+	OpArrow:                        "operator->",
+	OpDereference:                  "operator*",
+	OpIncrement:                    "operator++",
+	OpDecrement:                    "operator--",
+	OpMinus:                        "operator-",
+	OpPlus:                         "operator+",
+	OpAddressOf:                    "operator&",
+	OpArrowDeref:                   "operator->*",
+	OpDivide:                       "operator/",
+	OpModulo:                       "operator%",
+	OpLess:                         "operator<",
+	OpLessEqual:                    "operator<=",
+	OpGreater:                      "operator>",
+	OpGreaterEqual:                 "operator>=",
+	OpComma:                        "operator,",
+	OpCall:                         "operator()",
+	OpComplement:                   "operator~",
+	OpXor:                          "operator^",
+	OpBitwiseOr:                    "operator|",
+	OpLogicalAnd:                   "operator&&",
+	OpLogicalOr:                    "operator||",
+	OpMultiplyAssign:               "operator*=",
+	OpPlusAssign:                   "operator+=",
+	OpMinusAssign:                  "operator-=",
+	OpDivideAssign:                 "operator/=",
+	OpModuloAssign:                 "operator%=",
+	OpRightShiftAssign:             "operator>>=",
+	OpLeftShiftAssign:              "operator<<=",
+	OpAndAssign:                    "operator&=",
+	OpOrAssign:                     "operator|=",
+	OpXorAssign:                    "operator^=",
+	OpNewArray:                     "operator new[]",
+	OpDeleteArray:                  "operator delete[]",
+	OpSpaceship:                    "operator<=>",
+	OpCoAwait:                      "operator co_await",
+	OpVFTable:                      "`vftable'",
+	OpVBTable:                      "`vbtable'",
+	OpVCall:                        "`vcall'",
+	OpTypeof:                       "`typeof'",
+	OpLocalStaticGuard:             "`local static guard'",
+	OpStringLiteral:                "`string'",
+	OpVBaseDtor:                    "`vbase destructor'",
+	OpVectorDeletingDtor:           "`vector deleting destructor'",
+	OpDefaultCtorClosure:           "`default constructor closure'",
+	OpScalarDeletingDtor:           "`scalar deleting destructor'",
+	OpVectorCtorIterator:           "`vector constructor iterator'",
+	OpVectorDtorIterator:           "`vector destructor iterator'",
+	OpVectorVbaseCtorIterator:      "`vector vbase constructor iterator'",
+	OpVirtualDisplacementMap:       "`virtual displacement map'",
+	OpEHVectorCtorIterator:         "`eh vector constructor iterator'",
+	OpEHVectorDtorIterator:         "`eh vector destructor iterator'",
+	OpEHVectorVbaseCtorIterator:    "`eh vector vbase constructor iterator'",
+	OpCopyCtorClosure:              "`copy constructor closure'",
+	OpLocalVFTable:                 "`local vftable'",
+	OpLocalVFTableCtorClosure:      "`local vftable constructor closure'",
+	OpRTTITypeDescriptor:           "`RTTI Type Descriptor'",
+	OpRTTIBaseClassArray:           "`RTTI Base Class Array'",
+	OpRTTIBaseClassDescriptor:      "`RTTI Base Class Descriptor'",
+	OpRTTIClassHierarchyDescriptor: "`RTTI Class Hierarchy Descriptor'",
+	OpRTTICompleteObjectLocator:    "`RTTI Complete Object Locator'",
+}
+
+// Operator represents an operator name.
+type Operator struct {
+	Op OperatorKind
+}
+
+func (n *Operator) Kind() NodeKind { return NodeKindOperator }
+func (n *Operator) String() string { return Format(n) }
+
+// ConversionOperator represents a conversion operator.
+type ConversionOperator struct {
+	TargetType Node
+}
+
+func (n *ConversionOperator) Kind() NodeKind { return NodeKindConversionOperator }
+func (n *ConversionOperator) String() string { return Format(n) }
+
+// Structor represents a constructor or destructor, named after its class.
+type Structor struct {
+	Class        Node // Enclosing class, possibly a template instantiation
+	IsDestructor bool
+}
+
+func (n *Structor) Kind() NodeKind {
+	if n.IsDestructor {
+		return NodeKindDestructor
+	}
+	return NodeKindConstructor
+}
+
+func (n *Structor) String() string { return Format(n) }
+
+// TemplateInstantiation represents a template with arguments.
+type TemplateInstantiation struct {
+	Name      Node
+	Arguments []Node
+}
+
+func (n *TemplateInstantiation) Kind() NodeKind { return NodeKindTemplateInstantiation }
+func (n *TemplateInstantiation) String() string { return Format(n) }
+
+// PrimitiveKind identifies primitive types.
+type PrimitiveKind int
+
+const (
+	PrimVoid PrimitiveKind = iota
+	PrimBool
+	PrimChar
+	PrimSignedChar
+	PrimUnsignedChar
+	PrimShort
+	PrimUnsignedShort
+	PrimInt
+	PrimUnsignedInt
+	PrimLong
+	PrimUnsignedLong
+	PrimInt64
+	PrimUnsignedInt64
+	PrimInt128
+	PrimUnsignedInt128
+	PrimFloat
+	PrimDouble
+	PrimLongDouble
+	PrimWChar
+	PrimChar8
+	PrimChar16
+	PrimChar32
+	PrimNullptr
+	PrimAuto
+	PrimDecltypeAuto
+)
+
+var primitiveNames = map[PrimitiveKind]string{
+	PrimVoid:           "void",
+	PrimBool:           "bool",
+	PrimChar:           "char",
+	PrimSignedChar:     "signed char",
+	PrimUnsignedChar:   "unsigned char",
+	PrimShort:          "short",
+	PrimUnsignedShort:  "unsigned short",
+	PrimInt:            "int",
+	PrimUnsignedInt:    "unsigned int",
+	PrimLong:           "long",
+	PrimUnsignedLong:   "unsigned long",
+	PrimInt64:          "__int64",
+	PrimUnsignedInt64:  "unsigned __int64",
+	PrimInt128:         "__int128",
+	PrimUnsignedInt128: "unsigned __int128",
+	PrimFloat:          "float",
+	PrimDouble:         "double",
+	PrimLongDouble:     "long double",
+	PrimWChar:          "wchar_t",
+	PrimChar8:          "char8_t",
+	PrimChar16:         "char16_t",
+	PrimChar32:         "char32_t",
+	PrimNullptr:        "std::nullptr_t",
+	PrimAuto:           "auto",
+	PrimDecltypeAuto:   "decltype(auto)",
+}
+
+// PrimitiveType represents a fundamental C++ type.
+type PrimitiveType struct {
+	Type PrimitiveKind
+}
+
+func (n *PrimitiveType) Kind() NodeKind { return NodeKindPrimitiveType }
+
+func (n *PrimitiveType) String() string {
+	if name, ok := primitiveNames[n.Type]; ok {
+		return name
+	}
+	return "?"
+}
+
+// Qualifiers represents CV-qualifiers.
+type Qualifiers struct {
+	IsConst     bool
+	IsVolatile  bool
+	IsRestrict  bool
+	IsUnaligned bool
+}
+
+func (q Qualifiers) String() string {
+	var parts []string
+	if q.IsConst {
+		parts = append(parts, "const")
+	}
+	if q.IsVolatile {
+		parts = append(parts, "volatile")
+	}
+	if q.IsRestrict {
+		parts = append(parts, "__restrict")
+	}
+	if q.IsUnaligned {
+		parts = append(parts, "__unaligned")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (q Qualifiers) IsEmpty() bool {
+	return !q.IsConst && !q.IsVolatile && !q.IsRestrict && !q.IsUnaligned
+}
+
+// PointerAffinity distinguishes pointer types.
+type PointerAffinity int
+
+const (
+	AffinityPointer PointerAffinity = iota
+	AffinityReference
+	AffinityRValueReference
+)
+
+// PointerType represents a pointer, reference, or rvalue reference.
+type PointerType struct {
+	Pointee  Node
+	Affinity PointerAffinity
+	Quals    Qualifiers
+	Is64Bit  bool
+}
+
+func (n *PointerType) Kind() NodeKind {
+	switch n.Affinity {
+	case AffinityReference:
+		return NodeKindReferenceType
+	case AffinityRValueReference:
+		return NodeKindRValueReferenceType
+	default:
+		return NodeKindPointerType
+	}
+}
+
+func (n *PointerType) String() string { return Format(n) }
+
+// ArrayType represents a C++ array type.
+type ArrayType struct {
+	ElementType Node
+	Dimensions  []uint64
+}
+
+func (n *ArrayType) Kind() NodeKind { return NodeKindArrayType }
+
+func (n *ArrayType) String() string { return Format(n) }
+
+// CallingConvention represents function calling conventions.
+type CallingConvention int
+
+const (
+	CallingConvCdecl CallingConvention = iota
+	CallingConvPascal
+	CallingConvThiscall
+	CallingConvStdcall
+	CallingConvFastcall
+	CallingConvVectorcall
+	CallingConvClrcall
+	CallingConvEabi
+	CallingConvSwift
+	CallingConvSwiftAsync
+)
+
+var callingConvNames = map[CallingConvention]string{
+	CallingConvCdecl:      "__cdecl",
+	CallingConvPascal:     "__pascal",
+	CallingConvThiscall:   "__thiscall",
+	CallingConvStdcall:    "__stdcall",
+	CallingConvFastcall:   "__fastcall",
+	CallingConvVectorcall: "__vectorcall",
+	CallingConvClrcall:    "__clrcall",
+	CallingConvEabi:       "__eabi",
+	CallingConvSwift:      "__swiftcall",
+	CallingConvSwiftAsync: "__swiftasynccall",
+}
+
+// FunctionType represents a function signature. For member functions,
+// Quals, Is64Bit and RefQualifier describe the this pointer.
+type FunctionType struct {
+	CallingConv  CallingConvention
+	ReturnType   Node // nil for constructors and destructors
+	Parameters   []Node
+	Quals        Qualifiers
+	IsVariadic   bool
+	Is64Bit      bool
+	RefQualifier RefQualifier
+}
+
+func (n *FunctionType) Kind() NodeKind { return NodeKindFunctionType }
+func (n *FunctionType) String() string { return Format(n) }
+
+// RefQualifier for member function reference qualifiers.
+type RefQualifier int
+
+const (
+	RefQualifierNone RefQualifier = iota
+	RefQualifierLValue
+	RefQualifierRValue
+)
+
+// TagKind identifies class types.
+type TagKind int
+
+const (
+	TagUnion TagKind = iota
+	TagStruct
+	TagClass
+	TagEnum
+)
+
+var tagNames = map[TagKind]string{
+	TagUnion:  "union",
+	TagStruct: "struct",
+	TagClass:  "class",
+	TagEnum:   "enum",
+}
+
+// TagType represents class, struct, union, or enum types.
+type TagType struct {
+	Tag  TagKind
+	Name *QualifiedName
+}
+
+func (n *TagType) Kind() NodeKind { return NodeKindTagType }
+
+func (n *TagType) String() string { return Format(n) }
+
+// MemberPointerType represents pointer-to-member.
+type MemberPointerType struct {
+	ClassType  Node
+	MemberType Node
+	Quals      Qualifiers
+	Is64Bit    bool
+}
+
+func (n *MemberPointerType) Kind() NodeKind { return NodeKindMemberPointerType }
+func (n *MemberPointerType) String() string { return Format(n) }
+
+// QualifiedType represents a CV-qualified type.
+type QualifiedType struct {
+	Type  Node
+	Quals Qualifiers
+}
+
+func (n *QualifiedType) Kind() NodeKind { return NodeKindQualifiedType }
+
+func (n *QualifiedType) String() string { return Format(n) }
+
+// AccessSpecifier identifies member accessibility.
+type AccessSpecifier int
+
+const (
+	AccessNone AccessSpecifier = iota
+	AccessPrivate
+	AccessProtected
+	AccessPublic
+)
+
+var accessNames = map[AccessSpecifier]string{
+	AccessPrivate:   "private",
+	AccessProtected: "protected",
+	AccessPublic:    "public",
+}
+
+// FunctionSymbol represents a function definition. Thunks adjust the this
+// pointer by Adjustment before calling the function.
+type FunctionSymbol struct {
+	Name       *QualifiedName
+	Signature  *FunctionType
+	AccessSpec AccessSpecifier
+	IsStatic   bool
+	IsVirtual  bool
+	IsThunk    bool
+	Adjustment int64
+}
+
+func (n *FunctionSymbol) Kind() NodeKind { return NodeKindFunctionSymbol }
+func (n *FunctionSymbol) String() string { return Format(n) }
+
+// VariableSymbol represents a variable definition.
+type VariableSymbol struct {
+	Name       *QualifiedName
+	Type       Node
+	AccessSpec AccessSpecifier
+	IsStatic   bool
+}
+
+func (n *VariableSymbol) Kind() NodeKind { return NodeKindVariableSymbol }
+func (n *VariableSymbol) String() string { return Format(n) }
+
+// SpecialTableSymbol represents a vftable, vbtable or RTTI complete object
+// locator. Targets name the base classes whose part of the object the table
+// is for, outermost first.
+type SpecialTableSymbol struct {
+	Name    *QualifiedName
+	Quals   Qualifiers
+	Targets []*QualifiedName
+}
+
+func (n *SpecialTableSymbol) Kind() NodeKind { return NodeKindSpecialTableSymbol }
+func (n *SpecialTableSymbol) String() string { return Format(n) }
+
+// StringLiteral represents a string literal (??_C@_...). The decorated name
+// keeps at most the first 32 bytes of the string, so Prefix is the complete
+// string only if Truncated is false. Length counts characters including
+// the terminating NUL.
+type StringLiteral struct {
+	CharType  PrimitiveKind // PrimChar, PrimWChar, PrimChar16 or PrimChar32
+	CharWidth int           // Bytes per character
+	Length    int
+	Prefix    string
+	Truncated bool
+}
+
+func (n *StringLiteral) Kind() NodeKind { return NodeKindStringLiteral }
+func (n *StringLiteral) String() string { return Format(n) }
+
+// RTTIBaseClassDescriptor is the name component of an RTTI base class
+// descriptor, which records where a base class is found in the object.
+type RTTIBaseClassDescriptor struct {
+	NVOffset      int64
+	VBPtrOffset   int64
+	VBTableOffset int64
+	Flags         int64
+}
+
+func (n *RTTIBaseClassDescriptor) Kind() NodeKind { return NodeKindRTTI }
+func (n *RTTIBaseClassDescriptor) String() string { return Format(n) }
+
+// DynamicStructor is the name of a function that constructs a global at
+// startup (dynamic initializer) or destroys it at exit (atexit destructor).
+// Variable is a VariableSymbol for static data members and a QualifiedName
+// otherwise.
+type DynamicStructor struct {
+	Variable     Node
+	IsDestructor bool
+}
+
+func (n *DynamicStructor) Kind() NodeKind { return NodeKindIdentifier }
+func (n *DynamicStructor) String() string { return Format(n) }
+
+// HashedName represents a name the compiler replaced by its MD5 hash
+// (??@<hash>@) because the decoration was too long.
+type HashedName struct {
+	Hash string
+}
+
+func (n *HashedName) Kind() NodeKind { return NodeKindIdentifier }
+func (n *HashedName) String() string { return Format(n) }
+
+// AnonymousNamespace represents an anonymous namespace (?A<hash>@), whose
+// decoration holds a hash identifying the translation unit.
+type AnonymousNamespace struct {
+	Hash string
+}
+
+func (n *AnonymousNamespace) Kind() NodeKind { return NodeKindIdentifier }
+func (n *AnonymousNamespace) String() string { return "`anonymous namespace'" }
+
+// IntegerLiteral represents an integer constant.
+type IntegerLiteral struct {
+	Value    int64
+	Negative bool
+}
+
+func (n *IntegerLiteral) Kind() NodeKind { return NodeKindIntegerLiteral }
+
+func (n *IntegerLiteral) String() string {
+	return fmt.Sprintf("%d", n.Value)
+}
+
+// NameOf returns the qualified name of a function, variable or table
+// symbol, or the node itself if it is a qualified name. It returns nil for
+// other nodes.
+func NameOf(n Node) *QualifiedName {
+	switch n := n.(type) {
+	case *FunctionSymbol:
+		return n.Name
+	case *VariableSymbol:
+		return n.Name
+	case *SpecialTableSymbol:
+		return n.Name
+	case *QualifiedName:
+		return n
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+package demangle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Option leaves parts of a declaration out of the demangled text, like the
+// UNDNAME_* flags of undname, or changes how names are read. Options are
+// combined with |.
+type Option uint32
+
+const (
+	NoAccessSpecifiers  Option = 1 << iota // public:, protected: and private:
+	NoCallingConvention                    // __cdecl, __thiscall, ...
+	NoReturnType                           // Return type of the function
+	NoPtr64                                // __ptr64 on pointers and this
+	NoTagKeywords                          // class, struct, union and enum before type names
+	NoMemberType                           // static and virtual
+	NameOnly                               // Qualified name without type or parameters
+	NoHash                                 // Hash and crate disambiguators of Rust names
+	X86                                    // Names are from 32-bit x86, where __cdecl C names start with '_'
+	MSVCSpacing                            // Bare commas in lists and "> >" between closing brackets, as MSVC's undname prints
+)
+
+// Format renders a node as text, leaving out what the options exclude.
+func Format(n Node, options ...Option) string {
+	var p printer
+	for _, o := range options {
+		p.opts |= o
+	}
+	return p.node(n)
+}
+
+// printer renders nodes in undname style.
+type printer struct {
+	opts Option
+}
+
+func (p *printer) has(o Option) bool { return p.opts&o != 0 }
+
+func (p *printer) node(n Node) string {
+	switch n := n.(type) {
+	case nil:
+		return ""
+	case *FunctionSymbol:
+		return p.function(n)
+	case *VariableSymbol:
+		return p.variable(n)
+	case *SpecialTableSymbol:
+		return p.table(n)
+	case *StringLiteral:
+		return p.stringLiteral(n)
+	case *QualifiedName:
+		return p.name(n)
+	case *Operator, *ConversionOperator, *Structor, *TemplateInstantiation, *Identifier, *IntegerLiteral,
+		*RTTIBaseClassDescriptor, *DynamicStructor, *HashedName, *AnonymousNamespace:
+		return p.component(n)
+	default:
+		return p.typ(n, "")
+	}
+}
+
+func (p *printer) function(fn *FunctionSymbol) string {
+	if p.has(NameOnly) {
+		return p.name(fn.Name)
+	}
+
+	var b strings.Builder
+	if fn.IsThunk {
+		b.WriteString("[thunk]:")
+	}
+	if fn.AccessSpec != AccessNone && !p.has(NoAccessSpecifiers) {
+		b.WriteString(accessNames[fn.AccessSpec])
+		b.WriteString(": ")
+	}
+	if !p.has(NoMemberType) {
+		if fn.IsStatic {
+			b.WriteString("static ")
+		}
+		if fn.IsVirtual {
+			b.WriteString("virtual ")
+		}
+	}
+
+	sig := fn.Signature
+	if sig == nil {
+		b.WriteString(p.name(fn.Name))
+		return b.String()
+	}
+
+	// Conversion operators name their return type
+	_, conversion := fn.Name.BaseName().(*ConversionOperator)
+	if sig.ReturnType != nil && !conversion && !p.has(NoReturnType) {
+		b.WriteString(p.typ(sig.ReturnType, ""))
+		b.WriteByte(' ')
+	}
+	if cc := p.callingConv(sig); cc != "" {
+		b.WriteString(cc)
+		b.WriteByte(' ')
+	}
+
+	b.WriteString(p.name(fn.Name))
+	if fn.IsThunk {
+		b.WriteString("`adjustor{" + strconv.FormatInt(fn.Adjustment, 10) + "}' ")
+	}
+	b.WriteString(p.params(sig))
+	b.WriteString(p.thisQuals(sig))
+	return b.String()
+}
+
+func (p *printer) variable(v *VariableSymbol) string {
+	if p.has(NameOnly) || v.Type == nil {
+		return p.name(v.Name)
+	}
+
+	var b strings.Builder
+	if v.AccessSpec != AccessNone && !p.has(NoAccessSpecifiers) {
+		b.WriteString(accessNames[v.AccessSpec])
+		b.WriteString(": ")
+	}
+	if v.IsStatic && !p.has(NoMemberType) {
+		b.WriteString("static ")
+	}
+	b.WriteString(p.typ(v.Type, p.name(v.Name)))
+	return b.String()
+}
+
+// table renders a special table such as "const Foo::`vftable'{for `Base'}".
+func (p *printer) table(t *SpecialTableSymbol) string {
+	s := p.name(t.Name)
+	if p.has(NameOnly) {
+		return s
+	}
+	if q := t.Quals.String(); q != "" {
+		s = q + " " + s
+	}
+	if len(t.Targets) > 0 {
+		targets := make([]string, len(t.Targets))
+		for i, target := range t.Targets {
+			targets[i] = "`" + p.name(target) + "'"
+		}
+		s += "{for " + strings.Join(targets, "s ") + "}"
+	}
+	return s
+}
+
+// stringLiteral renders a string literal as its type and the known prefix
+// of its contents, e.g. const char [12] {"hello world"}.
+func (p *printer) stringLiteral(l *StringLiteral) string {
+	if p.has(NameOnly) {
+		return operatorNames[OpStringLiteral]
+	}
+	s := "const " + primitiveNames[l.CharType] + " [" + strconv.Itoa(l.Length) + "] {" + strconv.Quote(l.Prefix)
+	if l.Truncated {
+		s += "..."
+	}
+	return s + "}"
+}
+
+func (p *printer) name(q *QualifiedName) string {
+	if q == nil {
+		return ""
+	}
+	parts := make([]string, len(q.Components))
+	for i, c := range q.Components {
+		parts[i] = p.component(c)
+	}
+	return strings.Join(parts, "::")
+}
+
+// component renders one component of a qualified name.
+func (p *printer) component(n Node) string {
+	switch n := n.(type) {
+	case *Identifier:
+		return n.Name
+	case *Operator:
+		if name, ok := operatorNames[n.Op]; ok {
+			return name
+		}
+		return "operator?"
+	case *ConversionOperator:
+		return "operator " + p.typ(n.TargetType, "")
+	case *Structor:
+		name := p.component(n.Class)
+		if n.IsDestructor {
+			return "~" + name
+		}
+		return name
+	case *TemplateInstantiation:
+		args := make([]string, len(n.Arguments))
+		for i, a := range n.Arguments {
+			args[i] = p.node(a)
+		}
+		list := p.list(args)
+		if p.has(MSVCSpacing) && strings.HasSuffix(list, ">") {
+			list += " "
+		}
+		return p.component(n.Name) + "<" + list + ">"
+	case *IntegerLiteral:
+		return n.String()
+	case *RTTIBaseClassDescriptor:
+		return fmt.Sprintf("`RTTI Base Class Descriptor at (%d,%d,%d,%d)'", n.NVOffset, n.VBPtrOffset, n.VBTableOffset, n.Flags)
+	case *DynamicStructor:
+		kind := "`dynamic initializer for "
+		if n.IsDestructor {
+			kind = "`dynamic atexit destructor for "
+		}
+		if v, ok := n.Variable.(*VariableSymbol); ok {
+			return kind + "`" + p.variable(v) + "''"
+		}
+		return kind + "'" + p.node(n.Variable) + "''"
+	case *HashedName:
+		return "`hashed name " + n.Hash + "'"
+	case *AnonymousNamespace:
+		return n.String()
+	case *QualifiedName:
+		return p.name(n)
+	case nil:
+		return ""
+	}
+	return p.node(n)
+}
+
+// typ renders a type around a declarator, which is the declared name or
+// the pointer part of an enclosing type (e.g. "* __ptr64 x").
+func (p *printer) typ(n Node, decl string) string {
+	withDecl := func(s string) string {
+		if decl == "" {
+			return s
+		}
+		return s + " " + decl
+	}
+
+	switch n := n.(type) {
+	case *PrimitiveType:
+		return withDecl(n.String())
+
+	case *TagType:
+		if p.has(NoTagKeywords) {
+			return withDecl(p.name(n.Name))
+		}
+		return withDecl(tagNames[n.Tag] + " " + p.name(n.Name))
+
+	case *QualifiedType:
+		s := p.typ(n.Type, "")
+		if q := n.Quals.String(); q != "" {
+			s += " " + q
+		}
+		return withDecl(s)
+
+	case *PointerType:
+		d := "*"
+		switch n.Affinity {
+		case AffinityReference:
+			d = "&"
+		case AffinityRValueReference:
+			d = "&&"
+		}
+		d += p.pointerQuals(n.Quals, n.Is64Bit)
+		if decl != "" {
+			d += " " + decl
+		}
+
+		switch pointee := n.Pointee.(type) {
+		case *FunctionType:
+			return p.functionType(pointee, d)
+		case *ArrayType:
+			return p.typ(pointee, "("+d+")")
+		}
+		return p.typ(n.Pointee, d)
+
+	case *MemberPointerType:
+		d := p.component(n.ClassType) + "::*" + p.pointerQuals(n.Quals, n.Is64Bit)
+		if decl != "" {
+			d += " " + decl
+		}
+		if fn, ok := n.MemberType.(*FunctionType); ok {
+			return p.functionType(fn, d)
+		}
+		return p.typ(n.MemberType, d)
+
+	case *ArrayType:
+		var dims strings.Builder
+		for _, dim := range n.Dimensions {
+			dims.WriteString("[" + strconv.FormatUint(dim, 10) + "]")
+		}
+		return p.typ(n.ElementType, "") + withDeclArray(decl, dims.String())
+
+	case *FunctionType:
+		return p.functionType(n, decl)
+
+	case *QualifiedName:
+		return withDecl(p.name(n))
+
+	case nil:
+		return decl
+	}
+
+	return withDecl(p.component(n))
+}
+
+func withDeclArray(decl, dims string) string {
+	if decl == "" {
+		return dims
+	}
+	return " " + decl + dims
+}
+
+// functionType renders a function type. A pointer declarator is wrapped in
+// parentheses together with the calling convention.
+func (p *printer) functionType(fn *FunctionType, decl string) string {
+	var b strings.Builder
+	if fn.ReturnType != nil {
+		b.WriteString(p.typ(fn.ReturnType, ""))
+		b.WriteByte(' ')
+	}
+
+	cc := p.callingConv(fn)
+	switch {
+	case decl != "" && cc != "":
+		b.WriteString("(" + cc + " " + decl + ")")
+	case decl != "":
+		b.WriteString("(" + decl + ")")
+	default:
+		b.WriteString(cc)
+	}
+
+	b.WriteString(p.params(fn))
+	b.WriteString(p.thisQuals(fn))
+	return b.String()
+}
+
+func (p *printer) callingConv(fn *FunctionType) string {
+	if p.has(NoCallingConvention) {
+		return ""
+	}
+	return callingConvNames[fn.CallingConv]
+}
+
+func (p *printer) params(fn *FunctionType) string {
+	params := make([]string, 0, len(fn.Parameters)+1)
+	for _, param := range fn.Parameters {
+		params = append(params, p.typ(param, ""))
+	}
+	if fn.IsVariadic {
+		params = append(params, "...")
+	}
+	if len(params) == 0 {
+		params = append(params, "void")
+	}
+	return "(" + p.list(params) + ")"
+}
+
+// list joins parameters or template arguments.
+func (p *printer) list(items []string) string {
+	if p.has(MSVCSpacing) {
+		return strings.Join(items, ",")
+	}
+	return strings.Join(items, ", ")
+}
+
+// thisQuals renders the qualifiers of a member function's this pointer,
+// which undname writes in the opposite order to those of pointers.
+func (p *printer) thisQuals(fn *FunctionType) string {
+	var s string
+	if q := fn.Quals.String(); q != "" {
+		s = " " + q
+	}
+	if fn.Is64Bit && !p.has(NoPtr64) {
+		s += " __ptr64"
+	}
+	switch fn.RefQualifier {
+	case RefQualifierLValue:
+		s += " &"
+	case RefQualifierRValue:
+		s += " &&"
+	}
+	return s
+}
+
+// pointerQuals renders the qualifiers following a "*", "&" or "::*".
+func (p *printer) pointerQuals(q Qualifiers, is64Bit bool) string {
+	var s string
+	if q.IsUnaligned {
+		s += " __unaligned"
+	}
+	if is64Bit && !p.has(NoPtr64) {
+		s += " __ptr64"
+	}
+	if q.IsRestrict {
+		s += " __restrict"
+	}
+	if q.IsConst {
+		s += " const"
+	}
+	if q.IsVolatile {
+		s += " volatile"
+	}
+	return s
+}
@@ -0,0 +1,920 @@
+package demangle
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxRustDepth bounds recursion on nested or back-referenced v0 paths.
+const maxRustDepth = 300
+
+// IsRustMangled reports whether a name is a Rust symbol in the v0 (_R...)
+// or legacy (_ZN...E) scheme. 32-bit Windows targets add a second leading
+// underscore.
+func IsRustMangled(name string) bool {
+	name = trimRustPrefix(name)
+	switch {
+	case strings.HasPrefix(name, "_R"):
+		return len(name) > 2 && (name[2] >= 'A' && name[2] <= 'Z')
+	case strings.HasPrefix(name, "_ZN"):
+		_, ok := legacyComponents(name)
+		return ok
+	}
+	return false
+}
+
+// trimRustPrefix drops the C decoration underscore of 32-bit targets.
+func trimRustPrefix(name string) string {
+	if strings.HasPrefix(name, "__R") || strings.HasPrefix(name, "__ZN") {
+		return name[1:]
+	}
+	return name
+}
+
+// DemangleRust demangles a Rust symbol name in the style of rustc-demangle,
+// e.g.
+//
+//	_ZN4core3ptr13drop_in_place17h0123456789abcdefE => core::ptr::drop_in_place::h0123456789abcdef
+//	_RNvCs1234_7mycrate3foo                         => mycrate[4d3]::foo
+//
+// NoHash leaves out the legacy hash, v0 crate disambiguators and the type
+// suffixes of v0 constants.
+func DemangleRust(name string, options ...Option) (string, error) {
+	var opts Option
+	for _, o := range options {
+		opts |= o
+	}
+
+	mangled := trimRustPrefix(name)
+
+	// LLVM appends .llvm.<hash> to names of promoted local symbols
+	if i := strings.Index(mangled, ".llvm."); i >= 0 {
+		mangled = mangled[:i]
+	}
+
+	switch {
+	case strings.HasPrefix(mangled, "_R"):
+		return demangleRustV0(mangled[2:], opts)
+	case strings.HasPrefix(mangled, "_ZN"):
+		return demangleRustLegacy(mangled, opts)
+	}
+	return name, ErrInvalidMangled
+}
+
+// legacyComponents splits a legacy name into its length-prefixed
+// identifiers. Anything after the closing 'E' is a vendor suffix such as
+// ".cold" and is kept with the last component.
+func legacyComponents(name string) ([]string, bool) {
+	s := name[3:]
+	var parts []string
+	for {
+		if s == "" {
+			return nil, false
+		}
+		if s[0] == 'E' {
+			break
+		}
+
+		n := 0
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		if n == 0 {
+			return nil, false
+		}
+		size, err := strconv.Atoi(s[:n])
+		if err != nil || size == 0 || size > len(s)-n {
+			return nil, false
+		}
+		parts = append(parts, s[n:n+size])
+		s = s[n+size:]
+	}
+
+	// The suffix must not look like the parameters of an Itanium C++ name
+	if suffix := s[1:]; suffix != "" && suffix[0] != '.' {
+		return nil, false
+	}
+	return parts, len(parts) > 0
+}
+
+// isRustHash reports whether a legacy component is the h<16 hex digits>
+// hash rustc appends to every path.
+func isRustHash(s string) bool {
+	if len(s) != 17 || s[0] != 'h' {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func demangleRustLegacy(name string, opts Option) (string, error) {
+	parts, ok := legacyComponents(name)
+	if !ok {
+		return name, ErrInvalidMangled
+	}
+	if opts&NoHash != 0 && len(parts) > 1 && isRustHash(parts[len(parts)-1]) {
+		parts = parts[:len(parts)-1]
+	}
+
+	var b strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			b.WriteString("::")
+		}
+		unescapeLegacy(&b, part)
+	}
+	return b.String(), nil
+}
+
+// Escapes used by legacy names for characters that are not valid in
+// symbol names
+var legacyEscapes = map[string]string{
+	"SP": "@", "BP": "*", "RF": "&", "LT": "<", "GT": ">", "LP": "(", "RP": ")", "C": ",",
+}
+
+func unescapeLegacy(b *strings.Builder, s string) {
+	// Identifiers starting with '$' are prefixed with '_'
+	if strings.HasPrefix(s, "_$") {
+		s = s[1:]
+	}
+
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			b.WriteString("::")
+			s = s[2:]
+			continue
+		case s[0] == '$':
+			if end := strings.IndexByte(s[1:], '$'); end >= 0 {
+				esc := s[1 : end+1]
+				if r, ok := legacyEscapes[esc]; ok {
+					b.WriteString(r)
+					s = s[end+2:]
+					continue
+				}
+				if esc != "" && esc[0] == 'u' {
+					if v, err := strconv.ParseUint(esc[1:], 16, 32); err == nil && utf8.ValidRune(rune(v)) {
+						b.WriteRune(rune(v))
+						s = s[end+2:]
+						continue
+					}
+				}
+			}
+		}
+		b.WriteByte(s[0])
+		s = s[1:]
+	}
+}
+
+func demangleRustV0(sym string, opts Option) (string, error) {
+	// Encoding versions other than the implicit 0 are not defined yet
+	if sym != "" && sym[0] >= '0' && sym[0] <= '9' {
+		return "", ErrInvalidMangled
+	}
+
+	p := &rustPrinter{sym: sym, opts: opts}
+	if err := p.path(true); err != nil {
+		return "", err
+	}
+
+	// The instantiating crate is not printed
+	if p.pos < len(p.sym) && p.sym[p.pos] >= 'A' && p.sym[p.pos] <= 'Z' {
+		p.skipping++
+		err := p.path(false)
+		p.skipping--
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Vendor-specific suffixes start with '.' or '$'
+	if rest := p.sym[p.pos:]; rest != "" {
+		if rest[0] != '.' && rest[0] != '$' {
+			return "", ErrInvalidMangled
+		}
+		p.out.WriteString(" (" + rest + ")")
+	}
+	return p.out.String(), nil
+}
+
+// rustPrinter parses a v0 symbol and prints it as it goes.
+type rustPrinter struct {
+	sym  string
+	pos  int
+	opts Option
+	out  strings.Builder
+
+	// skipping is non-zero while parsing parts that are not printed
+	skipping int
+	depth    int
+
+	// boundLifetimes is the number of lifetimes bound by enclosing
+	// for<...> binders
+	boundLifetimes uint64
+}
+
+func (p *rustPrinter) print(s string) {
+	if p.skipping == 0 {
+		p.out.WriteString(s)
+	}
+}
+
+func (p *rustPrinter) peek() byte {
+	if p.pos >= len(p.sym) {
+		return 0
+	}
+	return p.sym[p.pos]
+}
+
+func (p *rustPrinter) eat(c byte) bool {
+	if p.peek() == c && p.pos < len(p.sym) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *rustPrinter) next() (byte, error) {
+	if p.pos >= len(p.sym) {
+		return 0, ErrUnexpectedEnd
+	}
+	c := p.sym[p.pos]
+	p.pos++
+	return c, nil
+}
+
+// enter guards against unbounded recursion.
+func (p *rustPrinter) enter() error {
+	p.depth++
+	if p.depth > maxRustDepth {
+		return ErrInvalidMangled
+	}
+	return nil
+}
+
+func (p *rustPrinter) leave() { p.depth-- }
+
+// backref runs fn at the position a back-reference points to.
+func (p *rustPrinter) backref(fn func() error) error {
+	start := p.pos - 1
+	target, err := p.base62()
+	if err != nil {
+		return err
+	}
+	if target >= uint64(start) {
+		return ErrInvalidBackref
+	}
+
+	saved := p.pos
+	p.pos = int(target)
+	err = fn()
+	p.pos = saved
+	return err
+}
+
+// base62 parses {<0-9a-zA-Z>} '_', where "_" is 0.
+func (p *rustPrinter) base62() (uint64, error) {
+	if p.eat('_') {
+		return 0, nil
+	}
+
+	var x uint64
+	for !p.eat('_') {
+		c, err := p.next()
+		if err != nil {
+			return 0, err
+		}
+		var d byte
+		switch {
+		case c >= '0' && c <= '9':
+			d = c - '0'
+		case c >= 'a' && c <= 'z':
+			d = 10 + c - 'a'
+		case c >= 'A' && c <= 'Z':
+			d = 36 + c - 'A'
+		default:
+			return 0, ErrInvalidMangled
+		}
+		if x > (1<<64-1-uint64(d))/62 {
+			return 0, ErrInvalidMangled
+		}
+		x = x*62 + uint64(d)
+	}
+	return x + 1, nil
+}
+
+// optionalBase62 parses tag <base-62-number> if the tag is present, as
+// disambiguators and binders are; it returns 0 otherwise.
+func (p *rustPrinter) optionalBase62(tag byte) (uint64, error) {
+	if !p.eat(tag) {
+		return 0, nil
+	}
+	x, err := p.base62()
+	if err != nil {
+		return 0, err
+	}
+	return x + 1, nil
+}
+
+func (p *rustPrinter) decimal() (int, error) {
+	start := p.pos
+	for p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	s := p.sym[start:p.pos]
+	if s == "" || (s[0] == '0' && len(s) > 1) {
+		return 0, ErrInvalidMangled
+	}
+	return strconv.Atoi(s)
+}
+
+// ident parses an undisambiguated identifier: ["u"] <decimal> ["_"] <bytes>.
+func (p *rustPrinter) ident() (string, error) {
+	punycode := p.eat('u')
+	n, err := p.decimal()
+	if err != nil {
+		return "", err
+	}
+	p.eat('_')
+	if n > len(p.sym)-p.pos {
+		return "", ErrUnexpectedEnd
+	}
+	s := p.sym[p.pos : p.pos+n]
+	p.pos += n
+
+	if punycode {
+		return decodePunycode(s)
+	}
+	return s, nil
+}
+
+// path prints a path. In value position generic arguments are written with
+// a turbofish (foo::<T>).
+func (p *rustPrinter) path(inValue bool) error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+
+	tag, err := p.next()
+	if err != nil {
+		return err
+	}
+
+	switch tag {
+	case 'C':
+		dis, err := p.optionalBase62('s')
+		if err != nil {
+			return err
+		}
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		p.print(name)
+		if p.opts&NoHash == 0 {
+			p.print("[" + strconv.FormatUint(dis, 16) + "]")
+		}
+
+	case 'N':
+		ns, err := p.next()
+		if err != nil {
+			return err
+		}
+		if !(ns >= 'a' && ns <= 'z' || ns >= 'A' && ns <= 'Z') {
+			return ErrInvalidMangled
+		}
+		if err := p.path(inValue); err != nil {
+			return err
+		}
+		dis, err := p.optionalBase62('s')
+		if err != nil {
+			return err
+		}
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+
+		// Upper-case namespaces are special, e.g. closures and shims
+		if ns >= 'A' && ns <= 'Z' {
+			p.print("::{")
+			switch ns {
+			case 'C':
+				p.print("closure")
+			case 'S':
+				p.print("shim")
+			default:
+				p.print(string(ns))
+			}
+			if name != "" {
+				p.print(":" + name)
+			}
+			p.print("#" + strconv.FormatUint(dis, 10) + "}")
+		} else if name != "" {
+			p.print("::" + name)
+		}
+
+	case 'M', 'X':
+		// The impl path is the module containing the impl
+		p.skipping++
+		_, err := p.optionalBase62('s')
+		if err == nil {
+			err = p.path(false)
+		}
+		p.skipping--
+		if err != nil {
+			return err
+		}
+		fallthrough
+
+	case 'Y':
+		p.print("<")
+		if err := p.typ(); err != nil {
+			return err
+		}
+		if tag != 'M' {
+			p.print(" as ")
+			if err := p.path(false); err != nil {
+				return err
+			}
+		}
+		p.print(">")
+
+	case 'I':
+		if err := p.path(inValue); err != nil {
+			return err
+		}
+		if inValue {
+			p.print("::")
+		}
+		p.print("<")
+		if err := p.genericArgs(); err != nil {
+			return err
+		}
+		p.print(">")
+
+	case 'B':
+		return p.backref(func() error { return p.path(inValue) })
+
+	default:
+		return ErrInvalidMangled
+	}
+	return nil
+}
+
+// genericArgs prints {<generic-arg>} 'E'.
+func (p *rustPrinter) genericArgs() error {
+	for i := 0; !p.eat('E'); i++ {
+		if i > 0 {
+			p.print(", ")
+		}
+		if err := p.genericArg(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *rustPrinter) genericArg() error {
+	switch {
+	case p.eat('L'):
+		lt, err := p.base62()
+		if err != nil {
+			return err
+		}
+		return p.lifetime(lt)
+	case p.eat('K'):
+		return p.constant()
+	}
+	return p.typ()
+}
+
+// lifetime prints a lifetime by its de Bruijn index: 0 is erased, others
+// count outwards from the innermost binder.
+func (p *rustPrinter) lifetime(lt uint64) error {
+	if lt == 0 {
+		p.print("'_")
+		return nil
+	}
+	if lt > p.boundLifetimes {
+		return ErrInvalidMangled
+	}
+	depth := p.boundLifetimes - lt
+	if depth < 26 {
+		p.print("'" + string(rune('a'+depth)))
+	} else {
+		p.print("'_" + strconv.FormatUint(depth, 10))
+	}
+	return nil
+}
+
+// binder prints for<'a, ...> for a binder and runs fn with the lifetimes
+// in scope.
+func (p *rustPrinter) binder(fn func() error) error {
+	n, err := p.optionalBase62('G')
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		p.print("for<")
+		for i := uint64(0); i < n; i++ {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.boundLifetimes++
+			if err := p.lifetime(1); err != nil {
+				return err
+			}
+		}
+		p.print("> ")
+	}
+
+	err = fn()
+	p.boundLifetimes -= n
+	return err
+}
+
+// Basic type codes
+var rustBasicTypes = map[byte]string{
+	'a': "i8", 'b': "bool", 'c': "char", 'd': "f64", 'e': "str", 'f': "f32",
+	'h': "u8", 'i': "isize", 'j': "usize", 'l': "i32", 'm': "u32", 'n': "i128",
+	'o': "u128", 's': "i16", 't': "u16", 'u': "()", 'v': "...", 'x': "i64",
+	'y': "u64", 'z': "!", 'p': "_",
+}
+
+func (p *rustPrinter) typ() error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+
+	c := p.peek()
+	if name, ok := rustBasicTypes[c]; ok {
+		p.pos++
+		p.print(name)
+		return nil
+	}
+
+	p.pos++
+	switch c {
+	case 'R', 'Q':
+		p.print("&")
+		if p.eat('L') {
+			lt, err := p.base62()
+			if err != nil {
+				return err
+			}
+			if lt != 0 {
+				if err := p.lifetime(lt); err != nil {
+					return err
+				}
+				p.print(" ")
+			}
+		}
+		if c == 'Q' {
+			p.print("mut ")
+		}
+		return p.typ()
+
+	case 'P':
+		p.print("*const ")
+		return p.typ()
+	case 'O':
+		p.print("*mut ")
+		return p.typ()
+
+	case 'A', 'S':
+		p.print("[")
+		if err := p.typ(); err != nil {
+			return err
+		}
+		if c == 'A' {
+			p.print("; ")
+			if err := p.constant(); err != nil {
+				return err
+			}
+		}
+		p.print("]")
+		return nil
+
+	case 'T':
+		p.print("(")
+		n := 0
+		for ; !p.eat('E'); n++ {
+			if n > 0 {
+				p.print(", ")
+			}
+			if err := p.typ(); err != nil {
+				return err
+			}
+		}
+		if n == 1 {
+			p.print(",")
+		}
+		p.print(")")
+		return nil
+
+	case 'F':
+		return p.binder(p.fnSig)
+
+	case 'D':
+		p.print("dyn ")
+		err := p.binder(func() error {
+			for i := 0; !p.eat('E'); i++ {
+				if i > 0 {
+					p.print(" + ")
+				}
+				if err := p.dynTrait(); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if !p.eat('L') {
+			return ErrInvalidMangled
+		}
+		lt, err := p.base62()
+		if err != nil {
+			return err
+		}
+		if lt != 0 {
+			p.print(" + ")
+			return p.lifetime(lt)
+		}
+		return nil
+
+	case 'B':
+		return p.backref(p.typ)
+	}
+
+	// Otherwise a named type
+	p.pos--
+	return p.path(false)
+}
+
+// fnSig prints ["U"] ["K" <abi>] {<type>} "E" <type>.
+func (p *rustPrinter) fnSig() error {
+	if p.eat('U') {
+		p.print("unsafe ")
+	}
+	if p.eat('K') {
+		abi := "C"
+		if !p.eat('C') {
+			s, err := p.ident()
+			if err != nil {
+				return err
+			}
+			abi = strings.ReplaceAll(s, "_", "-")
+		}
+		p.print("extern \"" + abi + "\" ")
+	}
+
+	p.print("fn(")
+	for i := 0; !p.eat('E'); i++ {
+		if i > 0 {
+			p.print(", ")
+		}
+		if err := p.typ(); err != nil {
+			return err
+		}
+	}
+	p.print(")")
+
+	if p.eat('u') {
+		// Unit return type is not written
+		return nil
+	}
+	p.print(" -> ")
+	return p.typ()
+}
+
+// dynTrait prints a trait path with its associated type bindings merged
+// into the generic arguments, e.g. Iterator<Item = u8>.
+func (p *rustPrinter) dynTrait() error {
+	open, err := p.pathOpenGenerics()
+	if err != nil {
+		return err
+	}
+
+	for p.eat('p') {
+		if open {
+			p.print(", ")
+		} else {
+			p.print("<")
+			open = true
+		}
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		p.print(name + " = ")
+		if err := p.typ(); err != nil {
+			return err
+		}
+	}
+
+	if open {
+		p.print(">")
+	}
+	return nil
+}
+
+// pathOpenGenerics prints a type-position path, leaving its generic
+// argument list open. It returns true if the list was opened.
+func (p *rustPrinter) pathOpenGenerics() (bool, error) {
+	switch {
+	case p.eat('B'):
+		var open bool
+		err := p.backref(func() error {
+			var err error
+			open, err = p.pathOpenGenerics()
+			return err
+		})
+		return open, err
+
+	case p.eat('I'):
+		if err := p.path(false); err != nil {
+			return false, err
+		}
+		p.print("<")
+		for i := 0; !p.eat('E'); i++ {
+			if i > 0 {
+				p.print(", ")
+			}
+			if err := p.genericArg(); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	return false, p.path(false)
+}
+
+// constant prints <type> <const-data>, a placeholder 'p' or a
+// back-reference.
+func (p *rustPrinter) constant() error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+
+	c, err := p.next()
+	if err != nil {
+		return err
+	}
+	switch c {
+	case 'p':
+		p.print("_")
+		return nil
+	case 'B':
+		return p.backref(p.constant)
+	}
+
+	typ, ok := rustBasicTypes[c]
+	if !ok {
+		return ErrUnknownType
+	}
+
+	negative := false
+	if c == 'a' || c == 's' || c == 'l' || c == 'x' || c == 'n' || c == 'i' {
+		negative = p.eat('n')
+	}
+	start := p.pos
+	for p.peek() != '_' {
+		if _, err := p.next(); err != nil {
+			return err
+		}
+	}
+	hex := p.sym[start:p.pos]
+	p.pos++
+
+	switch c {
+	case 'b':
+		switch hex {
+		case "0":
+			p.print("false")
+		case "1":
+			p.print("true")
+		default:
+			return ErrInvalidMangled
+		}
+		return nil
+
+	case 'c':
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || !utf8.ValidRune(rune(v)) {
+			return ErrInvalidMangled
+		}
+		p.print(strconv.QuoteRune(rune(v)))
+		return nil
+
+	case 'a', 's', 'l', 'x', 'n', 'i', 'h', 't', 'm', 'y', 'o', 'j':
+		if negative {
+			p.print("-")
+		}
+		if v, err := strconv.ParseUint(hex, 16, 64); err == nil {
+			p.print(strconv.FormatUint(v, 10))
+		} else {
+			p.print("0x" + hex)
+		}
+		if p.opts&NoHash == 0 {
+			p.print(typ)
+		}
+		return nil
+	}
+	return ErrUnknownType
+}
+
+// decodePunycode decodes a v0 punycode identifier (RFC 3492 with '_' as
+// the delimiter).
+func decodePunycode(s string) (string, error) {
+	const (
+		base        = 36
+		tMin        = 1
+		tMax        = 26
+		skew        = 38
+		damp        = 700
+		initialBias = 72
+		initialN    = 128
+	)
+
+	var out []rune
+	if i := strings.LastIndexByte(s, '_'); i >= 0 {
+		out = []rune(s[:i])
+		s = s[i+1:]
+	}
+
+	n, bias, i := rune(initialN), 1, 0
+	adapt := func(delta, points int, first bool) int {
+		if first {
+			delta /= damp
+		} else {
+			delta /= 2
+		}
+		delta += delta / points
+		k := 0
+		for delta > ((base-tMin)*tMax)/2 {
+			delta /= base - tMin
+			k += base
+		}
+		return k + (base-tMin+1)*delta/(delta+skew)
+	}
+	bias = initialBias
+
+	for pos := 0; pos < len(s); {
+		oldI, w := i, 1
+		for k := base; ; k += base {
+			if pos >= len(s) {
+				return "", ErrUnexpectedEnd
+			}
+			c := s[pos]
+			pos++
+			var digit int
+			switch {
+			case c >= 'a' && c <= 'z':
+				digit = int(c - 'a')
+			case c >= '0' && c <= '9':
+				digit = int(c-'0') + 26
+			default:
+				return "", ErrInvalidMangled
+			}
+			i += digit * w
+			t := k - bias
+			if t < tMin {
+				t = tMin
+			} else if t > tMax {
+				t = tMax
+			}
+			if digit < t {
+				break
+			}
+			w *= base - t
+			if i < 0 || w <= 0 {
+				return "", ErrInvalidMangled
+			}
+		}
+
+		bias = adapt(i-oldI, len(out)+1, oldI == 0)
+		n += rune(i / (len(out) + 1))
+		i %= len(out) + 1
+		if !utf8.ValidRune(n) {
+			return "", ErrInvalidMangled
+		}
+
+		out = append(out, 0)
+		copy(out[i+1:], out[i:])
+		out[i] = n
+		i++
+	}
+	return string(out), nil
+}
@@ -186,6 +186,7 @@ type SectionContribution struct {
 	Padding2        uint16
 	DataCrc         uint32
 	RelocCrc        uint32
+	ISectCoff       uint32 // Only in SectionContribVer2
 }
 
 // SectionMap contains information about sections.
@@ -554,9 +555,10 @@ func (s *Stream) parseModuleInfo(data []byte) error {
 // Section Contribution version signatures
 const (
 	// SectionContribVer60 = 0xeffe0000 + 19970605
-	SectionContribVer60 uint32 = 0xF13151F5
-	// SectionContribVer2 is an older version format
-	SectionContribVer2 uint32 = 0xF12EBA2D
+	SectionContribVer60 uint32 = 0xF12EBA2D
+	// SectionContribVer2 = 0xeffe0000 + 20140516, which adds the COFF
+	// section index to each entry
+	SectionContribVer2 uint32 = 0xF13151E4
 )
 
 func (s *Stream) parseSectionContributions(data []byte) error {
@@ -573,13 +575,15 @@ func (s *Stream) parseSectionContributions(data []byte) error {
 		return err
 	}
 
-	// Version 60 (0xF13151F5) and Ver2 (0xF12EBA2D) have extra DataCrc and RelocCrc fields
-	hasExtraCrc := version == SectionContribVer60 || version == SectionContribVer2
-
-	// Calculate entry size based on version
-	entrySize := 28 // Base size: 2+2+4+4+4+2+2 = 20, but actually 28 for V1
-	if hasExtraCrc {
-		entrySize = 28 + 8 // Extra DataCrc (4) and RelocCrc (4)
+	// Ver60 entries are 28 bytes; Ver2 entries add a 4-byte COFF section index
+	entrySize := 28
+	switch version {
+	case SectionContribVer60:
+	case SectionContribVer2:
+		entrySize = 32
+	default:
+		// Unknown layout; leave the contributions empty rather than misread them
+		return nil
 	}
 
 	for r.Remaining() >= entrySize {
@@ -614,12 +618,16 @@ func (s *Stream) parseSectionContributions(data []byte) error {
 			return err
 		}
 
-		if hasExtraCrc {
-			sc.DataCrc, err = r.ReadU32()
-			if err != nil {
-				return err
-			}
-			sc.RelocCrc, err = r.ReadU32()
+		sc.DataCrc, err = r.ReadU32()
+		if err != nil {
+			return err
+		}
+		sc.RelocCrc, err = r.ReadU32()
+		if err != nil {
+			return err
+		}
+		if version == SectionContribVer2 {
+			sc.ISectCoff, err = r.ReadU32()
 			if err != nil {
 				return err
 			}
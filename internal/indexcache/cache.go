@@ -0,0 +1,177 @@
+// Package indexcache stores the lookup indexes built for a PDB in a cache
+// directory, so that later opens of the same PDB memory-map them instead of
+// rebuilding them.
+//
+// Each index is a file named after its kind in a directory named after the
+// PDB's GUID and age. A file starts with a header holding a magic number,
+// the format version, the index kind, the PDB's GUID and age and a
+// fingerprint of its layout; the index data follows. A file whose header
+// does not match the opened PDB is stale and is replaced when the index is
+// rebuilt.
+package indexcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+
+	"github.com/skdltmxn/pdb-go/internal/mmap"
+)
+
+// Version is the version of the cache file format. It changes whenever the
+// layout of a header or of any index changes.
+const Version = 2
+
+// headerSize is the size of a cache file header.
+const headerSize = 64
+
+var magic = [8]byte{'P', 'D', 'B', 'G', 'O', 'I', 'D', 'X'}
+
+// Errors returned by Load
+var (
+	ErrNotCached = errors.New("indexcache: index not cached")
+	ErrStale     = errors.New("indexcache: cached index does not match the PDB")
+)
+
+// Kind identifies an index.
+type Kind uint32
+
+const (
+	KindSymbolNames     Kind = iota + 1 // Symbol record offsets by name
+	KindSymbolAddresses                 // Public symbol offsets by address
+	KindTypeNames                       // Type indexes by name
+	KindMembers                         // Class members and base class names
+)
+
+var kindFiles = map[Kind]string{
+	KindSymbolNames:     "symbol-names.idx",
+	KindSymbolAddresses: "symbol-addresses.idx",
+	KindTypeNames:       "type-names.idx",
+	KindMembers:         "members.idx",
+}
+
+// Key identifies the PDB an index was built from. Indexes are stored by
+// GUID and age; a cached index is only used if the fingerprint matches too,
+// so a PDB rebuilt with the same GUID and age does not reuse the indexes of
+// the old one.
+type Key struct {
+	GUID        [16]byte
+	Age         uint32
+	Fingerprint uint32
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Fingerprint returns a fingerprint of a PDB's layout from the sizes of its
+// streams and the headers of the streams the indexes are built from. It is
+// cheap to compute, unlike a checksum of the streams themselves, and changes
+// whenever a rebuild adds, removes or resizes records.
+func Fingerprint(streamSizes []uint32, headers ...[]byte) uint32 {
+	h := crc32.New(castagnoli)
+	buf := make([]byte, 4*len(streamSizes))
+	for i, size := range streamSizes {
+		binary.LittleEndian.PutUint32(buf[4*i:], size)
+	}
+	h.Write(buf)
+	for _, header := range headers {
+		h.Write(header)
+	}
+	return h.Sum32()
+}
+
+// Cache is the cache directory of one PDB.
+type Cache struct {
+	dir string
+	key Key
+}
+
+// New returns the cache for the PDB with the given key under root.
+func New(root string, key Key) *Cache {
+	g := key.GUID
+	name := fmt.Sprintf("%08X%04X%04X%02X%02X%02X%02X%02X%02X%02X%02X%X",
+		binary.LittleEndian.Uint32(g[0:]), binary.LittleEndian.Uint16(g[4:]), binary.LittleEndian.Uint16(g[6:]),
+		g[8], g[9], g[10], g[11], g[12], g[13], g[14], g[15], key.Age)
+	return &Cache{dir: filepath.Join(root, name), key: key}
+}
+
+// Dir returns the directory holding the PDB's index files.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) path(kind Kind) string {
+	return filepath.Join(c.dir, kindFiles[kind])
+}
+
+// Load maps a cached index. The returned data is valid until the mapping is
+// closed. It returns ErrNotCached if the index has not been stored and
+// ErrStale if it was stored for a PDB with a different fingerprint or by a
+// different format version.
+func (c *Cache) Load(kind Kind) ([]byte, *mmap.Mapping, error) {
+	m, err := mmap.Open(c.path(kind))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrNotCached
+		}
+		return nil, nil, err
+	}
+
+	data := m.Bytes()
+	if len(data) < headerSize || !bytes.Equal(data[:8], magic[:]) ||
+		!bytes.Equal(data[:headerSize], c.header(kind, uint64(len(data)-headerSize))) {
+		m.Close()
+		return nil, nil, ErrStale
+	}
+	return data[headerSize:], m, nil
+}
+
+// Store writes an index to the cache. The file is written under a temporary name and renamed, so
+// concurrent readers see either the old or the new index.
+func (c *Cache) Store(kind Kind, data []byte) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.dir, kindFiles[kind]+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(c.header(kind, uint64(len(data)))); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(kind))
+}
+
+// header encodes the header of an index file:
+//
+//	magic [8]byte, version u32, kind u32, GUID [16]byte, age u32,
+//	fingerprint u32, data length u64, reserved [16]byte
+func (c *Cache) header(kind Kind, dataLen uint64) []byte {
+	h := make([]byte, headerSize)
+	copy(h, magic[:])
+	binary.LittleEndian.PutUint32(h[8:], Version)
+	binary.LittleEndian.PutUint32(h[12:], uint32(kind))
+	copy(h[16:32], c.key.GUID[:])
+	binary.LittleEndian.PutUint32(h[32:], c.key.Age)
+	binary.LittleEndian.PutUint32(h[36:], c.key.Fingerprint)
+	binary.LittleEndian.PutUint64(h[40:], dataLen)
+	return h
+}
@@ -0,0 +1,91 @@
+package indexcache
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrCorrupt is returned by Decoder when an index is truncated or malformed.
+var ErrCorrupt = errors.New("indexcache: corrupt index")
+
+// Encoder appends values to an index in little-endian order. Strings are
+// stored as a uvarint length followed by their bytes.
+type Encoder struct {
+	buf []byte
+}
+
+// Bytes returns the encoded index.
+func (e *Encoder) Bytes() []byte { return e.buf }
+
+func (e *Encoder) AddUint8(v uint8) { e.buf = append(e.buf, v) }
+
+func (e *Encoder) AddUint32(v uint32) { e.buf = binary.LittleEndian.AppendUint32(e.buf, v) }
+
+func (e *Encoder) AddUint64(v uint64) { e.buf = binary.LittleEndian.AppendUint64(e.buf, v) }
+
+func (e *Encoder) AddString(s string) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// Decoder reads values written by Encoder. After the first failure every
+// read returns a zero value and Err returns ErrCorrupt.
+type Decoder struct {
+	data []byte
+	err  error
+}
+
+// NewDecoder returns a decoder for an index.
+func NewDecoder(data []byte) *Decoder {
+	return &Decoder{data: data}
+}
+
+// Err returns ErrCorrupt if a read ran past the end of the index.
+func (d *Decoder) Err() error { return d.err }
+
+// Remaining returns the number of bytes not yet read.
+func (d *Decoder) Remaining() int { return len(d.data) }
+
+func (d *Decoder) take(n int) []byte {
+	if d.err != nil || n < 0 || n > len(d.data) {
+		d.err = ErrCorrupt
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *Decoder) ReadUint8() uint8 {
+	if b := d.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *Decoder) ReadUint32() uint32 {
+	if b := d.take(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *Decoder) ReadUint64() uint64 {
+	if b := d.take(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *Decoder) ReadString() string {
+	if d.err != nil {
+		return ""
+	}
+	n, size := binary.Uvarint(d.data)
+	if size <= 0 || n > uint64(len(d.data)-size) {
+		d.err = ErrCorrupt
+		return ""
+	}
+	d.data = d.data[size:]
+	return string(d.take(int(n)))
+}
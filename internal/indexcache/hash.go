@@ -0,0 +1,88 @@
+package indexcache
+
+import (
+	"encoding/binary"
+	"slices"
+)
+
+// hashEntrySize is the size of a HashIndex entry: a name hash and a value.
+const hashEntrySize = 8
+
+// Hash returns the 32-bit FNV-1a hash of a name.
+func Hash(name string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(name); i++ {
+		h ^= uint32(name[i])
+		h *= 16777619
+	}
+	return h
+}
+
+// HashIndex maps names to 32-bit values, such as symbol record offsets or
+// type indexes, by their hashes. It is an array of (hash, value) pairs
+// sorted by hash and then by value, and is searched in place whether it was
+// just built or mapped from a cache file. Names are not stored: callers
+// compare the names of the records a lookup returns.
+type HashIndex []byte
+
+// HashIndexBuilder collects the entries of a HashIndex.
+type HashIndexBuilder struct {
+	entries []uint64
+}
+
+// Add adds a name and its value.
+func (b *HashIndexBuilder) Add(name string, value uint32) {
+	b.entries = append(b.entries, uint64(Hash(name))<<32|uint64(value))
+}
+
+// Build returns the index.
+func (b *HashIndexBuilder) Build() HashIndex {
+	slices.Sort(b.entries)
+	idx := make(HashIndex, len(b.entries)*hashEntrySize)
+	for i, e := range b.entries {
+		binary.LittleEndian.PutUint32(idx[i*hashEntrySize:], uint32(e>>32))
+		binary.LittleEndian.PutUint32(idx[i*hashEntrySize+4:], uint32(e))
+	}
+	return idx
+}
+
+// LoadHashIndex checks that data holds a HashIndex.
+func LoadHashIndex(data []byte) (HashIndex, bool) {
+	if len(data)%hashEntrySize != 0 {
+		return nil, false
+	}
+	return HashIndex(data), true
+}
+
+// Len returns the number of entries.
+func (idx HashIndex) Len() int {
+	return len(idx) / hashEntrySize
+}
+
+func (idx HashIndex) hash(i int) uint32 {
+	return binary.LittleEndian.Uint32(idx[i*hashEntrySize:])
+}
+
+// Lookup returns the values of the names with the same hash as name, in
+// ascending order.
+func (idx HashIndex) Lookup(name string) []uint32 {
+	h := Hash(name)
+	n := idx.Len()
+
+	// Binary search for the first entry with the hash
+	lo, hi := 0, n
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if idx.hash(mid) < h {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	var values []uint32
+	for i := lo; i < n && idx.hash(i) == h; i++ {
+		values = append(values, binary.LittleEndian.Uint32(idx[i*hashEntrySize+4:]))
+	}
+	return values
+}
@@ -0,0 +1,281 @@
+// Package lines provides parsing for CodeView C13 debug subsections,
+// which hold the line number information of a module.
+package lines
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/skdltmxn/pdb-go/internal/stream"
+)
+
+// SubsectionKind identifies the type of a debug subsection.
+type SubsectionKind uint32
+
+// Debug subsection kinds (DEBUG_S_*)
+const (
+	DEBUG_S_IGNORE               SubsectionKind = 0x80000000
+	DEBUG_S_SYMBOLS              SubsectionKind = 0xf1
+	DEBUG_S_LINES                SubsectionKind = 0xf2
+	DEBUG_S_STRINGTABLE          SubsectionKind = 0xf3
+	DEBUG_S_FILECHKSMS           SubsectionKind = 0xf4
+	DEBUG_S_FRAMEDATA            SubsectionKind = 0xf5
+	DEBUG_S_INLINEELINES         SubsectionKind = 0xf6
+	DEBUG_S_CROSSSCOPEIMPORTS    SubsectionKind = 0xf7
+	DEBUG_S_CROSSSCOPEEXPORTS    SubsectionKind = 0xf8
+	DEBUG_S_IL_LINES             SubsectionKind = 0xf9
+	DEBUG_S_FUNC_MDTOKEN_MAP     SubsectionKind = 0xfa
+	DEBUG_S_TYPE_MDTOKEN_MAP     SubsectionKind = 0xfb
+	DEBUG_S_MERGED_ASSEMBLYINPUT SubsectionKind = 0xfc
+	DEBUG_S_COFF_SYMBOL_RVA      SubsectionKind = 0xfd
+)
+
+// Line number markers the compiler uses for code without a source line.
+const (
+	LineHidden     = 0xfeefee
+	LineAlwaysStep = 0xf00f00
+)
+
+// Errors
+var (
+	ErrInvalidSubsection = errors.New("lines: invalid debug subsection")
+)
+
+// Subsection is a raw debug subsection.
+type Subsection struct {
+	Kind SubsectionKind
+	Data []byte
+}
+
+// ParseSubsections splits C13 line information into its subsections.
+func ParseSubsections(data []byte) ([]Subsection, error) {
+	r := stream.NewReader(data)
+	var result []Subsection
+
+	for r.Remaining() >= 8 {
+		kind, err := r.ReadU32()
+		if err != nil {
+			return nil, err
+		}
+		length, err := r.ReadU32()
+		if err != nil {
+			return nil, err
+		}
+		body, err := r.ReadBytesRef(int(length))
+		if err != nil {
+			return nil, fmt.Errorf("%w: kind 0x%x, length %d", ErrInvalidSubsection, kind, length)
+		}
+		r.Align(4)
+
+		if SubsectionKind(kind)&DEBUG_S_IGNORE != 0 {
+			continue
+		}
+		result = append(result, Subsection{Kind: SubsectionKind(kind), Data: body})
+	}
+
+	return result, nil
+}
+
+// Line is a single entry of a line block.
+type Line struct {
+	Offset      uint32 // Offset from the start of the contribution
+	LineStart   uint32
+	LineEnd     uint32
+	IsStatement bool
+}
+
+// Column is the column range of a line entry.
+type Column struct {
+	Start uint16
+	End   uint16
+}
+
+// LineBlock holds the lines of a contribution that belong to one file.
+type LineBlock struct {
+	// FileChecksumOffset is the offset of the file's entry in the
+	// DEBUG_S_FILECHKSMS subsection
+	FileChecksumOffset uint32
+	Lines              []Line
+	Columns            []Column // Empty unless the subsection has columns
+}
+
+// LinesSubsection is a parsed DEBUG_S_LINES subsection. It describes the
+// lines of one contiguous code contribution.
+type LinesSubsection struct {
+	Offset   uint32
+	Segment  uint16
+	Flags    uint16
+	CodeSize uint32
+	Blocks   []LineBlock
+}
+
+// HasColumns returns true if the line blocks carry column information.
+func (s *LinesSubsection) HasColumns() bool {
+	return s.Flags&0x0001 != 0
+}
+
+// ParseLines parses a DEBUG_S_LINES subsection.
+func ParseLines(data []byte) (*LinesSubsection, error) {
+	r := stream.NewReader(data)
+	s := &LinesSubsection{}
+
+	var err error
+	if s.Offset, err = r.ReadU32(); err != nil {
+		return nil, err
+	}
+	if s.Segment, err = r.ReadU16(); err != nil {
+		return nil, err
+	}
+	if s.Flags, err = r.ReadU16(); err != nil {
+		return nil, err
+	}
+	if s.CodeSize, err = r.ReadU32(); err != nil {
+		return nil, err
+	}
+
+	for r.Remaining() >= 12 {
+		var block LineBlock
+		if block.FileChecksumOffset, err = r.ReadU32(); err != nil {
+			return nil, err
+		}
+		count, err := r.ReadU32()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.ReadU32(); err != nil { // block size
+			return nil, err
+		}
+
+		entrySize := 8
+		if s.HasColumns() {
+			entrySize += 4
+		}
+		if int(count) > r.Remaining()/entrySize {
+			return nil, fmt.Errorf("%w: %d lines in block", ErrInvalidSubsection, count)
+		}
+
+		block.Lines = make([]Line, count)
+		for i := range block.Lines {
+			offset, _ := r.ReadU32()
+			flags, _ := r.ReadU32()
+			start := flags & 0x00ffffff
+			block.Lines[i] = Line{
+				Offset:      offset,
+				LineStart:   start,
+				LineEnd:     start + (flags>>24)&0x7f,
+				IsStatement: flags&0x80000000 != 0,
+			}
+		}
+
+		if s.HasColumns() {
+			block.Columns = make([]Column, count)
+			for i := range block.Columns {
+				block.Columns[i].Start, _ = r.ReadU16()
+				block.Columns[i].End, _ = r.ReadU16()
+			}
+		}
+
+		s.Blocks = append(s.Blocks, block)
+	}
+
+	return s, nil
+}
+
+// FileChecksum is an entry of the DEBUG_S_FILECHKSMS subsection.
+type FileChecksum struct {
+	NameOffset uint32 // Offset of the file name in the /names string table
+	Kind       uint8  // 0 none, 1 MD5, 2 SHA1, 3 SHA256
+	Checksum   []byte
+}
+
+// ParseFileChecksums parses a DEBUG_S_FILECHKSMS subsection, keyed by the
+// offset of each entry within the subsection.
+func ParseFileChecksums(data []byte) (map[uint32]FileChecksum, error) {
+	r := stream.NewReader(data)
+	result := make(map[uint32]FileChecksum)
+
+	for r.Remaining() >= 6 {
+		offset := uint32(r.Offset())
+
+		var fc FileChecksum
+		var err error
+		if fc.NameOffset, err = r.ReadU32(); err != nil {
+			return nil, err
+		}
+		size, err := r.ReadU8()
+		if err != nil {
+			return nil, err
+		}
+		if fc.Kind, err = r.ReadU8(); err != nil {
+			return nil, err
+		}
+		if fc.Checksum, err = r.ReadBytes(int(size)); err != nil {
+			return nil, err
+		}
+		r.Align(4)
+
+		result[offset] = fc
+	}
+
+	return result, nil
+}
+
+// Inlinee line table signatures.
+const (
+	InlineeSourceLineSignature   = 0x0
+	InlineeSourceLineSignatureEx = 0x1
+)
+
+// InlineeSourceLine is an entry of the DEBUG_S_INLINEELINES subsection. It
+// gives the source location where an inlined function starts.
+type InlineeSourceLine struct {
+	Inlinee            uint32 // IPI index of the LF_FUNC_ID or LF_MFUNC_ID
+	FileChecksumOffset uint32
+	SourceLine         uint32
+	ExtraFiles         []uint32 // Checksum offsets of additional files
+}
+
+// ParseInlineeLines parses a DEBUG_S_INLINEELINES subsection.
+func ParseInlineeLines(data []byte) ([]InlineeSourceLine, error) {
+	r := stream.NewReader(data)
+
+	sig, err := r.ReadU32()
+	if err != nil {
+		return nil, err
+	}
+	if sig != InlineeSourceLineSignature && sig != InlineeSourceLineSignatureEx {
+		return nil, fmt.Errorf("%w: inlinee lines signature 0x%x", ErrInvalidSubsection, sig)
+	}
+
+	var result []InlineeSourceLine
+	for r.Remaining() >= 12 {
+		var entry InlineeSourceLine
+		if entry.Inlinee, err = r.ReadU32(); err != nil {
+			return nil, err
+		}
+		if entry.FileChecksumOffset, err = r.ReadU32(); err != nil {
+			return nil, err
+		}
+		if entry.SourceLine, err = r.ReadU32(); err != nil {
+			return nil, err
+		}
+
+		if sig == InlineeSourceLineSignatureEx {
+			count, err := r.ReadU32()
+			if err != nil {
+				return nil, err
+			}
+			if int(count) > r.Remaining()/4 {
+				return nil, fmt.Errorf("%w: %d extra inlinee files", ErrInvalidSubsection, count)
+			}
+			entry.ExtraFiles = make([]uint32, count)
+			for i := range entry.ExtraFiles {
+				entry.ExtraFiles[i], _ = r.ReadU32()
+			}
+		}
+
+		result = append(result, entry)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,26 @@
+// Package mmap provides read-only views of files. On Linux files are
+// memory-mapped, so their pages stay in the page cache instead of the Go
+// heap; elsewhere they are read into memory.
+package mmap
+
+// Mapping is a read-only view of a file's contents.
+type Mapping struct {
+	data   []byte
+	mapped bool
+}
+
+// Bytes returns the file's contents. The slice must not be modified and
+// must not be used after Close.
+func (m *Mapping) Bytes() []byte {
+	return m.data
+}
+
+// Len returns the size of the file.
+func (m *Mapping) Len() int {
+	return len(m.data)
+}
+
+// Mapped reports whether the file is memory-mapped rather than copied.
+func (m *Mapping) Mapped() bool {
+	return m.mapped
+}
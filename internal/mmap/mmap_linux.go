@@ -0,0 +1,47 @@
+package mmap
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Supported reports whether Open memory-maps files.
+const Supported = true
+
+// Open maps a file into memory read-only.
+func Open(path string) (*Mapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := stat.Size()
+	if size == 0 {
+		return &Mapping{}, nil
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("mmap: %s is too large to map", path)
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mmap: %w", err)
+	}
+	return &Mapping{data: data, mapped: true}, nil
+}
+
+// Close unmaps the file.
+func (m *Mapping) Close() error {
+	if !m.mapped || m.data == nil {
+		return nil
+	}
+	data := m.data
+	m.data = nil
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package mmap
+
+import "os"
+
+// Supported reports whether Open memory-maps files.
+const Supported = false
+
+// Open reads a file into memory.
+func Open(path string) (*Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Mapping{data: data}, nil
+}
+
+// Close releases the file's contents.
+func (m *Mapping) Close() error {
+	m.data = nil
+	return nil
+}
@@ -2,6 +2,7 @@
 package stream
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"io"
@@ -14,23 +15,76 @@ var (
 	ErrInvalidString   = errors.New("stream: invalid string encoding")
 	ErrNegativeOffset  = errors.New("stream: negative offset")
 	ErrInvalidNumeric  = errors.New("stream: invalid numeric encoding")
+	ErrUnterminatedString = errors.New("stream: unterminated string")
 )
 
+// DefaultWindowSize is the window size of a Reader backed by an
+// io.ReaderAt that is large enough for any symbol or type record.
+const DefaultWindowSize = 64 * 1024
+
 // Reader provides methods for reading binary data from PDB streams.
 // All multi-byte values are read in little-endian order.
+//
+// A Reader either reads a byte slice or, if created by NewReaderAt, reads an
+// io.ReaderAt through a window of bounded size, so that a large stream can
+// be parsed without holding it in memory.
 type Reader struct {
 	data   []byte
-	offset int
+	offset int // Relative to base
+
+	// Set for a Reader backed by an io.ReaderAt: data holds the bytes of
+	// src starting at base, and is refilled when a read leaves it.
+	src    io.ReaderAt
+	base   int
+	size   int
+	window int
 }
 
 // NewReader creates a Reader from a byte slice.
 func NewReader(data []byte) *Reader {
-	return &Reader{data: data, offset: 0}
+	return &Reader{data: data, offset: 0, size: len(data)}
+}
+
+// NewReaderAt creates a Reader for size bytes of an io.ReaderAt that reads
+// window bytes at a time. A single read larger than the window, such as a
+// long string, grows it.
+func NewReaderAt(src io.ReaderAt, size int64, window int) *Reader {
+	if window <= 0 {
+		window = DefaultWindowSize
+	}
+	return &Reader{src: src, size: int(size), window: window}
+}
+
+// fill makes sure that n bytes at the read position are in the window.
+func (r *Reader) fill(n int) error {
+	pos := r.base + r.offset
+	if n < 0 || pos+n > r.size {
+		return ErrUnexpectedEOF
+	}
+	if r.offset+n <= len(r.data) {
+		return nil
+	}
+
+	want := min(max(n, r.window), r.size-pos)
+	if cap(r.data) < want {
+		r.data = make([]byte, want)
+	}
+	r.data = r.data[:want]
+	read, err := r.src.ReadAt(r.data, int64(pos))
+	r.data = r.data[:read]
+	r.base, r.offset = pos, 0
+	if read < n {
+		if err == nil || err == io.EOF {
+			err = ErrUnexpectedEOF
+		}
+		return err
+	}
+	return nil
 }
 
 // Offset returns the current read position.
 func (r *Reader) Offset() int {
-	return r.offset
+	return r.base + r.offset
 }
 
 // SetOffset sets the read position.
@@ -38,21 +92,25 @@ func (r *Reader) SetOffset(offset int) error {
 	if offset < 0 {
 		return ErrNegativeOffset
 	}
-	r.offset = offset
+	if r.src != nil && (offset < r.base || offset > r.base+len(r.data)) {
+		// Outside the window: refill on the next read
+		r.base, r.data = offset, r.data[:0]
+	}
+	r.offset = offset - r.base
 	return nil
 }
 
 // Remaining returns the number of bytes remaining.
 func (r *Reader) Remaining() int {
-	if r.offset >= len(r.data) {
+	if r.Offset() >= r.size {
 		return 0
 	}
-	return len(r.data) - r.offset
+	return r.size - r.Offset()
 }
 
 // Skip advances the read position by n bytes.
 func (r *Reader) Skip(n int) error {
-	if r.offset+n > len(r.data) {
+	if r.Offset()+n > r.size {
 		return ErrUnexpectedEOF
 	}
 	r.offset += n
@@ -64,7 +122,7 @@ func (r *Reader) Align(alignment int) {
 	if alignment <= 1 {
 		return
 	}
-	mod := r.offset % alignment
+	mod := r.Offset() % alignment
 	if mod != 0 {
 		r.offset += alignment - mod
 	}
@@ -72,8 +130,8 @@ func (r *Reader) Align(alignment int) {
 
 // ReadU8 reads an unsigned 8-bit integer.
 func (r *Reader) ReadU8() (uint8, error) {
-	if r.offset >= len(r.data) {
-		return 0, ErrUnexpectedEOF
+	if err := r.fill(1); err != nil {
+		return 0, err
 	}
 	v := r.data[r.offset]
 	r.offset++
@@ -82,8 +140,8 @@ func (r *Reader) ReadU8() (uint8, error) {
 
 // ReadU16 reads an unsigned 16-bit integer.
 func (r *Reader) ReadU16() (uint16, error) {
-	if r.offset+2 > len(r.data) {
-		return 0, ErrUnexpectedEOF
+	if err := r.fill(2); err != nil {
+		return 0, err
 	}
 	v := binary.LittleEndian.Uint16(r.data[r.offset:])
 	r.offset += 2
@@ -92,8 +150,8 @@ func (r *Reader) ReadU16() (uint16, error) {
 
 // ReadU32 reads an unsigned 32-bit integer.
 func (r *Reader) ReadU32() (uint32, error) {
-	if r.offset+4 > len(r.data) {
-		return 0, ErrUnexpectedEOF
+	if err := r.fill(4); err != nil {
+		return 0, err
 	}
 	v := binary.LittleEndian.Uint32(r.data[r.offset:])
 	r.offset += 4
@@ -102,8 +160,8 @@ func (r *Reader) ReadU32() (uint32, error) {
 
 // ReadU64 reads an unsigned 64-bit integer.
 func (r *Reader) ReadU64() (uint64, error) {
-	if r.offset+8 > len(r.data) {
-		return 0, ErrUnexpectedEOF
+	if err := r.fill(8); err != nil {
+		return 0, err
 	}
 	v := binary.LittleEndian.Uint64(r.data[r.offset:])
 	r.offset += 8
@@ -154,8 +212,8 @@ func (r *Reader) ReadFloat64() (float64, error) {
 
 // ReadBytes reads n bytes.
 func (r *Reader) ReadBytes(n int) ([]byte, error) {
-	if r.offset+n > len(r.data) {
-		return nil, ErrUnexpectedEOF
+	if err := r.fill(n); err != nil {
+		return nil, err
 	}
 	v := make([]byte, n)
 	copy(v, r.data[r.offset:r.offset+n])
@@ -164,34 +222,47 @@ func (r *Reader) ReadBytes(n int) ([]byte, error) {
 }
 
 // ReadBytesRef returns a reference to n bytes without copying.
-// The returned slice is only valid as long as the underlying data.
+// The returned slice is only valid as long as the underlying data; for a
+// Reader backed by an io.ReaderAt, only until the next read.
 func (r *Reader) ReadBytesRef(n int) ([]byte, error) {
-	if r.offset+n > len(r.data) {
-		return nil, ErrUnexpectedEOF
+	if err := r.fill(n); err != nil {
+		return nil, err
 	}
 	v := r.data[r.offset : r.offset+n]
 	r.offset += n
 	return v, nil
 }
 
-// ReadCString reads a null-terminated string.
+// ReadCString reads a null-terminated string. If the data ends before the
+// terminator, it returns ErrUnterminatedString.
 func (r *Reader) ReadCString() (string, error) {
-	start := r.offset
-	for r.offset < len(r.data) {
-		if r.data[r.offset] == 0 {
-			s := string(r.data[start:r.offset])
-			r.offset++ // Skip null terminator
+	if err := r.fill(1); err != nil {
+		return "", err
+	}
+	for scanned := 0; ; {
+		if i := bytes.IndexByte(r.data[r.offset+scanned:], 0); i >= 0 {
+			end := r.offset + scanned + i
+			s := string(r.data[r.offset:end])
+			r.offset = end + 1 // Skip null terminator
 			return s, nil
 		}
-		r.offset++
+		// Not terminated within the window: widen it
+		scanned = len(r.data) - r.offset
+		grow := min(2*scanned, r.size-r.Offset())
+		if grow <= scanned {
+			r.offset = len(r.data)
+			return "", ErrUnterminatedString
+		}
+		if err := r.fill(grow); err != nil {
+			return "", err
+		}
 	}
-	return "", ErrUnexpectedEOF
 }
 
 // ReadFixedString reads a fixed-length string, trimming any null padding.
 func (r *Reader) ReadFixedString(n int) (string, error) {
-	if r.offset+n > len(r.data) {
-		return "", ErrUnexpectedEOF
+	if err := r.fill(n); err != nil {
+		return "", err
 	}
 	data := r.data[r.offset : r.offset+n]
 	r.offset += n
@@ -207,8 +278,8 @@ func (r *Reader) ReadFixedString(n int) (string, error) {
 // ReadGUID reads a 16-byte GUID.
 func (r *Reader) ReadGUID() ([16]byte, error) {
 	var guid [16]byte
-	if r.offset+16 > len(r.data) {
-		return guid, ErrUnexpectedEOF
+	if err := r.fill(16); err != nil {
+		return guid, err
 	}
 	copy(guid[:], r.data[r.offset:r.offset+16])
 	r.offset += 16
@@ -257,8 +328,8 @@ func (r *Reader) ReadNumeric() (uint64, error) {
 
 // Peek returns a copy of the next n bytes without advancing the position.
 func (r *Reader) Peek(n int) ([]byte, error) {
-	if r.offset+n > len(r.data) {
-		return nil, ErrUnexpectedEOF
+	if err := r.fill(n); err != nil {
+		return nil, err
 	}
 	v := make([]byte, n)
 	copy(v, r.data[r.offset:r.offset+n])
@@ -267,25 +338,28 @@ func (r *Reader) Peek(n int) ([]byte, error) {
 
 // PeekU8 returns the next byte without advancing the position.
 func (r *Reader) PeekU8() (uint8, error) {
-	if r.offset >= len(r.data) {
-		return 0, ErrUnexpectedEOF
+	if err := r.fill(1); err != nil {
+		return 0, err
 	}
 	return r.data[r.offset], nil
 }
 
 // PeekU16 returns the next 16-bit integer without advancing the position.
 func (r *Reader) PeekU16() (uint16, error) {
-	if r.offset+2 > len(r.data) {
-		return 0, ErrUnexpectedEOF
+	if err := r.fill(2); err != nil {
+		return 0, err
 	}
 	return binary.LittleEndian.Uint16(r.data[r.offset:]), nil
 }
 
 // Read implements io.Reader.
 func (r *Reader) Read(p []byte) (n int, err error) {
-	if r.offset >= len(r.data) {
+	if r.Remaining() == 0 {
 		return 0, io.EOF
 	}
+	if err := r.fill(min(len(p), r.Remaining())); err != nil {
+		return 0, err
+	}
 	n = copy(p, r.data[r.offset:])
 	r.offset += n
 	return n, nil
@@ -293,14 +367,35 @@ func (r *Reader) Read(p []byte) (n int, err error) {
 
 // Slice returns a new Reader for a subset of the data.
 func (r *Reader) Slice(offset, length int) (*Reader, error) {
-	if offset < 0 || offset+length > len(r.data) {
+	if offset < 0 || length < 0 || offset+length > r.size {
 		return nil, ErrUnexpectedEOF
 	}
+	if r.src != nil {
+		return NewReaderAt(io.NewSectionReader(r.src, int64(offset), int64(length)), int64(length), r.window), nil
+	}
 	return NewReader(r.data[offset : offset+length]), nil
 }
 
 // SubReader returns a new Reader starting at the current position with the given length.
+// For a Reader backed by an io.ReaderAt, a sub-reader that fits in the
+// window reads a copy of the data, and a larger one reads the same source.
 func (r *Reader) SubReader(length int) (*Reader, error) {
+	if r.src != nil && length > r.window {
+		sub, err := r.Slice(r.Offset(), length)
+		if err != nil {
+			return nil, err
+		}
+		r.offset += length
+		return sub, nil
+	}
+	if r.src != nil {
+		data, err := r.ReadBytes(length)
+		if err != nil {
+			return nil, err
+		}
+		return NewReader(data), nil
+	}
+
 	if r.offset+length > len(r.data) {
 		return nil, ErrUnexpectedEOF
 	}
@@ -309,12 +404,14 @@ func (r *Reader) SubReader(length int) (*Reader, error) {
 	return sub, nil
 }
 
-// Data returns the underlying byte slice.
+// Data returns the underlying byte slice. For a Reader backed by an
+// io.ReaderAt, it returns the current window.
 func (r *Reader) Data() []byte {
 	return r.data
 }
 
-// RemainingData returns the remaining unread data.
+// RemainingData returns the remaining unread data. For a Reader backed by
+// an io.ReaderAt, it returns the unread part of the current window.
 func (r *Reader) RemainingData() []byte {
 	if r.offset >= len(r.data) {
 		return nil
@@ -2,8 +2,10 @@
 package symbols
 
 import (
+	"encoding/binary"
 	"sort"
 
+	"github.com/skdltmxn/pdb-go/internal/indexcache"
 	"github.com/skdltmxn/pdb-go/internal/stream"
 )
 
@@ -101,6 +103,9 @@ func (g *GSI) RecordOffsets() []uint32 {
 	return offsets
 }
 
+// psiHeaderSize is the size of PSIHeader, at the start of the stream.
+const psiHeaderSize = 28
+
 // PSI (Public Symbol Index) extends GSI with address-sorted lookup.
 type PSI struct {
 	*GSI
@@ -110,88 +115,37 @@ type PSI struct {
 
 // ParsePSI parses a Public Symbol Index stream.
 func ParsePSI(data []byte) (*PSI, error) {
-	if len(data) < 16 {
+	if len(data) < psiHeaderSize {
 		return nil, ErrUnexpectedEnd
 	}
 
+	// The PSI header comes first, followed by the GSI hash table and the
+	// address map
 	r := stream.NewReader(data)
-
-	// First parse GSI header
-	verSig, _ := r.ReadU32()
-	verHdr, _ := r.ReadU32()
-	hrSize, _ := r.ReadU32()
-	bucketSize, _ := r.ReadU32()
-
-	_ = verSig
-	_ = verHdr
-
-	// Skip hash records and buckets to get to PSI header
-	if err := r.Skip(int(hrSize)); err != nil {
-		return nil, err
-	}
-	if err := r.Skip(int(bucketSize)); err != nil {
-		return nil, err
-	}
-
-	// Read PSI-specific header
 	var header PSIHeader
-	var err error
-
-	header.SymHash, err = r.ReadU32()
-	if err != nil {
-		return nil, err
-	}
-
-	header.AddrMapSize, err = r.ReadU32()
-	if err != nil {
-		return nil, err
-	}
-
-	header.NumThunks, err = r.ReadU32()
-	if err != nil {
-		return nil, err
-	}
-
-	header.SizeOfThunk, err = r.ReadU32()
-	if err != nil {
-		return nil, err
-	}
-
-	header.ISectThunkTable, err = r.ReadU16()
-	if err != nil {
-		return nil, err
-	}
-
-	header.Padding, err = r.ReadU16()
-	if err != nil {
-		return nil, err
-	}
-
-	header.OffThunkTable, err = r.ReadU32()
-	if err != nil {
-		return nil, err
+	header.SymHash, _ = r.ReadU32()
+	header.AddrMapSize, _ = r.ReadU32()
+	header.NumThunks, _ = r.ReadU32()
+	header.SizeOfThunk, _ = r.ReadU32()
+	header.ISectThunkTable, _ = r.ReadU16()
+	header.Padding, _ = r.ReadU16()
+	header.OffThunkTable, _ = r.ReadU32()
+	header.NumSects, _ = r.ReadU32()
+
+	hashEnd := uint64(psiHeaderSize) + uint64(header.SymHash)
+	if hashEnd+uint64(header.AddrMapSize) > uint64(len(data)) {
+		return nil, ErrUnexpectedEnd
 	}
 
-	header.NumSects, err = r.ReadU32()
+	gsi, err := ParseGSI(data[psiHeaderSize:hashEnd])
 	if err != nil {
 		return nil, err
 	}
 
-	// Read address map
 	numAddrs := header.AddrMapSize / 4
-	addrMap := make([]uint32, 0, numAddrs)
-	for i := uint32(0); i < numAddrs; i++ {
-		offset, err := r.ReadU32()
-		if err != nil {
-			break
-		}
-		addrMap = append(addrMap, offset)
-	}
-
-	// Parse GSI part separately for hash records
-	gsi, err := ParseGSI(data)
-	if err != nil {
-		return nil, err
+	addrMap := make([]uint32, numAddrs)
+	for i := range addrMap {
+		addrMap[i] = binary.LittleEndian.Uint32(data[hashEnd+uint64(i)*4:])
 	}
 
 	return &PSI{
@@ -209,14 +163,20 @@ func (p *PSI) AddressMap() []uint32 {
 
 // SymbolAddress represents a symbol's location for address lookup.
 type SymbolAddress struct {
-	Section uint16
-	Offset  uint32
+	Section   uint16
+	Offset    uint32
 	SymOffset uint32 // Offset in symbol record stream
 }
 
-// AddressIndex provides fast address-based symbol lookup.
+// addressEntrySize is the size of an AddressIndex entry: section (padded to
+// 4 bytes), offset and symbol record offset.
+const addressEntrySize = 12
+
+// AddressIndex provides fast address-based symbol lookup. Entries are
+// stored sorted by address in the form they take in an index cache file,
+// so a cached index is searched in place.
 type AddressIndex struct {
-	entries []SymbolAddress
+	data []byte
 }
 
 // NewAddressIndex creates an address index from PSI address map and symbol data.
@@ -252,38 +212,77 @@ func NewAddressIndex(addrMap []uint32, symData []byte) *AddressIndex {
 	}
 
 	// Sort by section then offset
-	sort.Slice(entries, func(i, j int) bool {
+	sort.SliceStable(entries, func(i, j int) bool {
 		if entries[i].Section != entries[j].Section {
 			return entries[i].Section < entries[j].Section
 		}
 		return entries[i].Offset < entries[j].Offset
 	})
 
-	return &AddressIndex{entries: entries}
+	data := make([]byte, len(entries)*addressEntrySize)
+	for i, e := range entries {
+		b := data[i*addressEntrySize:]
+		binary.LittleEndian.PutUint32(b, uint32(e.Section))
+		binary.LittleEndian.PutUint32(b[4:], e.Offset)
+		binary.LittleEndian.PutUint32(b[8:], e.SymOffset)
+	}
+	return &AddressIndex{data: data}
+}
+
+// LoadAddressIndex returns the address index stored in data by Bytes.
+func LoadAddressIndex(data []byte) (*AddressIndex, bool) {
+	if len(data)%addressEntrySize != 0 {
+		return nil, false
+	}
+	return &AddressIndex{data: data}, true
+}
+
+// Bytes returns the index in the form stored in an index cache file.
+func (idx *AddressIndex) Bytes() []byte {
+	return idx.data
+}
+
+// Len returns the number of symbols in the index.
+func (idx *AddressIndex) Len() int {
+	return len(idx.data) / addressEntrySize
+}
+
+// Entry returns the i'th symbol in address order.
+func (idx *AddressIndex) Entry(i int) SymbolAddress {
+	b := idx.data[i*addressEntrySize:]
+	return SymbolAddress{
+		Section:   uint16(binary.LittleEndian.Uint32(b)),
+		Offset:    binary.LittleEndian.Uint32(b[4:]),
+		SymOffset: binary.LittleEndian.Uint32(b[8:]),
+	}
 }
 
 // FindByAddress finds the symbol at or before the given address.
 // Returns the symbol offset and whether an exact match was found.
 func (idx *AddressIndex) FindByAddress(section uint16, offset uint32) (symOffset uint32, exact bool, found bool) {
-	if len(idx.entries) == 0 {
+	n := idx.Len()
+	if n == 0 {
 		return 0, false, false
 	}
 
 	// Binary search for the address
-	i := sort.Search(len(idx.entries), func(i int) bool {
-		if idx.entries[i].Section != section {
-			return idx.entries[i].Section > section
+	i := sort.Search(n, func(i int) bool {
+		e := idx.Entry(i)
+		if e.Section != section {
+			return e.Section > section
 		}
-		return idx.entries[i].Offset >= offset
+		return e.Offset >= offset
 	})
 
-	if i < len(idx.entries) && idx.entries[i].Section == section && idx.entries[i].Offset == offset {
-		return idx.entries[i].SymOffset, true, true
+	if i < n {
+		if e := idx.Entry(i); e.Section == section && e.Offset == offset {
+			return e.SymOffset, true, true
+		}
 	}
 
 	// Return the symbol just before this address (containing symbol)
 	if i > 0 {
-		prev := idx.entries[i-1]
+		prev := idx.Entry(i - 1)
 		if prev.Section == section {
 			return prev.SymOffset, false, true
 		}
@@ -292,25 +291,40 @@ func (idx *AddressIndex) FindByAddress(section uint16, offset uint32) (symOffset
 	return 0, false, false
 }
 
-// NameIndex provides hash-based symbol name lookup.
-type NameIndex struct {
-	buckets    [][]nameEntry
-	numBuckets uint32
+// FindAllAt returns the offsets of the symbols at exactly the given
+// address.
+func (idx *AddressIndex) FindAllAt(section uint16, offset uint32) []uint32 {
+	n := idx.Len()
+	i := sort.Search(n, func(i int) bool {
+		e := idx.Entry(i)
+		if e.Section != section {
+			return e.Section > section
+		}
+		return e.Offset >= offset
+	})
+
+	var offsets []uint32
+	for ; i < n; i++ {
+		e := idx.Entry(i)
+		if e.Section != section || e.Offset != offset {
+			break
+		}
+		offsets = append(offsets, e.SymOffset)
+	}
+	return offsets
 }
 
-type nameEntry struct {
-	name      string
-	symOffset uint32
+// NameIndex provides hash-based symbol name lookup. It stores only name
+// hashes and record offsets; the names are compared against the symbol
+// records, so a cached index is searched in place.
+type NameIndex struct {
+	hashes  indexcache.HashIndex
+	symData []byte
 }
 
 // NewNameIndex creates a name index from symbol data.
 func NewNameIndex(symData []byte) *NameIndex {
-	const numBuckets = 4096
-
-	idx := &NameIndex{
-		buckets:    make([][]nameEntry, numBuckets),
-		numBuckets: numBuckets,
-	}
+	var b indexcache.HashIndexBuilder
 
 	r := stream.NewReader(symData)
 	for r.Remaining() > 4 {
@@ -320,46 +334,47 @@ func NewNameIndex(symData []byte) *NameIndex {
 			break
 		}
 
-		name := getSymbolName(rec)
-		if name != "" {
-			bucket := hashName(name) % numBuckets
-			idx.buckets[bucket] = append(idx.buckets[bucket], nameEntry{
-				name:      name,
-				symOffset: uint32(offset),
-			})
+		if name := getSymbolName(rec); name != "" {
+			b.Add(name, uint32(offset))
 		}
 
 		r.Skip(size)
 	}
 
-	return idx
+	return &NameIndex{hashes: b.Build(), symData: symData}
+}
+
+// LoadNameIndex returns the name index stored in data by Bytes, for the
+// symbol data it was built from.
+func LoadNameIndex(data, symData []byte) (*NameIndex, bool) {
+	hashes, ok := indexcache.LoadHashIndex(data)
+	if !ok {
+		return nil, false
+	}
+	return &NameIndex{hashes: hashes, symData: symData}, true
+}
+
+// Bytes returns the index in the form stored in an index cache file.
+func (idx *NameIndex) Bytes() []byte {
+	return idx.hashes
 }
 
 // FindByName finds symbols with the given name.
 // Returns offsets into the symbol record stream.
 func (idx *NameIndex) FindByName(name string) []uint32 {
-	bucket := hashName(name) % idx.numBuckets
-	entries := idx.buckets[bucket]
-
 	var results []uint32
-	for _, e := range entries {
-		if e.name == name {
-			results = append(results, e.symOffset)
+	for _, offset := range idx.hashes.Lookup(name) {
+		if int(offset) >= len(idx.symData) {
+			continue
+		}
+		rec, _, err := ParseSymbolRecord(idx.symData[offset:])
+		if err == nil && getSymbolName(rec) == name {
+			results = append(results, offset)
 		}
 	}
 	return results
 }
 
-// hashName computes hash for symbol name lookup.
-// This uses a simple hash; the actual PDB uses a more complex hash.
-func hashName(name string) uint32 {
-	var hash uint32
-	for i := 0; i < len(name); i++ {
-		hash = hash*31 + uint32(name[i])
-	}
-	return hash
-}
-
 // getSymbolName extracts name from a symbol record.
 func getSymbolName(rec *SymbolRecord) string {
 	switch rec.Kind {
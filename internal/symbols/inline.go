@@ -0,0 +1,163 @@
+package symbols
+
+import (
+	"github.com/skdltmxn/pdb-go/internal/stream"
+	"github.com/skdltmxn/pdb-go/internal/tpi"
+)
+
+// ParseInlineSiteSym parses an inline site symbol (S_INLINESITE or
+// S_INLINESITE2). S_INLINESITE2 carries an invocation count before the
+// binary annotations, which is skipped.
+func ParseInlineSiteSym(kind SymbolRecordKind, data []byte) (*InlineSiteSym, error) {
+	r := stream.NewReader(data)
+
+	ptrParent, err := r.ReadU32()
+	if err != nil {
+		return nil, err
+	}
+
+	ptrEnd, err := r.ReadU32()
+	if err != nil {
+		return nil, err
+	}
+
+	inlinee, err := r.ReadU32()
+	if err != nil {
+		return nil, err
+	}
+
+	if kind == S_INLINESITE2 {
+		if _, err := r.ReadU32(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &InlineSiteSym{
+		PtrParent:         ptrParent,
+		PtrEnd:            ptrEnd,
+		Inlinee:           tpi.TypeIndex(inlinee),
+		BinaryAnnotations: r.RemainingData(),
+	}, nil
+}
+
+// BinaryAnnotationOpcode identifies an inline site binary annotation.
+type BinaryAnnotationOpcode uint8
+
+// Binary annotation opcodes (BA_OP_*)
+const (
+	BA_OP_Invalid                       BinaryAnnotationOpcode = 0
+	BA_OP_CodeOffset                    BinaryAnnotationOpcode = 1
+	BA_OP_ChangeCodeOffsetBase          BinaryAnnotationOpcode = 2
+	BA_OP_ChangeCodeOffset              BinaryAnnotationOpcode = 3
+	BA_OP_ChangeCodeLength              BinaryAnnotationOpcode = 4
+	BA_OP_ChangeFile                    BinaryAnnotationOpcode = 5
+	BA_OP_ChangeLineOffset              BinaryAnnotationOpcode = 6
+	BA_OP_ChangeLineEndDelta            BinaryAnnotationOpcode = 7
+	BA_OP_ChangeRangeKind               BinaryAnnotationOpcode = 8
+	BA_OP_ChangeColumnStart             BinaryAnnotationOpcode = 9
+	BA_OP_ChangeColumnEndDelta          BinaryAnnotationOpcode = 10
+	BA_OP_ChangeCodeOffsetAndLineOffset BinaryAnnotationOpcode = 11
+	BA_OP_ChangeCodeLengthAndCodeOffset BinaryAnnotationOpcode = 12
+	BA_OP_ChangeColumnEnd               BinaryAnnotationOpcode = 13
+)
+
+// BinaryAnnotation is a decoded inline site annotation. Line and column
+// deltas are stored in Signed; all other operands in U1 and U2.
+type BinaryAnnotation struct {
+	Opcode BinaryAnnotationOpcode
+	U1     uint32
+	U2     uint32
+	Signed int32
+}
+
+// DecodeBinaryAnnotations decodes the binary annotations of an inline site.
+// Decoding stops at the first invalid opcode or malformed operand.
+func DecodeBinaryAnnotations(data []byte) []BinaryAnnotation {
+	var result []BinaryAnnotation
+	pos := 0
+
+	next := func() (uint32, bool) {
+		v, n := decodeCompressed(data[pos:])
+		if n == 0 {
+			return 0, false
+		}
+		pos += n
+		return v, true
+	}
+
+	for pos < len(data) {
+		op, ok := next()
+		if !ok || BinaryAnnotationOpcode(op) == BA_OP_Invalid {
+			break
+		}
+
+		a := BinaryAnnotation{Opcode: BinaryAnnotationOpcode(op)}
+		switch a.Opcode {
+		case BA_OP_ChangeLineOffset, BA_OP_ChangeColumnEndDelta:
+			v, ok := next()
+			if !ok {
+				return result
+			}
+			a.Signed = decodeSigned(v)
+		case BA_OP_ChangeCodeOffsetAndLineOffset:
+			v, ok := next()
+			if !ok {
+				return result
+			}
+			a.U1 = v & 0xf
+			a.Signed = decodeSigned(v >> 4)
+		case BA_OP_ChangeCodeLengthAndCodeOffset:
+			length, ok := next()
+			if !ok {
+				return result
+			}
+			offset, ok := next()
+			if !ok {
+				return result
+			}
+			a.U1, a.U2 = length, offset
+		default:
+			if a.U1, ok = next(); !ok {
+				return result
+			}
+		}
+
+		result = append(result, a)
+	}
+
+	return result
+}
+
+// decodeCompressed decodes a CodeView compressed unsigned integer and
+// returns it with the number of bytes consumed (0 if malformed).
+func decodeCompressed(data []byte) (uint32, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+
+	b := data[0]
+	switch {
+	case b&0x80 == 0:
+		return uint32(b), 1
+	case b&0xc0 == 0x80:
+		if len(data) < 2 {
+			return 0, 0
+		}
+		return uint32(b&0x3f)<<8 | uint32(data[1]), 2
+	case b&0xe0 == 0xc0:
+		if len(data) < 4 {
+			return 0, 0
+		}
+		return uint32(b&0x1f)<<24 | uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3]), 4
+	default:
+		return 0, 0
+	}
+}
+
+// decodeSigned decodes a signed value stored with its sign in bit 0.
+func decodeSigned(v uint32) int32 {
+	if v&1 != 0 {
+		return -int32(v >> 1)
+	}
+	return int32(v >> 1)
+}
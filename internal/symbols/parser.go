@@ -3,6 +3,7 @@ package symbols
 import (
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/skdltmxn/pdb-go/internal/stream"
 	"github.com/skdltmxn/pdb-go/internal/tpi"
@@ -57,33 +58,79 @@ func ParseSymbolRecord(data []byte) (*SymbolRecord, int, error) {
 
 // SymbolIterator iterates over symbol records in a stream.
 type SymbolIterator struct {
-	data   []byte
-	offset int
+	r *stream.Reader
+
+	// Set when reading through a window, whose records must be copied
+	windowed bool
 }
 
 // NewSymbolIterator creates a new symbol iterator.
 func NewSymbolIterator(data []byte) *SymbolIterator {
-	return &SymbolIterator{data: data}
+	return &SymbolIterator{r: stream.NewReader(data)}
+}
+
+// NewSymbolIteratorAt creates a symbol iterator over size bytes of an
+// io.ReaderAt. The records are read through a bounded window, so the
+// iterator's memory use does not depend on the size of the stream; each
+// record's data is a copy.
+func NewSymbolIteratorAt(r io.ReaderAt, size int64) *SymbolIterator {
+	return &SymbolIterator{r: stream.NewReaderAt(r, size, stream.DefaultWindowSize), windowed: true}
+}
+
+// Offset returns the offset of the next record.
+func (it *SymbolIterator) Offset() int {
+	return it.r.Offset()
 }
 
 // Next returns the next symbol record, or nil if there are no more.
 func (it *SymbolIterator) Next() (*SymbolRecord, error) {
-	if it.offset >= len(it.data) {
+	if it.r.Remaining() == 0 {
 		return nil, nil
 	}
+	if it.r.Remaining() < 4 {
+		return nil, ErrUnexpectedEnd
+	}
+
+	start, remaining := it.r.Offset(), it.r.Remaining()
+	length, err := it.r.ReadU16()
+	if err != nil {
+		return nil, err
+	}
+	kind, err := it.r.ReadU16()
+	if err != nil {
+		return nil, err
+	}
 
-	rec, size, err := ParseSymbolRecord(it.data[it.offset:])
+	// The length covers the kind field and the data, but not itself
+	if int(length)+2 > remaining {
+		it.r.SetOffset(start)
+		return nil, ErrUnexpectedEnd
+	}
+	dataLen := int(length) - 2
+	if dataLen < 0 {
+		it.r.SetOffset(start)
+		return nil, ErrInvalidSymbolRecord
+	}
+
+	var data []byte
+	if it.windowed {
+		data, err = it.r.ReadBytes(dataLen)
+	} else {
+		data, err = it.r.ReadBytesRef(dataLen)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	it.offset += size
-	return rec, nil
+	return &SymbolRecord{
+		Kind: SymbolRecordKind(kind),
+		Data: data,
+	}, nil
 }
 
 // Reset resets the iterator to the beginning.
 func (it *SymbolIterator) Reset() {
-	it.offset = 0
+	it.r.SetOffset(0)
 }
 
 // ParseProcSym parses a procedure symbol (S_GPROC32, S_LPROC32, etc.).
@@ -508,7 +555,7 @@ func ParseCompileSym3(data []byte) (*CompileSym3, error) {
 	}
 
 	return &CompileSym3{
-		Flags:         flags,
+		Flags:         CompileFlags(flags),
 		Machine:       machine,
 		FrontendMajor: frontendMajor,
 		FrontendMinor: frontendMinor,
@@ -759,7 +806,7 @@ func ParseSymbol(rec *SymbolRecord) (interface{}, error) {
 
 // PSIHeader is the header of the Public Symbol Index stream.
 type PSIHeader struct {
-	// SymHash is the hash of the symbol stream
+	// SymHash is the size of the GSI hash table that follows the header
 	SymHash uint32
 	// AddrMap size in bytes
 	AddrMapSize uint32
@@ -356,9 +356,21 @@ type ObjNameSym struct {
 	Name      string
 }
 
+// CompileFlags describes the compile unit of S_COMPILE3. The low byte is
+// the source language (CV_CFL_LANG).
+type CompileFlags uint32
+
+// Source languages of compile units
+const (
+	LanguageC   = 0x00
+	LanguageCpp = 0x01
+)
+
+func (cf CompileFlags) Language() uint8 { return uint8(cf) }
+
 // CompileSym3 represents S_COMPILE3.
 type CompileSym3 struct {
-	Flags       uint32
+	Flags       CompileFlags
 	Machine     uint16
 	FrontendMajor uint16
 	FrontendMinor uint16
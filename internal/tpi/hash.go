@@ -0,0 +1,167 @@
+package tpi
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// IndexOffset is an entry in the hash stream's index offset table.
+// The table is sparse: it records the offset of roughly one type record
+// per 8KB of record data, which is enough to seek to any record quickly.
+type IndexOffset struct {
+	TypeIndex TypeIndex
+	Offset    uint32 // Offset from the start of the type record data
+}
+
+// ParseHashStream parses the TPI/IPI hash stream referenced by
+// Header.HashStreamIndex and attaches the per-type hash values and the index
+// offset table to the stream. Each hash value is the bucket
+// (hash % NumHashBuckets) of the corresponding type record.
+func (s *Stream) ParseHashStream(data []byte) error {
+	if s.Header.HashKeySize != 4 {
+		return fmt.Errorf("tpi: unsupported hash key size: %d", s.Header.HashKeySize)
+	}
+
+	start := int(s.Header.HashValueBufferOffset)
+	length := int(s.Header.HashValueBufferLength)
+	if start < 0 || start+length > len(data) {
+		return fmt.Errorf("tpi: hash value buffer out of range: offset %d, length %d", start, length)
+	}
+
+	count := int(s.TypeCount())
+	if length/4 < count {
+		count = length / 4
+	}
+
+	values := make([]uint32, count)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint32(data[start+i*4:])
+	}
+
+	// There are usually far more buckets than types; size the map by the
+	// types that fill it
+	buckets := make(map[uint32][]TypeIndex, min(count, int(s.Header.NumHashBuckets)))
+	for i, v := range values {
+		buckets[v] = append(buckets[v], s.Header.TypeIndexBegin+TypeIndex(i))
+	}
+
+	indexOffsets, err := s.parseIndexOffsets(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.hashValues = values
+	s.hashBuckets = buckets
+	s.indexOffsets = indexOffsets
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Stream) parseIndexOffsets(data []byte) ([]IndexOffset, error) {
+	start := int(s.Header.IndexOffsetBufferOffset)
+	length := int(s.Header.IndexOffsetBufferLength)
+	if length == 0 {
+		return nil, nil
+	}
+	if start < 0 || start+length > len(data) {
+		return nil, fmt.Errorf("tpi: index offset buffer out of range: offset %d, length %d", start, length)
+	}
+
+	count := length / 8
+	indexOffsets := make([]IndexOffset, 0, count)
+	for i := 0; i < count; i++ {
+		entry := IndexOffset{
+			TypeIndex: TypeIndex(binary.LittleEndian.Uint32(data[start+i*8:])),
+			Offset:    binary.LittleEndian.Uint32(data[start+i*8+4:]),
+		}
+
+		// Entries must be ascending and within the record data to be usable
+		if entry.TypeIndex < s.Header.TypeIndexBegin || entry.TypeIndex >= s.Header.TypeIndexEnd ||
+			int(entry.Offset) >= s.recordSize {
+			return nil, fmt.Errorf("tpi: invalid index offset entry %d", i)
+		}
+		if n := len(indexOffsets); n > 0 && indexOffsets[n-1].TypeIndex >= entry.TypeIndex {
+			return nil, fmt.Errorf("tpi: index offset table not sorted at entry %d", i)
+		}
+
+		indexOffsets = append(indexOffsets, entry)
+	}
+
+	return indexOffsets, nil
+}
+
+// IndexOffsets returns the index offset table from the hash stream, if any.
+func (s *Stream) IndexOffsets() []IndexOffset {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.indexOffsets
+}
+
+// HasHashTable returns true if a hash stream with a hash value for every
+// type record has been attached. Some producers write the hash stream
+// without hash values; bucket lookups are unusable for those.
+func (s *Stream) HasHashTable() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.hashValues) > 0 && len(s.hashValues) == int(s.TypeCount())
+}
+
+// HashValue returns the hash bucket of the given type record.
+func (s *Stream) HashValue(ti TypeIndex) (uint32, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if ti < s.Header.TypeIndexBegin {
+		return 0, false
+	}
+	i := int(ti - s.Header.TypeIndexBegin)
+	if i >= len(s.hashValues) {
+		return 0, false
+	}
+	return s.hashValues[i], true
+}
+
+// TypesInBucket returns the type indices whose hash falls in the given bucket.
+func (s *Stream) TypesInBucket(bucket uint32) []TypeIndex {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hashBuckets[bucket]
+}
+
+// NameBucket returns the hash bucket a UDT with the given name is stored in.
+func (s *Stream) NameBucket(name string) uint32 {
+	if s.Header.NumHashBuckets == 0 {
+		return 0
+	}
+	return HashStringV1(name) % s.Header.NumHashBuckets
+}
+
+// HashStringV1 computes the PDB string hash (lhashPbCb) used for UDT names
+// in the TPI hash stream.
+func HashStringV1(str string) uint32 {
+	var result uint32
+	data := []byte(str)
+	n := len(data)
+
+	i := 0
+	for ; i+4 <= n; i += 4 {
+		result ^= binary.LittleEndian.Uint32(data[i:])
+	}
+
+	remaining := n - i
+	if remaining >= 2 {
+		result ^= uint32(binary.LittleEndian.Uint16(data[i:]))
+		i += 2
+		remaining -= 2
+	}
+	if remaining == 1 {
+		result ^= uint32(data[i])
+	}
+
+	const toLowerMask = 0x20202020
+	result |= toLowerMask
+	result ^= result >> 11
+	return result ^ (result >> 16)
+}
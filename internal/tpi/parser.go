@@ -1,8 +1,11 @@
 package tpi
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"sync"
 
 	"github.com/skdltmxn/pdb-go/internal/stream"
@@ -79,16 +82,32 @@ func (h *Header) TypeCount() uint32 {
 type Stream struct {
 	Header Header
 
-	// rawRecords holds the raw type record data
+	// rawRecords holds the raw type record data, unless the stream is
+	// read through records
 	rawRecords []byte
+	records    *io.SectionReader
+	recordSize int
 
 	// recordOffsets maps TypeIndex to byte offset in rawRecords
-	// This enables O(1) random access to types
-	recordOffsets map[TypeIndex]uint32
+	// This enables O(1) random access to types. It is built lazily and
+	// only when the hash stream provides no index offset table.
+	recordOffsets     map[TypeIndex]uint32
+	recordOffsetsEnd  uint32 // Where the scan for the offset map stopped
+	recordOffsetsOnce sync.Once
+	recordOffsetsErr  error
 
 	// parsed types cache with thread-safe access
 	typeCache sync.Map // map[TypeIndex]*TypeRecord
 
+	// hashValues holds the hash bucket of each type record, and hashBuckets
+	// maps each bucket to its type indices (populated by ParseHashStream)
+	hashValues  []uint32
+	hashBuckets map[uint32][]TypeIndex
+
+	// indexOffsets is the sparse TypeIndex -> offset table from the hash
+	// stream, sorted by type index (populated by ParseHashStream)
+	indexOffsets []IndexOffset
+
 	mu sync.RWMutex
 }
 
@@ -99,9 +118,7 @@ func ParseStream(data []byte) (*Stream, error) {
 	}
 
 	r := stream.NewReader(data)
-	s := &Stream{
-		recordOffsets: make(map[TypeIndex]uint32),
-	}
+	s := &Stream{}
 
 	// Parse header
 	if err := s.parseHeader(r); err != nil {
@@ -115,12 +132,34 @@ func ParseStream(data []byte) (*Stream, error) {
 		return nil, fmt.Errorf("tpi: truncated stream: expected %d bytes, got %d", recordEnd, len(data))
 	}
 	s.rawRecords = data[recordStart:recordEnd]
+	s.recordSize = len(s.rawRecords)
+
+	return s, nil
+}
 
-	// Build offset index for random access
-	if err := s.buildOffsetIndex(); err != nil {
+// ParseStreamAt parses a TPI or IPI stream of the given size read from an
+// io.ReaderAt. Only the header is read up front: records are read when they
+// are first looked up and cached like those of ParseStream, and the offset
+// index is built by reading the records through a bounded window, so the
+// stream as a whole is never held in memory.
+func ParseStreamAt(r io.ReaderAt, size int64) (*Stream, error) {
+	if size < TPIHeaderSize {
+		return nil, ErrInvalidTPIHeader
+	}
+
+	s := &Stream{}
+	if err := s.parseHeader(stream.NewReaderAt(r, size, TPIHeaderSize)); err != nil {
 		return nil, err
 	}
 
+	recordStart := int64(s.Header.HeaderSize)
+	recordEnd := recordStart + int64(s.Header.TypeRecordBytes)
+	if recordEnd > size {
+		return nil, fmt.Errorf("tpi: truncated stream: expected %d bytes, got %d", recordEnd, size)
+	}
+	s.records = io.NewSectionReader(r, recordStart, int64(s.Header.TypeRecordBytes))
+	s.recordSize = int(s.Header.TypeRecordBytes)
+
 	return s, nil
 }
 
@@ -216,9 +255,12 @@ func (s *Stream) parseHeader(r *stream.Reader) error {
 }
 
 // buildOffsetIndex scans the record data to build the type index -> offset mapping.
+// If a record cannot be read, the offsets of the records before it are kept.
 func (s *Stream) buildOffsetIndex() error {
-	r := stream.NewReader(s.rawRecords)
+	s.recordOffsets = make(map[TypeIndex]uint32, s.TypeCount())
+	r := s.recordReader(stream.DefaultWindowSize)
 	typeIndex := s.Header.TypeIndexBegin
+	defer func() { s.recordOffsetsEnd = uint32(r.Offset()) }()
 
 	for r.Remaining() > 0 && typeIndex < s.Header.TypeIndexEnd {
 		offset := uint32(r.Offset())
@@ -241,6 +283,90 @@ func (s *Stream) buildOffsetIndex() error {
 	return nil
 }
 
+// seekWindowSize is the window used to walk from an index offset entry to
+// a record; MSVC writes an entry about every 8KB of records.
+const seekWindowSize = 8 * 1024
+
+// recordReader returns a reader over the type records, reading window
+// bytes at a time if the stream was parsed by ParseStreamAt.
+func (s *Stream) recordReader(window int) *stream.Reader {
+	if s.records != nil {
+		return stream.NewReaderAt(s.records, s.records.Size(), window)
+	}
+	return stream.NewReader(s.rawRecords)
+}
+
+// recordOffset returns the byte offset of a type record in the record data.
+// When the hash stream's index offset table is available, the record is
+// located by seeking from the nearest preceding entry; otherwise the full
+// offset map is built on first use.
+func (s *Stream) recordOffset(ti TypeIndex) (uint32, error) {
+	s.mu.RLock()
+	indexOffsets := s.indexOffsets
+	s.mu.RUnlock()
+
+	if len(indexOffsets) > 0 {
+		return s.seekRecord(indexOffsets, ti)
+	}
+
+	s.recordOffsetsOnce.Do(func() {
+		s.recordOffsetsErr = s.buildOffsetIndex()
+	})
+
+	offset, ok := s.recordOffsets[ti]
+	if !ok {
+		if s.recordOffsetsErr != nil {
+			return s.recordOffsetsEnd, s.recordOffsetsErr
+		}
+		return s.recordOffsetsEnd, fmt.Errorf("%w: no offset for type %d", ErrTypeIndexOutOfRange, ti)
+	}
+	return offset, nil
+}
+
+// RecordOffset returns the offset of a type record from the start of the
+// stream. If the record cannot be located, it returns an error and the
+// offset at which reading the records failed.
+func (s *Stream) RecordOffset(ti TypeIndex) (uint32, error) {
+	if ti < s.Header.TypeIndexBegin || ti >= s.Header.TypeIndexEnd {
+		return 0, fmt.Errorf("%w: %d", ErrTypeIndexOutOfRange, ti)
+	}
+	offset, err := s.recordOffset(ti)
+	return s.Header.HeaderSize + offset, err
+}
+
+// seekRecord walks forward from the closest index offset entry at or before ti.
+func (s *Stream) seekRecord(indexOffsets []IndexOffset, ti TypeIndex) (uint32, error) {
+	i := sort.Search(len(indexOffsets), func(i int) bool {
+		return indexOffsets[i].TypeIndex > ti
+	})
+
+	current := s.Header.TypeIndexBegin
+	offset := uint32(0)
+	if i > 0 {
+		current = indexOffsets[i-1].TypeIndex
+		offset = indexOffsets[i-1].Offset
+	}
+
+	r := s.recordReader(seekWindowSize)
+	r.SetOffset(int(offset))
+	for current < ti {
+		recordLen, err := r.ReadU16()
+		if err == nil {
+			err = r.Skip(int(recordLen))
+		}
+		if err != nil {
+			return uint32(r.Offset()), fmt.Errorf("%w: no offset for type %d", ErrTypeIndexOutOfRange, ti)
+		}
+		current++
+	}
+
+	offset = uint32(r.Offset())
+	if int(offset) >= s.recordSize {
+		return offset, fmt.Errorf("%w: no offset for type %d", ErrTypeIndexOutOfRange, ti)
+	}
+	return offset, nil
+}
+
 // TypeRecord represents a parsed type record.
 type TypeRecord struct {
 	Kind TypeRecordKind
@@ -265,9 +391,18 @@ func (s *Stream) GetTypeRecord(ti TypeIndex) (*TypeRecord, error) {
 	}
 
 	// Get offset
-	offset, ok := s.recordOffsets[ti]
-	if !ok {
-		return nil, fmt.Errorf("%w: no offset for type %d", ErrTypeIndexOutOfRange, ti)
+	offset, err := s.recordOffset(ti)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.records != nil {
+		record, err := s.readRecord(offset)
+		if err != nil {
+			return nil, err
+		}
+		s.typeCache.Store(ti, record)
+		return record, nil
 	}
 
 	// Parse record
@@ -305,6 +440,48 @@ func (s *Stream) GetTypeRecord(ti TypeIndex) (*TypeRecord, error) {
 	return record, nil
 }
 
+// readRecord reads the type record at offset from a stream parsed by
+// ParseStreamAt.
+func (s *Stream) readRecord(offset uint32) (*TypeRecord, error) {
+	var header [4]byte
+	if _, err := s.records.ReadAt(header[:], int64(offset)); err != nil {
+		return nil, err
+	}
+	r := stream.NewReader(header[:])
+	recordLen, _ := r.ReadU16()
+	kind, _ := r.ReadU16()
+
+	// recordLen includes the kind field, so subtract 2
+	dataLen := int(recordLen) - 2
+	if dataLen < 0 {
+		return nil, ErrInvalidTypeRecord
+	}
+
+	data := make([]byte, dataLen)
+	if _, err := s.records.ReadAt(data, int64(offset)+4); err != nil {
+		return nil, err
+	}
+
+	return &TypeRecord{
+		Kind: TypeRecordKind(kind),
+		Data: data,
+	}, nil
+}
+
+// RecordData returns the raw type records following the header, or nil if
+// the stream was parsed by ParseStreamAt.
+func (s *Stream) RecordData() []byte {
+	return s.rawRecords
+}
+
+// Records returns a reader over the raw type records following the header.
+func (s *Stream) Records() *io.SectionReader {
+	if s.records != nil {
+		return io.NewSectionReader(s.records, 0, s.records.Size())
+	}
+	return io.NewSectionReader(bytes.NewReader(s.rawRecords), 0, int64(len(s.rawRecords)))
+}
+
 // TypeIndexBegin returns the first valid type index.
 func (s *Stream) TypeIndexBegin() TypeIndex {
 	return s.Header.TypeIndexBegin
@@ -501,6 +678,41 @@ func ParseMFunctionRecord(data []byte) (*MFunctionRecord, error) {
 	}, nil
 }
 
+// FuncIDRecord represents an LF_FUNC_ID or LF_MFUNC_ID item in the IPI
+// stream. For LF_FUNC_ID, Scope is the parent scope (an LF_STRING_ID or 0);
+// for LF_MFUNC_ID it is the containing class.
+type FuncIDRecord struct {
+	Scope        TypeIndex
+	FunctionType TypeIndex
+	Name         string
+}
+
+// ParseFuncIDRecord parses an LF_FUNC_ID or LF_MFUNC_ID record.
+func ParseFuncIDRecord(data []byte) (*FuncIDRecord, error) {
+	r := stream.NewReader(data)
+
+	scope, err := r.ReadU32()
+	if err != nil {
+		return nil, err
+	}
+
+	funcType, err := r.ReadU32()
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := r.ReadCString()
+	if err != nil {
+		return nil, err
+	}
+
+	return &FuncIDRecord{
+		Scope:        TypeIndex(scope),
+		FunctionType: TypeIndex(funcType),
+		Name:         name,
+	}, nil
+}
+
 // ArgListRecord represents an LF_ARGLIST type.
 type ArgListRecord struct {
 	ArgTypes []TypeIndex
@@ -527,6 +739,59 @@ func ParseArgListRecord(data []byte) (*ArgListRecord, error) {
 	return &ArgListRecord{ArgTypes: args}, nil
 }
 
+// MethodListEntry is a single overload in an LF_METHODLIST record.
+type MethodListEntry struct {
+	Attributes  MethodProperties
+	Type        TypeIndex
+	VBaseOffset int32 // Only for intro virtual methods
+}
+
+// MethodListRecord represents an LF_METHODLIST type (overloads of a method).
+type MethodListRecord struct {
+	Methods []MethodListEntry
+}
+
+// ParseMethodListRecord parses an LF_METHODLIST record.
+func ParseMethodListRecord(data []byte) (*MethodListRecord, error) {
+	r := stream.NewReader(data)
+	rec := &MethodListRecord{}
+
+	for r.Remaining() >= 8 {
+		attrs, err := r.ReadU16()
+		if err != nil {
+			return nil, err
+		}
+
+		// Padding
+		if _, err := r.ReadU16(); err != nil {
+			return nil, err
+		}
+
+		typ, err := r.ReadU32()
+		if err != nil {
+			return nil, err
+		}
+
+		entry := MethodListEntry{
+			Attributes: MethodProperties(attrs),
+			Type:       TypeIndex(typ),
+		}
+
+		// VBaseOffset is present for intro virtual methods
+		if entry.Attributes.IsIntro() {
+			vbaseOffset, err := r.ReadI32()
+			if err != nil {
+				return nil, err
+			}
+			entry.VBaseOffset = vbaseOffset
+		}
+
+		rec.Methods = append(rec.Methods, entry)
+	}
+
+	return rec, nil
+}
+
 // ArrayRecord represents an LF_ARRAY type.
 type ArrayRecord struct {
 	ElementType TypeIndex
@@ -792,9 +1057,45 @@ func ParseBitFieldRecord(data []byte) (*BitFieldRecord, error) {
 	}, nil
 }
 
+// VTShapeRecord represents an LF_VTSHAPE type (virtual function table shape).
+type VTShapeRecord struct {
+	// Slots holds one CV_VTS_desc_e descriptor per vtable entry
+	// (0 = near, 1 = far, 2 = thin, 3 = outer, 4 = meta, 5 = near32, 6 = far32).
+	Slots []uint8
+}
+
+// ParseVTShapeRecord parses an LF_VTSHAPE record.
+func ParseVTShapeRecord(data []byte) (*VTShapeRecord, error) {
+	r := stream.NewReader(data)
+
+	count, err := r.ReadU16()
+	if err != nil {
+		return nil, err
+	}
+
+	// Descriptors are packed two per byte, low nibble first
+	rec := &VTShapeRecord{Slots: make([]uint8, 0, count)}
+	for i := uint16(0); i < count; i += 2 {
+		b, err := r.ReadU8()
+		if err != nil {
+			return nil, err
+		}
+		rec.Slots = append(rec.Slots, b&0x0F)
+		if i+1 < count {
+			rec.Slots = append(rec.Slots, b>>4)
+		}
+	}
+
+	return rec, nil
+}
+
 // FieldListRecord represents an LF_FIELDLIST type containing class/struct members.
 type FieldListRecord struct {
 	Members []FieldListMember
+
+	// Continuation is the next field list in the chain (from a trailing
+	// LF_INDEX), or 0. Large classes split their members across records.
+	Continuation TypeIndex
 }
 
 // FieldListMember is a member within a field list.
@@ -864,6 +1165,7 @@ type VirtualBaseClassRecord struct {
 	VBPtrType         TypeIndex
 	VBPtrOffset       uint64
 	VBTableIndex      uint64
+	Indirect          bool // LF_IVBCLASS: inherited through another base
 }
 
 func (*VirtualBaseClassRecord) fieldListMember() {}
@@ -913,6 +1215,19 @@ func ParseFieldListRecord(data []byte) (*FieldListRecord, error) {
 			break
 		}
 
+		if TypeRecordKind(kind) == LF_INDEX {
+			// Continuation: padding followed by the next field list
+			if _, err := r.ReadU16(); err != nil {
+				break
+			}
+			next, err := r.ReadU32()
+			if err != nil {
+				break
+			}
+			rec.Continuation = TypeIndex(next)
+			continue
+		}
+
 		member, err := parseFieldListMember(TypeRecordKind(kind), r)
 		if err != nil {
 			// Skip unknown member types instead of failing
@@ -941,15 +1256,11 @@ func parseFieldListMember(kind TypeRecordKind, r *stream.Reader) (FieldListMembe
 	case LF_BCLASS:
 		return parseBaseClassField(r)
 	case LF_VBCLASS, LF_IVBCLASS:
-		return parseVirtualBaseClassField(r)
+		return parseVirtualBaseClassField(r, kind == LF_IVBCLASS)
 	case LF_ENUMERATE:
 		return parseEnumerateField(r)
 	case LF_VFUNCTAB:
 		return parseVFuncTabField(r)
-	case LF_INDEX:
-		// Continuation index - skip for now
-		r.ReadU32() // type index
-		return nil, nil
 	default:
 		return nil, nil
 	}
@@ -1111,7 +1422,7 @@ func parseBaseClassField(r *stream.Reader) (*BaseClassRecord, error) {
 	}, nil
 }
 
-func parseVirtualBaseClassField(r *stream.Reader) (*VirtualBaseClassRecord, error) {
+func parseVirtualBaseClassField(r *stream.Reader, indirect bool) (*VirtualBaseClassRecord, error) {
 	attrs, err := r.ReadU16()
 	if err != nil {
 		return nil, err
@@ -1143,6 +1454,7 @@ func parseVirtualBaseClassField(r *stream.Reader) (*VirtualBaseClassRecord, erro
 		VBPtrType:    TypeIndex(vbptrType),
 		VBPtrOffset:  vbptrOffset,
 		VBTableIndex: vbtableIndex,
+		Indirect:     indirect,
 	}, nil
 }
 
@@ -376,12 +376,14 @@ func (cp ClassProperties) Mocom() uint8            { return uint8((cp >> 14) & 0
 type MethodProperties uint16
 
 func (mp MethodProperties) Access() uint8       { return uint8(mp & 0x03) }
-func (mp MethodProperties) IsIntro() bool       { return (mp & 0x04) != 0 }
-func (mp MethodProperties) IsPure() bool        { return (mp & 0x08) != 0 }
-func (mp MethodProperties) IsNoInherit() bool   { return (mp & 0x10) != 0 }
-func (mp MethodProperties) IsNoConstruct() bool { return (mp & 0x20) != 0 }
-func (mp MethodProperties) IsCompGenX() bool    { return (mp & 0x40) != 0 }
-func (mp MethodProperties) IsSealed() bool      { return (mp & 0x80) != 0 }
+func (mp MethodProperties) Kind() MethodKind    { return MethodKind((mp >> 2) & 0x07) }
+func (mp MethodProperties) IsIntro() bool       { return mp.Kind().IsIntro() }
+func (mp MethodProperties) IsPure() bool        { return mp.Kind().IsPure() }
+func (mp MethodProperties) IsPseudo() bool      { return (mp & 0x0020) != 0 }
+func (mp MethodProperties) IsNoInherit() bool   { return (mp & 0x0040) != 0 }
+func (mp MethodProperties) IsNoConstruct() bool { return (mp & 0x0080) != 0 }
+func (mp MethodProperties) IsCompGenX() bool    { return (mp & 0x0100) != 0 }
+func (mp MethodProperties) IsSealed() bool      { return (mp & 0x0200) != 0 }
 
 // MethodKind identifies the kind of a method.
 type MethodKind uint8
@@ -396,6 +398,25 @@ const (
 	MethodKindPureIntro   MethodKind = 0x06
 )
 
+// IsVirtual returns true for any of the virtual method kinds.
+func (mk MethodKind) IsVirtual() bool {
+	switch mk {
+	case MethodKindVirtual, MethodKindIntroVirtual, MethodKindPureVirtual, MethodKindPureIntro:
+		return true
+	}
+	return false
+}
+
+// IsIntro returns true if the method introduces a new vtable slot.
+func (mk MethodKind) IsIntro() bool {
+	return mk == MethodKindIntroVirtual || mk == MethodKindPureIntro
+}
+
+// IsPure returns true for pure virtual methods.
+func (mk MethodKind) IsPure() bool {
+	return mk == MethodKindPureVirtual || mk == MethodKindPureIntro
+}
+
 // MemberAccess identifies member accessibility.
 type MemberAccess uint8
 
@@ -0,0 +1,145 @@
+package minidump
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Sizes of the CONTEXT records, used to tell the architecture when the dump
+// has no system info stream.
+const (
+	contextSizeX86   = 0x2cc
+	contextSizeAMD64 = 0x4d0
+	contextSizeARM64 = 0x390
+)
+
+// contextLayout gives the register offsets within a CONTEXT record.
+type contextLayout struct {
+	ip, sp, fp int
+	width      int // Register size in bytes
+}
+
+var contextLayouts = map[uint16]contextLayout{
+	ArchX86:   {ip: 0xb8, sp: 0xc4, fp: 0xb4, width: 4},
+	ArchAMD64: {ip: 0xf8, sp: 0x98, fp: 0xa0, width: 8},
+	ArchARM64: {ip: 0x108, sp: 0x100, fp: 0xf0, width: 8},
+}
+
+// Context is the CPU context (CONTEXT record) of a thread.
+type Context struct {
+	Arch uint16 // Processor architecture (ArchX86, ArchAMD64, ...)
+	Data []byte // Raw CONTEXT record
+}
+
+// IP returns the instruction pointer.
+func (c *Context) IP() uint64 {
+	l := contextLayouts[c.Arch]
+	return c.register(l.ip, l.width)
+}
+
+// SP returns the stack pointer.
+func (c *Context) SP() uint64 {
+	l := contextLayouts[c.Arch]
+	return c.register(l.sp, l.width)
+}
+
+// FP returns the frame pointer (EBP, RBP or X29).
+func (c *Context) FP() uint64 {
+	l := contextLayouts[c.Arch]
+	return c.register(l.fp, l.width)
+}
+
+// Offsets of the general-purpose registers of x86 and x64 contexts, in the
+// order of the instruction encoding (eax, ecx, edx, ebx, esp, ebp, esi, edi,
+// r8-r15).
+var gprOffsets = map[uint16][]int{
+	ArchX86: {0xb0, 0xac, 0xa8, 0xa4, 0xc4, 0xb4, 0xa0, 0x9c},
+	ArchAMD64: {0x78, 0x80, 0x88, 0x90, 0x98, 0xa0, 0xa8, 0xb0,
+		0xb8, 0xc0, 0xc8, 0xd0, 0xd8, 0xe0, 0xe8, 0xf0},
+}
+
+// GPRs returns the general-purpose registers of an x86 or x64 context in
+// instruction encoding order. x86 contexts fill the first eight; other
+// architectures return zeros.
+func (c *Context) GPRs() [16]uint64 {
+	var regs [16]uint64
+	width := contextLayouts[c.Arch].width
+	for i, off := range gprOffsets[c.Arch] {
+		regs[i] = c.register(off, width)
+	}
+	return regs
+}
+
+// PointerSize returns the size of a pointer on the context's architecture.
+func (c *Context) PointerSize() int {
+	if l, ok := contextLayouts[c.Arch]; ok {
+		return l.width
+	}
+	return 8
+}
+
+// register reads a register of the given width at an offset of the record.
+// Unknown architectures have a zero width and read as 0.
+func (c *Context) register(off, width int) uint64 {
+	if width == 0 || off+width > len(c.Data) {
+		return 0
+	}
+	if width == 4 {
+		return uint64(binary.LittleEndian.Uint32(c.Data[off:]))
+	}
+	return binary.LittleEndian.Uint64(c.Data[off:])
+}
+
+// ThreadContext returns the context of a thread at the time of the dump.
+func (f *File) ThreadContext(t *Thread) (*Context, error) {
+	return f.readContext(t.context)
+}
+
+// ExceptionContext returns the context of the faulting thread at the time
+// of the exception.
+func (f *File) ExceptionContext() (*Context, error) {
+	if f.Exception == nil {
+		return nil, fmt.Errorf("minidump: no exception stream")
+	}
+	return f.readContext(f.Exception.context)
+}
+
+// StackMemory returns the captured stack memory of a thread.
+func (f *File) StackMemory(t *Thread) ([]byte, error) {
+	if t.Stack.rva != 0 && t.Stack.Size != 0 {
+		return f.read(t.Stack.rva, t.Stack.Size)
+	}
+	return nil, ErrNoMemory
+}
+
+// Arch returns the processor architecture of the dump, or ArchUnknown.
+func (f *File) Arch() uint16 {
+	if f.SystemInfo != nil {
+		return f.SystemInfo.ProcessorArchitecture
+	}
+
+	// Infer the architecture from the size of the first thread context
+	for _, t := range f.Threads {
+		switch t.context.size {
+		case contextSizeX86:
+			return ArchX86
+		case contextSizeAMD64:
+			return ArchAMD64
+		case contextSizeARM64:
+			return ArchARM64
+		}
+	}
+	return ArchUnknown
+}
+
+func (f *File) readContext(loc location) (*Context, error) {
+	if loc.size == 0 {
+		return nil, fmt.Errorf("minidump: no thread context")
+	}
+
+	data, err := f.read(uint64(loc.rva), uint64(loc.size))
+	if err != nil {
+		return nil, err
+	}
+	return &Context{Arch: f.Arch(), Data: data}, nil
+}
@@ -0,0 +1,221 @@
+// Package minidump reads Windows minidump (.dmp) files.
+//
+// It parses the thread list, module list, memory lists, exception and
+// system information streams, and gives access to thread contexts and
+// captured memory. Everything is read with encoding/binary, so dumps can be
+// inspected on any platform.
+package minidump
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Signature is the "MDMP" magic at the start of a minidump.
+const Signature = 0x504d444d
+
+// Stream types (MINIDUMP_STREAM_TYPE) parsed by this package.
+const (
+	StreamThreadList     = 3
+	StreamModuleList     = 4
+	StreamMemoryList     = 5
+	StreamException      = 6
+	StreamSystemInfo     = 7
+	StreamMemory64List   = 9
+	StreamMiscInfo       = 15
+	StreamThreadInfoList = 17
+)
+
+// Errors
+var (
+	ErrNotMinidump = errors.New("minidump: not a minidump file")
+	ErrNoMemory    = errors.New("minidump: memory not captured")
+)
+
+// Header is the MINIDUMP_HEADER.
+type Header struct {
+	Signature          uint32
+	Version            uint32
+	NumberOfStreams    uint32
+	StreamDirectoryRva uint32
+	CheckSum           uint32
+	TimeDateStamp      uint32
+	Flags              uint64
+}
+
+// Stream is an entry of the stream directory.
+type Stream struct {
+	Type uint32
+	Size uint32
+	Rva  uint32
+}
+
+// File is an opened minidump.
+type File struct {
+	r      io.ReaderAt
+	closer io.Closer
+	size   int64
+
+	Header     Header
+	Streams    []Stream
+	Threads    []Thread
+	Modules    []Module
+	Memory     []MemoryRange // From the memory list and the 64-bit memory list
+	Exception  *Exception    // nil if the dump has no exception stream
+	SystemInfo *SystemInfo   // nil if the dump has no system info stream
+}
+
+// Open opens a minidump from the given path.
+func Open(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("minidump: failed to open file: %w", err)
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("minidump: failed to stat file: %w", err)
+	}
+
+	md, err := NewFile(f, stat.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	md.closer = f
+	return md, nil
+}
+
+// NewFile reads a minidump from an io.ReaderAt.
+// The caller is responsible for closing the underlying reader if needed.
+func NewFile(r io.ReaderAt, size int64) (*File, error) {
+	f := &File{r: r, size: size}
+
+	data, err := f.read(0, 32)
+	if err != nil {
+		return nil, ErrNotMinidump
+	}
+	f.Header = Header{
+		Signature:          binary.LittleEndian.Uint32(data[0:]),
+		Version:            binary.LittleEndian.Uint32(data[4:]),
+		NumberOfStreams:    binary.LittleEndian.Uint32(data[8:]),
+		StreamDirectoryRva: binary.LittleEndian.Uint32(data[12:]),
+		CheckSum:           binary.LittleEndian.Uint32(data[16:]),
+		TimeDateStamp:      binary.LittleEndian.Uint32(data[20:]),
+		Flags:              binary.LittleEndian.Uint64(data[24:]),
+	}
+	if f.Header.Signature != Signature {
+		return nil, ErrNotMinidump
+	}
+
+	dir, err := f.read(uint64(f.Header.StreamDirectoryRva), uint64(f.Header.NumberOfStreams)*12)
+	if err != nil {
+		return nil, fmt.Errorf("minidump: failed to read stream directory: %w", err)
+	}
+	f.Streams = make([]Stream, f.Header.NumberOfStreams)
+	for i := range f.Streams {
+		f.Streams[i] = Stream{
+			Type: binary.LittleEndian.Uint32(dir[i*12:]),
+			Size: binary.LittleEndian.Uint32(dir[i*12+4:]),
+			Rva:  binary.LittleEndian.Uint32(dir[i*12+8:]),
+		}
+	}
+
+	for _, s := range f.Streams {
+		var err error
+		switch s.Type {
+		case StreamThreadList:
+			err = f.parseThreadList(s)
+		case StreamModuleList:
+			err = f.parseModuleList(s)
+		case StreamMemoryList:
+			err = f.parseMemoryList(s)
+		case StreamMemory64List:
+			err = f.parseMemory64List(s)
+		case StreamException:
+			err = f.parseException(s)
+		case StreamSystemInfo:
+			err = f.parseSystemInfo(s)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Close releases resources associated with the minidump.
+func (f *File) Close() error {
+	if f.closer != nil {
+		return f.closer.Close()
+	}
+	return nil
+}
+
+// ReadMemory returns size bytes of captured process memory at addr.
+// ErrNoMemory is returned if the range was not captured.
+func (f *File) ReadMemory(addr uint64, size uint64) ([]byte, error) {
+	for _, m := range f.Memory {
+		if addr < m.Start || addr-m.Start >= m.Size {
+			continue
+		}
+		if size > m.Size-(addr-m.Start) {
+			return nil, ErrNoMemory
+		}
+		return f.read(m.rva+(addr-m.Start), size)
+	}
+	return nil, ErrNoMemory
+}
+
+// ModuleAt returns the module loaded at an address, or nil.
+func (f *File) ModuleAt(addr uint64) *Module {
+	for i := range f.Modules {
+		m := &f.Modules[i]
+		if addr >= m.BaseOfImage && addr-m.BaseOfImage < uint64(m.SizeOfImage) {
+			return m
+		}
+	}
+	return nil
+}
+
+// Thread returns the thread with the given id, or nil.
+func (f *File) Thread(id uint32) *Thread {
+	for i := range f.Threads {
+		if f.Threads[i].ID == id {
+			return &f.Threads[i]
+		}
+	}
+	return nil
+}
+
+// read reads n bytes at a file offset.
+func (f *File) read(rva uint64, n uint64) ([]byte, error) {
+	if rva > uint64(f.size) || n > uint64(f.size)-rva {
+		return nil, fmt.Errorf("minidump: read of %d bytes at 0x%x out of range", n, rva)
+	}
+
+	buf := make([]byte, n)
+	if _, err := f.r.ReadAt(buf, int64(rva)); err != nil {
+		return nil, fmt.Errorf("minidump: failed to read at 0x%x: %w", rva, err)
+	}
+	return buf, nil
+}
+
+// location is a MINIDUMP_LOCATION_DESCRIPTOR.
+type location struct {
+	size uint32
+	rva  uint32
+}
+
+func readLocation(data []byte) location {
+	return location{
+		size: binary.LittleEndian.Uint32(data[0:]),
+		rva:  binary.LittleEndian.Uint32(data[4:]),
+	}
+}
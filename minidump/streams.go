@@ -0,0 +1,304 @@
+package minidump
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// Thread is a MINIDUMP_THREAD.
+type Thread struct {
+	ID            uint32
+	SuspendCount  uint32
+	PriorityClass uint32
+	Priority      uint32
+	Teb           uint64
+	Stack         MemoryRange
+
+	context location
+}
+
+// MemoryRange is a range of captured process memory.
+type MemoryRange struct {
+	Start uint64
+	Size  uint64
+
+	rva uint64 // File offset of the captured bytes
+}
+
+// Module is a MINIDUMP_MODULE.
+type Module struct {
+	BaseOfImage   uint64
+	SizeOfImage   uint32
+	CheckSum      uint32
+	TimeDateStamp uint32
+	Name          string
+
+	// CodeView is the RSDS debug record identifying the module's PDB, or
+	// nil if the module has none.
+	CodeView *CodeViewInfo
+}
+
+// CodeViewInfo is the RSDS CodeView record of a module.
+type CodeViewInfo struct {
+	GUID    [16]byte
+	Age     uint32
+	PDBName string // As recorded by the linker, usually a full Windows path
+}
+
+// DebugID returns the identifier a symbol store uses for the PDB: the GUID
+// followed by the age, in upper case hexadecimal.
+func (cv *CodeViewInfo) DebugID() string {
+	g := cv.GUID
+	return fmt.Sprintf("%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%02X%X",
+		g[3], g[2], g[1], g[0], g[5], g[4], g[7], g[6],
+		g[8], g[9], g[10], g[11], g[12], g[13], g[14], g[15], cv.Age)
+}
+
+// Exception is the MINIDUMP_EXCEPTION_STREAM.
+type Exception struct {
+	ThreadID   uint32
+	Code       uint32
+	Flags      uint32
+	Record     uint64 // Address of a nested EXCEPTION_RECORD
+	Address    uint64
+	Parameters []uint64
+
+	context location
+}
+
+// Processor architectures (PROCESSOR_ARCHITECTURE_*).
+const (
+	ArchX86     = 0
+	ArchARM     = 5
+	ArchAMD64   = 9
+	ArchARM64   = 12
+	ArchUnknown = 0xffff
+)
+
+// SystemInfo is the MINIDUMP_SYSTEM_INFO stream.
+type SystemInfo struct {
+	ProcessorArchitecture uint16
+	ProcessorLevel        uint16
+	ProcessorRevision     uint16
+	NumberOfProcessors    uint8
+	ProductType           uint8
+	MajorVersion          uint32
+	MinorVersion          uint32
+	BuildNumber           uint32
+	PlatformID            uint32
+}
+
+const (
+	threadSize    = 48
+	moduleSize    = 108
+	rsdsSignature = 0x53445352 // "RSDS"
+)
+
+func (f *File) parseThreadList(s Stream) error {
+	data, err := f.read(uint64(s.Rva), uint64(s.Size))
+	if err != nil || len(data) < 4 {
+		return fmt.Errorf("minidump: invalid thread list stream")
+	}
+
+	count := binary.LittleEndian.Uint32(data)
+	if uint64(count)*threadSize > uint64(len(data)-4) {
+		return fmt.Errorf("minidump: thread list holds %d threads, stream too small", count)
+	}
+
+	f.Threads = make([]Thread, count)
+	for i := range f.Threads {
+		t := data[4+i*threadSize:]
+		stack := readLocation(t[32:])
+		f.Threads[i] = Thread{
+			ID:            binary.LittleEndian.Uint32(t[0:]),
+			SuspendCount:  binary.LittleEndian.Uint32(t[4:]),
+			PriorityClass: binary.LittleEndian.Uint32(t[8:]),
+			Priority:      binary.LittleEndian.Uint32(t[12:]),
+			Teb:           binary.LittleEndian.Uint64(t[16:]),
+			Stack: MemoryRange{
+				Start: binary.LittleEndian.Uint64(t[24:]),
+				Size:  uint64(stack.size),
+				rva:   uint64(stack.rva),
+			},
+			context: readLocation(t[40:]),
+		}
+	}
+
+	return nil
+}
+
+func (f *File) parseModuleList(s Stream) error {
+	data, err := f.read(uint64(s.Rva), uint64(s.Size))
+	if err != nil || len(data) < 4 {
+		return fmt.Errorf("minidump: invalid module list stream")
+	}
+
+	count := binary.LittleEndian.Uint32(data)
+	if uint64(count)*moduleSize > uint64(len(data)-4) {
+		return fmt.Errorf("minidump: module list holds %d modules, stream too small", count)
+	}
+
+	f.Modules = make([]Module, count)
+	for i := range f.Modules {
+		m := data[4+i*moduleSize:]
+		mod := Module{
+			BaseOfImage:   binary.LittleEndian.Uint64(m[0:]),
+			SizeOfImage:   binary.LittleEndian.Uint32(m[8:]),
+			CheckSum:      binary.LittleEndian.Uint32(m[12:]),
+			TimeDateStamp: binary.LittleEndian.Uint32(m[16:]),
+		}
+
+		// Names and CodeView records are optional; a bad one leaves the
+		// field empty rather than failing the whole dump
+		mod.Name, _ = f.readString(binary.LittleEndian.Uint32(m[20:]))
+		mod.CodeView = f.readCodeView(readLocation(m[76:]))
+
+		f.Modules[i] = mod
+	}
+
+	return nil
+}
+
+// readString reads a MINIDUMP_STRING.
+func (f *File) readString(rva uint32) (string, error) {
+	header, err := f.read(uint64(rva), 4)
+	if err != nil {
+		return "", err
+	}
+
+	length := binary.LittleEndian.Uint32(header)
+	data, err := f.read(uint64(rva)+4, uint64(length&^1))
+	if err != nil {
+		return "", err
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// readCodeView reads an RSDS CodeView record.
+func (f *File) readCodeView(loc location) *CodeViewInfo {
+	if loc.size < 24 {
+		return nil
+	}
+
+	data, err := f.read(uint64(loc.rva), uint64(loc.size))
+	if err != nil || binary.LittleEndian.Uint32(data) != rsdsSignature {
+		return nil
+	}
+
+	cv := &CodeViewInfo{Age: binary.LittleEndian.Uint32(data[20:])}
+	copy(cv.GUID[:], data[4:20])
+
+	name := data[24:]
+	for i, b := range name {
+		if b == 0 {
+			name = name[:i]
+			break
+		}
+	}
+	cv.PDBName = string(name)
+
+	return cv
+}
+
+func (f *File) parseMemoryList(s Stream) error {
+	data, err := f.read(uint64(s.Rva), uint64(s.Size))
+	if err != nil || len(data) < 4 {
+		return fmt.Errorf("minidump: invalid memory list stream")
+	}
+
+	count := binary.LittleEndian.Uint32(data)
+	if uint64(count)*16 > uint64(len(data)-4) {
+		return fmt.Errorf("minidump: memory list holds %d ranges, stream too small", count)
+	}
+
+	for i := 0; i < int(count); i++ {
+		d := data[4+i*16:]
+		loc := readLocation(d[8:])
+		f.Memory = append(f.Memory, MemoryRange{
+			Start: binary.LittleEndian.Uint64(d[0:]),
+			Size:  uint64(loc.size),
+			rva:   uint64(loc.rva),
+		})
+	}
+
+	return nil
+}
+
+// parseMemory64List parses the memory list of full dumps, whose ranges are
+// stored back to back from a single base offset.
+func (f *File) parseMemory64List(s Stream) error {
+	data, err := f.read(uint64(s.Rva), uint64(s.Size))
+	if err != nil || len(data) < 16 {
+		return fmt.Errorf("minidump: invalid memory64 list stream")
+	}
+
+	count := binary.LittleEndian.Uint64(data)
+	rva := binary.LittleEndian.Uint64(data[8:])
+	if count > uint64(len(data)-16)/16 {
+		return fmt.Errorf("minidump: memory64 list holds %d ranges, stream too small", count)
+	}
+
+	for i := 0; i < int(count); i++ {
+		d := data[16+i*16:]
+		r := MemoryRange{
+			Start: binary.LittleEndian.Uint64(d[0:]),
+			Size:  binary.LittleEndian.Uint64(d[8:]),
+			rva:   rva,
+		}
+		f.Memory = append(f.Memory, r)
+		rva += r.Size
+	}
+
+	return nil
+}
+
+func (f *File) parseException(s Stream) error {
+	data, err := f.read(uint64(s.Rva), uint64(s.Size))
+	if err != nil || len(data) < 168 {
+		return fmt.Errorf("minidump: invalid exception stream")
+	}
+
+	e := &Exception{
+		ThreadID: binary.LittleEndian.Uint32(data[0:]),
+		Code:     binary.LittleEndian.Uint32(data[8:]),
+		Flags:    binary.LittleEndian.Uint32(data[12:]),
+		Record:   binary.LittleEndian.Uint64(data[16:]),
+		Address:  binary.LittleEndian.Uint64(data[24:]),
+		context:  readLocation(data[160:]),
+	}
+
+	params := min(binary.LittleEndian.Uint32(data[32:]), 15)
+	e.Parameters = make([]uint64, params)
+	for i := range e.Parameters {
+		e.Parameters[i] = binary.LittleEndian.Uint64(data[40+i*8:])
+	}
+
+	f.Exception = e
+	return nil
+}
+
+func (f *File) parseSystemInfo(s Stream) error {
+	data, err := f.read(uint64(s.Rva), uint64(s.Size))
+	if err != nil || len(data) < 24 {
+		return fmt.Errorf("minidump: invalid system info stream")
+	}
+
+	f.SystemInfo = &SystemInfo{
+		ProcessorArchitecture: binary.LittleEndian.Uint16(data[0:]),
+		ProcessorLevel:        binary.LittleEndian.Uint16(data[2:]),
+		ProcessorRevision:     binary.LittleEndian.Uint16(data[4:]),
+		NumberOfProcessors:    data[6],
+		ProductType:           data[7],
+		MajorVersion:          binary.LittleEndian.Uint32(data[8:]),
+		MinorVersion:          binary.LittleEndian.Uint32(data[12:]),
+		BuildNumber:           binary.LittleEndian.Uint32(data[16:]),
+		PlatformID:            binary.LittleEndian.Uint32(data[20:]),
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package msf
+
+import (
+	"fmt"
+	"iter"
+)
+
+// BlockKind identifies what a block is used for.
+type BlockKind int
+
+const (
+	BlockNone         BlockKind = iota // Not in use; free or leaked
+	BlockSuperBlock                    // Block 0
+	BlockFreeBlockMap                  // Reserved for a free block map
+	BlockDirectoryMap                  // Holds the block indices of the directory
+	BlockDirectory                     // Holds the stream directory
+	BlockStream                        // Holds stream data
+)
+
+func (k BlockKind) String() string {
+	switch k {
+	case BlockNone:
+		return "none"
+	case BlockSuperBlock:
+		return "superblock"
+	case BlockFreeBlockMap:
+		return "free block map"
+	case BlockDirectoryMap:
+		return "directory block map"
+	case BlockDirectory:
+		return "directory"
+	case BlockStream:
+		return "stream"
+	default:
+		return fmt.Sprintf("BlockKind(%d)", int(k))
+	}
+}
+
+// BlockOwner describes the use of a block.
+type BlockOwner struct {
+	Kind   BlockKind
+	Stream uint32 // The stream index, if Kind is BlockStream
+}
+
+func (o BlockOwner) String() string {
+	if o.Kind == BlockStream {
+		return fmt.Sprintf("stream %d", o.Stream)
+	}
+	return o.Kind.String()
+}
+
+// BlockUses returns an iterator over the uses of blocks: the superblock,
+// the blocks reserved for free block maps, the directory's block map and
+// blocks, and then the blocks of each stream in order. Uses are yielded as
+// the file records them, so in a corrupt file a block may be yielded twice,
+// and a stream may use blocks past the end of the file.
+func (f *File) BlockUses() (iter.Seq2[uint32, BlockOwner], error) {
+	dir, err := f.Directory()
+	if err != nil {
+		return nil, err
+	}
+
+	sb := f.superBlock
+	return func(yield func(uint32, BlockOwner) bool) {
+		if !yield(0, BlockOwner{Kind: BlockSuperBlock}) {
+			return
+		}
+		for block := uint32(1); block < sb.NumBlocks; block++ {
+			if sb.IsFreeBlockMapBlock(block) && !yield(block, BlockOwner{Kind: BlockFreeBlockMap}) {
+				return
+			}
+		}
+		for i := uint32(0); i < sb.NumBlockMapBlocks(); i++ {
+			if !yield(sb.BlockMapAddr+i, BlockOwner{Kind: BlockDirectoryMap}) {
+				return
+			}
+		}
+		for _, block := range dir.Blocks {
+			if !yield(block, BlockOwner{Kind: BlockDirectory}) {
+				return
+			}
+		}
+		for i, blocks := range dir.StreamBlocks {
+			for _, block := range blocks {
+				if !yield(block, BlockOwner{Kind: BlockStream, Stream: uint32(i)}) {
+					return
+				}
+			}
+		}
+	}, nil
+}
+
+// BlockOwner returns the use of a block. If a corrupt file uses a block
+// twice, the first use given by BlockUses is returned. Whether a block not
+// in use is free is recorded in the FreeBlockMap.
+func (f *File) BlockOwner(block uint32) (BlockOwner, error) {
+	owners, err := f.blockOwners()
+	if err != nil {
+		return BlockOwner{}, err
+	}
+	if block >= uint32(len(owners)) {
+		return BlockOwner{}, fmt.Errorf("%w: %d >= %d", ErrInvalidBlockIndex, block, len(owners))
+	}
+	return owners[block], nil
+}
+
+// blockOwners returns the owner of each block, building the table on first
+// use.
+func (f *File) blockOwners() ([]BlockOwner, error) {
+	f.ownersOnce.Do(func() {
+		uses, err := f.BlockUses()
+		if err != nil {
+			f.ownersErr = err
+			return
+		}
+
+		owners := make([]BlockOwner, f.superBlock.NumBlocks)
+		for block, owner := range uses {
+			if block < uint32(len(owners)) && owners[block].Kind == BlockNone {
+				owners[block] = owner
+			}
+		}
+		f.owners = owners
+	})
+	return f.owners, f.ownersErr
+}
+
+// FreeBlocks returns the blocks marked free in the active free block map.
+func (f *File) FreeBlocks() ([]uint32, error) {
+	fpm, err := f.FreeBlockMap()
+	if err != nil {
+		return nil, err
+	}
+	return fpm.FreeBlocks(), nil
+}
+
+// Extent is a run of consecutive blocks.
+type Extent struct {
+	First uint32
+	Count uint32
+}
+
+// StreamLayout describes where the blocks of a stream are.
+type StreamLayout struct {
+	Stream  uint32
+	Size    uint32
+	Extents []Extent // In stream order
+}
+
+// Fragmentation describes how the blocks of a file are allocated.
+type Fragmentation struct {
+	NumBlocks uint32
+
+	// FreeBlocks counts the blocks not in use and marked free in the active
+	// free block map, and UnusedBlocks those that are not marked free.
+	FreeBlocks   uint32
+	UnusedBlocks uint32
+
+	// FreeExtents are the runs of free blocks, in file order.
+	FreeExtents []Extent
+
+	// Streams holds the layout of each stream that has blocks.
+	Streams []StreamLayout
+}
+
+// FragmentedStreams returns the number of streams split over more than one
+// extent.
+func (fr *Fragmentation) FragmentedStreams() int {
+	count := 0
+	for _, s := range fr.Streams {
+		if len(s.Extents) > 1 {
+			count++
+		}
+	}
+	return count
+}
+
+// LargestFreeExtent returns the longest run of free blocks.
+func (fr *Fragmentation) LargestFreeExtent() Extent {
+	var largest Extent
+	for _, e := range fr.FreeExtents {
+		if e.Count > largest.Count {
+			largest = e
+		}
+	}
+	return largest
+}
+
+// Fragmentation reports the free blocks and the extents of each stream.
+func (f *File) Fragmentation() (*Fragmentation, error) {
+	dir, err := f.Directory()
+	if err != nil {
+		return nil, err
+	}
+	owners, err := f.blockOwners()
+	if err != nil {
+		return nil, err
+	}
+	fpm, err := f.FreeBlockMap()
+	if err != nil {
+		return nil, err
+	}
+
+	fr := &Fragmentation{NumBlocks: f.superBlock.NumBlocks}
+	var free []uint32
+	for block, owner := range owners {
+		if owner.Kind != BlockNone {
+			continue
+		}
+		if fpm.IsFree(uint32(block)) {
+			free = append(free, uint32(block))
+		} else {
+			fr.UnusedBlocks++
+		}
+	}
+	fr.FreeBlocks = uint32(len(free))
+	fr.FreeExtents = extents(free)
+
+	for i, blocks := range dir.StreamBlocks {
+		if len(blocks) == 0 {
+			continue
+		}
+		fr.Streams = append(fr.Streams, StreamLayout{
+			Stream:  uint32(i),
+			Size:    dir.StreamSizes[i],
+			Extents: extents(blocks),
+		})
+	}
+	return fr, nil
+}
+
+// extents merges blocks that follow each other into extents.
+func extents(blocks []uint32) []Extent {
+	var result []Extent
+	for _, block := range blocks {
+		if n := len(result); n > 0 && result[n-1].First+result[n-1].Count == block {
+			result[n-1].Count++
+			continue
+		}
+		result = append(result, Extent{First: block, Count: 1})
+	}
+	return result
+}
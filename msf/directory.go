@@ -40,6 +40,10 @@ type StreamDirectory struct {
 	// StreamBlocks is a jagged array where StreamBlocks[i] contains
 	// the block indices for stream i. For nil streams, this will be nil.
 	StreamBlocks [][]uint32
+
+	// Blocks holds the block indices of the directory itself. It is set by
+	// DirectoryReader.ReadDirectory.
+	Blocks []uint32
 }
 
 // ParseDirectory reads the stream directory from the given byte slice.
@@ -154,18 +158,20 @@ func (dr *DirectoryReader) ReadDirectory() (*StreamDirectory, error) {
 	}
 
 	// Parse the directory
-	return ParseDirectory(directoryData, dr.sb.BlockSize)
+	dir, err := ParseDirectory(directoryData, dr.sb.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	dir.Blocks = blockMapBytes
+	return dir, nil
 }
 
 // readBlockMap reads the array of block indices that make up the stream directory.
 func (dr *DirectoryReader) readBlockMap() ([]uint32, error) {
 	numDirectoryBlocks := dr.sb.NumDirectoryBlocks()
 
-	// Calculate how many bytes the block map takes
-	blockMapSize := numDirectoryBlocks * 4
-
 	// Calculate how many blocks the block map spans
-	numBlockMapBlocks := (blockMapSize + dr.sb.BlockSize - 1) / dr.sb.BlockSize
+	numBlockMapBlocks := dr.sb.NumBlockMapBlocks()
 
 	// Read block indices from BlockMapAddr
 	blockMapData := make([]byte, numBlockMapBlocks*dr.sb.BlockSize)
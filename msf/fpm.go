@@ -0,0 +1,85 @@
+package msf
+
+import "fmt"
+
+// FreeBlockMap is a decoded free block map (FPM), which records whether
+// each block of the file is free.
+//
+// The map holds one bit per block, set if the block is free. It does not
+// live in a stream: its bytes are split over the intervals of BlockSize
+// blocks, and the k-th BlockSize bytes are stored in block 1 or 2 of the
+// k-th interval. A file has two maps: the active one, in the blocks given by
+// SuperBlock.FreeBlockMapBlock, and the inactive one in the other blocks,
+// which a writer updates before switching to it.
+type FreeBlockMap struct {
+	bits      []byte
+	numBlocks uint32
+}
+
+// IsFree reports whether a block is marked free. Blocks past the end of
+// the file are never free.
+func (m *FreeBlockMap) IsFree(block uint32) bool {
+	if block >= m.numBlocks {
+		return false
+	}
+	return m.bits[block/8]&(1<<(block%8)) != 0
+}
+
+// NumBlocks returns the number of blocks the map covers.
+func (m *FreeBlockMap) NumBlocks() uint32 {
+	return m.numBlocks
+}
+
+// FreeBlocks returns the blocks marked free, in order.
+func (m *FreeBlockMap) FreeBlocks() []uint32 {
+	var blocks []uint32
+	for block := uint32(0); block < m.numBlocks; block++ {
+		if m.IsFree(block) {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// FreeBlockMap reads the active free block map.
+func (f *File) FreeBlockMap() (*FreeBlockMap, error) {
+	return f.readFreeBlockMap(f.superBlock.FreeBlockMapBlock)
+}
+
+// InactiveFreeBlockMap reads the inactive free block map. It records the
+// allocation before the last update of the file, or is left unwritten.
+func (f *File) InactiveFreeBlockMap() (*FreeBlockMap, error) {
+	return f.readFreeBlockMap(3 - f.superBlock.FreeBlockMapBlock)
+}
+
+// freeBlockMapBlocks returns the blocks holding the free block map that
+// starts at block fpm, in order.
+func (f *File) freeBlockMapBlocks(fpm uint32) []uint32 {
+	sb := f.superBlock
+	bitsPerBlock := sb.BlockSize * 8
+	n := (sb.NumBlocks + bitsPerBlock - 1) / bitsPerBlock
+
+	blocks := make([]uint32, n)
+	for k := range blocks {
+		blocks[k] = uint32(k)*sb.BlockSize + fpm
+	}
+	return blocks
+}
+
+func (f *File) readFreeBlockMap(fpm uint32) (*FreeBlockMap, error) {
+	sb := f.superBlock
+	blocks := f.freeBlockMapBlocks(fpm)
+
+	bits := make([]byte, len(blocks)*int(sb.BlockSize))
+	for k, block := range blocks {
+		if block >= sb.NumBlocks {
+			return nil, fmt.Errorf("%w: free block map block %d >= %d", ErrInvalidBlockIndex, block, sb.NumBlocks)
+		}
+		chunk := bits[k*int(sb.BlockSize) : (k+1)*int(sb.BlockSize)]
+		if _, err := f.data.ReadAt(chunk, sb.BlockOffset(block)); err != nil {
+			return nil, fmt.Errorf("msf: failed to read free block map block %d: %w", block, err)
+		}
+	}
+
+	return &FreeBlockMap{bits: bits, numBlocks: sb.NumBlocks}, nil
+}
@@ -6,14 +6,17 @@ import (
 	"io"
 	"os"
 	"sync"
+
+	"github.com/skdltmxn/pdb-go/internal/mmap"
 )
 
 // File represents an opened MSF file.
 // It provides access to the underlying streams in a thread-safe manner.
 type File struct {
-	data      io.ReaderAt
-	closer    io.Closer // may be nil if data doesn't need closing
-	size      int64
+	data       io.ReaderAt
+	closer     io.Closer // may be nil if data doesn't need closing
+	mapped     []byte    // The whole file if it is memory-mapped
+	size       int64
 	superBlock *SuperBlock
 	directory  *StreamDirectory
 
@@ -21,10 +24,19 @@ type File struct {
 	dirOnce sync.Once
 	dirErr  error
 
+	// Owner of each block, built by BlockOwner
+	owners     []BlockOwner
+	ownersOnce sync.Once
+	ownersErr  error
+
+	// Assembled blocks of mapped streams, by stream index
+	assemblies map[uint32]*assembly
+
 	mu sync.RWMutex
 }
 
-// Open opens an MSF file from the given path.
+// Open opens an MSF file from the given path and reads it with ReadAt.
+// Stream data is copied into memory, and stays valid after Close.
 func Open(path string) (*File, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -47,6 +59,33 @@ func Open(path string) (*File, error) {
 	return msf, nil
 }
 
+// OpenMapped opens an MSF file from the given path and, on Linux,
+// memory-maps it, so stream data stays in the page cache: ReadStream returns
+// streams whose blocks are contiguous without copying them. Slices of the
+// mapping must not be used after Close; accessing them then faults. Where
+// mapping is not supported, or if the file cannot be mapped, OpenMapped
+// falls back to Open.
+func OpenMapped(path string) (*File, error) {
+	if !mmap.Supported {
+		return Open(path)
+	}
+
+	m, err := mmap.Open(path)
+	if err != nil {
+		return Open(path)
+	}
+
+	msf, err := NewFile(bytes.NewReader(m.Bytes()), int64(m.Len()))
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+
+	msf.mapped = m.Bytes()
+	msf.closer = m
+	return msf, nil
+}
+
 // NewFile creates an MSF file from an io.ReaderAt.
 // This allows reading from arbitrary sources (embedded, network, etc.)
 // The caller is responsible for closing the underlying reader if needed.
@@ -79,7 +118,9 @@ func NewFile(r io.ReaderAt, size int64) (*File, error) {
 	}, nil
 }
 
-// Close releases resources associated with the MSF file.
+// Close releases resources associated with the MSF file. If the file is
+// memory-mapped, it is unmapped, and slices of the mapping returned by
+// ReadStream and Stream.Slice become invalid.
 func (f *File) Close() error {
 	if f.closer != nil {
 		return f.closer.Close()
@@ -87,6 +128,11 @@ func (f *File) Close() error {
 	return nil
 }
 
+// Mapped reports whether the file is memory-mapped.
+func (f *File) Mapped() bool {
+	return f.mapped != nil
+}
+
 // SuperBlock returns the MSF superblock.
 func (f *File) SuperBlock() *SuperBlock {
 	return f.superBlock
@@ -151,11 +197,41 @@ func (f *File) OpenStream(streamIndex uint32) (*Stream, error) {
 	}
 
 	blocks := dir.StreamBlocks[streamIndex]
-	return NewStream(f.data, blocks, f.superBlock.BlockSize, size), nil
+	stream := NewStream(f.data, blocks, f.superBlock.BlockSize, size)
+	if f.mapped != nil {
+		stream.mapped = f.mapped
+		stream.assembly = f.assembly(streamIndex)
+	}
+	return stream, nil
+}
+
+// assembly returns the assembled blocks of a mapped stream, shared by the
+// streams opened for the index.
+func (f *File) assembly(streamIndex uint32) *assembly {
+	f.mu.RLock()
+	a := f.assemblies[streamIndex]
+	f.mu.RUnlock()
+	if a != nil {
+		return a
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if a = f.assemblies[streamIndex]; a == nil {
+		if f.assemblies == nil {
+			f.assemblies = make(map[uint32]*assembly)
+		}
+		a = &assembly{}
+		f.assemblies[streamIndex] = a
+	}
+	return a
 }
 
 // ReadStream reads an entire stream into memory.
-// This is a convenience method for smaller streams.
+// This is a convenience method for smaller streams. If the file is
+// memory-mapped, the returned slice refers to the mapping if the stream's
+// blocks are contiguous, or else to a copy the file assembles once and
+// returns again on later calls; it must not be modified.
 func (f *File) ReadStream(streamIndex uint32) ([]byte, error) {
 	stream, err := f.OpenStream(streamIndex)
 	if err != nil {
@@ -3,16 +3,26 @@ package msf
 import (
 	"fmt"
 	"io"
+	"sync"
 )
 
 // Stream provides sequential reading across non-contiguous blocks.
 // It implements io.Reader, io.Seeker, and io.ReaderAt interfaces.
+// ReadAt, Bytes and Slice may be called from several goroutines; Read and
+// Seek share the stream's position and may not.
 type Stream struct {
 	data       io.ReaderAt
 	blocks     []uint32
 	blockSize  uint32
 	streamSize uint32
 
+	// The whole file, if it is memory-mapped
+	mapped []byte
+
+	// Blocks of a mapped stream assembled for slices across non-consecutive
+	// blocks, shared by the streams the file opens for the same index
+	assembly *assembly
+
 	// Current position for Read/Seek
 	pos uint32
 }
@@ -150,14 +160,125 @@ func (s *Stream) Remaining() uint32 {
 }
 
 // Bytes reads the entire stream into a byte slice.
-// This is useful for smaller streams that fit in memory.
+// This is useful for smaller streams that fit in memory. If the file is
+// memory-mapped and the stream's blocks are contiguous, the slice refers to
+// the mapping instead of a copy.
 func (s *Stream) Bytes() ([]byte, error) {
-	data := make([]byte, s.streamSize)
-	n, err := s.ReadAt(data, 0)
+	return s.Slice(0, s.streamSize)
+}
+
+// Slice returns n bytes of the stream starting at off. If the file is
+// memory-mapped, a range in consecutive blocks refers to the mapping; other
+// ranges are assembled from the mapping a block at a time as they are
+// requested, into a buffer the file keeps for the stream and shares between
+// its slices, so blocks are not copied twice. Neither must be modified. If the file is not mapped, the
+// range is copied. A range past the end of the stream is shortened.
+func (s *Stream) Slice(off, n uint32) ([]byte, error) {
+	if off > s.streamSize {
+		return nil, io.EOF
+	}
+	if n > s.streamSize-off {
+		n = s.streamSize - off
+	}
+	if data, ok := s.mappedRange(off, n); ok {
+		return data, nil
+	}
+	if s.mapped != nil && n > 0 {
+		return s.assemble(off, n)
+	}
+
+	data := make([]byte, n)
+	read, err := s.ReadAt(data, int64(off))
 	if err != nil && err != io.EOF {
 		return nil, err
 	}
-	return data[:n], nil
+	return data[:read], nil
+}
+
+// assembly holds the blocks of a mapped stream copied in stream order,
+// once a slice across non-consecutive blocks needed them.
+type assembly struct {
+	mu     sync.Mutex
+	data   []byte
+	filled []bool
+}
+
+// assemble copies the blocks of a range of a mapped stream that are not
+// assembled yet, and returns the range. The buffer for the whole stream is
+// allocated on first use; pages of it holding blocks never requested are
+// not touched.
+func (s *Stream) assemble(off, n uint32) ([]byte, error) {
+	a := s.assembly
+	if a == nil {
+		a = &assembly{}
+		s.assembly = a
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.data == nil {
+		a.data = make([]byte, s.streamSize)
+		a.filled = make([]bool, len(s.blocks))
+	}
+
+	first := off / s.blockSize
+	last := (off + n - 1) / s.blockSize
+	if int(last) >= len(s.blocks) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	for i := first; i <= last; i++ {
+		if a.filled[i] {
+			continue
+		}
+		start := int64(s.blocks[i]) * int64(s.blockSize)
+		size := min(s.blockSize, s.streamSize-i*s.blockSize)
+		if start+int64(size) > int64(len(s.mapped)) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		copy(a.data[i*s.blockSize:], s.mapped[start:start+int64(size)])
+		a.filled[i] = true
+	}
+
+	end := off + n
+	return a.data[off:end:end], nil
+}
+
+// mappedRange returns the mapped bytes of a range of the stream if they
+// lie in consecutive blocks.
+func (s *Stream) mappedRange(off, n uint32) ([]byte, bool) {
+	if s.mapped == nil || n == 0 {
+		return nil, false
+	}
+
+	first := off / s.blockSize
+	last := (off + n - 1) / s.blockSize
+	if int(last) >= len(s.blocks) {
+		return nil, false
+	}
+	for i := first; i < last; i++ {
+		if s.blocks[i+1] != s.blocks[i]+1 {
+			return nil, false
+		}
+	}
+
+	start := int64(s.blocks[first])*int64(s.blockSize) + int64(off%s.blockSize)
+	end := start + int64(n)
+	if end > int64(len(s.mapped)) {
+		return nil, false
+	}
+	// Limit the capacity so appending to the slice copies it
+	return s.mapped[start:end:end], true
+}
+
+// Contiguous reports whether the stream's blocks are consecutive in the
+// file, so that Bytes of a memory-mapped file does not copy the stream.
+func (s *Stream) Contiguous() bool {
+	for i := 1; i < len(s.blocks); i++ {
+		if s.blocks[i] != s.blocks[i-1]+1 {
+			return false
+		}
+	}
+	return true
 }
 
 // Reset resets the stream position to the beginning.
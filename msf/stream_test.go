@@ -0,0 +1,92 @@
+package msf
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+// mappedFile returns a memory-mapped file, as OpenMapped would, holding one
+// stream whose blocks are out of order, and the stream's data.
+func mappedFile() (*File, []byte) {
+	const blockSize = 512
+	blocks := []uint32{3, 1, 4, 2}
+	size := uint32(len(blocks)-1)*blockSize + 100
+
+	mapped := make([]byte, 5*blockSize)
+	want := make([]byte, size)
+	for i := range want {
+		want[i] = byte(i * 7)
+	}
+	for i, b := range blocks {
+		copy(mapped[b*blockSize:(b+1)*blockSize], want[min(i*blockSize, len(want)):min((i+1)*blockSize, len(want))])
+	}
+
+	f := &File{
+		data:       bytes.NewReader(mapped),
+		mapped:     mapped,
+		size:       int64(len(mapped)),
+		superBlock: &SuperBlock{BlockSize: blockSize, NumBlocks: 5},
+	}
+	f.dirOnce.Do(func() {
+		f.directory = &StreamDirectory{
+			NumStreams:   1,
+			StreamSizes:  []uint32{size},
+			StreamBlocks: [][]uint32{blocks},
+		}
+	})
+	return f, want
+}
+
+// TestSliceConcurrent slices a non-contiguous stream of a mapped file from
+// several goroutines, each through its own Stream, and checks that its
+// blocks are assembled once per file.
+func TestSliceConcurrent(t *testing.T) {
+	f, want := mappedFile()
+
+	var wg sync.WaitGroup
+	results := make([][]byte, 8)
+	for g := range results {
+		wg.Go(func() {
+			s, err := f.OpenStream(0)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			// Each goroutine assembles the blocks in a different order
+			for i := range 4 {
+				off := uint32((g+i)%4)*f.BlockSize() + 10
+				if _, err := s.Slice(off, 600); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			if results[g], err = s.Bytes(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	for g, got := range results {
+		if !bytes.Equal(got, want) {
+			t.Errorf("goroutine %d read different data", g)
+		}
+		if len(got) > 0 && len(results[0]) > 0 && &got[0] != &results[0][0] {
+			t.Errorf("goroutine %d assembled its own copy", g)
+		}
+	}
+
+	// A range in consecutive blocks refers to the mapping
+	s, err := f.OpenStream(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Slice(2*f.BlockSize(), 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &got[0] != &f.mapped[4*f.BlockSize()] {
+		t.Error("slice in one block copied")
+	}
+}
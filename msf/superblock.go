@@ -118,6 +118,20 @@ func (sb *SuperBlock) NumDirectoryBlocks() uint32 {
 	return (sb.NumDirectoryBytes + sb.BlockSize - 1) / sb.BlockSize
 }
 
+// NumBlockMapBlocks returns the number of blocks, starting at BlockMapAddr,
+// that hold the block indices of the stream directory.
+func (sb *SuperBlock) NumBlockMapBlocks() uint32 {
+	return (sb.NumDirectoryBlocks()*4 + sb.BlockSize - 1) / sb.BlockSize
+}
+
+// IsFreeBlockMapBlock reports whether a block is reserved for a free block
+// map. Blocks 1 and 2 of every interval of BlockSize blocks are reserved,
+// whether or not the map needs them.
+func (sb *SuperBlock) IsFreeBlockMapBlock(blockNum uint32) bool {
+	i := blockNum % sb.BlockSize
+	return i == 1 || i == 2
+}
+
 // FileSize returns the expected file size based on NumBlocks and BlockSize.
 func (sb *SuperBlock) FileSize() int64 {
 	return int64(sb.NumBlocks) * int64(sb.BlockSize)
@@ -0,0 +1,102 @@
+package pdb_test
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+var benchPDB = flag.String("pdb", filepath.Join("..", "testdata", "x64.pdb"), "PDB file read by the benchmarks")
+
+// BenchmarkOpenModes runs each workload over a freshly opened file in every
+// open mode. The fixture is small; pass -pdb with a large PDB to compare the
+// bytes allocated per operation, which are dominated by the streams read
+// whole in the default mode.
+func BenchmarkOpenModes(b *testing.B) {
+	modes := []struct {
+		name string
+		opts pdb.OpenOptions
+	}{
+		{"read", pdb.OpenOptions{}},
+		{"windowed", pdb.OpenOptions{WindowedStreams: true}},
+		{"mmap", pdb.OpenOptions{Mmap: true}},
+		{"mmap-windowed", pdb.OpenOptions{Mmap: true, WindowedStreams: true}},
+	}
+	workloads := []struct {
+		name string
+		run  func(*pdb.File) (int, error)
+	}{
+		{"publics", benchPublics},
+		{"modules", benchModules},
+		{"types", benchTypes},
+	}
+
+	for _, w := range workloads {
+		for _, m := range modes {
+			b.Run(w.name+"/"+m.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					f, err := pdb.OpenWithOptions(*benchPDB, m.opts)
+					if err != nil {
+						b.Fatal(err)
+					}
+					n, err := w.run(f)
+					f.Close()
+					if err != nil {
+						b.Fatal(err)
+					}
+					if n == 0 {
+						b.Fatalf("no %s in %s", w.name, *benchPDB)
+					}
+				}
+			})
+		}
+	}
+}
+
+func benchPublics(f *pdb.File) (int, error) {
+	st, err := f.Symbols()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for range st.Public() {
+		n++
+	}
+	return n, nil
+}
+
+func benchModules(f *pdb.File) (int, error) {
+	modules, err := f.Modules()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, mod := range modules {
+		for range mod.Symbols() {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// benchTypes iterates all types, then looks each one up by index, which
+// reads it from the file the first time in windowed mode.
+func benchTypes(f *pdb.File) (int, error) {
+	tt, err := f.Types()
+	if err != nil {
+		return 0, err
+	}
+	var indexes []pdb.TypeIndex
+	for t := range tt.All() {
+		indexes = append(indexes, t.Index())
+	}
+	for _, ti := range indexes {
+		if _, err := tt.ByIndex(ti); err != nil {
+			return 0, err
+		}
+	}
+	return len(indexes), nil
+}
@@ -0,0 +1,110 @@
+package pdb
+
+import (
+	"github.com/skdltmxn/pdb-go/internal/dbi"
+	"github.com/skdltmxn/pdb-go/internal/indexcache"
+	"github.com/skdltmxn/pdb-go/internal/tpi"
+	"github.com/skdltmxn/pdb-go/msf"
+)
+
+// indexCache returns the index cache of the PDB, or nil if the file was
+// opened without an index cache directory or its layout cannot be read.
+func (f *File) indexCache() *indexcache.Cache {
+	f.cacheOnce.Do(func() {
+		if f.opts.IndexCacheDir == "" {
+			return
+		}
+		info, err := f.Info()
+		if err != nil {
+			return
+		}
+		fingerprint, err := f.fingerprint()
+		if err != nil {
+			return
+		}
+		f.cache = indexcache.New(f.opts.IndexCacheDir,
+			indexcache.Key{GUID: info.GUID, Age: info.Age, Fingerprint: fingerprint})
+	})
+	return f.cache
+}
+
+// fingerprint returns the fingerprint of the PDB's stream sizes and of the
+// DBI and TPI headers, which describe the streams the indexes are built
+// from. Only the directory and the headers are read.
+func (f *File) fingerprint() (uint32, error) {
+	dir, err := f.msf.Directory()
+	if err != nil {
+		return 0, err
+	}
+
+	var headers [][]byte
+	for _, h := range []struct {
+		index uint32
+		size  uint32
+	}{
+		{msf.StreamDBI, dbi.DBIHeaderSize},
+		{msf.StreamTPI, tpi.TPIHeaderSize},
+	} {
+		if exists, err := f.msf.StreamExists(h.index); err != nil || !exists {
+			continue
+		}
+		s, err := f.msf.OpenStream(h.index)
+		if err != nil {
+			return 0, err
+		}
+		data, err := s.Slice(0, h.size)
+		if err != nil {
+			return 0, err
+		}
+		headers = append(headers, data)
+	}
+	return indexcache.Fingerprint(dir.StreamSizes, headers...), nil
+}
+
+// cachedIndex is an index in the PDB's index cache. Its methods do nothing
+// on a nil cachedIndex, which stands for a PDB opened without an index
+// cache.
+type cachedIndex struct {
+	f     *File
+	cache *indexcache.Cache
+	kind  indexcache.Kind
+}
+
+// cachedIndex returns the cached index of the given kind, or nil if the PDB
+// has no index cache.
+func (f *File) cachedIndex(kind indexcache.Kind) *cachedIndex {
+	cache := f.indexCache()
+	if cache == nil {
+		return nil
+	}
+	return &cachedIndex{f: f, cache: cache, kind: kind}
+}
+
+// load returns the cached index, or nil if it is missing or stale. The
+// index stays mapped until the file is closed.
+func (c *cachedIndex) load() []byte {
+	if c == nil {
+		return nil
+	}
+	data, m, err := c.cache.Load(c.kind)
+	if err != nil {
+		return nil
+	}
+
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	if c.f.closed {
+		m.Close()
+		return nil
+	}
+	c.f.mappings = append(c.f.mappings, m)
+	return data
+}
+
+// store writes a newly built index to the cache. Failures are ignored: the
+// index is rebuilt the next time instead.
+func (c *cachedIndex) store(data []byte) {
+	if c != nil {
+		c.cache.Store(c.kind, data)
+	}
+}
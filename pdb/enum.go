@@ -0,0 +1,227 @@
+package pdb
+
+import (
+	"iter"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/skdltmxn/pdb-go/internal/tpi"
+)
+
+// Enumerator represents a named value of an enum type.
+type Enumerator struct {
+	Name     string
+	Value    uint64    // Raw value, sign-extended to 64 bits if IsSigned
+	IsSigned bool      // True if the enum's underlying type is signed
+	EnumType TypeIndex // The enum that declares this enumerator
+	EnumName string    // Name of the enum
+}
+
+// SignedValue returns the value interpreted as a signed integer.
+func (e *Enumerator) SignedValue() int64 { return int64(e.Value) }
+
+// ValueString returns the value formatted as a decimal number, honoring
+// the signedness of the underlying type.
+func (e *Enumerator) ValueString() string {
+	if e.IsSigned {
+		return strconv.FormatInt(int64(e.Value), 10)
+	}
+	return strconv.FormatUint(e.Value, 10)
+}
+
+// Enumerators returns the enumerators of an enum type in declaration order.
+// Forward references are resolved to their complete definition.
+func (tt *TypeTable) Enumerators(typeIndex TypeIndex) ([]*Enumerator, error) {
+	typ, err := tt.Resolve(typeIndex)
+	if err != nil {
+		return nil, err
+	}
+	enum, ok := typ.(*EnumType)
+	if !ok {
+		return nil, ErrTypeNotFound
+	}
+	return tt.enumerators(enum)
+}
+
+func (tt *TypeTable) enumerators(enum *EnumType) ([]*Enumerator, error) {
+	fieldList, err := tt.fieldListMembers(tpi.TypeIndex(enum.fieldList))
+	if err != nil {
+		return nil, err
+	}
+
+	var size uint64
+	var signed bool
+	if underlying, err := tt.ByIndex(enum.underlyingType); err == nil {
+		size = underlying.Size()
+		if p, ok := underlying.(*PrimitiveType); ok {
+			signed = p.IsSigned()
+		}
+	}
+
+	var enumerators []*Enumerator
+	for _, member := range fieldList {
+		if e, ok := member.(*tpi.EnumerateRecord); ok {
+			enumerators = append(enumerators, &Enumerator{
+				Name:     e.Name,
+				Value:    normalizeEnumValue(e.Value, size, signed),
+				IsSigned: signed,
+				EnumType: enum.index,
+				EnumName: enum.name,
+			})
+		}
+	}
+
+	return enumerators, nil
+}
+
+// normalizeEnumValue truncates a value to the size of the underlying type,
+// then sign- or zero-extends it back to 64 bits.
+func normalizeEnumValue(value, size uint64, signed bool) uint64 {
+	if size == 0 || size >= 8 {
+		return value
+	}
+	shift := 64 - size*8
+	if signed {
+		return uint64(int64(value<<shift) >> shift)
+	}
+	return value << shift >> shift
+}
+
+// FormatEnumValue returns the symbolic form of a value of the given enum type.
+// An exact match returns the enumerator name. For flag-style enums (every
+// non-zero enumerator is a single bit or a combination of other enumerators)
+// the value is decomposed into "A | B", with any unmatched bits appended in
+// hex. Otherwise the value is returned as a number.
+func (tt *TypeTable) FormatEnumValue(typeIndex TypeIndex, value uint64) (string, error) {
+	enumerators, err := tt.Enumerators(typeIndex)
+	if err != nil {
+		return "", err
+	}
+
+	var size uint64
+	var signed bool
+	if len(enumerators) > 0 {
+		signed = enumerators[0].IsSigned
+	}
+	if typ, err := tt.Resolve(typeIndex); err == nil {
+		size = typ.Size()
+	}
+	value = normalizeEnumValue(value, size, signed)
+
+	for _, e := range enumerators {
+		if e.Value == value {
+			return e.Name, nil
+		}
+	}
+
+	if isFlagEnum(enumerators) && value != 0 {
+		// Prefer wider masks so combined names are used where possible
+		candidates := slices.Clone(enumerators)
+		slices.SortStableFunc(candidates, func(a, b *Enumerator) int {
+			return bits.OnesCount64(b.Value) - bits.OnesCount64(a.Value)
+		})
+
+		var names []string
+		remaining := value
+		for _, e := range candidates {
+			if e.Value != 0 && remaining&e.Value == e.Value {
+				names = append(names, e.Name)
+				remaining &^= e.Value
+			}
+		}
+		if len(names) > 0 {
+			if remaining != 0 {
+				names = append(names, "0x"+strconv.FormatUint(remaining, 16))
+			}
+			return strings.Join(names, " | "), nil
+		}
+	}
+
+	if signed {
+		return strconv.FormatInt(int64(value), 10), nil
+	}
+	return strconv.FormatUint(value, 10), nil
+}
+
+// isFlagEnum reports whether every non-zero enumerator is a single bit or
+// is made up entirely of bits that other single-bit enumerators define.
+func isFlagEnum(enumerators []*Enumerator) bool {
+	var singleBits uint64
+	for _, e := range enumerators {
+		if e.Value != 0 && bits.OnesCount64(e.Value) == 1 {
+			singleBits |= e.Value
+		}
+	}
+	if singleBits == 0 {
+		return false
+	}
+
+	for _, e := range enumerators {
+		if e.Value&^singleBits != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// FindEnumerator searches all enums for enumerators with the given name.
+// Supports both simple names ("Red") and qualified names ("Color::Red").
+// Uses a cached index for O(1) lookup after the first call.
+func (tt *TypeTable) FindEnumerator(name string) iter.Seq[*Enumerator] {
+	return func(yield func(*Enumerator) bool) {
+		tt.buildEnumeratorIndex()
+
+		enumName := ""
+		if idx := strings.LastIndex(name, "::"); idx > 0 {
+			enumName = name[:idx]
+			name = name[idx+2:]
+		}
+
+		for _, e := range tt.enumeratorIndex[name] {
+			if enumName != "" && e.EnumName != enumName {
+				continue
+			}
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+func (tt *TypeTable) buildEnumeratorIndex() {
+	tt.enumeratorIndexOnce.Do(func() {
+		tt.enumeratorIndex = make(map[string][]*Enumerator)
+
+		begin := tt.tpiStream.TypeIndexBegin()
+		end := tt.tpiStream.TypeIndexEnd()
+
+		for ti := begin; ti < end; ti++ {
+			record, err := tt.tpiStream.GetTypeRecord(ti)
+			if err != nil || record == nil {
+				continue
+			}
+			if record.Kind != tpi.LF_ENUM && record.Kind != tpi.LF_ENUM_ST {
+				continue
+			}
+
+			typ, err := tt.ByIndex(TypeIndex(ti))
+			if err != nil {
+				continue
+			}
+			enum, ok := typ.(*EnumType)
+			if !ok || enum.isForwardRef {
+				continue
+			}
+
+			enumerators, err := tt.enumerators(enum)
+			if err != nil {
+				continue
+			}
+			for _, e := range enumerators {
+				tt.enumeratorIndex[e.Name] = append(tt.enumeratorIndex[e.Name], e)
+			}
+		}
+	})
+}
@@ -48,3 +48,33 @@ func (e *ParseError) Error() string {
 }
 
 func (e *ParseError) Unwrap() error { return e.Err }
+
+// Stream names reported in ParseError
+const (
+	streamTPI           = "TPI"
+	streamIPI           = "IPI"
+	streamSymbolRecords = "symbol records"
+)
+
+// recordError returns a ParseError for a record that cannot be read. The
+// records after it cannot be located, so it ends the iteration.
+func recordError(stream string, offset int64, err error) *ParseError {
+	return &ParseError{Stream: stream, Offset: offset, Message: "cannot read record", Err: err}
+}
+
+// decodeError returns a ParseError for a record that was read but whose
+// contents cannot be decoded.
+func decodeError(stream string, offset int64, kind uint16, err error) *ParseError {
+	return &ParseError{
+		Stream:  stream,
+		Offset:  offset,
+		Message: fmt.Sprintf("cannot decode record of kind 0x%04X", kind),
+		Err:     err,
+	}
+}
+
+// hashStreamError returns a ParseError for the hash stream of a TPI or IPI
+// stream that cannot be read. Lookups fall back to linear scans.
+func hashStreamError(stream string, err error) *ParseError {
+	return &ParseError{Stream: stream + " hash", Message: "cannot read hash stream", Err: err}
+}
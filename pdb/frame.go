@@ -0,0 +1,137 @@
+package pdb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/skdltmxn/pdb-go/internal/dbi"
+)
+
+// FrameData describes the stack frame of an x86 function (FRAMEDATA). It
+// comes from the new FPO stream and is what debuggers use to unwind frames
+// that omit the frame pointer.
+type FrameData struct {
+	RVA             uint32
+	CodeSize        uint32
+	LocalSize       uint32
+	ParamsSize      uint32
+	MaxStackSize    uint32
+	Program         string // Postfix program computing the caller's registers
+	PrologSize      uint16
+	SavedRegsSize   uint16
+	HasSEH          bool
+	HasEH           bool
+	IsFunctionStart bool
+}
+
+// FPOData is a legacy x86 frame pointer omission record (FPO_DATA).
+type FPOData struct {
+	RVA        uint32
+	CodeSize   uint32
+	Locals     uint32 // Size of locals in DWORDs
+	Params     uint16 // Size of parameters in DWORDs
+	PrologSize uint8
+	SavedRegs  uint8 // Number of saved registers
+	HasSEH     bool
+	UsesBP     bool
+	FrameType  uint8 // 0 FPO, 1 trap, 2 TSS, 3 non-FPO
+}
+
+// Sizes of the raw records
+const (
+	frameDataSize = 32
+	fpoDataSize   = 16
+)
+
+// FrameData returns the records of the new FPO stream, sorted by RVA as
+// stored by the linker. PDBs without the stream yield no records.
+func (f *File) FrameData() ([]FrameData, error) {
+	data, err := f.readOptionalDbgStream(func(h *dbi.OptionalDbgHeader) uint16 { return h.NewFPOStreamIndex })
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	// The stream may start with a relocation pointer
+	if len(data)%frameDataSize == 4 {
+		data = data[4:]
+	}
+
+	names, err := f.getStringTable()
+	if err != nil {
+		return nil, err
+	}
+
+	count := len(data) / frameDataSize
+	result := make([]FrameData, count)
+	for i := range result {
+		rec := data[i*frameDataSize:]
+		flags := binary.LittleEndian.Uint32(rec[28:])
+		result[i] = FrameData{
+			RVA:             binary.LittleEndian.Uint32(rec[0:]),
+			CodeSize:        binary.LittleEndian.Uint32(rec[4:]),
+			LocalSize:       binary.LittleEndian.Uint32(rec[8:]),
+			ParamsSize:      binary.LittleEndian.Uint32(rec[12:]),
+			MaxStackSize:    binary.LittleEndian.Uint32(rec[16:]),
+			Program:         names.get(binary.LittleEndian.Uint32(rec[20:])),
+			PrologSize:      binary.LittleEndian.Uint16(rec[24:]),
+			SavedRegsSize:   binary.LittleEndian.Uint16(rec[26:]),
+			HasSEH:          flags&0x1 != 0,
+			HasEH:           flags&0x2 != 0,
+			IsFunctionStart: flags&0x4 != 0,
+		}
+	}
+
+	return result, nil
+}
+
+// FPOData returns the records of the legacy FPO stream. PDBs without the
+// stream yield no records.
+func (f *File) FPOData() ([]FPOData, error) {
+	data, err := f.readOptionalDbgStream(func(h *dbi.OptionalDbgHeader) uint16 { return h.FPOStreamIndex })
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	count := len(data) / fpoDataSize
+	result := make([]FPOData, count)
+	for i := range result {
+		rec := data[i*fpoDataSize:]
+		bits := binary.LittleEndian.Uint16(rec[14:])
+		result[i] = FPOData{
+			RVA:        binary.LittleEndian.Uint32(rec[0:]),
+			CodeSize:   binary.LittleEndian.Uint32(rec[4:]),
+			Locals:     binary.LittleEndian.Uint32(rec[8:]),
+			Params:     binary.LittleEndian.Uint16(rec[12:]),
+			PrologSize: uint8(bits),
+			SavedRegs:  uint8(bits>>8) & 0x7,
+			HasSEH:     bits&0x0800 != 0,
+			UsesBP:     bits&0x1000 != 0,
+			FrameType:  uint8(bits>>14) & 0x3,
+		}
+	}
+
+	return result, nil
+}
+
+// readOptionalDbgStream reads one of the streams listed in the DBI optional
+// debug header. It returns nil data if the stream is absent.
+func (f *File) readOptionalDbgStream(index func(*dbi.OptionalDbgHeader) uint16) ([]byte, error) {
+	dbiStream, err := f.getDBI()
+	if err != nil {
+		return nil, err
+	}
+	if dbiStream.OptionalDbgStreams == nil {
+		return nil, nil
+	}
+
+	streamIndex := index(dbiStream.OptionalDbgStreams)
+	if streamIndex == 0xFFFF {
+		return nil, nil
+	}
+
+	data, err := f.msf.ReadStream(uint32(streamIndex))
+	if err != nil {
+		return nil, fmt.Errorf("pdb: failed to read debug stream %d: %w", streamIndex, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,193 @@
+package pdb
+
+import (
+	"fmt"
+
+	"github.com/skdltmxn/pdb-go/internal/lines"
+	"github.com/skdltmxn/pdb-go/internal/stream"
+	"github.com/skdltmxn/pdb-go/internal/symbols"
+	"github.com/skdltmxn/pdb-go/internal/tpi"
+)
+
+// inlineSite is an S_INLINESITE record within a procedure.
+type inlineSite struct {
+	parent      int    // Index of the enclosing inline site, -1 for the procedure
+	inlinee     uint32 // IPI index of the inlined function
+	annotations []byte
+}
+
+// inlineRange is a range of code attributed to one line of an inlined
+// function. Offsets are relative to the start of the procedure.
+type inlineRange struct {
+	start uint32
+	end   uint32
+	line  uint32
+	file  uint32 // File checksum offset
+}
+
+// loadInlinees parses the inlinee source lines and file names of the module.
+func (m *Module) loadInlinees() error {
+	m.inlineesOnce.Do(func() {
+		m.inlineesErr = m.parseInlinees()
+	})
+	return m.inlineesErr
+}
+
+func (m *Module) parseInlinees() error {
+	subsections, err := m.debugSubsections()
+	if err != nil {
+		return err
+	}
+
+	names, err := m.pdb.getStringTable()
+	if err != nil {
+		return err
+	}
+
+	m.inlinees = make(map[uint32]lines.InlineeSourceLine)
+	m.fileNames = make(map[uint32]string)
+
+	for _, ss := range subsections {
+		switch ss.Kind {
+		case lines.DEBUG_S_FILECHKSMS:
+			checksums, err := lines.ParseFileChecksums(ss.Data)
+			if err != nil {
+				return fmt.Errorf("pdb: module %d: %w", m.index, err)
+			}
+			for offset, fc := range checksums {
+				m.fileNames[offset] = names.get(fc.NameOffset)
+			}
+		case lines.DEBUG_S_INLINEELINES:
+			entries, err := lines.ParseInlineeLines(ss.Data)
+			if err != nil {
+				return fmt.Errorf("pdb: module %d: %w", m.index, err)
+			}
+			for _, e := range entries {
+				m.inlinees[e.Inlinee] = e
+			}
+		}
+	}
+
+	return nil
+}
+
+// inlineRanges decodes the code ranges of an inline site from its binary
+// annotations. Ranges left open by the annotations end at procEnd.
+func (m *Module) inlineRanges(site *inlineSite, procEnd uint32) []inlineRange {
+	var line, file uint32
+	if src, ok := m.inlinees[site.inlinee]; ok {
+		line, file = src.SourceLine, src.FileChecksumOffset
+	}
+
+	var ranges []inlineRange
+	var codeOffset uint32
+	open := false
+
+	begin := func() {
+		if open && ranges[len(ranges)-1].end == 0 {
+			ranges[len(ranges)-1].end = codeOffset
+		}
+		ranges = append(ranges, inlineRange{start: codeOffset, line: line, file: file})
+		open = true
+	}
+	setLength := func(length uint32) {
+		if open {
+			r := &ranges[len(ranges)-1]
+			r.end = r.start + length
+			codeOffset = r.end
+			open = false
+		}
+	}
+
+	for _, a := range symbols.DecodeBinaryAnnotations(site.annotations) {
+		switch a.Opcode {
+		case symbols.BA_OP_CodeOffset:
+			codeOffset = a.U1
+		case symbols.BA_OP_ChangeCodeOffset:
+			codeOffset += a.U1
+			begin()
+		case symbols.BA_OP_ChangeCodeLength:
+			setLength(a.U1)
+		case symbols.BA_OP_ChangeFile:
+			file = a.U1
+		case symbols.BA_OP_ChangeLineOffset:
+			line = uint32(int32(line) + a.Signed)
+		case symbols.BA_OP_ChangeCodeOffsetAndLineOffset:
+			line = uint32(int32(line) + a.Signed)
+			codeOffset += a.U1
+			begin()
+		case symbols.BA_OP_ChangeCodeLengthAndCodeOffset:
+			codeOffset += a.U2
+			begin()
+			setLength(a.U1)
+		}
+	}
+
+	if open && ranges[len(ranges)-1].end == 0 {
+		ranges[len(ranges)-1].end = max(procEnd, ranges[len(ranges)-1].start)
+	}
+	return ranges
+}
+
+// inlineeName returns the qualified name of an inlined function from its
+// LF_FUNC_ID or LF_MFUNC_ID record.
+func (f *File) inlineeName(index uint32) string {
+	ipi, err := f.getIPI()
+	if err != nil {
+		return ""
+	}
+
+	record, err := ipi.GetTypeRecord(tpi.TypeIndex(index))
+	if err != nil || record == nil {
+		return ""
+	}
+	if record.Kind != tpi.LF_FUNC_ID && record.Kind != tpi.LF_MFUNC_ID {
+		return ""
+	}
+
+	id, err := tpi.ParseFuncIDRecord(record.Data)
+	if err != nil {
+		return ""
+	}
+	if id.Scope == 0 {
+		return id.Name
+	}
+
+	// Member functions are scoped by their class type, free functions by
+	// an LF_STRING_ID naming the namespace
+	var scope string
+	if record.Kind == tpi.LF_MFUNC_ID {
+		if types, err := f.Types(); err == nil {
+			if typ, err := types.ByIndex(TypeIndex(id.Scope)); err == nil {
+				scope = typ.Name()
+			}
+		}
+	} else {
+		scope = f.stringID(uint32(id.Scope))
+	}
+
+	if scope == "" {
+		return id.Name
+	}
+	return scope + "::" + id.Name
+}
+
+// stringID returns the string of an IPI LF_STRING_ID record.
+func (f *File) stringID(index uint32) string {
+	ipi, err := f.getIPI()
+	if err != nil {
+		return ""
+	}
+
+	record, err := ipi.GetTypeRecord(tpi.TypeIndex(index))
+	if err != nil || record == nil || record.Kind != tpi.LF_STRING_ID {
+		return ""
+	}
+
+	r := stream.NewReader(record.Data)
+	if _, err := r.ReadU32(); err != nil { // substring list
+		return ""
+	}
+	s, _ := r.ReadCString()
+	return s
+}
@@ -0,0 +1,149 @@
+package pdb
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/skdltmxn/pdb-go/internal/lines"
+)
+
+// Line maps a range of code to a source line.
+type Line struct {
+	Offset      uint32 // Offset within the section
+	Length      uint32 // Bytes of code up to the next line entry
+	LineStart   uint32
+	LineEnd     uint32
+	IsStatement bool
+}
+
+// IsHidden returns true if the line marks compiler-generated code without a
+// source line.
+func (l *Line) IsHidden() bool {
+	return l.LineStart == lines.LineHidden || l.LineStart == lines.LineAlwaysStep
+}
+
+// LineBlock is the line information of one source file within a contiguous
+// range of a module's code.
+type LineBlock struct {
+	FileName     string
+	ChecksumKind uint8 // 0 none, 1 MD5, 2 SHA1, 3 SHA256
+	Checksum     []byte
+	Section      uint16
+	Offset       uint32 // Start of the code range within the section
+	Length       uint32
+	Lines        []Line
+}
+
+// Lines returns the C13 line information of the module.
+func (m *Module) Lines() ([]LineBlock, error) {
+	m.linesOnce.Do(func() {
+		m.lines, m.linesErr = m.parseLines()
+	})
+	return m.lines, m.linesErr
+}
+
+func (m *Module) parseLines() ([]LineBlock, error) {
+	subsections, err := m.debugSubsections()
+	if err != nil || subsections == nil {
+		return nil, err
+	}
+
+	// Line blocks refer to files through the checksum subsection
+	var checksums map[uint32]lines.FileChecksum
+	for _, ss := range subsections {
+		if ss.Kind == lines.DEBUG_S_FILECHKSMS {
+			if checksums, err = lines.ParseFileChecksums(ss.Data); err != nil {
+				return nil, fmt.Errorf("pdb: module %d: %w", m.index, err)
+			}
+			break
+		}
+	}
+
+	names, err := m.pdb.getStringTable()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []LineBlock
+	for _, ss := range subsections {
+		if ss.Kind != lines.DEBUG_S_LINES {
+			continue
+		}
+
+		ls, err := lines.ParseLines(ss.Data)
+		if err != nil {
+			return nil, fmt.Errorf("pdb: module %d: %w", m.index, err)
+		}
+
+		// A line extends to the next line of any file in the range
+		var offsets []uint32
+		for _, b := range ls.Blocks {
+			for _, l := range b.Lines {
+				offsets = append(offsets, l.Offset)
+			}
+		}
+		slices.Sort(offsets)
+		offsets = slices.Compact(offsets)
+
+		for _, b := range ls.Blocks {
+			block := LineBlock{
+				Section: ls.Segment,
+				Offset:  ls.Offset,
+				Length:  ls.CodeSize,
+				Lines:   make([]Line, len(b.Lines)),
+			}
+			if fc, ok := checksums[b.FileChecksumOffset]; ok {
+				block.FileName = names.get(fc.NameOffset)
+				block.ChecksumKind = fc.Kind
+				block.Checksum = fc.Checksum
+			}
+
+			for i, l := range b.Lines {
+				next := ls.CodeSize
+				if j, _ := slices.BinarySearch(offsets, l.Offset+1); j < len(offsets) {
+					next = offsets[j]
+				}
+				length := uint32(0)
+				if next > l.Offset {
+					length = next - l.Offset
+				}
+
+				block.Lines[i] = Line{
+					Offset:      ls.Offset + l.Offset,
+					Length:      length,
+					LineStart:   l.LineStart,
+					LineEnd:     l.LineEnd,
+					IsStatement: l.IsStatement,
+				}
+			}
+
+			result = append(result, block)
+		}
+	}
+
+	return result, nil
+}
+
+// debugSubsections returns the C13 debug subsections of the module.
+func (m *Module) debugSubsections() ([]lines.Subsection, error) {
+	if m.info.C13ByteSize == 0 {
+		return nil, nil
+	}
+
+	data, err := m.pdb.readModuleSymbols(m.info.ModuleSymStreamIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	start := uint64(m.info.SymByteSize) + uint64(m.info.C11ByteSize)
+	end := start + uint64(m.info.C13ByteSize)
+	if end > uint64(len(data)) {
+		return nil, fmt.Errorf("pdb: module %d line information out of range", m.index)
+	}
+
+	subsections, err := lines.ParseSubsections(data[start:end])
+	if err != nil {
+		return nil, fmt.Errorf("pdb: module %d: %w", m.index, err)
+	}
+	return subsections, nil
+}
@@ -0,0 +1,817 @@
+package pdb
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/skdltmxn/pdb-go/demangle"
+	"github.com/skdltmxn/pdb-go/internal/tpi"
+)
+
+// MangledName returns the MSVC-decorated name of a function or variable
+// declared with a qualified name and a type from the table, e.g.
+//
+//	tt.MangledName("ui::Widget::resize", resizeType) // ?resize@Widget@ui@@QEAAXHH@Z
+//
+// See Declaration for how the name and type are read.
+func (tt *TypeTable) MangledName(name string, typeIndex TypeIndex) (string, error) {
+	decl, err := tt.Declaration(name, typeIndex)
+	if err != nil {
+		return "", err
+	}
+	return demangle.Mangle(decl)
+}
+
+// MangledName returns the decorated name of the function, generated from
+// its name and type as TypeTable.MangledName does. A function with C
+// linkage (see DataSymbol.MangledName) has the C decoration of its calling
+// convention instead, e.g. _Add@8 for int __stdcall Add(int, int) on x86.
+func (s *FunctionSymbol) MangledName() (string, error) {
+	if s.pdb == nil {
+		return "", ErrTypeNotFound
+	}
+
+	types, err := s.pdb.Types()
+	if err != nil {
+		return "", err
+	}
+	typeIndex, err := s.functionType()
+	if err != nil {
+		return "", err
+	}
+	if s.pdb.hasCLinkage(s.name, s.section, s.offset, s.compiledAsC) {
+		if name, ok := types.cFunctionName(s.name, typeIndex); ok {
+			return name, nil
+		}
+	}
+	return types.MangledName(s.name, typeIndex)
+}
+
+// MangledName returns the decorated name of the variable, generated from
+// its name and type as TypeTable.MangledName does. A variable has C
+// linkage if a public symbol at its address has its name with C
+// decorations or none, or else, if no public there is named after it, if
+// its module was compiled as C; its name is then decorated with a leading
+// underscore on x86 only.
+func (s *DataSymbol) MangledName() (string, error) {
+	if s.pdb == nil {
+		return "", ErrTypeNotFound
+	}
+
+	types, err := s.pdb.Types()
+	if err != nil {
+		return "", err
+	}
+	if s.pdb.hasCLinkage(s.name, s.section, s.offset, s.compiledAsC) {
+		c := demangle.CName{Name: s.name, CallingConv: demangle.CallingConvCdecl, ArgBytes: -1}
+		return c.Decorated(types.cNameOptions()...), nil
+	}
+	return types.MangledName(s.name, TypeIndex(s.typeIndex))
+}
+
+// hasCLinkage reports whether the function or variable with a name and
+// address has C linkage, as DataSymbol.MangledName describes.
+func (f *File) hasCLinkage(name string, section uint16, offset uint32, compiledAsC bool) bool {
+	st, err := f.Symbols()
+	if err != nil {
+		return compiledAsC
+	}
+	for _, pub := range st.publicsAt(section, offset) {
+		if c, ok := pub.CName(); ok {
+			if c.Name == name {
+				return true
+			}
+		} else if pub.Name() == name {
+			return true
+		} else if undecoratedName(pub) == name {
+			return false
+		}
+	}
+	return compiledAsC
+}
+
+// cFunctionName returns the C decoration of a free function's name for its
+// calling convention and the bytes of arguments it takes, each rounded up to
+// a stack slot. The second result is false if the type is not that of a
+// free function or the size of an argument is unknown.
+func (tt *TypeTable) cFunctionName(name string, typeIndex TypeIndex) (string, bool) {
+	sig, err := tt.Signature(typeIndex)
+	if err != nil || sig.IsMember() {
+		return "", false
+	}
+	cc, ok := callingConventions[sig.CallingConvention]
+	if !ok {
+		return "", false
+	}
+
+	slot := uint64(4)
+	if tt.is64Bit() {
+		slot = 8
+	}
+	var argBytes uint64
+	for _, p := range sig.Parameters {
+		typ, err := tt.ByIndex(p.Type)
+		if err != nil || typ.Size() == 0 {
+			return "", false
+		}
+		argBytes += (typ.Size() + slot - 1) / slot * slot
+	}
+
+	c := demangle.CName{Name: name, CallingConv: cc, ArgBytes: int(argBytes)}
+	return c.Decorated(tt.cNameOptions()...), true
+}
+
+// cNameOptions returns the options of C names on the PDB's machine.
+func (tt *TypeTable) cNameOptions() []demangle.Option {
+	if tt.pdb == nil {
+		return nil
+	}
+	if machine, err := tt.pdb.Machine(); err == nil && machine == MachineI386 {
+		return []demangle.Option{demangle.X86}
+	}
+	return nil
+}
+
+// Declaration returns the declaration of a function or variable with a
+// qualified name and a type from the table, as demangle.DemangleToNode
+// returns it for the decorated name.
+//
+// Functions take an LF_PROCEDURE or LF_MFUNCTION type. The class of a
+// member function is taken from its type, and its access, virtual and
+// static attributes from the class's field list; constructors,
+// destructors, operators and conversion operators are recognized by name.
+//
+// Classes are named by the decorated unique names the compiler records for
+// them, so only the namespaces of free functions and globals, and the
+// template arguments of function templates, are read from the name.
+// Template arguments may be integers, built-in types and classes in the
+// table, with *, &, &&, const and volatile.
+func (tt *TypeTable) Declaration(name string, typeIndex TypeIndex) (demangle.Node, error) {
+	parts := splitQualifiedName(name)
+	if parts[len(parts)-1] == "" {
+		return nil, fmt.Errorf("pdb: invalid name %q", name)
+	}
+
+	if typ, err := tt.ByIndex(typeIndex); err == nil {
+		switch typ.(type) {
+		case *FunctionType, *MemberFunctionType:
+			return tt.functionDeclaration(parts, typeIndex)
+		}
+	}
+	return tt.variableDeclaration(parts, typeIndex)
+}
+
+func (tt *TypeTable) functionDeclaration(parts []string, typeIndex TypeIndex) (demangle.Node, error) {
+	sig, err := tt.Signature(typeIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	fn := &demangle.FunctionSymbol{}
+	baseText := parts[len(parts)-1]
+
+	var scope []demangle.Node
+	if sig.IsMember() {
+		class, err := tt.tagNode(sig.ClassType)
+		if err != nil {
+			return nil, err
+		}
+		scope = class.Name.Components
+		fn.AccessSpec, fn.IsVirtual, fn.IsStatic = tt.methodAttributes(sig, baseText, typeIndex)
+	} else if scope, err = tt.nameComponents(parts[:len(parts)-1]); err != nil {
+		return nil, err
+	}
+
+	if fn.Signature, err = tt.functionTypeNode(sig); err != nil {
+		return nil, err
+	}
+
+	base, err := tt.baseName(baseText, scope, sig)
+	if err != nil {
+		return nil, err
+	}
+	switch b := base.(type) {
+	case *demangle.Structor:
+		fn.Signature.ReturnType = nil
+	case *demangle.ConversionOperator:
+		b.TargetType = fn.Signature.ReturnType
+	}
+
+	fn.Name = &demangle.QualifiedName{Components: append(slices.Clone(scope), base)}
+	return fn, nil
+}
+
+// methodAttributes returns the access, virtual and static attributes of a
+// member function, found in its class's field list by name and type.
+// Methods missing from the field list are taken to be public.
+func (tt *TypeTable) methodAttributes(sig *Signature, name string, typeIndex TypeIndex) (demangle.AccessSpecifier, bool, bool) {
+	isStatic := sig.ThisType == 0
+
+	methods, err := tt.Methods(sig.ClassType)
+	if err != nil {
+		return demangle.AccessPublic, false, isStatic
+	}
+	for _, m := range methods {
+		if m.Name != name {
+			continue
+		}
+		for _, o := range m.Overloads {
+			if o.Type == typeIndex {
+				return accessSpecifier(o.Access), o.IsVirtual(), o.IsStatic()
+			}
+		}
+	}
+	return demangle.AccessPublic, false, isStatic
+}
+
+func accessSpecifier(access string) demangle.AccessSpecifier {
+	switch access {
+	case "private":
+		return demangle.AccessPrivate
+	case "protected":
+		return demangle.AccessProtected
+	}
+	return demangle.AccessPublic
+}
+
+// Operators by the name they are declared with
+var operatorsByName = func() map[string]demangle.OperatorKind {
+	m := make(map[string]demangle.OperatorKind)
+	for op := demangle.OpNew; op <= demangle.OpCoAwait; op++ {
+		if op == demangle.OpConversion {
+			continue
+		}
+		if name := demangle.Format(&demangle.Operator{Op: op}); strings.HasPrefix(name, "operator") {
+			m[name] = op
+		}
+	}
+	return m
+}()
+
+// baseName returns the node of a function's unqualified name.
+func (tt *TypeTable) baseName(text string, scope []demangle.Node, sig *Signature) (demangle.Node, error) {
+	var class demangle.Node
+	var className string
+	if len(scope) > 0 {
+		class = scope[len(scope)-1]
+		className = class.String()
+		if t, ok := class.(*demangle.TemplateInstantiation); ok {
+			className = t.Name.String()
+		}
+	}
+
+	if isOperatorName(text) {
+		if op, ok := operatorsByName[text]; ok {
+			return &demangle.Operator{Op: op}, nil
+		}
+		// The target type of a conversion is the return type
+		return &demangle.ConversionOperator{}, nil
+	}
+
+	if class != nil {
+		if dtor, ok := strings.CutPrefix(text, "~"); ok && templateName(dtor) == className {
+			return &demangle.Structor{Class: class, IsDestructor: true}, nil
+		}
+		if sig.IsConstructor || templateName(text) == className {
+			return &demangle.Structor{Class: class}, nil
+		}
+	}
+	return tt.nameComponent(text)
+}
+
+func (tt *TypeTable) variableDeclaration(parts []string, typeIndex TypeIndex) (demangle.Node, error) {
+	typ, err := tt.typeNode(typeIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &demangle.VariableSymbol{Type: typ}
+	baseText := parts[len(parts)-1]
+
+	// Static data members are named by their class
+	var scope []demangle.Node
+	if scopeText := parts[:len(parts)-1]; len(scopeText) > 0 {
+		if class, classIndex, ok := tt.udtByName(strings.Join(scopeText, "::")); ok {
+			scope = class.Name.Components
+			v.AccessSpec, v.IsStatic = demangle.AccessPublic, true
+			if members, err := tt.GetMembers(classIndex); err == nil {
+				for _, m := range members {
+					if m.Name == baseText && m.IsStatic {
+						v.AccessSpec = accessSpecifier(m.Access)
+						break
+					}
+				}
+			}
+		} else if scope, err = tt.nameComponents(scopeText); err != nil {
+			return nil, err
+		}
+	}
+
+	v.Name = &demangle.QualifiedName{Components: append(slices.Clone(scope), &demangle.Identifier{Name: baseText})}
+	return v, nil
+}
+
+// typeNode returns the node of a type in the table.
+func (tt *TypeTable) typeNode(index TypeIndex) (demangle.Node, error) {
+	if index.IsSimpleType() {
+		return simpleTypeNode(index)
+	}
+
+	typ, err := tt.ByIndex(index)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := typ.(type) {
+	case *ModifierType:
+		inner, err := tt.typeNode(t.ModifiedType())
+		if err != nil {
+			return nil, err
+		}
+		q := demangle.Qualifiers{IsConst: t.IsConst(), IsVolatile: t.IsVolatile(), IsUnaligned: t.IsUnaligned()}
+		if q.IsEmpty() {
+			return inner, nil
+		}
+		return &demangle.QualifiedType{Type: inner, Quals: q}, nil
+
+	case *PointerType:
+		return tt.pointerNode(index)
+
+	case *ArrayType:
+		return tt.arrayNode(t)
+
+	case *FunctionType, *MemberFunctionType:
+		sig, err := tt.Signature(index)
+		if err != nil {
+			return nil, err
+		}
+		return tt.functionTypeNode(sig)
+
+	case *ClassType, *StructType, *UnionType, *EnumType:
+		return tt.tagNode(index)
+	}
+	return nil, fmt.Errorf("pdb: type 0x%X has no decorated form", uint32(index))
+}
+
+// Primitive types of simple type kinds
+var simplePrimitives = map[tpi.SimpleTypeKind]demangle.PrimitiveKind{
+	tpi.SimpleTypeVoid:         demangle.PrimVoid,
+	tpi.SimpleTypeHResult:      demangle.PrimLong,
+	tpi.SimpleTypeSignedChar:   demangle.PrimSignedChar,
+	tpi.SimpleTypeUnsignedChar: demangle.PrimUnsignedChar,
+	tpi.SimpleTypeNarrowChar:   demangle.PrimChar,
+	tpi.SimpleTypeWideChar:     demangle.PrimWChar,
+	tpi.SimpleTypeChar16:       demangle.PrimChar16,
+	tpi.SimpleTypeChar32:       demangle.PrimChar32,
+	tpi.SimpleTypeChar8:        demangle.PrimChar8,
+	tpi.SimpleTypeSByte:        demangle.PrimSignedChar,
+	tpi.SimpleTypeByte:         demangle.PrimUnsignedChar,
+	tpi.SimpleTypeInt16Short:   demangle.PrimShort,
+	tpi.SimpleTypeUInt16Short:  demangle.PrimUnsignedShort,
+	tpi.SimpleTypeInt16:        demangle.PrimShort,
+	tpi.SimpleTypeUInt16:       demangle.PrimUnsignedShort,
+	tpi.SimpleTypeInt32Long:    demangle.PrimLong,
+	tpi.SimpleTypeUInt32Long:   demangle.PrimUnsignedLong,
+	tpi.SimpleTypeInt32:        demangle.PrimInt,
+	tpi.SimpleTypeUInt32:       demangle.PrimUnsignedInt,
+	tpi.SimpleTypeInt64Quad:    demangle.PrimInt64,
+	tpi.SimpleTypeUInt64Quad:   demangle.PrimUnsignedInt64,
+	tpi.SimpleTypeInt64:        demangle.PrimInt64,
+	tpi.SimpleTypeUInt64:       demangle.PrimUnsignedInt64,
+	tpi.SimpleTypeInt128Oct:    demangle.PrimInt128,
+	tpi.SimpleTypeUInt128Oct:   demangle.PrimUnsignedInt128,
+	tpi.SimpleTypeInt128:       demangle.PrimInt128,
+	tpi.SimpleTypeUInt128:      demangle.PrimUnsignedInt128,
+	tpi.SimpleTypeFloat32:      demangle.PrimFloat,
+	tpi.SimpleTypeFloat64:      demangle.PrimDouble,
+	tpi.SimpleTypeFloat80:      demangle.PrimLongDouble,
+	tpi.SimpleTypeBool8:        demangle.PrimBool,
+}
+
+func simpleTypeNode(index TypeIndex) (demangle.Node, error) {
+	ti := tpi.TypeIndex(index)
+	prim, ok := simplePrimitives[ti.SimpleKind()]
+	if !ok {
+		return nil, fmt.Errorf("pdb: type 0x%X has no decorated form", uint32(index))
+	}
+
+	n := &demangle.PrimitiveType{Type: prim}
+	switch ti.SimpleMode() {
+	case tpi.SimpleModeDirect:
+		return n, nil
+	case tpi.SimpleModeNearPointer, tpi.SimpleModeNearPointer32:
+		return &demangle.PointerType{Pointee: n}, nil
+	case tpi.SimpleModeNearPointer64:
+		return &demangle.PointerType{Pointee: n, Is64Bit: true}, nil
+	}
+	return nil, fmt.Errorf("pdb: type 0x%X has no decorated form", uint32(index))
+}
+
+// pointerNode returns the node of an LF_POINTER type: a pointer,
+// reference or pointer to member.
+func (tt *TypeTable) pointerNode(index TypeIndex) (demangle.Node, error) {
+	record, err := tt.tpiStream.GetTypeRecord(tpi.TypeIndex(index))
+	if err != nil || record == nil {
+		return nil, ErrTypeNotFound
+	}
+	rec, err := tpi.ParsePointerRecord(record.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	pointee, err := tt.typeNode(TypeIndex(rec.ReferentType))
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := rec.Attributes
+	quals := demangle.Qualifiers{
+		IsConst:     attrs.IsConst(),
+		IsVolatile:  attrs.IsVolatile(),
+		IsRestrict:  attrs.IsRestrict(),
+		IsUnaligned: attrs.IsUnaligned(),
+	}
+	is64Bit := attrs.Size() == 8
+
+	ptr := &demangle.PointerType{Pointee: pointee, Quals: quals, Is64Bit: is64Bit}
+	switch attrs.Mode() {
+	case tpi.PointerModeLValueReference:
+		ptr.Affinity = demangle.AffinityReference
+	case tpi.PointerModeRValueReference:
+		ptr.Affinity = demangle.AffinityRValueReference
+	case tpi.PointerModePointerToDataMember, tpi.PointerModePointerToMemberFunction:
+		class, err := tt.tagNode(TypeIndex(rec.ContainingClass))
+		if err != nil {
+			return nil, err
+		}
+		return &demangle.MemberPointerType{
+			ClassType:  class.Name,
+			MemberType: pointee,
+			Quals:      quals,
+			Is64Bit:    is64Bit,
+		}, nil
+	}
+	return ptr, nil
+}
+
+// arrayNode returns the node of an array, with the dimensions of nested
+// arrays folded into one.
+func (tt *TypeTable) arrayNode(t *ArrayType) (demangle.Node, error) {
+	elem, err := tt.ByIndex(t.ElementType())
+	if err != nil {
+		return nil, err
+	}
+	if elem.Size() == 0 {
+		return nil, fmt.Errorf("pdb: array type 0x%X has elements of unknown size", uint32(t.Index()))
+	}
+
+	elemNode, err := tt.typeNode(t.ElementType())
+	if err != nil {
+		return nil, err
+	}
+	arr := &demangle.ArrayType{ElementType: elemNode, Dimensions: []uint64{t.Size() / elem.Size()}}
+	if inner, ok := elemNode.(*demangle.ArrayType); ok {
+		arr.ElementType = inner.ElementType
+		arr.Dimensions = append(arr.Dimensions, inner.Dimensions...)
+	}
+	return arr, nil
+}
+
+// Calling conventions by the names tpi.CallingConvention returns
+var callingConventions = map[string]demangle.CallingConvention{
+	"__cdecl":      demangle.CallingConvCdecl,
+	"__pascal":     demangle.CallingConvPascal,
+	"__fastcall":   demangle.CallingConvFastcall,
+	"__stdcall":    demangle.CallingConvStdcall,
+	"__thiscall":   demangle.CallingConvThiscall,
+	"__clrcall":    demangle.CallingConvClrcall,
+	"__vectorcall": demangle.CallingConvVectorcall,
+	"__swift":      demangle.CallingConvSwift,
+	"__swiftasync": demangle.CallingConvSwiftAsync,
+}
+
+// functionTypeNode returns the node of a function signature. Top-level
+// qualifiers of parameters are not part of the function's type.
+func (tt *TypeTable) functionTypeNode(sig *Signature) (*demangle.FunctionType, error) {
+	cc, ok := callingConventions[sig.CallingConvention]
+	if !ok {
+		return nil, fmt.Errorf("pdb: calling convention %q has no decorated form", sig.CallingConvention)
+	}
+	ft := &demangle.FunctionType{CallingConv: cc, IsVariadic: sig.IsVariadic}
+
+	ret, err := tt.typeNode(sig.ReturnType)
+	if err != nil {
+		return nil, err
+	}
+	ft.ReturnType = ret
+
+	for _, p := range sig.Parameters {
+		param, err := tt.typeNode(p.Type)
+		if err != nil {
+			return nil, err
+		}
+		if q, ok := param.(*demangle.QualifiedType); ok {
+			param = q.Type
+		}
+		ft.Parameters = append(ft.Parameters, param)
+	}
+
+	if sig.ThisType != 0 {
+		if err := tt.thisQualifiers(ft, sig.ThisType); err != nil {
+			return nil, err
+		}
+	}
+	return ft, nil
+}
+
+// thisQualifiers sets the qualifiers of a member function's this pointer.
+func (tt *TypeTable) thisQualifiers(ft *demangle.FunctionType, thisType TypeIndex) error {
+	record, err := tt.tpiStream.GetTypeRecord(tpi.TypeIndex(thisType))
+	if err != nil || record == nil || record.Kind != tpi.LF_POINTER {
+		return ErrTypeNotFound
+	}
+	rec, err := tpi.ParsePointerRecord(record.Data)
+	if err != nil {
+		return err
+	}
+
+	attrs := rec.Attributes
+	ft.Is64Bit = attrs.Size() == 8
+	ft.Quals.IsRestrict, ft.Quals.IsUnaligned = attrs.IsRestrict(), attrs.IsUnaligned()
+	switch {
+	case attrs.IsLRef():
+		ft.RefQualifier = demangle.RefQualifierLValue
+	case attrs.IsRRef():
+		ft.RefQualifier = demangle.RefQualifierRValue
+	}
+
+	if mod, err := tt.ByIndex(TypeIndex(rec.ReferentType)); err == nil {
+		if m, ok := mod.(*ModifierType); ok {
+			ft.Quals.IsConst, ft.Quals.IsVolatile = m.IsConst(), m.IsVolatile()
+		}
+	}
+	return nil
+}
+
+// tagNode returns the node of a class, struct, union or enum, read from
+// its unique name when it has one.
+func (tt *TypeTable) tagNode(index TypeIndex) (*demangle.TagType, error) {
+	typ, err := tt.ByIndex(index)
+	if err != nil {
+		return nil, err
+	}
+
+	var tag demangle.TagKind
+	var name, uniqueName string
+	switch t := typ.(type) {
+	case *ClassType:
+		tag, name, uniqueName = demangle.TagClass, t.Name(), t.UniqueName()
+	case *StructType:
+		tag, name, uniqueName = demangle.TagStruct, t.Name(), t.UniqueName()
+	case *UnionType:
+		tag, name, uniqueName = demangle.TagUnion, t.Name(), t.UniqueName()
+	case *EnumType:
+		tag, name, uniqueName = demangle.TagEnum, t.Name(), t.UniqueName()
+	default:
+		return nil, fmt.Errorf("pdb: type 0x%X is not a class, struct, union or enum", uint32(index))
+	}
+
+	if uniqueName != "" {
+		if n, err := demangle.DemangleType(uniqueName); err == nil {
+			if t, ok := n.(*demangle.TagType); ok {
+				return t, nil
+			}
+		}
+	}
+
+	components, err := tt.nameComponents(splitQualifiedName(name))
+	if err != nil {
+		return nil, err
+	}
+	return &demangle.TagType{Tag: tag, Name: &demangle.QualifiedName{Components: components}}, nil
+}
+
+// udtByName returns the node and index of the class, struct, union or
+// enum with a name.
+func (tt *TypeTable) udtByName(name string) (*demangle.TagType, TypeIndex, bool) {
+	for typ := range tt.ByName(name) {
+		switch typ.(type) {
+		case *ClassType, *StructType, *UnionType, *EnumType:
+			if tag, err := tt.tagNode(typ.Index()); err == nil {
+				return tag, typ.Index(), true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// nameComponents returns the nodes of namespace and class names.
+func (tt *TypeTable) nameComponents(parts []string) ([]demangle.Node, error) {
+	components := make([]demangle.Node, 0, len(parts))
+	for _, part := range parts {
+		n, err := tt.nameComponent(part)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, n)
+	}
+	return components, nil
+}
+
+// nameComponent returns the node of an identifier or template
+// instantiation.
+func (tt *TypeTable) nameComponent(text string) (demangle.Node, error) {
+	name := templateName(text)
+	if name == text {
+		return &demangle.Identifier{Name: text}, nil
+	}
+
+	t := &demangle.TemplateInstantiation{Name: &demangle.Identifier{Name: name}}
+	for _, arg := range splitTemplateArgs(text[len(name)+1 : len(text)-1]) {
+		n, err := tt.templateArgNode(strings.TrimSpace(arg))
+		if err != nil {
+			return nil, err
+		}
+		t.Arguments = append(t.Arguments, n)
+	}
+	return t, nil
+}
+
+func (tt *TypeTable) templateArgNode(text string) (demangle.Node, error) {
+	if v, err := strconv.ParseInt(text, 0, 64); err == nil {
+		return &demangle.IntegerLiteral{Value: v, Negative: v < 0}, nil
+	}
+	return tt.typeNodeByName(text)
+}
+
+// Primitive types by the names they are declared with
+var primitivesByName = func() map[string]demangle.PrimitiveKind {
+	m := map[string]demangle.PrimitiveKind{
+		"long long":          demangle.PrimInt64,
+		"unsigned long long": demangle.PrimUnsignedInt64,
+		"unsigned":           demangle.PrimUnsignedInt,
+		"nullptr_t":          demangle.PrimNullptr,
+	}
+	for prim := demangle.PrimVoid; prim <= demangle.PrimNullptr; prim++ {
+		m[(&demangle.PrimitiveType{Type: prim}).String()] = prim
+	}
+	return m
+}()
+
+// typeNodeByName returns the node of a type written as in a declaration,
+// e.g. "const char *" or "std::string &".
+func (tt *TypeTable) typeNodeByName(text string) (demangle.Node, error) {
+	text = strings.TrimSpace(text)
+
+	// Declarators apply to everything on their left
+	switch {
+	case strings.HasSuffix(text, "&&"):
+		return tt.pointerNodeByName(text[:len(text)-2], demangle.AffinityRValueReference)
+	case strings.HasSuffix(text, "&"):
+		return tt.pointerNodeByName(text[:len(text)-1], demangle.AffinityReference)
+	case strings.HasSuffix(text, "*"):
+		return tt.pointerNodeByName(text[:len(text)-1], demangle.AffinityPointer)
+	}
+	for _, kw := range []string{"const", "volatile", "__ptr64"} {
+		inner, ok := strings.CutSuffix(text, kw)
+		if !ok || (inner != "" && !strings.ContainsAny(inner[len(inner)-1:], " *&")) {
+			continue
+		}
+		n, err := tt.typeNodeByName(inner)
+		if err != nil {
+			return nil, err
+		}
+		return qualifyNode(n, kw), nil
+	}
+	for _, kw := range []string{"const ", "volatile "} {
+		if inner, ok := strings.CutPrefix(text, kw); ok {
+			n, err := tt.typeNodeByName(inner)
+			if err != nil {
+				return nil, err
+			}
+			return qualifyNode(n, strings.TrimSpace(kw)), nil
+		}
+	}
+
+	if prim, ok := primitivesByName[text]; ok {
+		return &demangle.PrimitiveType{Type: prim}, nil
+	}
+	for _, kw := range []string{"class ", "struct ", "union ", "enum "} {
+		text = strings.TrimPrefix(text, kw)
+	}
+	if tag, _, ok := tt.udtByName(text); ok {
+		return tag, nil
+	}
+	return nil, fmt.Errorf("pdb: type %q not found", text)
+}
+
+func (tt *TypeTable) pointerNodeByName(pointee string, affinity demangle.PointerAffinity) (demangle.Node, error) {
+	n, err := tt.typeNodeByName(pointee)
+	if err != nil {
+		return nil, err
+	}
+	return &demangle.PointerType{Pointee: n, Affinity: affinity, Is64Bit: tt.is64Bit()}, nil
+}
+
+// qualifyNode applies a const or volatile qualifier to a type, or to a
+// pointer itself. __ptr64 is implied by the machine.
+func qualifyNode(n demangle.Node, kw string) demangle.Node {
+	var q *demangle.Qualifiers
+	switch t := n.(type) {
+	case *demangle.PointerType:
+		q = &t.Quals
+	case *demangle.QualifiedType:
+		q = &t.Quals
+	default:
+		qt := &demangle.QualifiedType{Type: n}
+		n, q = qt, &qt.Quals
+	}
+	switch kw {
+	case "const":
+		q.IsConst = true
+	case "volatile":
+		q.IsVolatile = true
+	}
+	return n
+}
+
+// is64Bit reports whether pointers are 64-bit on the PDB's machine.
+func (tt *TypeTable) is64Bit() bool {
+	if tt.pdb == nil {
+		return false
+	}
+	machine, err := tt.pdb.Machine()
+	return err == nil && (machine == MachineAMD64 || machine == MachineARM64)
+}
+
+// splitQualifiedName splits a name at the "::" separators outside template
+// arguments. Operator names end the name, since their symbols could be
+// mistaken for separators.
+func splitQualifiedName(name string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(name); i++ {
+		if i == start && isOperatorName(name[start:]) {
+			break
+		}
+		switch name[i] {
+		case '<', '(':
+			depth++
+		case '>', ')':
+			depth--
+		case ':':
+			if depth == 0 && strings.HasPrefix(name[i:], "::") {
+				parts = append(parts, name[start:i])
+				start = i + 2
+				i++
+			}
+		}
+	}
+	return append(parts, name[start:])
+}
+
+// splitTemplateArgs splits template arguments at the commas outside nested
+// template arguments and parentheses.
+func splitTemplateArgs(text string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '<', '(':
+			depth++
+		case '>', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, text[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// templateName returns a name without its template arguments.
+func templateName(text string) string {
+	if !strings.HasSuffix(text, ">") {
+		return text
+	}
+	if i := strings.IndexByte(text, '<'); i > 0 {
+		return text[:i]
+	}
+	return text
+}
+
+func isOperatorName(s string) bool {
+	rest, ok := strings.CutPrefix(s, "operator")
+	if !ok {
+		return false
+	}
+	return rest == "" || !isIdentifierChar(rest[0])
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,78 @@
+package pdb_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// TestMangledName mangles every function and global variable of the
+// fixtures from its type and compares the name with the public symbols at
+// its address.
+func TestMangledName(t *testing.T) {
+	for _, name := range []string{"x86.pdb", "x64.pdb"} {
+		t.Run(name, func(t *testing.T) {
+			f, err := pdb.Open(filepath.Join("..", "testdata", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			st, err := f.Symbols()
+			if err != nil {
+				t.Fatal(err)
+			}
+			type address struct {
+				section uint16
+				offset  uint32
+			}
+			publics := make(map[address][]string)
+			for pub := range st.Public() {
+				addr := address{pub.Section(), pub.Offset()}
+				publics[addr] = append(publics[addr], pub.Name())
+			}
+
+			var syms []pdb.Symbol
+			modules, err := f.Modules()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, mod := range modules {
+				for sym := range mod.Symbols() {
+					syms = append(syms, sym)
+				}
+			}
+			for sym := range st.Globals() {
+				syms = append(syms, sym)
+			}
+
+			count := 0
+			for _, sym := range syms {
+				var mangled string
+				var err error
+				switch s := sym.(type) {
+				case *pdb.FunctionSymbol:
+					mangled, err = s.MangledName()
+				case *pdb.DataSymbol:
+					mangled, err = s.MangledName()
+				default:
+					continue
+				}
+				if err != nil {
+					t.Errorf("%s: %v", sym.Name(), err)
+					continue
+				}
+				want := publics[address{sym.Section(), sym.Offset()}]
+				if !slices.Contains(want, mangled) {
+					t.Errorf("%s mangles to %s, want one of %q", sym.Name(), mangled, want)
+				}
+				count++
+			}
+			if count == 0 {
+				t.Error("no functions or variables")
+			}
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package pdb
 
 import (
+	"io"
 	"iter"
+	"sort"
 	"sync"
 
 	"github.com/skdltmxn/pdb-go/internal/dbi"
+	"github.com/skdltmxn/pdb-go/internal/lines"
 	"github.com/skdltmxn/pdb-go/internal/symbols"
 )
 
@@ -16,8 +19,21 @@ type Module struct {
 
 	// Lazy-loaded symbols
 	symbols     []Symbol
+	procs       []*FunctionSymbol // Sorted by address
 	symbolsOnce sync.Once
 	symbolsErr  error
+	parseErrs   []symbolError // Reported by SymbolsChecked
+
+	// Lazy-loaded line information
+	lines     []LineBlock
+	linesOnce sync.Once
+	linesErr  error
+
+	// Lazy-loaded inlinee information
+	inlinees     map[uint32]lines.InlineeSourceLine
+	fileNames    map[uint32]string // Keyed by file checksum offset
+	inlineesOnce sync.Once
+	inlineesErr  error
 }
 
 // Index returns the module index.
@@ -55,6 +71,12 @@ func (m *Module) SourceFileCount() uint16 {
 	return m.info.SourceFileCount
 }
 
+// symbolError is a parse error met before the symbol at index.
+type symbolError struct {
+	index int
+	err   *ParseError
+}
+
 // Symbols returns an iterator over symbols in this module.
 func (m *Module) Symbols() iter.Seq[Symbol] {
 	return func(yield func(Symbol) bool) {
@@ -72,61 +94,192 @@ func (m *Module) Symbols() iter.Seq[Symbol] {
 	}
 }
 
+// SymbolsChecked is like Symbols, but also yields the errors that Symbols
+// hides: the error reading the module stream, a truncated or corrupt record
+// (*ParseError), after which there are no more symbols, and with
+// OpenOptions.Strict the records that cannot be decoded (*ParseError).
+func (m *Module) SymbolsChecked() iter.Seq2[Symbol, error] {
+	return func(yield func(Symbol, error) bool) {
+		m.loadSymbols()
+
+		if m.symbolsErr != nil {
+			yield(nil, m.symbolsErr)
+			return
+		}
+
+		errs := m.parseErrs
+		for i, sym := range m.symbols {
+			for len(errs) > 0 && errs[0].index == i {
+				if !yield(nil, errs[0].err) {
+					return
+				}
+				errs = errs[1:]
+			}
+			if !yield(sym, nil) {
+				return
+			}
+		}
+		for _, e := range errs {
+			if !yield(nil, e.err) {
+				return
+			}
+		}
+	}
+}
+
 func (m *Module) loadSymbols() {
 	m.symbolsOnce.Do(func() {
-		m.symbols, m.symbolsErr = m.parseSymbols()
+		m.symbols, m.parseErrs, m.symbolsErr = m.parseSymbols()
+
+		for _, sym := range m.symbols {
+			if fn, ok := sym.(*FunctionSymbol); ok && fn.length > 0 {
+				m.procs = append(m.procs, fn)
+			}
+		}
+		sort.Slice(m.procs, func(i, j int) bool {
+			if m.procs[i].section != m.procs[j].section {
+				return m.procs[i].section < m.procs[j].section
+			}
+			return m.procs[i].offset < m.procs[j].offset
+		})
 	})
 }
 
-func (m *Module) parseSymbols() ([]Symbol, error) {
-	// Get module symbol stream data
-	data, err := m.pdb.readModuleSymbols(m.info.ModuleSymStreamIndex)
+// symbolIterator returns an iterator over the module's symbol records, or
+// nil if the module has none. The module stream starts with a signature,
+// then the symbol records, then line information.
+func (m *Module) symbolIterator() (*symbols.SymbolIterator, error) {
+	if m.info.ModuleSymStreamIndex == 0xFFFF {
+		return nil, nil
+	}
+	data, s, err := m.pdb.recordStream(uint32(m.info.ModuleSymStreamIndex))
 	if err != nil {
 		return nil, err
 	}
-	if data == nil || len(data) == 0 {
-		return nil, nil
-	}
 
-	// The module stream starts with a signature, then symbol records
-	if len(data) < 4 {
+	// SymByteSize includes the 4-byte signature
+	if s != nil {
+		size := min(int64(m.info.SymByteSize), int64(s.Size()))
+		if size < 4 {
+			return nil, nil
+		}
+		return symbols.NewSymbolIteratorAt(io.NewSectionReader(s, 4, size-4), size-4), nil
+	}
+	size := min(int(m.info.SymByteSize), len(data))
+	if size < 4 {
 		return nil, nil
 	}
+	return symbols.NewSymbolIterator(data[4:size]), nil
+}
 
-	// Skip signature (4 bytes)
-	symData := data[4:]
-	if uint32(len(symData)) < m.info.SymByteSize-4 {
-		symData = symData[:m.info.SymByteSize-4]
+// symbolStream names the module's symbol records in a ParseError.
+func (m *Module) symbolStream() string {
+	return "symbols of module " + m.Name()
+}
+
+func (m *Module) parseSymbols() ([]Symbol, []symbolError, error) {
+	iter, err := m.symbolIterator()
+	if err != nil || iter == nil {
+		return nil, nil, err
 	}
 
 	// Parse symbol records
-	iter := symbols.NewSymbolIterator(symData)
 	var result []Symbol
+	var parseErrs []symbolError
+
+	// Open scopes with the procedure and inline site they belong to
+	type scope struct {
+		fn   *FunctionSymbol // Set for procedure scopes only
+		proc *FunctionSymbol
+		site int // Index into proc's inline sites, -1 outside of any
+	}
+	var scopes []scope
+	compiledAsC := false // From the module's S_COMPILE3 record
+	enclosing := func() scope {
+		if len(scopes) == 0 {
+			return scope{site: -1}
+		}
+		return scopes[len(scopes)-1]
+	}
 
 	for {
+		// Offset in the module stream, whose records follow the signature
+		offset := int64(iter.Offset()) + 4
 		record, err := iter.Next()
 		if err != nil {
+			parseErrs = append(parseErrs, symbolError{len(result), recordError(m.symbolStream(), offset, err)})
 			break
 		}
 		if record == nil {
 			break
 		}
 
-		sym := m.convertSymbol(record)
+		sym, err := m.convertSymbol(record)
+		if err != nil && m.pdb.opts.Strict {
+			parseErrs = append(parseErrs, symbolError{len(result), decodeError(m.symbolStream(), offset, uint16(record.Kind), err)})
+		}
 		if sym != nil {
 			result = append(result, sym)
 		}
+
+		switch s := sym.(type) {
+		case *FunctionSymbol:
+			s.compiledAsC = compiledAsC
+		case *DataSymbol:
+			s.compiledAsC = compiledAsC
+		}
+
+		switch record.Kind {
+		case symbols.S_COMPILE3:
+			if compile, err := symbols.ParseCompileSym3(record.Data); err == nil {
+				compiledAsC = compile.Flags.Language() == symbols.LanguageC
+			}
+		case symbols.S_GPROC32, symbols.S_LPROC32, symbols.S_GPROC32_ID, symbols.S_LPROC32_ID:
+			fn, _ := sym.(*FunctionSymbol)
+			scopes = append(scopes, scope{fn: fn, proc: fn, site: -1})
+		case symbols.S_INLINESITE, symbols.S_INLINESITE2:
+			outer := enclosing()
+			inner := scope{proc: outer.proc, site: -1}
+			if site, err := symbols.ParseInlineSiteSym(record.Kind, record.Data); err == nil && outer.proc != nil {
+				outer.proc.inlineSites = append(outer.proc.inlineSites, inlineSite{
+					parent:      outer.site,
+					inlinee:     uint32(site.Inlinee),
+					annotations: site.BinaryAnnotations,
+				})
+				inner.site = len(outer.proc.inlineSites) - 1
+			}
+			scopes = append(scopes, inner)
+		case symbols.S_BLOCK32, symbols.S_THUNK32, symbols.S_WITH32, symbols.S_SEPCODE:
+			outer := enclosing()
+			scopes = append(scopes, scope{proc: outer.proc, site: outer.site})
+		case symbols.S_END, symbols.S_PROC_ID_END, symbols.S_INLINESITE_END:
+			if len(scopes) > 0 {
+				scopes = scopes[:len(scopes)-1]
+			}
+		case symbols.S_FRAMEPROC:
+			if fn := enclosing().fn; fn != nil {
+				if frame, err := symbols.ParseFrameProcSym(record.Data); err == nil {
+					fn.frameSize = int64(frame.TotalFrameBytes) + int64(frame.CalleeSaveBytes)
+				}
+			}
+		case symbols.S_LOCAL, symbols.S_REGREL32, symbols.S_BPREL32:
+			if fn := enclosing().fn; fn != nil {
+				if v, ok := parseFrameVar(record); ok {
+					fn.frameLocals = append(fn.frameLocals, v)
+				}
+			}
+		}
 	}
 
-	return result, nil
+	return result, parseErrs, nil
 }
 
-func (m *Module) convertSymbol(record *symbols.SymbolRecord) Symbol {
+func (m *Module) convertSymbol(record *symbols.SymbolRecord) (Symbol, error) {
 	switch record.Kind {
 	case symbols.S_GPROC32, symbols.S_LPROC32, symbols.S_GPROC32_ID, symbols.S_LPROC32_ID:
 		proc, err := symbols.ParseProcSym(record.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &FunctionSymbol{
 			baseSymbol: baseSymbol{name: proc.Name},
@@ -134,89 +287,92 @@ func (m *Module) convertSymbol(record *symbols.SymbolRecord) Symbol {
 			offset:     proc.CodeOffset,
 			length:     proc.CodeSize,
 			typeIndex:  uint32(proc.FunctionType),
-		}
+			pdb:        m.pdb,
+			isID:       record.Kind == symbols.S_GPROC32_ID || record.Kind == symbols.S_LPROC32_ID,
+		}, nil
 
 	case symbols.S_GDATA32, symbols.S_LDATA32:
 		data, err := symbols.ParseDataSym(record.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &DataSymbol{
 			baseSymbol: baseSymbol{name: data.Name},
 			section:    data.Segment,
 			offset:     data.Offset,
 			typeIndex:  uint32(data.Type),
-		}
+			pdb:        m.pdb,
+		}, nil
 
 	case symbols.S_UDT, symbols.S_UDT_ST:
 		udt, err := symbols.ParseUDTSym(record.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &UDTSymbol{
 			baseSymbol: baseSymbol{name: udt.Name},
 			typeIndex:  uint32(udt.Type),
-		}
+		}, nil
 
 	case symbols.S_CONSTANT, symbols.S_CONSTANT_ST:
 		c, err := symbols.ParseConstantSym(record.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &ConstantSymbol{
 			baseSymbol: baseSymbol{name: c.Name},
 			value:      c.Value,
 			typeIndex:  uint32(c.Type),
-		}
+		}, nil
 
 	case symbols.S_LOCAL:
 		local, err := symbols.ParseLocalSym(record.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &LocalSymbol{
 			baseSymbol:  baseSymbol{name: local.Name},
 			typeIndex:   uint32(local.Type),
 			isParameter: local.Flags.IsParameter(),
-		}
+		}, nil
 
 	case symbols.S_LABEL32:
 		label, err := symbols.ParseLabelSym(record.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &LabelSymbol{
 			baseSymbol: baseSymbol{name: label.Name},
 			section:    label.Segment,
 			offset:     label.Offset,
-		}
+		}, nil
 
 	case symbols.S_BLOCK32:
 		block, err := symbols.ParseBlockSym(record.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &BlockSymbol{
 			baseSymbol: baseSymbol{name: block.Name},
 			section:    block.Segment,
 			offset:     block.Offset,
 			length:     block.CodeSize,
-		}
+		}, nil
 
 	case symbols.S_THUNK32:
 		thunk, err := symbols.ParseThunkSym(record.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &ThunkSymbol{
 			baseSymbol: baseSymbol{name: thunk.Name},
 			section:    thunk.Segment,
 			offset:     thunk.Offset,
 			length:     uint32(thunk.Length),
-		}
+		}, nil
 
 	default:
-		return nil
+		return nil, nil
 	}
 }
 
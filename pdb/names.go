@@ -0,0 +1,148 @@
+package pdb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/skdltmxn/pdb-go/internal/stream"
+)
+
+// namesStreamName is the named stream holding the PDB string table.
+const namesStreamName = "/names"
+
+// stringTableSignature identifies the /names stream.
+const stringTableSignature = 0xEFFEEFFE
+
+// parseNamedStreams parses the named stream map that follows the fixed
+// header of the PDB info stream. It is a serialized hash table mapping
+// offsets into a string buffer to stream indices.
+func parseNamedStreams(data []byte) (map[string]uint32, error) {
+	r := stream.NewReader(data)
+
+	bufSize, err := r.ReadU32()
+	if err != nil {
+		return nil, err
+	}
+	buf, err := r.ReadBytesRef(int(bufSize))
+	if err != nil {
+		return nil, fmt.Errorf("pdb: named stream buffer truncated")
+	}
+
+	size, err := r.ReadU32()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.ReadU32(); err != nil { // capacity
+		return nil, err
+	}
+
+	present, err := readBitVector(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := readBitVector(r); err != nil { // deleted
+		return nil, err
+	}
+
+	names := make(map[string]uint32, size)
+	for _, word := range present {
+		for bit := 0; bit < 32; bit++ {
+			if word&(1<<bit) == 0 {
+				continue
+			}
+			key, err := r.ReadU32()
+			if err != nil {
+				return nil, err
+			}
+			value, err := r.ReadU32()
+			if err != nil {
+				return nil, err
+			}
+
+			if int(key) >= len(buf) {
+				return nil, fmt.Errorf("pdb: named stream name offset out of range: %d", key)
+			}
+			name, err := stream.NewReader(buf[key:]).ReadCString()
+			if err != nil {
+				return nil, fmt.Errorf("pdb: unterminated named stream name")
+			}
+			names[name] = value
+		}
+	}
+
+	return names, nil
+}
+
+func readBitVector(r *stream.Reader) ([]uint32, error) {
+	count, err := r.ReadU32()
+	if err != nil {
+		return nil, err
+	}
+	if int(count) > r.Remaining()/4 {
+		return nil, fmt.Errorf("pdb: bit vector too large: %d words", count)
+	}
+
+	words := make([]uint32, count)
+	for i := range words {
+		words[i], _ = r.ReadU32()
+	}
+	return words, nil
+}
+
+// stringTable is the PDB-wide string table stored in the /names stream.
+// File names in line information and frame data programs refer to it by
+// offset.
+type stringTable struct {
+	buf []byte
+}
+
+// get returns the string at the given offset, or "" if it is out of range.
+func (t *stringTable) get(offset uint32) string {
+	if t == nil || int(offset) >= len(t.buf) {
+		return ""
+	}
+	s, _ := stream.NewReader(t.buf[offset:]).ReadCString()
+	return s
+}
+
+func parseStringTable(data []byte) (*stringTable, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("pdb: string table too short")
+	}
+	if sig := binary.LittleEndian.Uint32(data); sig != stringTableSignature {
+		return nil, fmt.Errorf("pdb: invalid string table signature: 0x%X", sig)
+	}
+
+	size := binary.LittleEndian.Uint32(data[8:])
+	if int(size) > len(data)-12 {
+		return nil, fmt.Errorf("pdb: string table buffer truncated")
+	}
+
+	return &stringTable{buf: data[12 : 12+size]}, nil
+}
+
+// getStringTable loads the /names string table. PDBs without one yield a
+// nil table, whose lookups return empty strings.
+func (f *File) getStringTable() (*stringTable, error) {
+	f.stringTableOnce.Do(func() {
+		info, err := f.Info()
+		if err != nil {
+			f.stringTableErr = err
+			return
+		}
+
+		index, ok := info.NamedStreams[namesStreamName]
+		if !ok {
+			return
+		}
+
+		data, err := f.msf.ReadStream(index)
+		if err != nil {
+			f.stringTableErr = fmt.Errorf("pdb: failed to read string table: %w", err)
+			return
+		}
+		f.strings, f.stringTableErr = parseStringTable(data)
+	})
+
+	return f.strings, f.stringTableErr
+}
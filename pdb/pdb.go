@@ -6,6 +6,8 @@ import (
 	"sync"
 
 	"github.com/skdltmxn/pdb-go/internal/dbi"
+	"github.com/skdltmxn/pdb-go/internal/indexcache"
+	"github.com/skdltmxn/pdb-go/internal/mmap"
 	"github.com/skdltmxn/pdb-go/internal/tpi"
 	"github.com/skdltmxn/pdb-go/msf"
 )
@@ -14,9 +16,15 @@ import (
 // It is safe for concurrent read access after opening.
 type File struct {
 	msf    *msf.File
+	opts   OpenOptions
 	closed bool
 	mu     sync.RWMutex
 
+	// Index cache (nil if not enabled) and the cached indexes mapped from it
+	cache     *indexcache.Cache
+	cacheOnce sync.Once
+	mappings  []*mmap.Mapping
+
 	// Lazy-loaded streams
 	pdbInfo     *PDBInfo
 	pdbInfoOnce sync.Once
@@ -25,10 +33,12 @@ type File struct {
 	tpiStream     *tpi.Stream
 	tpiStreamOnce sync.Once
 	tpiStreamErr  error
+	tpiHashErr    error // Why TPI lookups fall back to linear scans
 
 	ipiStream     *tpi.Stream
 	ipiStreamOnce sync.Once
 	ipiStreamErr  error
+	ipiHashErr    error // Why IPI lookups fall back to linear scans
 
 	dbiStream     *dbi.Stream
 	dbiStreamOnce sync.Once
@@ -46,6 +56,17 @@ type File struct {
 	sectionHeaders     *SectionHeaders
 	sectionHeadersOnce sync.Once
 	sectionHeadersErr  error
+
+	strings         *stringTable
+	stringTableOnce sync.Once
+	stringTableErr  error
+
+	modules     []*Module
+	modulesOnce sync.Once
+	modulesErr  error
+
+	codeContributions     []SectionContribution // Code only, sorted by address
+	codeContributionsOnce sync.Once
 }
 
 // PDBInfo contains metadata about the PDB file.
@@ -54,30 +75,83 @@ type PDBInfo struct {
 	Signature uint32
 	Age       uint32
 	GUID      [16]byte
+
+	// NamedStreams maps stream names such as "/names" to stream indices
+	NamedStreams map[string]uint32
+}
+
+// OpenOptions configures how a PDB file is opened.
+type OpenOptions struct {
+	// IndexCacheDir is a directory for lookup indexes (symbol names and
+	// addresses, type names and class members). Indexes are stored there
+	// when first built, keyed by the PDB's GUID and age, and memory-mapped
+	// instead of rebuilt when the same PDB is opened again. Indexes built
+	// from a different PDB or by a different format version are rebuilt.
+	// Empty disables the cache.
+	IndexCacheDir string
+
+	// Mmap memory-maps the file (on Linux) instead of reading it, so that
+	// contiguous streams are used in place and stay in the page cache
+	// rather than the Go heap. Tables, modules and stream data obtained from
+	// a mapped file must not be used after Close: accessing them faults.
+	Mmap bool
+
+	// WindowedStreams parses the TPI, IPI, symbol record and module streams
+	// through a bounded window when they cannot be used in place (the file
+	// is not memory-mapped, or a stream's blocks are not contiguous),
+	// instead of reading them whole. This lowers peak memory on large PDBs:
+	// iterating symbols no longer holds their streams, though the type
+	// offset index and the types looked up are still kept, and each type
+	// looked up for the first time is read from the file. Data obtained
+	// from the file stays valid after Close either way.
+	WindowedStreams bool
+
+	// Strict reports records that cannot be decoded as *ParseError values
+	// from the checked iterators (Module.SymbolsChecked,
+	// SymbolTable.PublicChecked and TypeTable.AllChecked) and from
+	// SymbolTable.PublicCached, instead of skipping them.
+	Strict bool
 }
 
 // Open opens a PDB file from the given path.
 func Open(path string) (*File, error) {
-	msfFile, err := msf.Open(path)
+	return OpenWithOptions(path, OpenOptions{})
+}
+
+// OpenWithOptions opens a PDB file from the given path with options.
+func OpenWithOptions(path string, opts OpenOptions) (*File, error) {
+	open := msf.Open
+	if opts.Mmap {
+		open = msf.OpenMapped
+	}
+	msfFile, err := open(path)
 	if err != nil {
 		return nil, fmt.Errorf("pdb: failed to open file: %w", err)
 	}
 
-	return &File{msf: msfFile}, nil
+	return &File{msf: msfFile, opts: opts}, nil
 }
 
 // OpenReader opens a PDB from an io.ReaderAt.
 // This allows reading from arbitrary sources (embedded, network, etc.)
 func OpenReader(r io.ReaderAt, size int64) (*File, error) {
+	return OpenReaderWithOptions(r, size, OpenOptions{})
+}
+
+// OpenReaderWithOptions opens a PDB from an io.ReaderAt with options.
+// Mmap does not apply to a reader and is ignored.
+func OpenReaderWithOptions(r io.ReaderAt, size int64, opts OpenOptions) (*File, error) {
 	msfFile, err := msf.NewFile(r, size)
 	if err != nil {
 		return nil, fmt.Errorf("pdb: failed to open file: %w", err)
 	}
 
-	return &File{msf: msfFile}, nil
+	return &File{msf: msfFile, opts: opts}, nil
 }
 
-// Close releases resources associated with the PDB file.
+// Close releases resources associated with the PDB file. If the file is
+// memory-mapped, it is unmapped, and tables, modules and stream data
+// obtained from it must no longer be used.
 func (f *File) Close() error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -87,6 +161,10 @@ func (f *File) Close() error {
 	}
 
 	f.closed = true
+	for _, m := range f.mappings {
+		m.Close()
+	}
+	f.mappings = nil
 	return f.msf.Close()
 }
 
@@ -118,6 +196,9 @@ func (f *File) loadPDBInfo() (*PDBInfo, error) {
 	info.Age = uint32(data[8]) | uint32(data[9])<<8 | uint32(data[10])<<16 | uint32(data[11])<<24
 	copy(info.GUID[:], data[12:28])
 
+	// A damaged name map only hides named streams
+	info.NamedStreams, _ = parseNamedStreams(data[28:])
+
 	return info, nil
 }
 
@@ -161,26 +242,34 @@ func (f *File) loadTypeTable() (*TypeTable, error) {
 		return nil, err
 	}
 
-	return newTypeTable(tpiStream), nil
+	return newTypeTable(f, tpiStream), nil
 }
 
 // Modules returns all modules (compilands) in the PDB.
+// Modules are created once, so their symbols and lines are parsed at most
+// once per file.
 func (f *File) Modules() ([]*Module, error) {
-	dbiStream, err := f.getDBI()
-	if err != nil {
-		return nil, err
-	}
+	f.modulesOnce.Do(func() {
+		dbiStream, err := f.getDBI()
+		if err != nil {
+			f.modulesErr = err
+			return
+		}
 
-	modules := make([]*Module, len(dbiStream.Modules))
-	for i := range dbiStream.Modules {
-		modules[i] = &Module{
-			pdb:   f,
-			index: i,
-			info:  &dbiStream.Modules[i],
+		f.modules = make([]*Module, len(dbiStream.Modules))
+		for i := range dbiStream.Modules {
+			f.modules[i] = &Module{
+				pdb:   f,
+				index: i,
+				info:  &dbiStream.Modules[i],
+			}
 		}
-	}
+	})
 
-	return modules, nil
+	if f.modulesErr != nil {
+		return nil, f.modulesErr
+	}
+	return f.modules, nil
 }
 
 // ModuleCount returns the number of modules in the PDB.
@@ -192,6 +281,30 @@ func (f *File) ModuleCount() (int, error) {
 	return len(dbiStream.Modules), nil
 }
 
+// Machine types reported by Machine.
+const (
+	MachineUnknown uint16 = 0x0000
+	MachineI386    uint16 = 0x014c
+	MachineARMNT   uint16 = 0x01c4
+	MachineAMD64   uint16 = 0x8664
+	MachineARM64   uint16 = 0xaa64
+)
+
+// Machine returns the target machine type (IMAGE_FILE_MACHINE_*) recorded in
+// the DBI stream.
+func (f *File) Machine() (uint16, error) {
+	dbiStream, err := f.getDBI()
+	if err != nil {
+		return 0, err
+	}
+	return dbiStream.Header.Machine, nil
+}
+
+// Mapped reports whether the file is memory-mapped.
+func (f *File) Mapped() bool {
+	return f.msf.Mapped()
+}
+
 // BlockSize returns the block size used by this PDB file.
 func (f *File) BlockSize() uint32 {
 	return f.msf.BlockSize()
@@ -202,17 +315,61 @@ func (f *File) NumStreams() (uint32, error) {
 	return f.msf.NumStreams()
 }
 
+// MSF returns the MSF container of the PDB, which gives access to raw
+// streams and to the allocation of blocks.
+func (f *File) MSF() *msf.File {
+	return f.msf
+}
+
 // Internal helpers
 
+// recordStream opens a stream of records for parsing. The stream is
+// returned, to be read through a bounded window, if windowedStream returns
+// it; otherwise its data is.
+func (f *File) recordStream(streamIndex uint32) ([]byte, *msf.Stream, error) {
+	s, err := f.windowedStream(streamIndex)
+	if err != nil || s != nil {
+		return nil, s, err
+	}
+	data, err := f.msf.ReadStream(streamIndex)
+	return data, nil, err
+}
+
+// windowedStream returns a stream to be read through a bounded window, or
+// nil if it is read whole: unless OpenOptions.WindowedStreams is set, or if
+// it can be used in place (a contiguous stream of a memory-mapped file).
+func (f *File) windowedStream(streamIndex uint32) (*msf.Stream, error) {
+	if !f.opts.WindowedStreams {
+		return nil, nil
+	}
+	s, err := f.msf.OpenStream(streamIndex)
+	if err != nil {
+		return nil, err
+	}
+	if f.msf.Mapped() && s.Contiguous() {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// parseTypeStream parses a TPI or IPI stream.
+func (f *File) parseTypeStream(streamIndex uint32, name string) (*tpi.Stream, error) {
+	data, s, err := f.recordStream(streamIndex)
+	if err != nil {
+		return nil, fmt.Errorf("pdb: failed to read %s stream: %w", name, err)
+	}
+	if s != nil {
+		return tpi.ParseStreamAt(s, int64(s.Size()))
+	}
+	return tpi.ParseStream(data)
+}
+
 func (f *File) getTPI() (*tpi.Stream, error) {
 	f.tpiStreamOnce.Do(func() {
-		data, err := f.msf.ReadStream(msf.StreamTPI)
-		if err != nil {
-			f.tpiStreamErr = fmt.Errorf("pdb: failed to read TPI stream: %w", err)
-			return
+		f.tpiStream, f.tpiStreamErr = f.parseTypeStream(msf.StreamTPI, "TPI")
+		if f.tpiStreamErr == nil {
+			f.tpiHashErr = f.loadHashStream(f.tpiStream, streamTPI)
 		}
-
-		f.tpiStream, f.tpiStreamErr = tpi.ParseStream(data)
 	})
 
 	if f.tpiStreamErr != nil {
@@ -221,6 +378,25 @@ func (f *File) getTPI() (*tpi.Stream, error) {
 	return f.tpiStream, nil
 }
 
+// loadHashStream attaches the hash stream to a TPI or IPI stream.
+// The hash stream is optional; lookups fall back to linear scans without
+// it. A hash stream that cannot be read is not fatal either, but the
+// *ParseError returned for it is reported by TypeTable.AllChecked.
+func (f *File) loadHashStream(s *tpi.Stream, name string) error {
+	if s.Header.HashStreamIndex == 0xFFFF {
+		return nil
+	}
+
+	data, err := f.msf.ReadStream(uint32(s.Header.HashStreamIndex))
+	if err == nil {
+		err = s.ParseHashStream(data)
+	}
+	if err != nil {
+		return hashStreamError(name, err)
+	}
+	return nil
+}
+
 func (f *File) getIPI() (*tpi.Stream, error) {
 	f.ipiStreamOnce.Do(func() {
 		exists, err := f.msf.StreamExists(msf.StreamIPI)
@@ -229,13 +405,10 @@ func (f *File) getIPI() (*tpi.Stream, error) {
 			return
 		}
 
-		data, err := f.msf.ReadStream(msf.StreamIPI)
-		if err != nil {
-			f.ipiStreamErr = fmt.Errorf("pdb: failed to read IPI stream: %w", err)
-			return
+		f.ipiStream, f.ipiStreamErr = f.parseTypeStream(msf.StreamIPI, "IPI")
+		if f.ipiStreamErr == nil {
+			f.ipiHashErr = f.loadHashStream(f.ipiStream, streamIPI)
 		}
-
-		f.ipiStream, f.ipiStreamErr = tpi.ParseStream(data)
 	})
 
 	if f.ipiStreamErr != nil {
@@ -0,0 +1,101 @@
+package pdb_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// TestOpenReaderWithOptions checks that a PDB opened from a reader uses the
+// index cache it is given.
+func TestOpenReaderWithOptions(t *testing.T) {
+	r, err := os.Open(filepath.Join("..", "testdata", "x64.pdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	stat, err := r.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	f, err := pdb.OpenReaderWithOptions(r, stat.Size(), pdb.OpenOptions{IndexCacheDir: dir, Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	st, err := f.Symbols()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := st.FindByName("main"); !ok {
+		t.Fatal("main not found")
+	}
+	for _, err := range st.PublicChecked() {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Error("no index stored in the cache directory")
+	}
+}
+
+// TestIndexCacheReuse checks that a second open maps the indexes the first
+// one stored instead of storing them again.
+func TestIndexCacheReuse(t *testing.T) {
+	dir := t.TempDir()
+	lookup := func() map[string]time.Time {
+		f, err := pdb.OpenWithOptions(filepath.Join("..", "testdata", "x64.pdb"), pdb.OpenOptions{IndexCacheDir: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		st, err := f.Symbols()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := st.FindByName("main"); !ok {
+			t.Error("main not found")
+		}
+
+		stored := make(map[string]time.Time)
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				if info, err := d.Info(); err == nil {
+					stored[path] = info.ModTime()
+				}
+			}
+			return nil
+		})
+		return stored
+	}
+
+	first := lookup()
+	if len(first) == 0 {
+		t.Fatal("no index stored")
+	}
+	for path := range first {
+		old := time.Now().Add(-time.Hour)
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+		first[path] = old
+	}
+	for path, mtime := range lookup() {
+		if !mtime.Equal(first[path]) {
+			t.Errorf("%s stored again", filepath.Base(path))
+		}
+	}
+}
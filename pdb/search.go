@@ -0,0 +1,383 @@
+package pdb
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/skdltmxn/pdb-go/demangle"
+	"github.com/skdltmxn/pdb-go/internal/symbols"
+)
+
+// PatternSyntax selects how a search pattern is read.
+type PatternSyntax int
+
+const (
+	PatternText   PatternSyntax = iota // Literal text anywhere in the name
+	PatternExact                       // Literal text equal to the whole name
+	PatternPrefix                      // Literal text at the start of the name
+	PatternGlob                        // Shell glob: * any text, ? any character, [...] a character class
+	PatternRegexp                      // Go regular expression
+)
+
+// SearchOptions controls a name search.
+type SearchOptions struct {
+	Syntax     PatternSyntax
+	IgnoreCase bool
+	PublicOnly bool // Search public symbols only
+	Limit      int  // Maximum number of results (0 = unlimited)
+}
+
+// MatchRank orders search results by how well a name matched.
+type MatchRank int
+
+const (
+	RankExact     MatchRank = iota // The pattern matched the whole name
+	RankPrefix                     // The pattern matched the start of the name
+	RankSubstring                  // The pattern matched elsewhere in the name
+)
+
+func (r MatchRank) String() string {
+	switch r {
+	case RankExact:
+		return "exact"
+	case RankPrefix:
+		return "prefix"
+	default:
+		return "substring"
+	}
+}
+
+// SymbolMatch is a symbol found by a name search.
+type SymbolMatch struct {
+	Symbol Symbol
+	Name   string // The name that matched: the raw name or the undecorated name
+	Rank   MatchRank
+}
+
+// namePattern is a compiled search pattern.
+type namePattern struct {
+	syntax     PatternSyntax
+	ignoreCase bool
+	text       string
+
+	// Regular expression patterns, unanchored and anchored at the start
+	// and at both ends. A glob matches whole names, and only has exactRe.
+	re, prefixRe, exactRe *regexp.Regexp
+}
+
+// compileNamePattern compiles a search pattern.
+func compileNamePattern(pattern string, opts SearchOptions) (*namePattern, error) {
+	p := &namePattern{syntax: opts.Syntax, ignoreCase: opts.IgnoreCase, text: pattern}
+
+	var expr string
+	switch opts.Syntax {
+	case PatternText, PatternExact, PatternPrefix:
+		if opts.IgnoreCase {
+			p.text = strings.ToLower(pattern)
+		}
+		return p, nil
+	case PatternGlob:
+		var err error
+		if expr, err = globToRegexp(pattern); err != nil {
+			return nil, err
+		}
+	case PatternRegexp:
+		expr = pattern
+	default:
+		return nil, fmt.Errorf("pdb: unknown pattern syntax %d", opts.Syntax)
+	}
+
+	flags := ""
+	if opts.IgnoreCase {
+		flags = "(?i)"
+	}
+	var err error
+	if p.exactRe, err = regexp.Compile(flags + "^(?:" + expr + ")$"); err != nil {
+		return nil, fmt.Errorf("pdb: invalid pattern: %w", err)
+	}
+	if opts.Syntax == PatternRegexp {
+		p.re = regexp.MustCompile(flags + "(?:" + expr + ")")
+		p.prefixRe = regexp.MustCompile(flags + "^(?:" + expr + ")")
+	}
+	return p, nil
+}
+
+// match reports whether the name matches the pattern and how well.
+func (p *namePattern) match(name string) (MatchRank, bool) {
+	if p.exactRe != nil {
+		switch {
+		case p.exactRe.MatchString(name):
+			return RankExact, true
+		case p.re == nil:
+			return 0, false
+		case p.prefixRe.MatchString(name):
+			return RankPrefix, true
+		case p.re.MatchString(name):
+			return RankSubstring, true
+		}
+		return 0, false
+	}
+
+	if p.ignoreCase {
+		name = strings.ToLower(name)
+	}
+	return p.matchFolded(name)
+}
+
+// matchFolded matches a literal pattern against a name that is already
+// lower case if the pattern ignores case.
+func (p *namePattern) matchFolded(name string) (MatchRank, bool) {
+	switch {
+	case name == p.text:
+		return RankExact, true
+	case p.syntax == PatternExact:
+		return 0, false
+	case strings.HasPrefix(name, p.text):
+		return RankPrefix, true
+	case p.syntax == PatternPrefix:
+		return 0, false
+	case strings.Contains(name, p.text):
+		return RankSubstring, true
+	}
+	return 0, false
+}
+
+// bestMatch matches the names of a symbol, and their lower-case forms if
+// given, and returns the best match.
+func (p *namePattern) bestMatch(names, folded []string) (SymbolMatch, bool) {
+	var best SymbolMatch
+	found := false
+	for i, name := range names {
+		if name == "" {
+			continue
+		}
+		var rank MatchRank
+		var ok bool
+		if folded != nil && p.exactRe == nil && p.ignoreCase {
+			rank, ok = p.matchFolded(folded[i])
+		} else {
+			rank, ok = p.match(name)
+		}
+		if ok && (!found || rank < best.Rank) {
+			best.Name, best.Rank, found = name, rank, true
+		}
+	}
+	return best, found
+}
+
+// sortSymbolMatches orders matches by rank, then shorter names first.
+// Matches that tie keep their order.
+func sortSymbolMatches(matches []SymbolMatch) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Rank != b.Rank {
+			return a.Rank < b.Rank
+		}
+		return len(a.Name) < len(b.Name)
+	})
+}
+
+// dedupSymbolMatches keeps one of the sorted matches at each address, such
+// as a function's public symbol and its procedure symbol: the best ranked,
+// preferring a symbol other than a public among those. Matches without an
+// address are kept.
+func dedupSymbolMatches(matches []SymbolMatch) []SymbolMatch {
+	type address struct {
+		section uint16
+		offset  uint32
+	}
+	seen := make(map[address]int)
+	out := matches[:0]
+	for _, m := range matches {
+		if m.Symbol.Section() == 0 {
+			out = append(out, m)
+			continue
+		}
+		addr := address{m.Symbol.Section(), m.Symbol.Offset()}
+		i, ok := seen[addr]
+		if !ok {
+			seen[addr] = len(out)
+			out = append(out, m)
+			continue
+		}
+		if _, public := out[i].Symbol.(*PublicSymbol); public && out[i].Rank == m.Rank {
+			if _, public := m.Symbol.(*PublicSymbol); !public {
+				out[i].Symbol = m.Symbol
+			}
+		}
+	}
+	return out
+}
+
+// undecoratedName returns the qualified name of a symbol without its
+// decorations, e.g. MyClass::Run for ?Run@MyClass@@QEAAXXZ, or "" if the
+// name is not decorated.
+func undecoratedName(sym Symbol) string {
+	opts := []demangle.Option{demangle.NameOnly | demangle.NoHash}
+	if pub, ok := sym.(*PublicSymbol); ok && pub.x86 {
+		opts = append(opts, demangle.X86)
+	}
+	name, err := demangle.Demangle(sym.Name(), opts...)
+	if err != nil || name == sym.Name() {
+		return ""
+	}
+	return name
+}
+
+// searchIndex holds the raw and undecorated names of the symbols in the
+// symbol record stream and of the module symbols other than locals and
+// parameters.
+type searchIndex struct {
+	entries []searchEntry
+
+	// Lower-case names for case-insensitive searches (lazy-built)
+	folded     [][2]string
+	foldedOnce sync.Once
+}
+
+type searchEntry struct {
+	offset uint32    // Offset in the symbol record stream
+	sym    Symbol    // Module symbol, or nil for the record at offset
+	names  [2]string // Raw name and undecorated name ("" if not decorated)
+}
+
+func (idx *searchIndex) foldedNames() [][2]string {
+	idx.foldedOnce.Do(func() {
+		idx.folded = make([][2]string, len(idx.entries))
+		for i, e := range idx.entries {
+			idx.folded[i] = [2]string{strings.ToLower(e.names[0]), strings.ToLower(e.names[1])}
+		}
+	})
+	return idx.folded
+}
+
+func (st *SymbolTable) buildSearchIndex() {
+	st.searchIndexOnce.Do(func() {
+		idx := &searchIndex{}
+
+		if err := st.ensureSymRecordData(); err == nil && st.symRecordData != nil {
+			data := st.symRecordData
+			offset := 0
+			for offset < len(data)-4 {
+				rec, size, err := symbols.ParseSymbolRecord(data[offset:])
+				if err != nil {
+					break
+				}
+				if sym, _ := st.convertSymbolRecord(rec); sym != nil && sym.Name() != "" {
+					idx.entries = append(idx.entries, searchEntry{
+						offset: uint32(offset),
+						names:  [2]string{sym.Name(), undecoratedName(sym)},
+					})
+				}
+				offset += size
+			}
+		}
+
+		if modules, err := st.pdb.Modules(); err == nil {
+			for _, mod := range modules {
+				for sym := range mod.Symbols() {
+					switch sym.Kind() {
+					case SymbolKindLocal, SymbolKindParameter, SymbolKindBlock:
+						continue
+					}
+					if sym.Name() != "" {
+						idx.entries = append(idx.entries, searchEntry{
+							sym:   sym,
+							names: [2]string{sym.Name(), undecoratedName(sym)},
+						})
+					}
+				}
+			}
+		}
+
+		st.searchIndex = idx
+	})
+}
+
+// ByPattern searches the public, global and module symbols (other than
+// locals and parameters) by their raw names and by their undecorated
+// names, so that MyClass::Run finds ?Run@MyClass@@QEAAXXZ. Results are
+// ranked exact matches first, then prefix matches, then substring matches;
+// shorter names come first within a rank. A glob matches whole names, so
+// its results are all exact matches. Symbols at the same address, such as
+// a function's public and procedure symbols, are returned once.
+func (st *SymbolTable) ByPattern(pattern string, opts SearchOptions) ([]SymbolMatch, error) {
+	p, err := compileNamePattern(pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	st.buildSearchIndex()
+
+	var folded [][2]string
+	if opts.IgnoreCase && p.exactRe == nil {
+		folded = st.searchIndex.foldedNames()
+	}
+
+	var matches []SymbolMatch
+	for i, e := range st.searchIndex.entries {
+		if opts.PublicOnly && e.sym != nil {
+			continue
+		}
+		var f []string
+		if folded != nil {
+			f = folded[i][:]
+		}
+		m, ok := p.bestMatch(e.names[:], f)
+		if !ok {
+			continue
+		}
+		if m.Symbol = e.sym; m.Symbol == nil {
+			m.Symbol = st.parseSymbolAt(e.offset)
+		}
+		if m.Symbol == nil {
+			continue
+		}
+		if _, public := m.Symbol.(*PublicSymbol); opts.PublicOnly && !public {
+			continue
+		}
+		matches = append(matches, m)
+	}
+
+	sortSymbolMatches(matches)
+	matches = dedupSymbolMatches(matches)
+	if opts.Limit > 0 && len(matches) > opts.Limit {
+		matches = matches[:opts.Limit]
+	}
+	return matches, nil
+}
+
+// globToRegexp translates a shell glob to a regular expression.
+func globToRegexp(glob string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteByte('.')
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("pdb: invalid pattern: unterminated [ in %q", glob)
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String(), nil
+}
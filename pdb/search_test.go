@@ -0,0 +1,129 @@
+package pdb
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob    string
+		expr    string
+		matches []string
+		misses  []string
+	}{
+		{glob: "h?lper", expr: "h.lper", matches: []string{"helper", "hXlper"}, misses: []string{"hlper", "helpers"}},
+		{glob: "Widget::*", expr: `Widget::.*`, matches: []string{"Widget::", "Widget::Draw"}, misses: []string{"MyWidget::Draw"}},
+		{glob: "[abc]x", expr: "[abc]x", matches: []string{"ax", "cx"}, misses: []string{"dx"}},
+		{glob: "[!abc]x", expr: "[^abc]x", matches: []string{"dx"}, misses: []string{"ax"}},
+		{glob: `a\*b`, expr: `a\*b`, matches: []string{"a*b"}, misses: []string{"axb"}},
+		{glob: "operator()", expr: `operator\(\)`, matches: []string{"operator()"}},
+		{glob: "a.b+c", expr: `a\.b\+c`, matches: []string{"a.b+c"}, misses: []string{"axbbc"}},
+	}
+
+	for _, tt := range tests {
+		expr, err := globToRegexp(tt.glob)
+		if err != nil {
+			t.Errorf("globToRegexp(%q): %v", tt.glob, err)
+			continue
+		}
+		if expr != tt.expr {
+			t.Errorf("globToRegexp(%q) = %q, want %q", tt.glob, expr, tt.expr)
+		}
+		re := regexp.MustCompile("^(?:" + expr + ")$")
+		for _, name := range tt.matches {
+			if !re.MatchString(name) {
+				t.Errorf("%q does not match %q", tt.glob, name)
+			}
+		}
+		for _, name := range tt.misses {
+			if re.MatchString(name) {
+				t.Errorf("%q matches %q", tt.glob, name)
+			}
+		}
+	}
+
+	if _, err := globToRegexp("[abc"); err == nil {
+		t.Error("unterminated [ accepted")
+	}
+}
+
+func TestByPattern(t *testing.T) {
+	f, err := Open(filepath.Join("..", "testdata", "x64.pdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	st, err := f.Symbols()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		name string
+		rank MatchRank
+	}
+	tests := []struct {
+		pattern string
+		opts    SearchOptions
+		want    []result
+	}{
+		// Globs match whole names
+		{pattern: "elp*", opts: SearchOptions{Syntax: PatternGlob}},
+		{pattern: "Draw*", opts: SearchOptions{Syntax: PatternGlob}},
+		{pattern: "*Draw", opts: SearchOptions{Syntax: PatternGlob}, want: []result{{"Widget::Draw", RankExact}}},
+		{pattern: "*widget", opts: SearchOptions{Syntax: PatternGlob, IgnoreCase: true},
+			want: []result{{"Widget::Widget", RankExact}, {"Widget::~Widget", RankExact}}},
+
+		// A function's public and procedure symbols are returned once
+		{pattern: "h?lper", opts: SearchOptions{Syntax: PatternGlob}, want: []result{{"helper", RankExact}}},
+		{pattern: "helper", opts: SearchOptions{Syntax: PatternExact}, want: []result{{"helper", RankExact}}},
+
+		// Text and regular expressions still match anywhere, ranked
+		{pattern: "elp", want: []result{{"helper", RankSubstring}}},
+		{pattern: "^t.*r$|help", opts: SearchOptions{Syntax: PatternRegexp},
+			want: []result{{"trap_handler", RankExact}, {"helper", RankPrefix}}},
+		{pattern: "Widget::D", opts: SearchOptions{Syntax: PatternPrefix}, want: []result{{"Widget::Draw", RankPrefix}}},
+	}
+
+	for _, tt := range tests {
+		matches, err := st.ByPattern(tt.pattern, tt.opts)
+		if err != nil {
+			t.Errorf("ByPattern(%q): %v", tt.pattern, err)
+			continue
+		}
+		var got []result
+		for _, m := range matches {
+			got = append(got, result{m.Name, m.Rank})
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ByPattern(%q) = %v, want %v", tt.pattern, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ByPattern(%q) = %v, want %v", tt.pattern, got, tt.want)
+				break
+			}
+		}
+	}
+
+	// The procedure symbol is kept over the public, unless only publics are
+	// searched
+	for _, tt := range []struct {
+		publicOnly bool
+		kind       SymbolKind
+	}{
+		{false, SymbolKindFunction},
+		{true, SymbolKindPublic},
+	} {
+		matches, err := st.ByPattern("helper", SearchOptions{Syntax: PatternExact, PublicOnly: tt.publicOnly})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(matches) != 1 || matches[0].Symbol.Kind() != tt.kind {
+			t.Errorf("helper (public only %v): %d matches, want one %v", tt.publicOnly, len(matches), tt.kind)
+		}
+	}
+}
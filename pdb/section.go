@@ -35,6 +35,12 @@ type SectionHeaders struct {
 	sections []SectionHeader
 }
 
+// NewSectionHeaders wraps section headers taken from another source, such
+// as the executable, for address translation.
+func NewSectionHeaders(sections []SectionHeader) *SectionHeaders {
+	return &SectionHeaders{sections: sections}
+}
+
 // Count returns the number of sections.
 func (sh *SectionHeaders) Count() int {
 	return len(sh.sections)
@@ -139,3 +145,53 @@ func (f *File) loadSectionHeaders() (*SectionHeaders, error) {
 
 	return parseSectionHeaders(data)
 }
+
+// Section characteristics used to classify contributions.
+const (
+	imageScnCntCode              = 0x00000020
+	imageScnCntInitializedData   = 0x00000040
+	imageScnCntUninitializedData = 0x00000080
+)
+
+// SectionContribution describes a range of a PE section contributed by a
+// module.
+type SectionContribution struct {
+	Section         uint16 // 1-based section number
+	Offset          uint32 // Offset within the section
+	Size            uint32
+	Characteristics uint32 // IMAGE_SCN_* flags of the contributing section
+	ModuleIndex     int
+}
+
+// IsCode returns true if the contribution contains executable code.
+func (sc *SectionContribution) IsCode() bool {
+	return sc.Characteristics&imageScnCntCode != 0
+}
+
+// IsData returns true if the contribution contains initialized or
+// uninitialized data.
+func (sc *SectionContribution) IsData() bool {
+	return sc.Characteristics&(imageScnCntInitializedData|imageScnCntUninitializedData) != 0
+}
+
+// SectionContributions returns the section contributions of all modules,
+// in the order they appear in the DBI stream (sorted by section and offset).
+func (f *File) SectionContributions() ([]SectionContribution, error) {
+	dbiStream, err := f.getDBI()
+	if err != nil {
+		return nil, err
+	}
+
+	contribs := make([]SectionContribution, len(dbiStream.SectionContributions))
+	for i, sc := range dbiStream.SectionContributions {
+		contribs[i] = SectionContribution{
+			Section:         sc.Section,
+			Offset:          uint32(sc.Offset),
+			Size:            uint32(sc.Size),
+			Characteristics: sc.Characteristics,
+			ModuleIndex:     int(sc.ModuleIndex),
+		}
+	}
+
+	return contribs, nil
+}
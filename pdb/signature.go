@@ -0,0 +1,282 @@
+package pdb
+
+import (
+	"fmt"
+
+	"github.com/skdltmxn/pdb-go/internal/symbols"
+	"github.com/skdltmxn/pdb-go/internal/tpi"
+)
+
+// Signature describes a complete function signature.
+type Signature struct {
+	ReturnType        TypeIndex
+	Parameters        []Parameter // Excludes the implicit this parameter
+	IsVariadic        bool
+	CallingConvention string
+
+	// Member function information (zero for free functions)
+	ClassType  TypeIndex
+	ThisType   TypeIndex // 0 for static member functions
+	ThisAdjust int32
+
+	// Function attributes
+	IsConstructor  bool
+	IsCtorVBase    bool // Constructor of a class with virtual bases
+	IsCxxReturnUDT bool // Returns a C++ UDT by hidden pointer
+}
+
+// Parameter is a single function parameter.
+type Parameter struct {
+	Name string // Empty when only the type is known
+	Type TypeIndex
+}
+
+// IsMember returns true if the signature belongs to a member function.
+func (s *Signature) IsMember() bool { return s.ClassType != 0 }
+
+// Signature returns the signature of an LF_PROCEDURE or LF_MFUNCTION type,
+// with parameter types taken from its argument list.
+func (tt *TypeTable) Signature(typeIndex TypeIndex) (*Signature, error) {
+	if typeIndex.IsSimpleType() {
+		return nil, ErrTypeNotFound
+	}
+
+	record, err := tt.tpiStream.GetTypeRecord(tpi.TypeIndex(typeIndex))
+	if err != nil || record == nil {
+		return nil, ErrTypeNotFound
+	}
+
+	var sig *Signature
+	var argList tpi.TypeIndex
+
+	switch record.Kind {
+	case tpi.LF_PROCEDURE:
+		rec, err := tpi.ParseProcedureRecord(record.Data)
+		if err != nil {
+			return nil, err
+		}
+		sig = &Signature{
+			ReturnType:        TypeIndex(rec.ReturnType),
+			CallingConvention: rec.CallingConv.String(),
+			IsConstructor:     rec.FunctionOptions.IsConstructor(),
+			IsCtorVBase:       rec.FunctionOptions.IsCtorVBase(),
+			IsCxxReturnUDT:    rec.FunctionOptions.IsCxxReturnUDT(),
+		}
+		argList = rec.ArgumentList
+
+	case tpi.LF_MFUNCTION:
+		rec, err := tpi.ParseMFunctionRecord(record.Data)
+		if err != nil {
+			return nil, err
+		}
+		sig = &Signature{
+			ReturnType:        TypeIndex(rec.ReturnType),
+			CallingConvention: rec.CallingConv.String(),
+			ClassType:         TypeIndex(rec.ClassType),
+			ThisType:          TypeIndex(rec.ThisType),
+			ThisAdjust:        rec.ThisAdjust,
+			IsConstructor:     rec.FunctionOptions.IsConstructor(),
+			IsCtorVBase:       rec.FunctionOptions.IsCtorVBase(),
+			IsCxxReturnUDT:    rec.FunctionOptions.IsCxxReturnUDT(),
+		}
+		argList = rec.ArgumentList
+
+	default:
+		return nil, fmt.Errorf("pdb: type 0x%X is not a function type", uint32(typeIndex))
+	}
+
+	if argList == 0 {
+		return sig, nil
+	}
+
+	argRecord, err := tt.tpiStream.GetTypeRecord(argList)
+	if err != nil || argRecord == nil || argRecord.Kind != tpi.LF_ARGLIST {
+		return sig, nil
+	}
+	args, err := tpi.ParseArgListRecord(argRecord.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, arg := range args.ArgTypes {
+		// A trailing T_NOTYPE marks a C-style variadic function
+		if arg == 0 && i == len(args.ArgTypes)-1 {
+			sig.IsVariadic = true
+			break
+		}
+		sig.Parameters = append(sig.Parameters, Parameter{Type: TypeIndex(arg)})
+	}
+
+	return sig, nil
+}
+
+// frameVar is a variable declared directly in a function's scope.
+type frameVar struct {
+	name        string
+	typeIndex   TypeIndex
+	kind        symbols.SymbolRecordKind
+	isParameter bool // Only known for S_LOCAL
+	offset      int64
+	register    uint16 // Base register of S_REGREL32
+}
+
+// CodeView registers that frame-relative variables are based on
+const (
+	cvRegESP   = 21
+	cvRegEBP   = 22
+	cvAMD64RSP = 335
+)
+
+// parseFrameVar extracts a variable from an S_LOCAL, S_REGREL32 or
+// S_BPREL32 record.
+func parseFrameVar(record *symbols.SymbolRecord) (frameVar, bool) {
+	switch record.Kind {
+	case symbols.S_LOCAL:
+		local, err := symbols.ParseLocalSym(record.Data)
+		if err != nil {
+			return frameVar{}, false
+		}
+		return frameVar{
+			name:        local.Name,
+			typeIndex:   TypeIndex(local.Type),
+			kind:        record.Kind,
+			isParameter: local.Flags.IsParameter(),
+		}, true
+	case symbols.S_REGREL32:
+		rel, err := symbols.ParseRegRelSym(record.Data)
+		if err != nil {
+			return frameVar{}, false
+		}
+		return frameVar{
+			name:      rel.Name,
+			typeIndex: TypeIndex(rel.Type),
+			kind:      record.Kind,
+			offset:    int64(int32(rel.Offset)),
+			register:  rel.Register,
+		}, true
+	case symbols.S_BPREL32:
+		rel, err := symbols.ParseBPRelSym(record.Data)
+		if err != nil {
+			return frameVar{}, false
+		}
+		return frameVar{
+			name:      rel.Name,
+			typeIndex: TypeIndex(rel.Type),
+			kind:      record.Kind,
+			offset:    int64(rel.Offset),
+		}, true
+	}
+	return frameVar{}, false
+}
+
+// Signature returns the function's signature. When the function was read
+// from a module, parameter names are filled in from the S_LOCAL (or, for
+// older PDBs, S_REGREL32/S_BPREL32) records in the function's scope. Older
+// records are used only if each parameter has one at a stack offset above
+// the return address; otherwise the names are left empty.
+func (s *FunctionSymbol) Signature() (*Signature, error) {
+	if s.pdb == nil {
+		return nil, ErrTypeNotFound
+	}
+
+	types, err := s.pdb.Types()
+	if err != nil {
+		return nil, err
+	}
+
+	typeIndex, err := s.functionType()
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := types.Signature(typeIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	names := s.parameterNames(len(sig.Parameters))
+	for i := range sig.Parameters {
+		if i < len(names) {
+			sig.Parameters[i].Name = names[i]
+		}
+	}
+
+	return sig, nil
+}
+
+// functionType returns the TPI function type, following the IPI function
+// id for S_GPROC32_ID/S_LPROC32_ID symbols.
+func (s *FunctionSymbol) functionType() (TypeIndex, error) {
+	if !s.isID {
+		return TypeIndex(s.typeIndex), nil
+	}
+
+	ipi, err := s.pdb.getIPI()
+	if err != nil {
+		return 0, err
+	}
+
+	record, err := ipi.GetTypeRecord(tpi.TypeIndex(s.typeIndex))
+	if err != nil || record == nil {
+		return 0, ErrTypeNotFound
+	}
+	if record.Kind != tpi.LF_FUNC_ID && record.Kind != tpi.LF_MFUNC_ID {
+		return 0, ErrTypeNotFound
+	}
+
+	id, err := tpi.ParseFuncIDRecord(record.Data)
+	if err != nil {
+		return 0, err
+	}
+	return TypeIndex(id.FunctionType), nil
+}
+
+// parameterNames returns the names of the function's parameters in order,
+// excluding the implicit this pointer.
+func (s *FunctionSymbol) parameterNames(count int) []string {
+	var names []string
+
+	// S_LOCAL records carry an explicit parameter flag
+	for _, v := range s.frameLocals {
+		if v.kind == symbols.S_LOCAL && v.isParameter && v.name != "this" {
+			names = append(names, v.name)
+		}
+	}
+	if len(names) > 0 {
+		return names
+	}
+
+	// Otherwise only variables stored above the return address are known
+	// to be parameters. Names are left out unless there is one for every
+	// parameter, since a parameter that lives elsewhere would shift them.
+	machine, _ := s.pdb.Machine()
+	for _, v := range s.frameLocals {
+		if v.kind == symbols.S_LOCAL || v.name == "this" || !s.isStackParameter(machine, v) {
+			continue
+		}
+		names = append(names, v.name)
+	}
+	if len(names) < count {
+		return nil
+	}
+	return names[:count]
+}
+
+// isStackParameter reports whether a frame-relative variable is stored
+// above the function's return address, where only parameters live: above
+// EBP on x86, or past the frame given by S_FRAMEPROC when relative to the
+// stack pointer. Other variables, including all relative to RBP on x64,
+// cannot be told from locals.
+func (s *FunctionSymbol) isStackParameter(machine uint16, v frameVar) bool {
+	switch {
+	case machine == MachineI386 && v.kind == symbols.S_BPREL32:
+		return v.offset > 0
+	case machine == MachineI386 && v.register == cvRegEBP:
+		return v.offset > 0
+	case machine == MachineI386 && v.register == cvRegESP:
+		return s.frameSize > 0 && v.offset >= s.frameSize+4
+	case machine == MachineAMD64 && v.register == cvAMD64RSP:
+		return s.frameSize > 0 && v.offset >= s.frameSize+8
+	}
+	return false
+}
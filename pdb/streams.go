@@ -0,0 +1,63 @@
+package pdb
+
+import (
+	"github.com/skdltmxn/pdb-go/internal/dbi"
+	"github.com/skdltmxn/pdb-go/msf"
+)
+
+// StreamNames describes each stream whose purpose is known: the fixed
+// streams, the named streams of the PDB info stream, and the streams that
+// the DBI and type streams refer to. A stream that cannot be parsed only
+// hides the names it would give.
+func (f *File) StreamNames() map[uint32]string {
+	names := map[uint32]string{
+		msf.StreamOldDirectory: "old directory",
+		msf.StreamPDBInfo:      "PDB info",
+		msf.StreamTPI:          "TPI",
+		msf.StreamDBI:          "DBI",
+		msf.StreamIPI:          "IPI",
+	}
+	name := func(index uint16, name string) {
+		if index != dbi.InvalidStreamIndex {
+			names[uint32(index)] = name
+		}
+	}
+
+	if info, err := f.Info(); err == nil {
+		for n, index := range info.NamedStreams {
+			names[index] = n
+		}
+	}
+	if s, err := f.getTPI(); err == nil {
+		name(s.Header.HashStreamIndex, "TPI hash")
+	}
+	if s, err := f.getIPI(); err == nil {
+		name(s.Header.HashStreamIndex, "IPI hash")
+	}
+
+	dbiStream, err := f.getDBI()
+	if err != nil {
+		return names
+	}
+	name(dbiStream.Header.GlobalStreamIndex, "globals")
+	name(dbiStream.Header.PublicStreamIndex, "publics")
+	name(dbiStream.Header.SymRecordStreamIndex, "symbol records")
+	for i := range dbiStream.Modules {
+		name(dbiStream.Modules[i].ModuleSymStreamIndex, "module "+dbiStream.Modules[i].ModuleName)
+	}
+
+	if h := dbiStream.OptionalDbgStreams; h != nil {
+		name(h.FPOStreamIndex, "FPO")
+		name(h.ExceptionStreamIndex, "exception")
+		name(h.FixupStreamIndex, "fixups")
+		name(h.OmapToSrcStreamIndex, "OMAP to source")
+		name(h.OmapFromSrcStreamIndex, "OMAP from source")
+		name(h.SectionHdrStreamIndex, "section headers")
+		name(h.TokenRidMapStreamIndex, "token RID map")
+		name(h.XDataStreamIndex, "xdata")
+		name(h.PDataStreamIndex, "pdata")
+		name(h.NewFPOStreamIndex, "frame data")
+		name(h.SectionHdrOrigStreamIndex, "original section headers")
+	}
+	return names
+}
@@ -1,11 +1,14 @@
 package pdb
 
 import (
+	"errors"
+	"fmt"
 	"iter"
 	"sync"
 
+	"github.com/skdltmxn/pdb-go/demangle"
 	"github.com/skdltmxn/pdb-go/internal/dbi"
-	"github.com/skdltmxn/pdb-go/internal/demangle"
+	"github.com/skdltmxn/pdb-go/internal/indexcache"
 	"github.com/skdltmxn/pdb-go/internal/symbols"
 )
 
@@ -76,17 +79,25 @@ type baseSymbol struct {
 	name          string
 	demangledName string
 	demangledOnce sync.Once
+	x86           bool // Public name of a 32-bit x86 image, with C decorations
 }
 
 func (s *baseSymbol) Name() string { return s.name }
 
 func (s *baseSymbol) DemangledName() string {
 	s.demangledOnce.Do(func() {
-		s.demangledName = demangle.DemangleSimple(s.name)
+		s.demangledName = demangle.DemangleSimple(s.name, s.demangleOptions()...)
 	})
 	return s.demangledName
 }
 
+func (s *baseSymbol) demangleOptions() []demangle.Option {
+	if s.x86 {
+		return []demangle.Option{demangle.X86}
+	}
+	return nil
+}
+
 // PublicSymbol represents a public symbol export.
 type PublicSymbol struct {
 	baseSymbol
@@ -101,6 +112,13 @@ func (s *PublicSymbol) Offset() uint32   { return s.offset }
 func (s *PublicSymbol) IsCode() bool     { return s.flags.IsCode() }
 func (s *PublicSymbol) IsFunction() bool { return s.flags.IsFunction() }
 
+// CName returns the calling convention and stack argument size encoded in
+// the name of a C function. The second result is false for C++ names and
+// undecorated names.
+func (s *PublicSymbol) CName() (demangle.CName, bool) {
+	return demangle.ParseCName(s.name, s.demangleOptions()...)
+}
+
 // FunctionSymbol represents a function with full debug info.
 type FunctionSymbol struct {
 	baseSymbol
@@ -108,6 +126,13 @@ type FunctionSymbol struct {
 	offset    uint32
 	length    uint32
 	typeIndex uint32
+
+	pdb         *File
+	isID        bool       // typeIndex refers to an IPI LF_FUNC_ID/LF_MFUNC_ID
+	compiledAsC bool       // Declared in a module compiled as C
+	frameLocals []frameVar // Variables declared directly in the function scope
+	frameSize   int64      // Frame and callee-saved register bytes, from S_FRAMEPROC
+	inlineSites []inlineSite
 }
 
 func (s *FunctionSymbol) Kind() SymbolKind  { return SymbolKindFunction }
@@ -122,6 +147,9 @@ type DataSymbol struct {
 	section   uint16
 	offset    uint32
 	typeIndex uint32
+
+	pdb         *File
+	compiledAsC bool // Declared in a module compiled as C
 }
 
 func (s *DataSymbol) Kind() SymbolKind  { return SymbolKindData }
@@ -175,6 +203,9 @@ type SymbolTable struct {
 	addrIndex     *symbols.AddressIndex
 	addrIndexOnce sync.Once
 
+	searchIndex     *searchIndex
+	searchIndexOnce sync.Once
+
 	// PSI for address map
 	psi     *symbols.PSI
 	psiOnce sync.Once
@@ -190,6 +221,12 @@ func newSymbolTable(pdb *File, dbiStream *dbi.Stream) *SymbolTable {
 	}
 }
 
+// isX86 reports whether the PDB is for a 32-bit x86 image, whose public
+// names carry C calling-convention decorations.
+func (st *SymbolTable) isX86() bool {
+	return st.dbiStream.Header.Machine == MachineI386
+}
+
 // ensureSymRecordData loads the symbol record stream data.
 func (st *SymbolTable) ensureSymRecordData() error {
 	st.symRecordDataOnce.Do(func() {
@@ -202,6 +239,27 @@ func (st *SymbolTable) ensureSymRecordData() error {
 	return st.symRecordDataErr
 }
 
+// records returns an iterator over the symbol record stream, or nil if the
+// PDB has none. With OpenOptions.WindowedStreams, a stream that cannot be
+// used in place is read through a bounded window; otherwise the iterator
+// reads the stream data loaded once for the lookup indexes.
+func (st *SymbolTable) records() (*symbols.SymbolIterator, error) {
+	if st.dbiStream.Header.SymRecordStreamIndex == 0xFFFF {
+		return nil, nil
+	}
+	s, err := st.pdb.windowedStream(uint32(st.dbiStream.Header.SymRecordStreamIndex))
+	if err != nil {
+		return nil, err
+	}
+	if s != nil {
+		return symbols.NewSymbolIteratorAt(s, int64(s.Size())), nil
+	}
+	if err := st.ensureSymRecordData(); err != nil {
+		return nil, err
+	}
+	return symbols.NewSymbolIterator(st.symRecordData), nil
+}
+
 // ensurePSI loads and parses the PSI stream.
 func (st *SymbolTable) ensurePSI() error {
 	st.psiOnce.Do(func() {
@@ -244,40 +302,123 @@ func (st *SymbolTable) All() iter.Seq[Symbol] {
 	}
 }
 
+// AllChecked is like All, but also yields the errors of PublicChecked and
+// of each module's SymbolsChecked.
+func (st *SymbolTable) AllChecked() iter.Seq2[Symbol, error] {
+	return func(yield func(Symbol, error) bool) {
+		for sym, err := range st.PublicChecked() {
+			if err != nil {
+				if !yield(nil, err) {
+					return
+				}
+			} else if !yield(sym, nil) {
+				return
+			}
+		}
+
+		modules, err := st.pdb.Modules()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		for _, mod := range modules {
+			for sym, err := range mod.SymbolsChecked() {
+				if !yield(sym, err) {
+					return
+				}
+			}
+		}
+	}
+}
+
 // Public returns an iterator over public symbols only.
 // This streams symbols on-demand without loading all into memory.
 func (st *SymbolTable) Public() iter.Seq[*PublicSymbol] {
 	return func(yield func(*PublicSymbol) bool) {
-		if err := st.ensureSymRecordData(); err != nil || st.symRecordData == nil {
+		for sym, err := range st.PublicChecked() {
+			if err == nil && !yield(sym) {
+				return
+			}
+		}
+	}
+}
+
+// PublicChecked is like Public, but also yields the errors that Public
+// hides: the error reading the symbol record stream, a truncated or corrupt
+// record (*ParseError), after which there are no more symbols, and with
+// OpenOptions.Strict the public symbol records that cannot be decoded
+// (*ParseError).
+func (st *SymbolTable) PublicChecked() iter.Seq2[*PublicSymbol, error] {
+	return func(yield func(*PublicSymbol, error) bool) {
+		records, err := st.records()
+		if err != nil {
+			yield(nil, fmt.Errorf("pdb: failed to read symbol record stream: %w", err))
+			return
+		}
+		if records == nil {
 			return
 		}
 
 		// Stream through symbol records without pre-loading all
-		data := st.symRecordData
-		offset := 0
+		for {
+			offset := int64(records.Offset())
+			rec, err := records.Next()
+			if err != nil {
+				yield(nil, recordError(streamSymbolRecords, offset, err))
+				return
+			}
+			if rec == nil {
+				return
+			}
+			if rec.Kind != symbols.S_PUB32 {
+				continue
+			}
 
-		for offset < len(data)-4 {
-			rec, size, err := symbols.ParseSymbolRecord(data[offset:])
+			sym, err := symbols.ParsePublicSym32(rec.Data)
 			if err != nil {
+				if st.pdb.opts.Strict && !yield(nil, decodeError(streamSymbolRecords, offset, uint16(rec.Kind), err)) {
+					return
+				}
+				continue
+			}
+
+			pubSym := &PublicSymbol{
+				baseSymbol: baseSymbol{name: sym.Name, x86: st.isX86()},
+				section:    sym.Segment,
+				offset:     sym.Offset,
+				flags:      sym.Flags,
+			}
+			if !yield(pubSym, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Globals returns an iterator over the global symbols in the symbol record
+// stream: global and static data, UDTs and constants. Public symbols are
+// excluded; use Public for those.
+func (st *SymbolTable) Globals() iter.Seq[Symbol] {
+	return func(yield func(Symbol) bool) {
+		records, err := st.records()
+		if err != nil || records == nil {
+			return
+		}
+
+		for {
+			rec, err := records.Next()
+			if err != nil || rec == nil {
 				break
 			}
 
-			if rec.Kind == symbols.S_PUB32 {
-				sym, err := symbols.ParsePublicSym32(rec.Data)
-				if err == nil {
-					pubSym := &PublicSymbol{
-						baseSymbol: baseSymbol{name: sym.Name},
-						section:    sym.Segment,
-						offset:     sym.Offset,
-						flags:      sym.Flags,
-					}
-					if !yield(pubSym) {
+			if rec.Kind != symbols.S_PUB32 {
+				if sym, _ := st.convertSymbolRecord(rec); sym != nil {
+					if !yield(sym) {
 						return
 					}
 				}
 			}
-
-			offset += size
 		}
 	}
 }
@@ -292,57 +433,24 @@ func (st *SymbolTable) PublicCached() ([]*PublicSymbol, error) {
 }
 
 func (st *SymbolTable) loadPublicSymbols() ([]*PublicSymbol, error) {
-	if err := st.ensureSymRecordData(); err != nil {
-		return nil, err
-	}
-	if st.symRecordData == nil {
-		return nil, nil
-	}
-
-	// Count first to pre-allocate (single pass overhead but better allocation)
-	data := st.symRecordData
-	count := 0
-	offset := 0
-	for offset < len(data)-4 {
-		rec, size, err := symbols.ParseSymbolRecord(data[offset:])
-		if err != nil {
-			break
-		}
-		if rec.Kind == symbols.S_PUB32 {
-			count++
-		}
-		offset += size
-	}
-
-	// Second pass to collect
-	result := make([]*PublicSymbol, 0, count)
-	offset = 0
-	for offset < len(data)-4 {
-		rec, size, err := symbols.ParseSymbolRecord(data[offset:])
+	var result []*PublicSymbol
+	for sym, err := range st.PublicChecked() {
 		if err != nil {
-			break
-		}
-
-		if rec.Kind == symbols.S_PUB32 {
-			sym, err := symbols.ParsePublicSym32(rec.Data)
-			if err == nil {
-				result = append(result, &PublicSymbol{
-					baseSymbol: baseSymbol{name: sym.Name},
-					section:    sym.Segment,
-					offset:     sym.Offset,
-					flags:      sym.Flags,
-				})
+			// A truncated stream keeps the symbols before it unless strict
+			var perr *ParseError
+			if !errors.As(err, &perr) || st.pdb.opts.Strict {
+				return nil, err
 			}
+			continue
 		}
-
-		offset += size
+		result = append(result, sym)
 	}
-
 	return result, nil
 }
 
 // ByName looks up symbols by their (possibly mangled) name.
-// Uses hash-based index for O(1) average lookup.
+// Uses hash-based index for O(1) average lookup. Use ByPattern to search
+// by undecorated names.
 func (st *SymbolTable) ByName(name string) iter.Seq[Symbol] {
 	return func(yield func(Symbol) bool) {
 		st.buildNameIndex()
@@ -387,7 +495,15 @@ func (st *SymbolTable) buildNameIndex() {
 		if err := st.ensureSymRecordData(); err != nil || st.symRecordData == nil {
 			return
 		}
+		cached := st.pdb.cachedIndex(indexcache.KindSymbolNames)
+		if data := cached.load(); data != nil {
+			if idx, ok := symbols.LoadNameIndex(data, st.symRecordData); ok {
+				st.nameIndex = idx
+				return
+			}
+		}
 		st.nameIndex = symbols.NewNameIndex(st.symRecordData)
+		cached.store(st.nameIndex.Bytes())
 	})
 }
 
@@ -427,15 +543,52 @@ func (st *SymbolTable) FindSymbolContaining(section uint16, offset uint32) (Symb
 	return sym, sym != nil
 }
 
+// publicsAt returns the public symbols at an address.
+func (st *SymbolTable) publicsAt(section uint16, offset uint32) []*PublicSymbol {
+	st.buildAddrIndex()
+	if st.addrIndex == nil {
+		return nil
+	}
+
+	var publics []*PublicSymbol
+	for _, symOffset := range st.addrIndex.FindAllAt(section, offset) {
+		if pub, ok := st.parseSymbolAt(symOffset).(*PublicSymbol); ok {
+			publics = append(publics, pub)
+		}
+	}
+	return publics
+}
+
 func (st *SymbolTable) buildAddrIndex() {
 	st.addrIndexOnce.Do(func() {
 		if err := st.ensureSymRecordData(); err != nil || st.symRecordData == nil {
 			return
 		}
-		if err := st.ensurePSI(); err != nil || st.psi == nil {
-			return
+		cached := st.pdb.cachedIndex(indexcache.KindSymbolAddresses)
+		if data := cached.load(); data != nil {
+			if idx, ok := symbols.LoadAddressIndex(data); ok {
+				st.addrIndex = idx
+				return
+			}
+		}
+
+		// Without a public symbol stream, the public symbols are found in
+		// the symbol record stream
+		var addrMap []uint32
+		if err := st.ensurePSI(); err == nil && st.psi != nil {
+			addrMap = st.psi.AddressMap()
+		} else {
+			for offset := 0; offset < len(st.symRecordData)-4; {
+				_, size, err := symbols.ParseSymbolRecord(st.symRecordData[offset:])
+				if err != nil {
+					break
+				}
+				addrMap = append(addrMap, uint32(offset))
+				offset += size
+			}
 		}
-		st.addrIndex = symbols.NewAddressIndex(st.psi.AddressMap(), st.symRecordData)
+		st.addrIndex = symbols.NewAddressIndex(addrMap, st.symRecordData)
+		cached.store(st.addrIndex.Bytes())
 	})
 }
 
@@ -450,27 +603,28 @@ func (st *SymbolTable) parseSymbolAt(offset uint32) Symbol {
 		return nil
 	}
 
-	return st.convertSymbolRecord(rec)
+	sym, _ := st.convertSymbolRecord(rec)
+	return sym
 }
 
-func (st *SymbolTable) convertSymbolRecord(rec *symbols.SymbolRecord) Symbol {
+func (st *SymbolTable) convertSymbolRecord(rec *symbols.SymbolRecord) (Symbol, error) {
 	switch rec.Kind {
 	case symbols.S_PUB32:
 		sym, err := symbols.ParsePublicSym32(rec.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &PublicSymbol{
-			baseSymbol: baseSymbol{name: sym.Name},
+			baseSymbol: baseSymbol{name: sym.Name, x86: st.isX86()},
 			section:    sym.Segment,
 			offset:     sym.Offset,
 			flags:      sym.Flags,
-		}
+		}, nil
 
 	case symbols.S_GPROC32, symbols.S_LPROC32, symbols.S_GPROC32_ID, symbols.S_LPROC32_ID:
 		sym, err := symbols.ParseProcSym(rec.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &FunctionSymbol{
 			baseSymbol: baseSymbol{name: sym.Name},
@@ -478,43 +632,46 @@ func (st *SymbolTable) convertSymbolRecord(rec *symbols.SymbolRecord) Symbol {
 			offset:     sym.CodeOffset,
 			length:     sym.CodeSize,
 			typeIndex:  uint32(sym.FunctionType),
-		}
+			pdb:        st.pdb,
+			isID:       rec.Kind == symbols.S_GPROC32_ID || rec.Kind == symbols.S_LPROC32_ID,
+		}, nil
 
 	case symbols.S_GDATA32, symbols.S_LDATA32:
 		sym, err := symbols.ParseDataSym(rec.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &DataSymbol{
 			baseSymbol: baseSymbol{name: sym.Name},
 			section:    sym.Segment,
 			offset:     sym.Offset,
 			typeIndex:  uint32(sym.Type),
-		}
+			pdb:        st.pdb,
+		}, nil
 
 	case symbols.S_UDT:
 		sym, err := symbols.ParseUDTSym(rec.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &UDTSymbol{
 			baseSymbol: baseSymbol{name: sym.Name},
 			typeIndex:  uint32(sym.Type),
-		}
+		}, nil
 
 	case symbols.S_CONSTANT:
 		sym, err := symbols.ParseConstantSym(rec.Data)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		return &ConstantSymbol{
 			baseSymbol: baseSymbol{name: sym.Name},
 			value:      sym.Value,
 			typeIndex:  uint32(sym.Type),
-		}
+		}, nil
 
 	default:
-		return nil
+		return nil, nil
 	}
 }
 
@@ -0,0 +1,49 @@
+package pdb_test
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// TestPublicReusesRecords checks that iterating the publics does not read
+// the symbol record stream again each time.
+func TestPublicReusesRecords(t *testing.T) {
+	f, err := pdb.Open(filepath.Join("..", "testdata", "x64.pdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	st, err := f.Symbols()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range st.Public() {
+		break
+	}
+
+	const runs = 100
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for range runs {
+		for range st.Public() {
+			break
+		}
+	}
+	runtime.ReadMemStats(&after)
+
+	var streamSize uint32
+	for index, name := range f.StreamNames() {
+		if name == "symbol records" {
+			if streamSize, err = f.MSF().StreamSize(index); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if perRun := (after.TotalAlloc - before.TotalAlloc) / runs; perRun >= uint64(streamSize)/4 {
+		t.Errorf("Public allocates %d bytes per iteration", perRun)
+	}
+}
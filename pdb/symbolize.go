@@ -0,0 +1,240 @@
+package pdb
+
+import (
+	"sort"
+)
+
+// SourceFrame is the source location of a code address. An address inside
+// inlined code has one frame for each inlined call, innermost first.
+type SourceFrame struct {
+	Function  string // Qualified function name
+	File      string // Empty if the PDB has no line information
+	Line      uint32 // 0 if unknown
+	StartLine uint32 // First line of the function, 0 if unknown
+	Offset    uint32 // Distance of the address from the start of the procedure
+	Inlined   bool
+
+	// LinkageName is the decorated name of the public symbol at the start
+	// of the procedure. It is empty for inlined frames and functions
+	// without a public symbol.
+	LinkageName string
+
+	// Symbol is the procedure, or the public symbol when the PDB has no
+	// module symbols for the address. It is nil for inlined frames.
+	Symbol Symbol
+}
+
+// SymbolizeRVA returns the source frames at a relative virtual address.
+// See Symbolize.
+func (f *File) SymbolizeRVA(rva uint32) ([]SourceFrame, error) {
+	sections, err := f.Sections()
+	if err != nil {
+		return nil, err
+	}
+
+	section, offset := sections.FindSection(rva)
+	if section == 0 {
+		return nil, ErrSymbolNotFound
+	}
+	return f.Symbolize(section, offset)
+}
+
+// Symbolize returns the source frames at a section:offset address,
+// innermost first. The function, file and line come from the module
+// symbols, line tables and inline sites; stripped PDBs fall back to the
+// nearest public symbol. ErrSymbolNotFound is returned if no symbol covers
+// the address.
+func (f *File) Symbolize(section uint16, offset uint32) ([]SourceFrame, error) {
+	mod, fn, err := f.findProcedure(section, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if fn == nil {
+		st, err := f.Symbols()
+		if err != nil {
+			return nil, err
+		}
+		sym, ok := st.FindSymbolContaining(section, offset)
+		if !ok || sym.Section() != section || sym.Offset() > offset {
+			return nil, ErrSymbolNotFound
+		}
+		return []SourceFrame{{
+			Function:    sym.DemangledName(),
+			Offset:      offset - sym.Offset(),
+			Symbol:      sym,
+			LinkageName: sym.Name(),
+		}}, nil
+	}
+
+	frames := mod.inlineFrames(fn, offset-fn.offset)
+
+	outer := SourceFrame{
+		Function: fn.Name(),
+		Offset:   offset - fn.offset,
+		Symbol:   fn,
+	}
+	if st, err := f.Symbols(); err == nil {
+		if sym, ok := st.FindSymbolContaining(section, fn.offset); ok && sym.Kind() == SymbolKindPublic &&
+			sym.Section() == section && sym.Offset() == fn.offset {
+			outer.LinkageName = sym.Name()
+		}
+	}
+	if blocks, err := mod.Lines(); err == nil {
+		if b, l := findLine(blocks, section, offset); l != nil {
+			outer.File, outer.Line = b.FileName, l.LineStart
+		}
+		if _, l := findLine(blocks, section, fn.offset); l != nil {
+			outer.StartLine = l.LineStart
+		}
+	}
+
+	return append(frames, outer), nil
+}
+
+// inlineFrames returns the inlined frames at an offset within a procedure,
+// innermost first.
+func (m *Module) inlineFrames(fn *FunctionSymbol, rel uint32) []SourceFrame {
+	if len(fn.inlineSites) == 0 || m.loadInlinees() != nil {
+		return nil
+	}
+
+	type active struct {
+		site *inlineSite
+		r    inlineRange
+	}
+	var chain []active
+
+	// Descend from the procedure through the inline sites covering the offset
+	parent := -1
+	for {
+		found := -1
+		for i := range fn.inlineSites {
+			site := &fn.inlineSites[i]
+			if site.parent != parent {
+				continue
+			}
+			for _, r := range m.inlineRanges(site, fn.length) {
+				if rel >= r.start && rel < r.end {
+					chain = append(chain, active{site: site, r: r})
+					found = i
+					break
+				}
+			}
+			if found >= 0 {
+				break
+			}
+		}
+		if found < 0 {
+			break
+		}
+		parent = found
+	}
+
+	frames := make([]SourceFrame, 0, len(chain)+1)
+	for i := len(chain) - 1; i >= 0; i-- {
+		a := chain[i]
+		frames = append(frames, SourceFrame{
+			Function:  m.pdb.inlineeName(a.site.inlinee),
+			File:      m.fileNames[a.r.file],
+			Line:      a.r.line,
+			StartLine: m.inlinees[a.site.inlinee].SourceLine,
+			Offset:    rel,
+			Inlined:   true,
+		})
+	}
+	return frames
+}
+
+// findLine returns the line entry covering a section:offset address.
+func findLine(blocks []LineBlock, section uint16, offset uint32) (*LineBlock, *Line) {
+	for i := range blocks {
+		b := &blocks[i]
+		if b.Section != section || offset < b.Offset || offset-b.Offset >= b.Length {
+			continue
+		}
+		for j := range b.Lines {
+			l := &b.Lines[j]
+			if offset >= l.Offset && offset-l.Offset < l.Length && !l.IsHidden() {
+				return b, l
+			}
+		}
+	}
+	return nil, nil
+}
+
+// findProcedure returns the procedure containing an address and its module.
+// The module is located through the section contributions when the PDB has
+// them; otherwise every module is searched.
+func (f *File) findProcedure(section uint16, offset uint32) (*Module, *FunctionSymbol, error) {
+	modules, err := f.Modules()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if contribs := f.sortedCodeContributions(); len(contribs) > 0 {
+		i := sort.Search(len(contribs), func(i int) bool {
+			c := &contribs[i]
+			return c.Section > section || (c.Section == section && c.Offset+c.Size > offset)
+		})
+		if i == len(contribs) || contribs[i].Section != section || contribs[i].Offset > offset {
+			return nil, nil, nil
+		}
+		if idx := contribs[i].ModuleIndex; idx >= 0 && idx < len(modules) {
+			if fn := modules[idx].procedureAt(section, offset); fn != nil {
+				return modules[idx], fn, nil
+			}
+		}
+		return nil, nil, nil
+	}
+
+	for _, mod := range modules {
+		if fn := mod.procedureAt(section, offset); fn != nil {
+			return mod, fn, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// sortedCodeContributions returns the code section contributions sorted by
+// address.
+func (f *File) sortedCodeContributions() []SectionContribution {
+	f.codeContributionsOnce.Do(func() {
+		contribs, err := f.SectionContributions()
+		if err != nil {
+			return
+		}
+		for _, c := range contribs {
+			if c.IsCode() && c.Size > 0 {
+				f.codeContributions = append(f.codeContributions, c)
+			}
+		}
+		sort.Slice(f.codeContributions, func(i, j int) bool {
+			a, b := &f.codeContributions[i], &f.codeContributions[j]
+			if a.Section != b.Section {
+				return a.Section < b.Section
+			}
+			return a.Offset < b.Offset
+		})
+	})
+	return f.codeContributions
+}
+
+// procedureAt returns the module's procedure containing an address.
+func (m *Module) procedureAt(section uint16, offset uint32) *FunctionSymbol {
+	m.loadSymbols()
+
+	i := sort.Search(len(m.procs), func(i int) bool {
+		p := m.procs[i]
+		return p.section > section || (p.section == section && p.offset > offset)
+	})
+	if i == 0 {
+		return nil
+	}
+
+	fn := m.procs[i-1]
+	if fn.section != section || offset-fn.offset >= fn.length {
+		return nil
+	}
+	return fn
+}
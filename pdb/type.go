@@ -2,9 +2,11 @@ package pdb
 
 import (
 	"iter"
+	"sort"
 	"strings"
 	"sync"
 
+	"github.com/skdltmxn/pdb-go/internal/indexcache"
 	"github.com/skdltmxn/pdb-go/internal/tpi"
 )
 
@@ -90,6 +92,7 @@ type PrimitiveType struct {
 	name      string
 	size      uint64
 	isPointer bool
+	isSigned  bool
 }
 
 func (t *PrimitiveType) Index() TypeIndex { return t.index }
@@ -97,6 +100,7 @@ func (t *PrimitiveType) Kind() TypeKind   { return TypeKindPrimitive }
 func (t *PrimitiveType) Name() string     { return t.name }
 func (t *PrimitiveType) Size() uint64     { return t.size }
 func (t *PrimitiveType) IsPointer() bool  { return t.isPointer }
+func (t *PrimitiveType) IsSigned() bool   { return t.isSigned }
 
 // PointerType represents a pointer type.
 type PointerType struct {
@@ -189,12 +193,12 @@ type ClassType struct {
 	derivedFrom TypeIndex
 	vshape      TypeIndex
 	isForwardRef bool
+	table        *TypeTable
 }
 
 func (t *ClassType) Index() TypeIndex      { return t.index }
 func (t *ClassType) Kind() TypeKind        { return TypeKindClass }
 func (t *ClassType) Name() string          { return t.name }
-func (t *ClassType) Size() uint64          { return t.size }
 func (t *ClassType) UniqueName() string    { return t.uniqueName }
 func (t *ClassType) MemberCount() uint16   { return t.memberCount }
 func (t *ClassType) FieldList() TypeIndex  { return t.fieldList }
@@ -202,6 +206,17 @@ func (t *ClassType) DerivedFrom() TypeIndex { return t.derivedFrom }
 func (t *ClassType) VShape() TypeIndex     { return t.vshape }
 func (t *ClassType) IsForwardRef() bool    { return t.isForwardRef }
 
+// Size returns the size in bytes. For forward references, the size of the
+// complete definition is returned when one can be found.
+func (t *ClassType) Size() uint64 {
+	if t.isForwardRef && t.table != nil {
+		if def := t.table.definition(t.index); def != nil {
+			return def.Size()
+		}
+	}
+	return t.size
+}
+
 // StructType represents a struct type.
 type StructType struct {
 	index       TypeIndex
@@ -213,12 +228,12 @@ type StructType struct {
 	derivedFrom TypeIndex
 	vshape      TypeIndex
 	isForwardRef bool
+	table        *TypeTable
 }
 
 func (t *StructType) Index() TypeIndex      { return t.index }
 func (t *StructType) Kind() TypeKind        { return TypeKindStruct }
 func (t *StructType) Name() string          { return t.name }
-func (t *StructType) Size() uint64          { return t.size }
 func (t *StructType) UniqueName() string    { return t.uniqueName }
 func (t *StructType) MemberCount() uint16   { return t.memberCount }
 func (t *StructType) FieldList() TypeIndex  { return t.fieldList }
@@ -226,6 +241,17 @@ func (t *StructType) DerivedFrom() TypeIndex { return t.derivedFrom }
 func (t *StructType) VShape() TypeIndex     { return t.vshape }
 func (t *StructType) IsForwardRef() bool    { return t.isForwardRef }
 
+// Size returns the size in bytes. For forward references, the size of the
+// complete definition is returned when one can be found.
+func (t *StructType) Size() uint64 {
+	if t.isForwardRef && t.table != nil {
+		if def := t.table.definition(t.index); def != nil {
+			return def.Size()
+		}
+	}
+	return t.size
+}
+
 // UnionType represents a union type.
 type UnionType struct {
 	index       TypeIndex
@@ -235,17 +261,28 @@ type UnionType struct {
 	memberCount uint16
 	fieldList   TypeIndex
 	isForwardRef bool
+	table        *TypeTable
 }
 
 func (t *UnionType) Index() TypeIndex     { return t.index }
 func (t *UnionType) Kind() TypeKind       { return TypeKindUnion }
 func (t *UnionType) Name() string         { return t.name }
-func (t *UnionType) Size() uint64         { return t.size }
 func (t *UnionType) UniqueName() string   { return t.uniqueName }
 func (t *UnionType) MemberCount() uint16  { return t.memberCount }
 func (t *UnionType) FieldList() TypeIndex { return t.fieldList }
 func (t *UnionType) IsForwardRef() bool   { return t.isForwardRef }
 
+// Size returns the size in bytes. For forward references, the size of the
+// complete definition is returned when one can be found.
+func (t *UnionType) Size() uint64 {
+	if t.isForwardRef && t.table != nil {
+		if def := t.table.definition(t.index); def != nil {
+			return def.Size()
+		}
+	}
+	return t.size
+}
+
 // EnumType represents an enum type.
 type EnumType struct {
 	index          TypeIndex
@@ -255,18 +292,30 @@ type EnumType struct {
 	fieldList      TypeIndex
 	count          uint16
 	isForwardRef   bool
+	table          *TypeTable
 }
 
 func (t *EnumType) Index() TypeIndex         { return t.index }
 func (t *EnumType) Kind() TypeKind           { return TypeKindEnum }
 func (t *EnumType) Name() string             { return t.name }
-func (t *EnumType) Size() uint64             { return 0 } // Size depends on underlying type
 func (t *EnumType) UniqueName() string       { return t.uniqueName }
 func (t *EnumType) UnderlyingType() TypeIndex { return t.underlyingType }
 func (t *EnumType) FieldList() TypeIndex     { return t.fieldList }
 func (t *EnumType) Count() uint16            { return t.count }
 func (t *EnumType) IsForwardRef() bool       { return t.isForwardRef }
 
+// Size returns the size of the enum's underlying type.
+func (t *EnumType) Size() uint64 {
+	if t.table == nil {
+		return 0
+	}
+	underlying, err := t.table.ByIndex(t.underlyingType)
+	if err != nil {
+		return 0
+	}
+	return underlying.Size()
+}
+
 // BitfieldType represents a bitfield type.
 type BitfieldType struct {
 	index       TypeIndex
@@ -303,22 +352,36 @@ func (t *ModifierType) IsUnaligned() bool      { return t.isUnaligned }
 
 // TypeTable provides access to types in the PDB.
 type TypeTable struct {
+	pdb       *File
 	tpiStream *tpi.Stream
 
 	// Lazy-loaded types
 	typeCache sync.Map // map[TypeIndex]Type
 
+	// Forward reference resolution cache
+	resolved sync.Map // map[TypeIndex]TypeIndex
+
 	// Index by name for named types (protected by byNameOnce)
-	byName     map[string][]Type
+	byName     indexcache.HashIndex
 	byNameOnce sync.Once
 
 	// Index for member lookup (lazy-built, protected by memberIndexOnce)
 	memberIndex     *memberNameIndex
 	memberIndexOnce sync.Once
+
+	// Index for enumerator lookup (lazy-built, protected by enumeratorIndexOnce)
+	enumeratorIndex     map[string][]*Enumerator
+	enumeratorIndexOnce sync.Once
+
+	// Index of `??_7` vftable symbols by class (lazy-built, protected by vftableIndexOnce)
+	vftableIndex     map[string][]vftableSymbol
+	vftableIndexOnce sync.Once
 }
 
 // memberNameIndex provides fast member name lookup.
 type memberNameIndex struct {
+	// members lists all members in type index order
+	members []*Member
 	// byName maps member name -> list of members
 	byName map[string][]*Member
 	// byQualifiedName maps "OwnerName::MemberName" -> list of members
@@ -327,8 +390,9 @@ type memberNameIndex struct {
 	inheritance map[string][]string
 }
 
-func newTypeTable(tpiStream *tpi.Stream) *TypeTable {
+func newTypeTable(pdb *File, tpiStream *tpi.Stream) *TypeTable {
 	return &TypeTable{
+		pdb:       pdb,
 		tpiStream: tpiStream,
 	}
 }
@@ -351,6 +415,52 @@ func (tt *TypeTable) All() iter.Seq[Type] {
 	}
 }
 
+// AllChecked is like All, but also yields the errors that All hides as
+// *ParseError values: first a TPI or IPI hash stream that cannot be read,
+// which makes lookups by name scan every record, then a type record that
+// cannot be read, after which there are no more types, and with
+// OpenOptions.Strict the type records that cannot be decoded.
+func (tt *TypeTable) AllChecked() iter.Seq2[Type, error] {
+	return func(yield func(Type, error) bool) {
+		if err := tt.pdb.tpiHashErr; err != nil && !yield(nil, err) {
+			return
+		}
+		if _, err := tt.pdb.getIPI(); err == nil && tt.pdb.ipiHashErr != nil && !yield(nil, tt.pdb.ipiHashErr) {
+			return
+		}
+
+		begin := tt.tpiStream.TypeIndexBegin()
+		end := tt.tpiStream.TypeIndexEnd()
+
+		for ti := begin; ti < end; ti++ {
+			typ, err := tt.ByIndex(TypeIndex(ti))
+			if err == nil {
+				if typ != nil && !yield(typ, nil) {
+					return
+				}
+				continue
+			}
+
+			// Tell a record that cannot be read from one that cannot be decoded
+			offset, readErr := tt.tpiStream.RecordOffset(ti)
+			if readErr == nil {
+				var record *tpi.TypeRecord
+				if record, readErr = tt.tpiStream.GetTypeRecord(ti); readErr == nil && record != nil {
+					if tt.pdb.opts.Strict && !yield(nil, decodeError(streamTPI, int64(offset), uint16(record.Kind), err)) {
+						return
+					}
+					continue
+				}
+			}
+			if readErr == nil {
+				readErr = err
+			}
+			yield(nil, recordError(streamTPI, int64(offset), readErr))
+			return
+		}
+	}
+}
+
 // ByIndex returns the type at the given index.
 func (tt *TypeTable) ByIndex(index TypeIndex) (Type, error) {
 	// Check cache
@@ -385,12 +495,35 @@ func (tt *TypeTable) ByIndex(index TypeIndex) (Type, error) {
 	return typ, nil
 }
 
-// ByName looks up types by name.
+// ByName looks up types by name, including forward references.
+// When the PDB has a TPI hash stream, the complete UDT definitions in the
+// name's hash bucket are yielded first, so callers that stop at the first
+// match do not build the name index. The hash stream does not index forward
+// references or other types by name, so the remaining types with the name
+// are then yielded from the full name index, in type index order.
 func (tt *TypeTable) ByName(name string) iter.Seq[Type] {
 	return func(yield func(Type) bool) {
-		tt.buildNameIndex()
+		var seen map[TypeIndex]bool
+		if tt.tpiStream.HasHashTable() {
+			for _, ti := range tt.tpiStream.TypesInBucket(tt.tpiStream.NameBucket(name)) {
+				typ, err := tt.ByIndex(TypeIndex(ti))
+				if err != nil || typ.Name() != name {
+					continue
+				}
+				if seen == nil {
+					seen = make(map[TypeIndex]bool)
+				}
+				seen[typ.Index()] = true
+				if !yield(typ) {
+					return
+				}
+			}
+		}
 
-		for _, typ := range tt.byName[name] {
+		for _, typ := range tt.typesNamed(name) {
+			if seen[typ.Index()] {
+				continue
+			}
 			if !yield(typ) {
 				return
 			}
@@ -398,16 +531,154 @@ func (tt *TypeTable) ByName(name string) iter.Seq[Type] {
 	}
 }
 
+// Resolve returns the complete definition of a forward-referenced class,
+// struct, union or enum. The definition is located through the TPI hash
+// stream and matched by unique name (or name, if the type has no unique name).
+// Types that are not forward references are returned unchanged, as is a
+// forward reference whose definition is not present in the PDB.
+func (tt *TypeTable) Resolve(index TypeIndex) (Type, error) {
+	return tt.ByIndex(tt.resolveIndex(index))
+}
+
+// resolveIndex returns the index of the complete definition for a forward
+// reference, or index itself if there is nothing to resolve.
+func (tt *TypeTable) resolveIndex(index TypeIndex) TypeIndex {
+	if cached, ok := tt.resolved.Load(index); ok {
+		return cached.(TypeIndex)
+	}
+
+	result := index
+	if def := tt.findDefinition(index); def != nil {
+		result = def.Index()
+	}
+
+	tt.resolved.Store(index, result)
+	return result
+}
+
+// definition returns the complete definition of a forward reference,
+// or nil if none is found.
+func (tt *TypeTable) definition(index TypeIndex) Type {
+	resolved := tt.resolveIndex(index)
+	if resolved == index {
+		return nil
+	}
+	typ, err := tt.ByIndex(resolved)
+	if err != nil {
+		return nil
+	}
+	return typ
+}
+
+func (tt *TypeTable) findDefinition(index TypeIndex) Type {
+	if index.IsSimpleType() {
+		return nil
+	}
+
+	typ, err := tt.ByIndex(index)
+	if err != nil {
+		return nil
+	}
+
+	name, uniqueName, isForwardRef, ok := udtIdentity(typ)
+	if !ok || !isForwardRef {
+		return nil
+	}
+
+	matches := func(candidate Type) bool {
+		if candidate.Kind() != typ.Kind() {
+			return false
+		}
+		cName, cUniqueName, cForwardRef, ok := udtIdentity(candidate)
+		if !ok || cForwardRef {
+			return false
+		}
+		if uniqueName != "" && cUniqueName != "" {
+			return uniqueName == cUniqueName
+		}
+		return name == cName
+	}
+
+	if tt.tpiStream.HasHashTable() {
+		// Full definitions are hashed by name, or by unique name for
+		// scoped types, so both buckets may hold the definition.
+		buckets := []uint32{tt.tpiStream.NameBucket(name)}
+		if uniqueName != "" {
+			buckets = append(buckets, tt.tpiStream.NameBucket(uniqueName))
+		}
+
+		for _, bucket := range buckets {
+			for _, ti := range tt.tpiStream.TypesInBucket(bucket) {
+				candidate, err := tt.ByIndex(TypeIndex(ti))
+				if err != nil {
+					continue
+				}
+				if matches(candidate) {
+					return candidate
+				}
+			}
+		}
+		return nil
+	}
+
+	// No hash stream: fall back to the name index
+	for _, candidate := range tt.typesNamed(name) {
+		if matches(candidate) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// udtIdentity extracts the identifying properties of a user-defined type.
+func udtIdentity(typ Type) (name, uniqueName string, isForwardRef, ok bool) {
+	switch t := typ.(type) {
+	case *ClassType:
+		return t.name, t.uniqueName, t.isForwardRef, true
+	case *StructType:
+		return t.name, t.uniqueName, t.isForwardRef, true
+	case *UnionType:
+		return t.name, t.uniqueName, t.isForwardRef, true
+	case *EnumType:
+		return t.name, t.uniqueName, t.isForwardRef, true
+	default:
+		return "", "", false, false
+	}
+}
+
+// typesNamed returns the types with the given name, in type index order.
+func (tt *TypeTable) typesNamed(name string) []Type {
+	tt.buildNameIndex()
+
+	var types []Type
+	for _, ti := range tt.byName.Lookup(name) {
+		typ, err := tt.ByIndex(TypeIndex(ti))
+		if err == nil && typ.Name() == name {
+			types = append(types, typ)
+		}
+	}
+	return types
+}
+
 func (tt *TypeTable) buildNameIndex() {
 	tt.byNameOnce.Do(func() {
-		tt.byName = make(map[string][]Type)
+		cached := tt.pdb.cachedIndex(indexcache.KindTypeNames)
+		if data := cached.load(); data != nil {
+			if idx, ok := indexcache.LoadHashIndex(data); ok {
+				tt.byName = idx
+				return
+			}
+		}
 
+		var b indexcache.HashIndexBuilder
 		for typ := range tt.All() {
 			name := typ.Name()
 			if name != "" {
-				tt.byName[name] = append(tt.byName[name], typ)
+				b.Add(name, uint32(typ.Index()))
 			}
 		}
+		tt.byName = b.Build()
+		cached.store(tt.byName)
 	})
 }
 
@@ -530,8 +801,19 @@ func (tt *TypeTable) parseSimpleType(index TypeIndex) Type {
 		size = 0
 	}
 
+	var isSigned bool
+	switch kind {
+	case tpi.SimpleTypeSignedChar, tpi.SimpleTypeNarrowChar, tpi.SimpleTypeSByte,
+		tpi.SimpleTypeInt16Short, tpi.SimpleTypeInt16, tpi.SimpleTypeInt32Long, tpi.SimpleTypeInt32,
+		tpi.SimpleTypeInt64Quad, tpi.SimpleTypeInt64, tpi.SimpleTypeInt128Oct, tpi.SimpleTypeInt128,
+		tpi.SimpleTypeFloat16, tpi.SimpleTypeFloat32, tpi.SimpleTypeFloat64, tpi.SimpleTypeFloat80,
+		tpi.SimpleTypeFloat128, tpi.SimpleTypeHResult:
+		isSigned = true
+	}
+
 	isPointer := mode != tpi.SimpleModeDirect
 	if isPointer {
+		isSigned = false
 		switch mode {
 		case tpi.SimpleModeNearPointer, tpi.SimpleModeNearPointer32:
 			size = 4
@@ -547,6 +829,7 @@ func (tt *TypeTable) parseSimpleType(index TypeIndex) Type {
 		name:      name,
 		size:      size,
 		isPointer: isPointer,
+		isSigned:  isSigned,
 	}
 }
 
@@ -638,6 +921,7 @@ func (tt *TypeTable) parseTypeRecord(index TypeIndex, record *tpi.TypeRecord) (T
 			derivedFrom:  TypeIndex(rec.DerivedFrom),
 			vshape:       TypeIndex(rec.VShape),
 			isForwardRef: rec.Properties.IsForwardRef(),
+			table:        tt,
 		}, nil
 
 	case tpi.LF_STRUCTURE, tpi.LF_STRUCTURE_ST:
@@ -655,6 +939,7 @@ func (tt *TypeTable) parseTypeRecord(index TypeIndex, record *tpi.TypeRecord) (T
 			derivedFrom:  TypeIndex(rec.DerivedFrom),
 			vshape:       TypeIndex(rec.VShape),
 			isForwardRef: rec.Properties.IsForwardRef(),
+			table:        tt,
 		}, nil
 
 	case tpi.LF_UNION, tpi.LF_UNION_ST:
@@ -670,6 +955,7 @@ func (tt *TypeTable) parseTypeRecord(index TypeIndex, record *tpi.TypeRecord) (T
 			memberCount:  rec.MemberCount,
 			fieldList:    TypeIndex(rec.FieldList),
 			isForwardRef: rec.Properties.IsForwardRef(),
+			table:        tt,
 		}, nil
 
 	case tpi.LF_ENUM, tpi.LF_ENUM_ST:
@@ -685,6 +971,7 @@ func (tt *TypeTable) parseTypeRecord(index TypeIndex, record *tpi.TypeRecord) (T
 			fieldList:      TypeIndex(rec.FieldList),
 			count:          rec.Count,
 			isForwardRef:   rec.Properties.IsForwardRef(),
+			table:          tt,
 		}, nil
 
 	case tpi.LF_BITFIELD:
@@ -814,194 +1101,532 @@ func (tt *TypeTable) getInheritanceChain(className string) []string {
 
 func (tt *TypeTable) buildMemberIndex() {
 	tt.memberIndexOnce.Do(func() {
-		typeCount := int(tt.tpiStream.TypeCount())
-
-		idx := &memberNameIndex{
-			byName:          make(map[string][]*Member, typeCount/4),
-			byQualifiedName: make(map[string][]*Member, typeCount/4),
-			inheritance:     make(map[string][]string, typeCount/8),
+		cached := tt.pdb.cachedIndex(indexcache.KindMembers)
+		if data := cached.load(); data != nil {
+			if idx, err := decodeMemberIndex(data); err == nil {
+				tt.memberIndex = idx
+				return
+			}
 		}
+		tt.memberIndex = tt.scanMembers()
+		cached.store(tt.memberIndex.encode())
+	})
+}
 
-		// Single pass: collect type names and parse class definitions
-		// Store parsed class info for deferred inheritance resolution
-		type classInfo struct {
-			name           string
-			fieldListIndex tpi.TypeIndex
-			typeIndex      tpi.TypeIndex
-		}
+// scanMembers builds the member index from the class and union records.
+func (tt *TypeTable) scanMembers() *memberNameIndex {
+	typeCount := int(tt.tpiStream.TypeCount())
 
-		typeNames := make(map[tpi.TypeIndex]string, typeCount/2)
-		classes := make([]classInfo, 0, typeCount/4)
+	idx := &memberNameIndex{
+		byName:          make(map[string][]*Member, typeCount/4),
+		byQualifiedName: make(map[string][]*Member, typeCount/4),
+		inheritance:     make(map[string][]string, typeCount/8),
+	}
 
-		begin := tt.tpiStream.TypeIndexBegin()
-		end := tt.tpiStream.TypeIndexEnd()
+	// Single pass: collect type names and parse class definitions
+	// Store parsed class info for deferred inheritance resolution
+	type classInfo struct {
+		name           string
+		fieldListIndex tpi.TypeIndex
+		typeIndex      tpi.TypeIndex
+	}
 
-		for ti := begin; ti < end; ti++ {
-			record, err := tt.tpiStream.GetTypeRecord(ti)
-			if err != nil || record == nil {
+	typeNames := make(map[tpi.TypeIndex]string, typeCount/2)
+	classes := make([]classInfo, 0, typeCount/4)
+
+	begin := tt.tpiStream.TypeIndexBegin()
+	end := tt.tpiStream.TypeIndexEnd()
+
+	for ti := begin; ti < end; ti++ {
+		record, err := tt.tpiStream.GetTypeRecord(ti)
+		if err != nil || record == nil {
+			continue
+		}
+
+		switch record.Kind {
+		case tpi.LF_CLASS, tpi.LF_CLASS_ST, tpi.LF_STRUCTURE, tpi.LF_STRUCTURE_ST:
+			rec, err := tpi.ParseClassRecord(record.Data)
+			if err != nil {
+				continue
+			}
+			typeNames[ti] = rec.Name
+			if !rec.Properties.IsForwardRef() && rec.FieldList != 0 {
+				classes = append(classes, classInfo{
+					name:           rec.Name,
+					fieldListIndex: rec.FieldList,
+					typeIndex:      ti,
+				})
+			}
+		case tpi.LF_UNION, tpi.LF_UNION_ST:
+			rec, err := tpi.ParseUnionRecord(record.Data)
+			if err != nil {
 				continue
 			}
+			typeNames[ti] = rec.Name
+			if !rec.Properties.IsForwardRef() && rec.FieldList != 0 {
+				classes = append(classes, classInfo{
+					name:           rec.Name,
+					fieldListIndex: rec.FieldList,
+					typeIndex:      ti,
+				})
+			}
+		}
+	}
 
-			switch record.Kind {
-			case tpi.LF_CLASS, tpi.LF_CLASS_ST, tpi.LF_STRUCTURE, tpi.LF_STRUCTURE_ST:
-				rec, err := tpi.ParseClassRecord(record.Data)
-				if err != nil {
-					continue
+	// Process collected classes: build member index and inheritance map
+	for _, cls := range classes {
+		fieldList, err := tt.fieldListMembers(cls.fieldListIndex)
+		if err != nil {
+			continue
+		}
+
+		for _, member := range fieldList {
+			var m *Member
+
+			switch mem := member.(type) {
+			case *tpi.MemberRecord:
+				m = &Member{
+					Name:      mem.Name,
+					Type:      TypeIndex(mem.Type),
+					Offset:    mem.Offset,
+					Access:    tpi.MemberAccess(mem.Access).String(),
+					OwnerType: TypeIndex(cls.typeIndex),
+					OwnerName: cls.name,
 				}
-				typeNames[ti] = rec.Name
-				if !rec.Properties.IsForwardRef() && rec.FieldList != 0 {
-					classes = append(classes, classInfo{
-						name:           rec.Name,
-						fieldListIndex: rec.FieldList,
-						typeIndex:      ti,
-					})
+			case *tpi.StaticMemberRecord:
+				m = &Member{
+					Name:      mem.Name,
+					Type:      TypeIndex(mem.Type),
+					Offset:    0,
+					Access:    tpi.MemberAccess(mem.Access).String(),
+					OwnerType: TypeIndex(cls.typeIndex),
+					OwnerName: cls.name,
+					IsStatic:  true,
 				}
-			case tpi.LF_UNION, tpi.LF_UNION_ST:
-				rec, err := tpi.ParseUnionRecord(record.Data)
-				if err != nil {
-					continue
+			case *tpi.BaseClassRecord:
+				if baseName := typeNames[mem.Type]; baseName != "" {
+					idx.inheritance[cls.name] = append(idx.inheritance[cls.name], baseName)
 				}
-				typeNames[ti] = rec.Name
-				if !rec.Properties.IsForwardRef() && rec.FieldList != 0 {
-					classes = append(classes, classInfo{
-						name:           rec.Name,
-						fieldListIndex: rec.FieldList,
-						typeIndex:      ti,
-					})
+			case *tpi.VirtualBaseClassRecord:
+				if baseName := typeNames[mem.BaseType]; baseName != "" {
+					idx.inheritance[cls.name] = append(idx.inheritance[cls.name], baseName)
 				}
 			}
-		}
 
-		// Process collected classes: build member index and inheritance map
-		for _, cls := range classes {
-			fieldRecord, err := tt.tpiStream.GetTypeRecord(cls.fieldListIndex)
-			if err != nil || fieldRecord == nil || fieldRecord.Kind != tpi.LF_FIELDLIST {
-				continue
+			if m != nil {
+				idx.add(m)
 			}
+		}
+	}
 
-			fieldList, err := tpi.ParseFieldListRecord(fieldRecord.Data)
-			if err != nil {
-				continue
-			}
+	return idx
+}
 
-			for _, member := range fieldList.Members {
-				var m *Member
-
-				switch mem := member.(type) {
-				case *tpi.MemberRecord:
-					m = &Member{
-						Name:      mem.Name,
-						Type:      TypeIndex(mem.Type),
-						Offset:    mem.Offset,
-						Access:    tpi.MemberAccess(mem.Access).String(),
-						OwnerType: TypeIndex(cls.typeIndex),
-						OwnerName: cls.name,
-					}
-				case *tpi.StaticMemberRecord:
-					m = &Member{
-						Name:      mem.Name,
-						Type:      TypeIndex(mem.Type),
-						Offset:    0,
-						Access:    tpi.MemberAccess(mem.Access).String(),
-						OwnerType: TypeIndex(cls.typeIndex),
-						OwnerName: cls.name,
-						IsStatic:  true,
-					}
-				case *tpi.BaseClassRecord:
-					if baseName := typeNames[mem.Type]; baseName != "" {
-						idx.inheritance[cls.name] = append(idx.inheritance[cls.name], baseName)
-					}
-				case *tpi.VirtualBaseClassRecord:
-					if baseName := typeNames[mem.BaseType]; baseName != "" {
-						idx.inheritance[cls.name] = append(idx.inheritance[cls.name], baseName)
-					}
-				}
+func (idx *memberNameIndex) add(m *Member) {
+	idx.members = append(idx.members, m)
+	idx.byName[m.Name] = append(idx.byName[m.Name], m)
+	qualifiedName := m.OwnerName + "::" + m.Name
+	idx.byQualifiedName[qualifiedName] = append(idx.byQualifiedName[qualifiedName], m)
+}
 
-				if m != nil {
-					idx.byName[m.Name] = append(idx.byName[m.Name], m)
-					qualifiedName := cls.name + "::" + m.Name
-					idx.byQualifiedName[qualifiedName] = append(idx.byQualifiedName[qualifiedName], m)
-				}
-			}
+// encode stores the index for the index cache: the members in index
+// order, then each class's base class names.
+func (idx *memberNameIndex) encode() []byte {
+	var e indexcache.Encoder
+	e.AddUint32(uint32(len(idx.members)))
+	for _, m := range idx.members {
+		e.AddString(m.Name)
+		e.AddString(m.OwnerName)
+		e.AddString(m.Access)
+		e.AddUint32(uint32(m.Type))
+		e.AddUint32(uint32(m.OwnerType))
+		e.AddUint64(m.Offset)
+		if m.IsStatic {
+			e.AddUint8(1)
+		} else {
+			e.AddUint8(0)
 		}
+	}
 
-		tt.memberIndex = idx
-	})
+	classes := make([]string, 0, len(idx.inheritance))
+	for class := range idx.inheritance {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	e.AddUint32(uint32(len(classes)))
+	for _, class := range classes {
+		e.AddString(class)
+		e.AddUint32(uint32(len(idx.inheritance[class])))
+		for _, base := range idx.inheritance[class] {
+			e.AddString(base)
+		}
+	}
+	return e.Bytes()
+}
+
+// decodeMemberIndex reads an index stored by encode.
+func decodeMemberIndex(data []byte) (*memberNameIndex, error) {
+	d := indexcache.NewDecoder(data)
+	idx := &memberNameIndex{
+		byName:          make(map[string][]*Member),
+		byQualifiedName: make(map[string][]*Member),
+		inheritance:     make(map[string][]string),
+	}
+
+	count := d.ReadUint32()
+	for i := uint32(0); i < count && d.Err() == nil; i++ {
+		m := &Member{
+			Name:      d.ReadString(),
+			OwnerName: d.ReadString(),
+			Access:    d.ReadString(),
+			Type:      TypeIndex(d.ReadUint32()),
+			OwnerType: TypeIndex(d.ReadUint32()),
+			Offset:    d.ReadUint64(),
+			IsStatic:  d.ReadUint8() != 0,
+		}
+		idx.add(m)
+	}
+
+	count = d.ReadUint32()
+	for i := uint32(0); i < count && d.Err() == nil; i++ {
+		class := d.ReadString()
+		n := d.ReadUint32()
+		for j := uint32(0); j < n && d.Err() == nil; j++ {
+			idx.inheritance[class] = append(idx.inheritance[class], d.ReadString())
+		}
+	}
+
+	if d.Err() == nil && d.Remaining() != 0 {
+		return nil, indexcache.ErrCorrupt
+	}
+	return idx, d.Err()
 }
 
 // GetMembers returns all members of a class/struct/union type.
+// Forward references are resolved to their complete definition.
 func (tt *TypeTable) GetMembers(typeIndex TypeIndex) ([]*Member, error) {
+	typeIndex, ownerName, fieldList, err := tt.udtFieldList(typeIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []*Member
+	for _, m := range fieldList {
+		switch mem := m.(type) {
+		case *tpi.MemberRecord:
+			members = append(members, &Member{
+				Name:      mem.Name,
+				Type:      TypeIndex(mem.Type),
+				Offset:    mem.Offset,
+				Access:    tpi.MemberAccess(mem.Access).String(),
+				OwnerType: typeIndex,
+				OwnerName: ownerName,
+			})
+		case *tpi.StaticMemberRecord:
+			members = append(members, &Member{
+				Name:      mem.Name,
+				Type:      TypeIndex(mem.Type),
+				Offset:    0,
+				Access:    tpi.MemberAccess(mem.Access).String(),
+				OwnerType: typeIndex,
+				OwnerName: ownerName,
+				IsStatic:  true,
+			})
+		}
+	}
+
+	return members, nil
+}
+
+// udtFieldList returns the resolved index, name and field list members of a
+// class, struct or union type.
+func (tt *TypeTable) udtFieldList(typeIndex TypeIndex) (TypeIndex, string, []tpi.FieldListMember, error) {
+	typeIndex = tt.resolveIndex(typeIndex)
+
 	record, err := tt.tpiStream.GetTypeRecord(tpi.TypeIndex(typeIndex))
 	if err != nil || record == nil {
-		return nil, ErrTypeNotFound
+		return typeIndex, "", nil, ErrTypeNotFound
 	}
 
 	var ownerName string
 	var fieldListIndex tpi.TypeIndex
 
 	switch record.Kind {
-	case tpi.LF_CLASS, tpi.LF_CLASS_ST:
-		rec, err := tpi.ParseClassRecord(record.Data)
-		if err != nil {
-			return nil, err
-		}
-		ownerName = rec.Name
-		fieldListIndex = rec.FieldList
-	case tpi.LF_STRUCTURE, tpi.LF_STRUCTURE_ST:
+	case tpi.LF_CLASS, tpi.LF_CLASS_ST, tpi.LF_STRUCTURE, tpi.LF_STRUCTURE_ST:
 		rec, err := tpi.ParseClassRecord(record.Data)
 		if err != nil {
-			return nil, err
+			return typeIndex, "", nil, err
 		}
 		ownerName = rec.Name
 		fieldListIndex = rec.FieldList
 	case tpi.LF_UNION, tpi.LF_UNION_ST:
 		rec, err := tpi.ParseUnionRecord(record.Data)
 		if err != nil {
-			return nil, err
+			return typeIndex, "", nil, err
 		}
 		ownerName = rec.Name
 		fieldListIndex = rec.FieldList
 	default:
-		return nil, ErrTypeNotFound
+		return typeIndex, "", nil, ErrTypeNotFound
 	}
 
-	if fieldListIndex == 0 {
-		return nil, nil
+	members, err := tt.fieldListMembers(fieldListIndex)
+	if err != nil {
+		return typeIndex, "", nil, err
 	}
+	return typeIndex, ownerName, members, nil
+}
+
+// fieldListMembers returns the members of a field list, following
+// LF_INDEX continuations into subsequent field list records.
+func (tt *TypeTable) fieldListMembers(index tpi.TypeIndex) ([]tpi.FieldListMember, error) {
+	var members []tpi.FieldListMember
+	visited := make(map[tpi.TypeIndex]bool)
 
-	fieldRecord, err := tt.tpiStream.GetTypeRecord(fieldListIndex)
-	if err != nil || fieldRecord == nil || fieldRecord.Kind != tpi.LF_FIELDLIST {
-		return nil, nil
+	for index != 0 && !visited[index] {
+		visited[index] = true
+
+		record, err := tt.tpiStream.GetTypeRecord(index)
+		if err != nil || record == nil || record.Kind != tpi.LF_FIELDLIST {
+			break
+		}
+
+		fieldList, err := tpi.ParseFieldListRecord(record.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, fieldList.Members...)
+		index = fieldList.Continuation
 	}
 
-	fieldList, err := tpi.ParseFieldListRecord(fieldRecord.Data)
+	return members, nil
+}
+
+// MethodKind identifies how a member function is dispatched.
+type MethodKind uint8
+
+const (
+	MethodKindVanilla MethodKind = iota
+	MethodKindVirtual
+	MethodKindStatic
+	MethodKindFriend
+	MethodKindIntroVirtual
+	MethodKindPureVirtual
+	MethodKindPureIntro
+)
+
+func (k MethodKind) String() string {
+	switch k {
+	case MethodKindVanilla:
+		return "vanilla"
+	case MethodKindVirtual:
+		return "virtual"
+	case MethodKindStatic:
+		return "static"
+	case MethodKindFriend:
+		return "friend"
+	case MethodKindIntroVirtual:
+		return "intro_virtual"
+	case MethodKindPureVirtual:
+		return "pure_virtual"
+	case MethodKindPureIntro:
+		return "pure_intro"
+	default:
+		return "unknown"
+	}
+}
+
+// Method represents a named member function with all of its overloads.
+type Method struct {
+	Name      string
+	OwnerType TypeIndex // The class/struct that declares this method
+	OwnerName string    // Name of the owner class/struct
+	Overloads []*MethodOverload
+}
+
+// MethodOverload represents a single overload of a member function.
+type MethodOverload struct {
+	Type         TypeIndex           // LF_MFUNCTION type index
+	Signature    *MemberFunctionType // Parsed signature (nil if unavailable)
+	Access       string              // "public", "protected", "private", or ""
+	Kind         MethodKind
+	VTableOffset int32 // Byte offset of the vtable slot (introducing virtuals only)
+
+	IsCompilerGenerated bool
+	IsSealed            bool
+	IsPseudo            bool
+}
+
+// IsVirtual returns true if the method is dispatched through the vtable.
+func (m *MethodOverload) IsVirtual() bool { return tpi.MethodKind(m.Kind).IsVirtual() }
+
+// IsPure returns true for pure virtual methods.
+func (m *MethodOverload) IsPure() bool { return tpi.MethodKind(m.Kind).IsPure() }
+
+// IsStatic returns true for static member functions.
+func (m *MethodOverload) IsStatic() bool { return m.Kind == MethodKindStatic }
+
+// IntroducesSlot returns true if the method introduces a new vtable slot.
+// VTableOffset is only meaningful for such methods; overriding virtuals
+// reuse the slot introduced by a base class.
+func (m *MethodOverload) IntroducesSlot() bool { return tpi.MethodKind(m.Kind).IsIntro() }
+
+// Methods returns the member functions of a class/struct/union type,
+// grouped by name in declaration order.
+// Forward references are resolved to their complete definition.
+func (tt *TypeTable) Methods(typeIndex TypeIndex) ([]*Method, error) {
+	typeIndex, ownerName, fieldList, err := tt.udtFieldList(typeIndex)
 	if err != nil {
 		return nil, err
 	}
 
-	var members []*Member
-	for _, m := range fieldList.Members {
-		switch mem := m.(type) {
-		case *tpi.MemberRecord:
-			members = append(members, &Member{
-				Name:      mem.Name,
-				Type:      TypeIndex(mem.Type),
-				Offset:    mem.Offset,
-				Access:    tpi.MemberAccess(mem.Access).String(),
+	var methods []*Method
+	byName := make(map[string]*Method)
+
+	add := func(name string, overload *MethodOverload) {
+		m := byName[name]
+		if m == nil {
+			m = &Method{
+				Name:      name,
 				OwnerType: typeIndex,
 				OwnerName: ownerName,
-			})
-		case *tpi.StaticMemberRecord:
-			members = append(members, &Member{
+			}
+			byName[name] = m
+			methods = append(methods, m)
+		}
+		m.Overloads = append(m.Overloads, overload)
+	}
+
+	for _, member := range fieldList {
+		switch mem := member.(type) {
+		case *tpi.OneMethodRecord:
+			add(mem.Name, tt.newMethodOverload(mem.Attributes, mem.Type, mem.VBaseOffset))
+		case *tpi.MethodRecord:
+			record, err := tt.tpiStream.GetTypeRecord(mem.MethodList)
+			if err != nil || record == nil || record.Kind != tpi.LF_METHODLIST {
+				continue
+			}
+			list, err := tpi.ParseMethodListRecord(record.Data)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range list.Methods {
+				add(mem.Name, tt.newMethodOverload(entry.Attributes, entry.Type, entry.VBaseOffset))
+			}
+		}
+	}
+
+	return methods, nil
+}
+
+func (tt *TypeTable) newMethodOverload(attrs tpi.MethodProperties, typ tpi.TypeIndex, vbaseOffset int32) *MethodOverload {
+	overload := &MethodOverload{
+		Type:                TypeIndex(typ),
+		Access:              tpi.MemberAccess(attrs.Access()).String(),
+		Kind:                MethodKind(attrs.Kind()),
+		IsCompilerGenerated: attrs.IsCompGenX(),
+		IsSealed:            attrs.IsSealed(),
+		IsPseudo:            attrs.IsPseudo(),
+	}
+	if attrs.IsIntro() {
+		overload.VTableOffset = vbaseOffset
+	}
+	if sig, err := tt.ByIndex(TypeIndex(typ)); err == nil {
+		overload.Signature, _ = sig.(*MemberFunctionType)
+	}
+	return overload
+}
+
+// NestedType represents a type declared inside a class/struct/union.
+type NestedType struct {
+	Name      string
+	Type      TypeIndex
+	OwnerType TypeIndex // The enclosing class/struct
+	OwnerName string    // Name of the enclosing class/struct
+}
+
+// NestedTypes returns the types nested in a class/struct/union type,
+// including nested typedefs.
+// Forward references are resolved to their complete definition.
+func (tt *TypeTable) NestedTypes(typeIndex TypeIndex) ([]*NestedType, error) {
+	typeIndex, ownerName, fieldList, err := tt.udtFieldList(typeIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	var nested []*NestedType
+	for _, member := range fieldList {
+		if mem, ok := member.(*tpi.NestedTypeRecord); ok {
+			nested = append(nested, &NestedType{
 				Name:      mem.Name,
 				Type:      TypeIndex(mem.Type),
-				Offset:    0,
-				Access:    tpi.MemberAccess(mem.Access).String(),
 				OwnerType: typeIndex,
 				OwnerName: ownerName,
 			})
 		}
 	}
 
-	return members, nil
+	return nested, nil
+}
+
+// BaseClass represents a direct or virtual base class.
+type BaseClass struct {
+	Type       TypeIndex
+	Name       string
+	Access     string // "public", "protected", "private", or ""
+	Offset     uint64 // Offset of the base subobject (non-virtual bases only)
+	IsVirtual  bool
+	IsIndirect bool // Virtual base inherited through another base class
+
+	// Virtual base pointer information (virtual bases only)
+	VBPtrType    TypeIndex
+	VBPtrOffset  uint64 // Offset of the vbptr within the derived class
+	VBTableIndex uint64 // Index of this base in the virtual base table
+}
+
+// BaseClasses returns the base classes of a class/struct type, in
+// declaration order. Virtual bases, including indirect ones, are reported
+// with their virtual base pointer offset and table index.
+// Forward references are resolved to their complete definition.
+func (tt *TypeTable) BaseClasses(typeIndex TypeIndex) ([]*BaseClass, error) {
+	_, _, fieldList, err := tt.udtFieldList(typeIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	var bases []*BaseClass
+	for _, member := range fieldList {
+		switch mem := member.(type) {
+		case *tpi.BaseClassRecord:
+			bases = append(bases, &BaseClass{
+				Type:   TypeIndex(mem.Type),
+				Name:   tt.typeName(TypeIndex(mem.Type)),
+				Access: tpi.MemberAccess(mem.Access).String(),
+				Offset: mem.Offset,
+			})
+		case *tpi.VirtualBaseClassRecord:
+			bases = append(bases, &BaseClass{
+				Type:         TypeIndex(mem.BaseType),
+				Name:         tt.typeName(TypeIndex(mem.BaseType)),
+				Access:       tpi.MemberAccess(mem.Access).String(),
+				IsVirtual:    true,
+				IsIndirect:   mem.Indirect,
+				VBPtrType:    TypeIndex(mem.VBPtrType),
+				VBPtrOffset:  mem.VBPtrOffset,
+				VBTableIndex: mem.VBTableIndex,
+			})
+		}
+	}
+
+	return bases, nil
+}
+
+// typeName returns the name of a type, or "" if it cannot be loaded.
+func (tt *TypeTable) typeName(index TypeIndex) string {
+	typ, err := tt.ByIndex(index)
+	if err != nil {
+		return ""
+	}
+	return typ.Name()
 }
@@ -0,0 +1,405 @@
+package pdb
+
+import (
+	"strings"
+
+	"github.com/skdltmxn/pdb-go/demangle"
+	"github.com/skdltmxn/pdb-go/internal/tpi"
+)
+
+// VTable represents one virtual function table of a class.
+// A class has one vftable per vfptr in its layout: its primary table plus
+// one for each additional base class (non-virtual or virtual) that has its
+// own vfptr under multiple inheritance.
+type VTable struct {
+	OwnerType TypeIndex // The class the table was laid out for
+	OwnerName string    // Name of the owner class
+
+	// Path lists the base classes, outermost first, through which this
+	// table's vfptr was inherited. It is empty for a vfptr introduced by the
+	// owner itself.
+	Path []string
+
+	// VirtualBase is the virtual base class containing the vfptr, or "" if
+	// the vfptr is part of the non-virtual layout.
+	VirtualBase string
+
+	// Offset is the byte offset of the vfptr within the owner class, or
+	// within VirtualBase when VirtualBase is set.
+	Offset uint64
+
+	Slots []*VTableSlot
+
+	// Symbol and RVA identify the matching `??_7Class@@6B...@` public
+	// symbol. Both are empty if no symbol was found.
+	Symbol string
+	RVA    uint32
+}
+
+// VTableSlot represents a single entry of a virtual function table.
+type VTableSlot struct {
+	Index     int
+	Name      string    // Method name ("" if the slot is unknown)
+	Type      TypeIndex // LF_MFUNCTION type of the implementation
+	ClassName string    // Class that provides the implementation
+	IsPure    bool
+
+	// IsOverride is true if the owner class overrides the slot.
+	IsOverride bool
+	// IsInherited is true if the slot is inherited unchanged from a base.
+	IsInherited bool
+
+	argList TypeIndex
+}
+
+// VTable reconstructs the virtual function tables of a class or struct.
+// Slots introduced by base classes are inherited, overridden methods
+// replace the inherited entry in every table they appear in, and each
+// table is correlated with its `??_7` public symbol to report its RVA.
+// Forward references are resolved to their complete definition.
+// Returns nil if the class has no virtual functions.
+func (tt *TypeTable) VTable(typeIndex TypeIndex) ([]*VTable, error) {
+	typeIndex = tt.resolveIndex(typeIndex)
+
+	tables, err := tt.layoutVTables(typeIndex, make(map[TypeIndex]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	tt.correlateVTables(tables)
+	return tables, nil
+}
+
+// layoutVTables computes the vftables of a class, recursing into its bases.
+func (tt *TypeTable) layoutVTables(typeIndex TypeIndex, visiting map[TypeIndex]bool) ([]*VTable, error) {
+	typeIndex = tt.resolveIndex(typeIndex)
+	if visiting[typeIndex] {
+		return nil, nil
+	}
+	visiting[typeIndex] = true
+	defer delete(visiting, typeIndex)
+
+	bases, err := tt.BaseClasses(typeIndex)
+	if err != nil {
+		return nil, err
+	}
+	methods, err := tt.Methods(typeIndex)
+	if err != nil {
+		return nil, err
+	}
+	ownerName := tt.typeName(typeIndex)
+
+	var tables []*VTable
+
+	// Tables from non-virtual bases are placed at the base's offset
+	for _, base := range bases {
+		if base.IsVirtual {
+			continue
+		}
+		sub, err := tt.layoutVTables(base.Type, visiting)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range sub {
+			if t.VirtualBase != "" {
+				// Virtual bases are shared and laid out by the most derived class
+				continue
+			}
+			inherited := t.inherit(base.Name)
+			inherited.Offset += base.Offset
+			tables = append(tables, inherited)
+		}
+	}
+
+	// Each virtual base (direct or indirect) appears once
+	seen := make(map[TypeIndex]bool)
+	for _, base := range bases {
+		if !base.IsVirtual || seen[base.Type] {
+			continue
+		}
+		seen[base.Type] = true
+
+		sub, err := tt.layoutVTables(base.Type, visiting)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range sub {
+			if t.VirtualBase != "" {
+				continue
+			}
+			inherited := t.inherit(base.Name)
+			inherited.VirtualBase = base.Name
+			tables = append(tables, inherited)
+		}
+	}
+
+	// New virtual functions extend the primary table, which is the first
+	// non-virtual vfptr; if no base provides one the class gets its own.
+	var primary *VTable
+	for _, t := range tables {
+		if t.VirtualBase == "" && (primary == nil || t.Offset < primary.Offset) {
+			primary = t
+		}
+	}
+
+	for _, m := range methods {
+		for _, o := range m.Overloads {
+			if !o.IntroducesSlot() {
+				continue
+			}
+			if primary == nil {
+				primary = &VTable{}
+				tables = append([]*VTable{primary}, tables...)
+			}
+			primary.setSlot(int(o.VTableOffset)/tt.pointerSize(o), &VTableSlot{
+				Name:      m.Name,
+				Type:      o.Type,
+				ClassName: ownerName,
+				IsPure:    o.IsPure(),
+				argList:   argumentList(o),
+			})
+		}
+	}
+
+	if primary != nil && len(primary.Path) == 0 {
+		tt.applyVShape(typeIndex, primary)
+	}
+
+	// Overrides replace matching slots in every table
+	for _, m := range methods {
+		for _, o := range m.Overloads {
+			if !o.IsVirtual() || o.IntroducesSlot() {
+				continue
+			}
+			for _, t := range tables {
+				for _, slot := range t.Slots {
+					if !slot.overriddenBy(m.Name, o) {
+						continue
+					}
+					slot.Name = m.Name
+					slot.Type = o.Type
+					slot.ClassName = ownerName
+					slot.IsPure = o.IsPure()
+					slot.IsOverride = true
+					slot.IsInherited = false
+					slot.argList = argumentList(o)
+				}
+			}
+		}
+	}
+
+	for _, t := range tables {
+		t.OwnerType = typeIndex
+		t.OwnerName = ownerName
+	}
+
+	return tables, nil
+}
+
+// inherit returns a copy of the table as seen from a derived class.
+func (t *VTable) inherit(baseName string) *VTable {
+	nt := &VTable{
+		Path:        append([]string{baseName}, t.Path...),
+		VirtualBase: t.VirtualBase,
+		Offset:      t.Offset,
+		Slots:       make([]*VTableSlot, len(t.Slots)),
+	}
+	for i, slot := range t.Slots {
+		s := *slot
+		s.IsOverride = false
+		s.IsInherited = s.Name != ""
+		nt.Slots[i] = &s
+	}
+	return nt
+}
+
+// setSlot stores a slot at the given index, growing the table as needed.
+func (t *VTable) setSlot(index int, slot *VTableSlot) {
+	for len(t.Slots) <= index {
+		t.Slots = append(t.Slots, &VTableSlot{Index: len(t.Slots)})
+	}
+	slot.Index = index
+	t.Slots[index] = slot
+}
+
+// overriddenBy reports whether a virtual method with the given name and
+// signature overrides this slot. Destructors override each other regardless
+// of name.
+func (s *VTableSlot) overriddenBy(name string, o *MethodOverload) bool {
+	if s.Name == "" {
+		return false
+	}
+	if strings.HasPrefix(s.Name, "~") && strings.HasPrefix(name, "~") {
+		return true
+	}
+	if s.Name != name {
+		return false
+	}
+	args := argumentList(o)
+	return s.argList == 0 || args == 0 || s.argList == args
+}
+
+// applyVShape pads the class's own primary table to the slot count recorded
+// in its LF_VTSHAPE, so slots without a known method are still reported.
+func (tt *TypeTable) applyVShape(typeIndex TypeIndex, primary *VTable) {
+	typ, err := tt.ByIndex(typeIndex)
+	if err != nil {
+		return
+	}
+
+	var vshape TypeIndex
+	switch t := typ.(type) {
+	case *ClassType:
+		vshape = t.vshape
+	case *StructType:
+		vshape = t.vshape
+	}
+	if vshape == 0 {
+		return
+	}
+
+	record, err := tt.tpiStream.GetTypeRecord(tpi.TypeIndex(vshape))
+	if err != nil || record == nil || record.Kind != tpi.LF_VTSHAPE {
+		return
+	}
+	shape, err := tpi.ParseVTShapeRecord(record.Data)
+	if err != nil {
+		return
+	}
+	for len(primary.Slots) < len(shape.Slots) {
+		primary.Slots = append(primary.Slots, &VTableSlot{Index: len(primary.Slots)})
+	}
+}
+
+// pointerSize returns the size of a vtable entry, derived from the method's
+// this pointer. Defaults to 8 when the signature is unavailable.
+func (tt *TypeTable) pointerSize(o *MethodOverload) int {
+	if o.Signature != nil {
+		if this, err := tt.ByIndex(o.Signature.ThisType()); err == nil {
+			if ptr, ok := this.(*PointerType); ok && ptr.Size() > 0 {
+				return int(ptr.Size())
+			}
+		}
+	}
+	return 8
+}
+
+func argumentList(o *MethodOverload) TypeIndex {
+	if o.Signature == nil {
+		return 0
+	}
+	return o.Signature.ArgumentList()
+}
+
+// vftableSymbol is a `??_7` public symbol with its parsed name.
+type vftableSymbol struct {
+	sym     *PublicSymbol
+	forPath []string
+}
+
+// buildVFTableIndex groups all `??_7` public symbols by class name.
+func (tt *TypeTable) buildVFTableIndex() {
+	tt.vftableIndexOnce.Do(func() {
+		tt.vftableIndex = make(map[string][]vftableSymbol)
+		if tt.pdb == nil {
+			return
+		}
+
+		symbols, err := tt.pdb.Symbols()
+		if err != nil {
+			return
+		}
+		publics, err := symbols.PublicCached()
+		if err != nil {
+			return
+		}
+
+		for _, pub := range publics {
+			class, forPath, ok := parseVFTableName(pub.Name())
+			if ok {
+				tt.vftableIndex[class] = append(tt.vftableIndex[class], vftableSymbol{sym: pub, forPath: forPath})
+			}
+		}
+	})
+}
+
+// correlateVTables fills in Symbol and RVA from `??_7` public symbols.
+func (tt *TypeTable) correlateVTables(tables []*VTable) {
+	if len(tables) == 0 || tt.pdb == nil {
+		return
+	}
+
+	tt.buildVFTableIndex()
+	candidates := tt.vftableIndex[typeNameKey(tables[0].OwnerName)]
+	if len(candidates) == 0 {
+		return
+	}
+	sections, _ := tt.pdb.Sections()
+
+	for _, t := range tables {
+		var best *vftableSymbol
+		for i := range candidates {
+			c := &candidates[i]
+			switch {
+			case len(tables) == 1 && len(candidates) == 1:
+			case len(c.forPath) == 0 && len(t.Path) == 0:
+			case len(c.forPath) > 0 && isSubsequence(c.forPath, t.Path):
+			default:
+				continue
+			}
+			if best == nil || len(c.forPath) > len(best.forPath) {
+				best = c
+			}
+		}
+		if best == nil {
+			continue
+		}
+
+		t.Symbol = best.sym.Name()
+		if sections != nil {
+			t.RVA = sections.ToRVA(best.sym.Section(), best.sym.Offset())
+		}
+	}
+}
+
+// parseVFTableName parses a `??_7Class@@6B{for}@` vftable symbol name into
+// the class name and the optional "for" base class path, as name keys.
+func parseVFTableName(name string) (class string, forPath []string, ok bool) {
+	if !strings.HasPrefix(name, "??_7") {
+		return "", nil, false
+	}
+	node, err := demangle.DemangleToNode(name)
+	if err != nil {
+		return "", nil, false
+	}
+	table, ok := node.(*demangle.SpecialTableSymbol)
+	if !ok || table.Name == nil || len(table.Name.Components) < 2 {
+		return "", nil, false
+	}
+
+	// The last component is the `vftable' itself
+	owner := &demangle.QualifiedName{Components: table.Name.Scope()}
+	for _, target := range table.Targets {
+		forPath = append(forPath, typeNameKey(demangle.Format(target, demangle.NoTagKeywords)))
+	}
+	return typeNameKey(demangle.Format(owner, demangle.NoTagKeywords)), forPath, true
+}
+
+// typeNameKey returns a type name without spaces, so that names in the type
+// records ("vector<int,allocator<int> >") compare equal to demangled ones
+// ("vector<int, allocator<int>>").
+func typeNameKey(name string) string {
+	return strings.ReplaceAll(name, " ", "")
+}
+
+// isSubsequence reports whether all name keys of sub appear in the type
+// names of seq in order.
+func isSubsequence(sub, seq []string) bool {
+	i := 0
+	for _, s := range seq {
+		if i < len(sub) && sub[i] == typeNameKey(s) {
+			i++
+		}
+	}
+	return i == len(sub)
+}
@@ -0,0 +1,265 @@
+// Package pdbcheck checks the structure of a PDB file for corruption: the
+// MSF container, the DBI stream, the type streams and the symbol records.
+//
+// Unlike the pdb package, which refuses or skips what it cannot parse, a
+// check reads as much of a damaged file as it can and reports each problem
+// with its severity and location.
+package pdbcheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/skdltmxn/pdb-go/internal/dbi"
+	"github.com/skdltmxn/pdb-go/msf"
+)
+
+// Check checks the PDB file at path.
+func Check(path string) (*Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("pdbcheck: failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("pdbcheck: failed to stat file: %w", err)
+	}
+	return CheckReader(f, stat.Size())
+}
+
+// CheckReader checks a PDB file read from r. It returns an error only if r
+// does not hold an MSF file; everything past the superblock is reported as
+// problems. A truncated file is checked as far as it goes.
+func CheckReader(r io.ReaderAt, size int64) (*Report, error) {
+	if size < msf.SuperBlockSize {
+		return nil, msf.ErrTruncatedFile
+	}
+	sbData := make([]byte, msf.SuperBlockSize)
+	if _, err := r.ReadAt(sbData, 0); err != nil {
+		return nil, fmt.Errorf("pdbcheck: failed to read superblock: %w", err)
+	}
+	sb, err := msf.ReadSuperBlock(bytes.NewReader(sbData))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{report: &Report{Problems: []Problem{}}}
+	switch {
+	case size < sb.FileSize():
+		c.report.add(Error, streamMSF, size, "file is truncated: %d bytes, but the superblock declares %d blocks of %d bytes",
+			size, sb.NumBlocks, sb.BlockSize)
+	case size > sb.FileSize():
+		c.report.add(Warning, streamMSF, sb.FileSize(), "%d bytes follow the last block", size-sb.FileSize())
+	}
+
+	// Blocks past the end of a truncated file fail to read
+	c.file, err = msf.NewFile(r, max(size, sb.FileSize()))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.checkMSF() {
+		c.checkRecords()
+	}
+	return c.report, nil
+}
+
+// Stream names used in problems
+const (
+	streamMSF       = "MSF"
+	streamDirectory = "directory"
+	streamDBI       = "DBI"
+	streamTPI       = "TPI"
+	streamIPI       = "IPI"
+	streamSymbols   = "symbol records"
+)
+
+type checker struct {
+	file   *msf.File
+	dir    *msf.StreamDirectory
+	report *Report
+
+	// The DBI stream, or the error parsing it
+	dbi    *dbi.Stream
+	dbiErr error
+
+	// Names of streams known from the DBI stream, by index
+	names map[uint32]string
+
+	// Type index ranges of the type streams; nil if missing
+	tpi, ipi *typeRange
+}
+
+// ownerName describes the owner of a block.
+func (c *checker) ownerName(owner msf.BlockOwner) string {
+	if owner.Kind == msf.BlockStream {
+		return c.streamName(owner.Stream)
+	}
+	return "the " + owner.Kind.String()
+}
+
+// streamName describes a stream by its index and, if known, its contents.
+func (c *checker) streamName(index uint32) string {
+	name := fmt.Sprintf("stream %d", index)
+	if s, ok := c.names[index]; ok {
+		name += " (" + s + ")"
+	}
+	return name
+}
+
+// checkMSF checks the stream directory and the allocation of blocks. It
+// returns false if the directory cannot be read.
+func (c *checker) checkMSF() bool {
+	dir, err := c.file.Directory()
+	if errors.Is(err, msf.ErrTruncatedDirectory) {
+		c.report.add(Error, streamDirectory, -1, "stream sizes need more block indexes than the %d-byte directory holds",
+			c.file.SuperBlock().NumDirectoryBytes)
+		return false
+	}
+	if err != nil {
+		c.report.add(Error, streamDirectory, -1, "cannot read the stream directory: %v", err)
+		return false
+	}
+	c.dir = dir
+	c.names = map[uint32]string{
+		msf.StreamPDBInfo: "PDB info",
+		msf.StreamTPI:     streamTPI,
+		msf.StreamDBI:     streamDBI,
+		msf.StreamIPI:     streamIPI,
+	}
+	c.nameDBIStreams()
+
+	sb := c.file.SuperBlock()
+
+	// The directory holds the stream count, the sizes and the block lists
+	// implied by the sizes, and nothing else
+	want := 4 + 4*int64(dir.NumStreams)
+	for _, blocks := range dir.StreamBlocks {
+		want += 4 * int64(len(blocks))
+	}
+	if want != int64(sb.NumDirectoryBytes) {
+		c.report.add(Error, streamDirectory, -1, "stream sizes account for %d bytes, but the directory has %d",
+			want, sb.NumDirectoryBytes)
+	}
+
+	// A block used twice is a conflict between its first use and each later one
+	uses, err := c.file.BlockUses()
+	if err != nil {
+		c.report.add(Error, streamDirectory, -1, "cannot read the stream directory: %v", err)
+		return false
+	}
+	owners := make([]msf.BlockOwner, sb.NumBlocks)
+	conflicts := make(map[[2]msf.BlockOwner][]uint32)
+	outside := make(map[uint32][]uint32)
+	for block, owner := range uses {
+		if block >= sb.NumBlocks {
+			if owner.Kind == msf.BlockStream {
+				outside[owner.Stream] = append(outside[owner.Stream], block)
+			}
+			continue
+		}
+		if prev := owners[block]; prev.Kind != msf.BlockNone {
+			key := [2]msf.BlockOwner{prev, owner}
+			conflicts[key] = append(conflicts[key], block)
+			continue
+		}
+		owners[block] = owner
+	}
+
+	for i := range dir.NumStreams {
+		if blocks := outside[i]; len(blocks) > 0 {
+			c.report.add(Error, streamDirectory, -1, "%s has %d block(s) past the last block %d, starting with block %d",
+				c.streamName(i), len(blocks), sb.NumBlocks-1, blocks[0])
+		}
+	}
+
+	keys := make([][2]msf.BlockOwner, 0, len(conflicts))
+	for key := range conflicts {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b [2]msf.BlockOwner) int { return int(conflicts[a][0]) - int(conflicts[b][0]) })
+	for _, key := range keys {
+		for _, run := range blockRuns(conflicts[key]) {
+			if key[0] == key[1] {
+				c.report.add(Error, streamMSF, sb.BlockOffset(run.first), "%s used twice by %s",
+					run.subject(), c.ownerName(key[0]))
+				continue
+			}
+			c.report.add(Error, streamMSF, sb.BlockOffset(run.first), "%s used by both %s and %s",
+				run.subject(), c.ownerName(key[0]), c.ownerName(key[1]))
+		}
+	}
+
+	c.checkFreeBlockMap(owners)
+	return true
+}
+
+// checkFreeBlockMap compares the active free block map with the blocks in
+// use.
+func (c *checker) checkFreeBlockMap(owners []msf.BlockOwner) {
+	fpm, err := c.file.FreeBlockMap()
+	if err != nil {
+		c.report.add(Error, streamMSF, -1, "cannot read the free block map: %v", err)
+		return
+	}
+
+	freed := make(map[msf.BlockOwner][]uint32)
+	var order []msf.BlockOwner
+	leaked := 0
+	for block, owner := range owners {
+		free := fpm.IsFree(uint32(block))
+		switch {
+		case owner.Kind != msf.BlockNone && free:
+			if freed[owner] == nil {
+				order = append(order, owner)
+			}
+			freed[owner] = append(freed[owner], uint32(block))
+		case owner.Kind == msf.BlockNone && !free:
+			leaked++
+		}
+	}
+
+	for _, owner := range order {
+		for _, run := range blockRuns(freed[owner]) {
+			c.report.add(Error, streamMSF, c.file.SuperBlock().BlockOffset(run.first), "%s marked free but used by %s",
+				run.subject(), c.ownerName(owner))
+		}
+	}
+	if leaked > 0 {
+		c.report.add(Warning, streamMSF, -1, "%d block(s) are neither free nor in use", leaked)
+	}
+}
+
+// blockRun is a range of consecutive blocks.
+type blockRun struct {
+	first, last uint32
+}
+
+// subject returns the run as the subject of a sentence.
+func (r blockRun) subject() string {
+	if r.first == r.last {
+		return fmt.Sprintf("block %d is", r.first)
+	}
+	return fmt.Sprintf("blocks %d-%d are", r.first, r.last)
+}
+
+// blockRuns sorts blocks and merges consecutive ones into runs.
+func blockRuns(blocks []uint32) []blockRun {
+	slices.Sort(blocks)
+
+	var runs []blockRun
+	for _, block := range blocks {
+		if n := len(runs); n > 0 && runs[n-1].last+1 == block {
+			runs[n-1].last = block
+			continue
+		}
+		runs = append(runs, blockRun{block, block})
+	}
+	return runs
+}
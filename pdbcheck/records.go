@@ -0,0 +1,440 @@
+package pdbcheck
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/skdltmxn/pdb-go/internal/dbi"
+	"github.com/skdltmxn/pdb-go/internal/stream"
+	"github.com/skdltmxn/pdb-go/internal/symbols"
+	"github.com/skdltmxn/pdb-go/internal/tpi"
+	"github.com/skdltmxn/pdb-go/msf"
+)
+
+// typeRange is the range of type indexes defined by a type stream.
+type typeRange struct {
+	begin, end tpi.TypeIndex
+}
+
+// typeRef is a type index found in a record, and the type stream it refers
+// to.
+type typeRef struct {
+	index tpi.TypeIndex
+	ipi   bool
+}
+
+// nameDBIStreams names the streams the DBI stream refers to, if the DBI
+// stream can be parsed.
+func (c *checker) nameDBIStreams() {
+	data, err := c.file.ReadStream(msf.StreamDBI)
+	if err == nil {
+		c.dbi, err = dbi.ParseStream(data)
+	}
+	if err != nil {
+		c.dbiErr = err
+		return
+	}
+
+	name := func(index uint16, name string) {
+		if index != dbi.InvalidStreamIndex {
+			c.names[uint32(index)] = name
+		}
+	}
+	name(c.dbi.Header.GlobalStreamIndex, "globals")
+	name(c.dbi.Header.PublicStreamIndex, "publics")
+	name(c.dbi.Header.SymRecordStreamIndex, streamSymbols)
+	for i := range c.dbi.Modules {
+		name(c.dbi.Modules[i].ModuleSymStreamIndex, moduleStream(&c.dbi.Modules[i]))
+	}
+}
+
+func moduleStream(mod *dbi.ModuleInfo) string {
+	return "module " + mod.ModuleName
+}
+
+// streamExists reports whether a stream index refers to a stream that is
+// not nil.
+func (c *checker) streamExists(index uint32) bool {
+	return index < c.dir.NumStreams && c.dir.StreamSizes[index] != msf.NilStreamSize
+}
+
+// checkStreamRef reports a stream index that refers to a missing stream.
+// It returns true if the stream exists.
+func (c *checker) checkStreamRef(from, what string, index uint16) bool {
+	if index == dbi.InvalidStreamIndex {
+		return false
+	}
+	if !c.streamExists(uint32(index)) {
+		c.report.add(Error, from, -1, "%s refers to missing stream %d", what, index)
+		return false
+	}
+	return true
+}
+
+// checkRecords checks the type streams, the DBI stream and the symbol
+// records.
+func (c *checker) checkRecords() {
+	tpiStream := c.openTypeStream(msf.StreamTPI, streamTPI)
+	ipiStream := c.openTypeStream(msf.StreamIPI, streamIPI)
+	if tpiStream == nil && !c.streamExists(msf.StreamTPI) {
+		c.report.add(Error, streamTPI, -1, "stream %d is missing", msf.StreamTPI)
+	}
+	if tpiStream != nil {
+		c.tpi = &typeRange{tpiStream.TypeIndexBegin(), tpiStream.TypeIndexEnd()}
+		c.checkTypeRecords(tpiStream, streamTPI)
+	}
+	if ipiStream != nil {
+		c.ipi = &typeRange{ipiStream.TypeIndexBegin(), ipiStream.TypeIndexEnd()}
+		c.checkTypeRecords(ipiStream, streamIPI)
+	}
+
+	if c.dbiErr != nil {
+		c.report.add(Error, streamDBI, -1, "cannot parse the DBI stream: %v", c.dbiErr)
+		return
+	}
+	h := &c.dbi.Header
+	c.checkStreamRef(streamDBI, "global symbol index", h.GlobalStreamIndex)
+	c.checkStreamRef(streamDBI, "public symbol index", h.PublicStreamIndex)
+	if c.checkStreamRef(streamDBI, "symbol record stream", h.SymRecordStreamIndex) {
+		index := uint32(h.SymRecordStreamIndex)
+		c.checkSymbols(streamSymbols, index, 0, c.dir.StreamSizes[index])
+	}
+
+	for i := range c.dbi.Modules {
+		c.checkModule(&c.dbi.Modules[i])
+	}
+}
+
+// checkModule checks the symbols of a module.
+func (c *checker) checkModule(mod *dbi.ModuleInfo) {
+	name := moduleStream(mod)
+	if !c.checkStreamRef(streamDBI, name, mod.ModuleSymStreamIndex) {
+		return
+	}
+
+	index := uint32(mod.ModuleSymStreamIndex)
+	size := c.dir.StreamSizes[index]
+	if total := uint64(mod.SymByteSize) + uint64(mod.C11ByteSize) + uint64(mod.C13ByteSize); total > uint64(size) {
+		c.report.add(Error, name, -1, "symbols and line information take %d bytes, but the stream has %d", total, size)
+	}
+	if mod.SymByteSize == 0 {
+		return
+	}
+
+	// The symbols follow a 32-bit signature
+	st, err := c.file.OpenStream(index)
+	if err != nil {
+		c.report.add(Error, name, -1, "cannot open stream %d: %v", index, err)
+		return
+	}
+	var sig [4]byte
+	if mod.SymByteSize < 4 || size < 4 {
+		c.report.add(Error, name, 0, "%d bytes of symbols are too short for the signature", min(mod.SymByteSize, size))
+		return
+	}
+	if _, err := st.ReadAt(sig[:], 0); err != nil {
+		c.report.add(Error, name, 0, "cannot read the signature: %v", err)
+		return
+	}
+	if s := binary.LittleEndian.Uint32(sig[:]); s != 4 {
+		c.report.add(Warning, name, 0, "unexpected signature %d; expected 4 (C13)", s)
+	}
+
+	c.checkSymbols(name, index, 4, min(mod.SymByteSize, size))
+}
+
+// openTypeStream parses the header of a type stream, or returns nil if the
+// stream is missing or its header is corrupt.
+func (c *checker) openTypeStream(index uint32, name string) *tpi.Stream {
+	if !c.streamExists(index) {
+		return nil
+	}
+	st, err := c.file.OpenStream(index)
+	if err != nil {
+		c.report.add(Error, name, -1, "cannot open stream %d: %v", index, err)
+		return nil
+	}
+	s, err := tpi.ParseStreamAt(st, int64(st.Size()))
+	if err != nil {
+		c.report.add(Error, name, 0, "cannot parse the header: %v", err)
+		return nil
+	}
+	return s
+}
+
+// checkTypeRecords checks the records of a type stream.
+func (c *checker) checkTypeRecords(s *tpi.Stream, name string) {
+	c.checkStreamRef(name, "hash stream", s.Header.HashStreamIndex)
+
+	records := s.Records()
+	r := stream.NewReaderAt(records, records.Size(), stream.DefaultWindowSize)
+	count, complete := c.walkRecords(name, int64(s.Header.HeaderSize), r, func(offset int64, kind uint16, data []byte) {
+		refs, err := typeRecordRefs(tpi.TypeRecordKind(kind), data)
+		if err != nil {
+			c.reportDecodeError(name, offset, kind, err)
+			return
+		}
+		c.checkRefs(name, offset, kind, refs)
+	})
+
+	if complete && uint32(count) != s.TypeCount() {
+		c.report.add(Error, name, -1, "header declares %d records, but the stream has %d", s.TypeCount(), count)
+	}
+}
+
+// checkSymbols checks the symbol records in bytes start to end of a stream.
+func (c *checker) checkSymbols(name string, index, start, end uint32) {
+	st, err := c.file.OpenStream(index)
+	if err != nil {
+		c.report.add(Error, name, -1, "cannot open stream %d: %v", index, err)
+		return
+	}
+
+	size := int64(end - start)
+	r := stream.NewReaderAt(io.NewSectionReader(st, int64(start), size), size, stream.DefaultWindowSize)
+	c.walkRecords(name, int64(start), r, func(offset int64, kind uint16, data []byte) {
+		refs, err := symbolRefs(symbols.SymbolRecordKind(kind), data)
+		if err != nil {
+			c.reportDecodeError(name, offset, kind, err)
+			return
+		}
+		c.checkRefs(name, offset, kind, refs)
+	})
+}
+
+// walkRecords calls check for each record read from r, and reports the
+// records that do not fit. Offsets are relative to base. Symbol and type
+// records share their framing: a 16-bit length, which covers a 16-bit kind
+// and the data. It returns the number of records and whether all of r was
+// read.
+func (c *checker) walkRecords(name string, base int64, r *stream.Reader, check func(offset int64, kind uint16, data []byte)) (int, bool) {
+	count := 0
+	for r.Remaining() > 0 {
+		offset := base + int64(r.Offset())
+		if r.Remaining() < 4 {
+			c.report.add(Error, name, offset, "%d byte(s) at the end are too short for a record", r.Remaining())
+			return count, false
+		}
+
+		length, err := r.ReadU16()
+		if err != nil {
+			c.report.add(Error, name, offset, "cannot read record: %v", err)
+			return count, false
+		}
+		kind, err := r.ReadU16()
+		if err != nil {
+			c.report.add(Error, name, offset, "cannot read record: %v", err)
+			return count, false
+		}
+		if length < 2 {
+			c.report.add(Error, name, offset, "record length %d is too short for the kind", length)
+			return count, false
+		}
+		n := int(length) - 2
+		if n > r.Remaining() {
+			c.report.add(Error, name, offset, "record of kind 0x%04X and length %d overruns the stream by %d byte(s)",
+				kind, length, n-r.Remaining())
+			return count, false
+		}
+
+		data, err := r.ReadBytes(n)
+		if err != nil {
+			c.report.add(Error, name, offset, "cannot read record: %v", err)
+			return count, false
+		}
+		check(offset, kind, data)
+		count++
+	}
+	return count, true
+}
+
+// reportDecodeError reports a record whose data cannot be decoded.
+func (c *checker) reportDecodeError(name string, offset int64, kind uint16, err error) {
+	if errors.Is(err, stream.ErrUnterminatedString) {
+		c.report.add(Error, name, offset, "record of kind 0x%04X has an unterminated string", kind)
+		return
+	}
+	c.report.add(Error, name, offset, "cannot decode record of kind 0x%04X: %v", kind, err)
+}
+
+// checkRefs reports type indexes that the type streams do not define.
+func (c *checker) checkRefs(name string, offset int64, kind uint16, refs []typeRef) {
+	for _, ref := range refs {
+		if ref.index.IsSimpleType() {
+			continue
+		}
+
+		types, target := c.tpi, streamTPI
+		if ref.ipi {
+			types, target = c.ipi, streamIPI
+		}
+		if types == nil {
+			c.report.add(Error, name, offset, "record of kind 0x%04X refers to %s index 0x%X, but there is no %s stream",
+				kind, target, ref.index, target)
+			continue
+		}
+		if ref.index < types.begin || ref.index >= types.end {
+			c.report.add(Error, name, offset, "record of kind 0x%04X refers to %s index 0x%X outside 0x%X-0x%X",
+				kind, target, ref.index, types.begin, types.end-1)
+		}
+	}
+}
+
+// typeRecordRefs decodes a type record and returns the type indexes it
+// refers to. Kinds without references to check are not decoded.
+func typeRecordRefs(kind tpi.TypeRecordKind, data []byte) ([]typeRef, error) {
+	var refs []typeRef
+	add := func(indexes ...tpi.TypeIndex) {
+		for _, index := range indexes {
+			refs = append(refs, typeRef{index: index})
+		}
+	}
+
+	switch kind {
+	case tpi.LF_MODIFIER:
+		rec, err := tpi.ParseModifierRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		add(rec.ModifiedType)
+
+	case tpi.LF_POINTER:
+		rec, err := tpi.ParsePointerRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		add(rec.ReferentType, rec.ContainingClass)
+
+	case tpi.LF_PROCEDURE:
+		rec, err := tpi.ParseProcedureRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		add(rec.ReturnType, rec.ArgumentList)
+
+	case tpi.LF_MFUNCTION:
+		rec, err := tpi.ParseMFunctionRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		add(rec.ReturnType, rec.ClassType, rec.ThisType, rec.ArgumentList)
+
+	case tpi.LF_ARGLIST:
+		rec, err := tpi.ParseArgListRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		add(rec.ArgTypes...)
+
+	case tpi.LF_METHODLIST:
+		rec, err := tpi.ParseMethodListRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range rec.Methods {
+			add(m.Type)
+		}
+
+	case tpi.LF_ARRAY:
+		rec, err := tpi.ParseArrayRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		add(rec.ElementType, rec.IndexType)
+
+	case tpi.LF_CLASS, tpi.LF_STRUCTURE, tpi.LF_INTERFACE:
+		rec, err := tpi.ParseClassRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		add(rec.FieldList, rec.DerivedFrom, rec.VShape)
+
+	case tpi.LF_UNION:
+		rec, err := tpi.ParseUnionRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		add(rec.FieldList)
+
+	case tpi.LF_ENUM:
+		rec, err := tpi.ParseEnumRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		add(rec.UnderlyingType, rec.FieldList)
+
+	case tpi.LF_BITFIELD:
+		rec, err := tpi.ParseBitFieldRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		add(rec.Type)
+
+	case tpi.LF_FIELDLIST:
+		rec, err := tpi.ParseFieldListRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		add(rec.Continuation)
+		for _, m := range rec.Members {
+			switch m := m.(type) {
+			case *tpi.MemberRecord:
+				add(m.Type)
+			case *tpi.StaticMemberRecord:
+				add(m.Type)
+			case *tpi.MethodRecord:
+				add(m.MethodList)
+			case *tpi.OneMethodRecord:
+				add(m.Type)
+			case *tpi.NestedTypeRecord:
+				add(m.Type)
+			case *tpi.BaseClassRecord:
+				add(m.Type)
+			case *tpi.VirtualBaseClassRecord:
+				add(m.BaseType, m.VBPtrType)
+			case *tpi.VFuncTabRecord:
+				add(m.Type)
+			}
+		}
+
+	case tpi.LF_FUNC_ID:
+		rec, err := tpi.ParseFuncIDRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, typeRef{index: rec.Scope, ipi: true})
+		add(rec.FunctionType)
+	}
+
+	return refs, nil
+}
+
+// symbolRefs decodes a symbol record and returns the type indexes it
+// refers to.
+func symbolRefs(kind symbols.SymbolRecordKind, data []byte) ([]typeRef, error) {
+	sym, err := symbols.ParseSymbol(&symbols.SymbolRecord{Kind: kind, Data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	switch sym := sym.(type) {
+	case *symbols.ProcSym:
+		// The _ID variants refer to an LF_FUNC_ID or LF_MFUNC_ID record
+		ipi := kind == symbols.S_GPROC32_ID || kind == symbols.S_LPROC32_ID
+		return []typeRef{{sym.FunctionType, ipi}}, nil
+	case *symbols.DataSym:
+		return []typeRef{{index: sym.Type}}, nil
+	case *symbols.UDTSym:
+		return []typeRef{{index: sym.Type}}, nil
+	case *symbols.ConstantSym:
+		return []typeRef{{index: sym.Type}}, nil
+	case *symbols.LocalSym:
+		return []typeRef{{index: sym.Type}}, nil
+	case *symbols.RegRelSym:
+		return []typeRef{{index: sym.Type}}, nil
+	case *symbols.BPRelSym:
+		return []typeRef{{index: sym.Type}}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,92 @@
+package pdbcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Severity classifies a problem.
+type Severity string
+
+const (
+	// Error marks corruption: readers fail or return wrong data.
+	Error Severity = "error"
+	// Warning marks an unusual structure that readers can cope with.
+	Warning Severity = "warning"
+)
+
+// Problem is a single finding of a check.
+type Problem struct {
+	Severity Severity `json:"severity"`
+	Stream   string   `json:"stream"` // "MSF", "directory", "DBI", "TPI", ...
+	Offset   int64    `json:"offset"` // In the stream, or in the file for "MSF"; -1 if none
+	Message  string   `json:"message"`
+}
+
+// Location returns the stream and offset of the problem.
+func (p Problem) Location() string {
+	if p.Offset < 0 {
+		return p.Stream
+	}
+	return fmt.Sprintf("%s+0x%X", p.Stream, p.Offset)
+}
+
+// Report is the result of a check.
+type Report struct {
+	Problems []Problem `json:"problems"`
+}
+
+// Count returns the number of problems of the given severity.
+func (r *Report) Count(severity Severity) int {
+	count := 0
+	for _, p := range r.Problems {
+		if p.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// HasErrors returns true if any problem is an error.
+func (r *Report) HasErrors() bool {
+	return r.Count(Error) > 0
+}
+
+func (r *Report) add(severity Severity, stream string, offset int64, format string, args ...any) {
+	r.Problems = append(r.Problems, Problem{
+		Severity: severity,
+		Stream:   stream,
+		Offset:   offset,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// WriteText writes one line per problem followed by the number of errors
+// and warnings.
+func (r *Report) WriteText(w io.Writer) error {
+	if len(r.Problems) > 0 {
+		if _, err := fmt.Fprintf(w, "%-8s %-32s %s\n%s\n", "SEVERITY", "LOCATION", "MESSAGE", strings.Repeat("-", 100)); err != nil {
+			return err
+		}
+		for _, p := range r.Problems {
+			if _, err := fmt.Fprintf(w, "%-8s %-32s %s\n", p.Severity, p.Location(), p.Message); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d error(s), %d warning(s)\n", r.Count(Error), r.Count(Warning))
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
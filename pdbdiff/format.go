@@ -0,0 +1,106 @@
+package pdbdiff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// maxTypeDepth bounds recursion when formatting self-referencing types.
+const maxTypeDepth = 16
+
+// formatType renders a type as a C++-like string. Type indices are local to
+// a PDB, so types from two PDBs are compared through this representation.
+func formatType(tt *pdb.TypeTable, index pdb.TypeIndex) string {
+	return formatTypeDepth(tt, index, 0)
+}
+
+func formatTypeDepth(tt *pdb.TypeTable, index pdb.TypeIndex, depth int) string {
+	if depth > maxTypeDepth {
+		return "..."
+	}
+
+	typ, err := tt.ByIndex(index)
+	if err != nil {
+		return fmt.Sprintf("<0x%X>", uint32(index))
+	}
+
+	switch t := typ.(type) {
+	case *pdb.PrimitiveType:
+		if t.IsPointer() {
+			return t.Name() + " *"
+		}
+		return t.Name()
+
+	case *pdb.PointerType:
+		suffix := " *"
+		if t.IsReference() {
+			suffix = " &"
+		} else if t.IsRValueRef() {
+			suffix = " &&"
+		}
+		s := formatTypeDepth(tt, t.ReferentType(), depth+1) + suffix
+		if t.IsConst() {
+			s += " const"
+		}
+		if t.IsVolatile() {
+			s += " volatile"
+		}
+		return s
+
+	case *pdb.ModifierType:
+		var prefix string
+		if t.IsConst() {
+			prefix += "const "
+		}
+		if t.IsVolatile() {
+			prefix += "volatile "
+		}
+		if t.IsUnaligned() {
+			prefix += "__unaligned "
+		}
+		return prefix + formatTypeDepth(tt, t.ModifiedType(), depth+1)
+
+	case *pdb.ArrayType:
+		elem := formatTypeDepth(tt, t.ElementType(), depth+1)
+		if elemType, err := tt.ByIndex(t.ElementType()); err == nil && elemType.Size() > 0 {
+			return fmt.Sprintf("%s[%d]", elem, t.Size()/elemType.Size())
+		}
+		return fmt.Sprintf("%s[%d bytes]", elem, t.Size())
+
+	case *pdb.BitfieldType:
+		return fmt.Sprintf("%s : %d (bit %d)",
+			formatTypeDepth(tt, t.UnderlyingType(), depth+1), t.Length(), t.Position())
+
+	case *pdb.FunctionType, *pdb.MemberFunctionType:
+		sig, err := tt.Signature(index)
+		if err != nil {
+			return fmt.Sprintf("<0x%X>", uint32(index))
+		}
+		return formatSignature(tt, sig, depth)
+
+	default:
+		if typ.Name() != "" {
+			return typ.Name()
+		}
+		return fmt.Sprintf("<%s>", typ.Kind())
+	}
+}
+
+func formatSignature(tt *pdb.TypeTable, sig *pdb.Signature, depth int) string {
+	params := make([]string, 0, len(sig.Parameters)+1)
+	for _, p := range sig.Parameters {
+		params = append(params, formatTypeDepth(tt, p.Type, depth+1))
+	}
+	if sig.IsVariadic {
+		params = append(params, "...")
+	}
+
+	s := fmt.Sprintf("%s %s(%s)",
+		formatTypeDepth(tt, sig.ReturnType, depth+1), sig.CallingConvention, strings.Join(params, ", "))
+	if sig.IsMember() && sig.ThisType == 0 {
+		s = "static " + s
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+// Package pdbdiff compares two PDB files and reports the differences
+// between them.
+package pdbdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ChangeKind identifies the kind of a reported change.
+type ChangeKind string
+
+const (
+	TypeAdded       ChangeKind = "type_added"
+	TypeRemoved     ChangeKind = "type_removed"
+	TypeKindChanged ChangeKind = "kind_changed"
+	SizeChanged     ChangeKind = "size_changed"
+
+	FieldAdded         ChangeKind = "field_added"
+	FieldRemoved       ChangeKind = "field_removed"
+	FieldOffsetChanged ChangeKind = "field_offset_changed"
+	FieldTypeChanged   ChangeKind = "field_type_changed"
+	FieldAccessChanged ChangeKind = "field_access_changed"
+
+	BaseClassAdded   ChangeKind = "base_added"
+	BaseClassRemoved ChangeKind = "base_removed"
+	BaseClassChanged ChangeKind = "base_changed"
+
+	VTableAdded       ChangeKind = "vtable_added"
+	VTableRemoved     ChangeKind = "vtable_removed"
+	VTableSlotAdded   ChangeKind = "vtable_slot_added"
+	VTableSlotRemoved ChangeKind = "vtable_slot_removed"
+	VTableSlotChanged ChangeKind = "vtable_slot_changed"
+
+	EnumeratorAdded        ChangeKind = "enumerator_added"
+	EnumeratorRemoved      ChangeKind = "enumerator_removed"
+	EnumeratorValueChanged ChangeKind = "enumerator_value_changed"
+)
+
+// Change is a single difference between the old and new PDB.
+type Change struct {
+	Kind     ChangeKind `json:"kind"`
+	Type     string     `json:"type"`             // Name of the affected type
+	Member   string     `json:"member,omitempty"` // Field, base, slot or enumerator
+	Old      string     `json:"old,omitempty"`
+	New      string     `json:"new,omitempty"`
+	Breaking bool       `json:"breaking"`
+}
+
+// Report is the result of a comparison.
+type Report struct {
+	Changes []Change `json:"changes"`
+}
+
+// HasBreaking returns true if any change breaks layout compatibility.
+func (r *Report) HasBreaking() bool {
+	for _, c := range r.Changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// BreakingCount returns the number of breaking changes.
+func (r *Report) BreakingCount() int {
+	count := 0
+	for _, c := range r.Changes {
+		if c.Breaking {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *Report) add(c Change) {
+	r.Changes = append(r.Changes, c)
+}
+
+// sort orders changes by type name, keeping the per-type order stable.
+func (r *Report) sort() {
+	sort.SliceStable(r.Changes, func(i, j int) bool {
+		return r.Changes[i].Type < r.Changes[j].Type
+	})
+}
+
+// WriteText writes the report in a human-readable format.
+func (r *Report) WriteText(w io.Writer) error {
+	for _, c := range r.Changes {
+		marker := " "
+		if c.Breaking {
+			marker = "!"
+		}
+
+		name := c.Type
+		if c.Member != "" {
+			name += "::" + c.Member
+		}
+
+		var detail string
+		switch {
+		case c.Old != "" && c.New != "":
+			detail = fmt.Sprintf("%s -> %s", c.Old, c.New)
+		case c.Old != "":
+			detail = c.Old
+		case c.New != "":
+			detail = c.New
+		}
+
+		if _, err := fmt.Fprintf(w, "%s %-26s %s  %s\n", marker, c.Kind, name, detail); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d change(s), %d breaking\n", len(r.Changes), r.BreakingCount())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	out := struct {
+		Changes  []Change `json:"changes"`
+		Breaking int      `json:"breaking"`
+	}{
+		Changes:  r.Changes,
+		Breaking: r.BreakingCount(),
+	}
+	if out.Changes == nil {
+		out.Changes = []Change{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,482 @@
+package pdbdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// SymbolChangeKind identifies the kind of a reported symbol change.
+type SymbolChangeKind string
+
+const (
+	SymbolAdded       SymbolChangeKind = "added"
+	SymbolRemoved     SymbolChangeKind = "removed"
+	SymbolSizeChanged SymbolChangeKind = "size_changed"
+)
+
+// Symbol categories reported in SymbolChange.Category.
+const (
+	CategoryFunction = "function"
+	CategoryData     = "data"
+	CategoryPublic   = "public"
+)
+
+// SymbolChange is a function, data or public symbol that was added, removed
+// or changed size between the old and new PDB.
+type SymbolChange struct {
+	Kind     SymbolChangeKind `json:"kind"`
+	Category string           `json:"category"`
+	Name     string           `json:"name"`
+	Module   string           `json:"module,omitempty"`
+	OldSize  int64            `json:"old_size"`
+	NewSize  int64            `json:"new_size"`
+	Delta    int64            `json:"delta"`
+
+	// Decorated is the decorated name of an overloaded function, which
+	// tells it from the other functions with the same name.
+	Decorated string `json:"decorated,omitempty"`
+}
+
+// ModuleSizeChange is the change in code size contributed by a module.
+type ModuleSizeChange struct {
+	Module  string `json:"module"`
+	OldSize int64  `json:"old_size"`
+	NewSize int64  `json:"new_size"`
+	Delta   int64  `json:"delta"`
+}
+
+// SortOrder selects how a SymbolReport is ordered.
+type SortOrder int
+
+const (
+	SortByName     SortOrder = iota // Alphabetically by name
+	SortByDelta                     // Largest growth first
+	SortByAbsDelta                  // Largest change in either direction first
+)
+
+// SymbolReport is the result of a symbol comparison.
+type SymbolReport struct {
+	Symbols []SymbolChange     `json:"symbols"`
+	Modules []ModuleSizeChange `json:"modules"`
+
+	// Total code size of all modules
+	OldCodeSize int64 `json:"old_code_size"`
+	NewCodeSize int64 `json:"new_code_size"`
+}
+
+// CodeSizeDelta returns the change in total code size.
+func (r *SymbolReport) CodeSizeDelta() int64 {
+	return r.NewCodeSize - r.OldCodeSize
+}
+
+// Sort orders the symbol and module changes.
+func (r *SymbolReport) Sort(order SortOrder) {
+	sort.SliceStable(r.Symbols, func(i, j int) bool {
+		a, b := r.Symbols[i], r.Symbols[j]
+		if less, ok := compareDelta(order, a.Delta, b.Delta); ok {
+			return less
+		}
+		return a.Name < b.Name
+	})
+	sort.SliceStable(r.Modules, func(i, j int) bool {
+		a, b := r.Modules[i], r.Modules[j]
+		if less, ok := compareDelta(order, a.Delta, b.Delta); ok {
+			return less
+		}
+		return a.Module < b.Module
+	})
+}
+
+// compareDelta orders two deltas; ok is false if they are equal under order.
+func compareDelta(order SortOrder, a, b int64) (less, ok bool) {
+	switch order {
+	case SortByDelta:
+		return a > b, a != b
+	case SortByAbsDelta:
+		a, b = abs(a), abs(b)
+		return a > b, a != b
+	default:
+		return false, false
+	}
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// WriteText writes the report in a human-readable format.
+func (r *SymbolReport) WriteText(w io.Writer) error {
+	if len(r.Symbols) > 0 {
+		fmt.Fprintf(w, "%-12s %-8s %10s %10s %10s  %s\n", "CHANGE", "CATEGORY", "OLD", "NEW", "DELTA", "NAME")
+		fmt.Fprintf(w, "%s\n", strings.Repeat("-", 100))
+		for _, c := range r.Symbols {
+			name := c.Name
+			if c.Decorated != "" {
+				name += "  (" + c.Decorated + ")"
+			}
+			if c.Module != "" {
+				name += "  [" + c.Module + "]"
+			}
+			if _, err := fmt.Fprintf(w, "%-12s %-8s %10d %10d %+10d  %s\n",
+				c.Kind, c.Category, c.OldSize, c.NewSize, c.Delta, name); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(r.Modules) > 0 {
+		fmt.Fprintf(w, "%10s %10s %10s  %s\n", "OLD CODE", "NEW CODE", "DELTA", "MODULE")
+		fmt.Fprintf(w, "%s\n", strings.Repeat("-", 100))
+		for _, m := range r.Modules {
+			if _, err := fmt.Fprintf(w, "%10d %10d %+10d  %s\n",
+				m.OldSize, m.NewSize, m.Delta, m.Module); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	_, err := fmt.Fprintf(w, "%d symbol change(s), %d module(s) changed, code size %d -> %d (%+d)\n",
+		len(r.Symbols), len(r.Modules), r.OldCodeSize, r.NewCodeSize, r.CodeSizeDelta())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *SymbolReport) WriteJSON(w io.Writer) error {
+	out := struct {
+		*SymbolReport
+		CodeSizeDelta int64 `json:"code_size_delta"`
+	}{
+		SymbolReport:  r,
+		CodeSizeDelta: r.CodeSizeDelta(),
+	}
+	if r.Symbols == nil {
+		r.Symbols = []SymbolChange{}
+	}
+	if r.Modules == nil {
+		r.Modules = []ModuleSizeChange{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// sizedSymbol is a function or data symbol with its size in bytes.
+type sizedSymbol struct {
+	category  string
+	name      string
+	decorated string // Generated for functions, empty if it cannot be
+	module    *moduleName
+	size      int64
+}
+
+// symbolSet holds the comparable symbols and module code sizes of one PDB.
+type symbolSet struct {
+	symbols     map[string]*sizedSymbol
+	keys        []string
+	overloaded  map[string]bool // Category and name of overloaded functions
+	moduleSizes map[moduleKey]int64
+	moduleNames map[moduleKey]string
+	codeSize    int64
+}
+
+// nameKey identifies a symbol by category, name and module, for symbols
+// that have a decorated name in only one of the PDBs.
+func (s *sizedSymbol) nameKey() string {
+	key := s.category + "\x00" + s.name
+	if s.module != nil {
+		key += fmt.Sprintf("\x00%s\x00%s\x00%d", s.module.key.name, s.module.key.objFile, s.module.key.nth)
+	}
+	return key
+}
+
+// overload returns the decorated name of a symbol if its name is overloaded.
+func (set *symbolSet) overload(s *sizedSymbol) string {
+	if set.overloaded[s.category+"\x00"+s.name] {
+		return s.decorated
+	}
+	return ""
+}
+
+// moduleKey identifies a module across PDBs. Modules are matched by name
+// and object file, and modules with the same name and object file (such as
+// import modules of one library) in the order they appear.
+type moduleKey struct {
+	name    string
+	objFile string
+	nth     int
+}
+
+// moduleName is the key of a module and the name it is reported by.
+type moduleName struct {
+	key   moduleKey
+	label string
+}
+
+// moduleNames returns the names of the modules, by module index. Modules
+// whose names repeat are labeled with their object file and position.
+func moduleNames(modules []*pdb.Module) []*moduleName {
+	names := make([]*moduleName, len(modules))
+	byName := make(map[string]int)
+	byKey := make(map[moduleKey]int)
+	for i, mod := range modules {
+		key := moduleKey{name: mod.Name(), objFile: mod.ObjectFileName()}
+		key.nth = byKey[key]
+		byKey[moduleKey{name: key.name, objFile: key.objFile}]++
+		byName[key.name]++
+		names[i] = &moduleName{key: key}
+	}
+
+	for _, n := range names {
+		n.label = n.key.name
+		if byName[n.key.name] > 1 && n.key.objFile != "" && n.key.objFile != n.key.name {
+			n.label += " (" + n.key.objFile + ")"
+		}
+		if byKey[moduleKey{name: n.key.name, objFile: n.key.objFile}] > 1 {
+			n.label += fmt.Sprintf(" #%d", n.key.nth+1)
+		}
+	}
+	return names
+}
+
+// labelOf returns the name a module is reported by, or "" for none.
+func labelOf(n *moduleName) string {
+	if n == nil {
+		return ""
+	}
+	return n.label
+}
+
+// DiffSymbols compares the functions, global data and public symbols of two
+// PDBs, and the code size each module contributes.
+//
+// Functions are matched by their decorated name, generated from the name and
+// type, so that overloads are told apart, and data by name. A symbol defined
+// in more than one module (such as a static function) is also matched by
+// module. A function whose decorated name cannot be generated in one of the
+// PDBs is matched by name and module instead. Modules are matched by name
+// and object file.
+// Public symbols are only reported when no function or data symbol exists at
+// their address, which is the case for PDBs stripped of private symbols;
+// their size is unknown and reported as 0. Module code sizes are the sum of
+// the module's section contributions to code sections.
+func DiffSymbols(oldFile, newFile *pdb.File) (*SymbolReport, error) {
+	oldSet, err := collectSymbols(oldFile)
+	if err != nil {
+		return nil, err
+	}
+	newSet, err := collectSymbols(newFile)
+	if err != nil {
+		return nil, err
+	}
+	return diffSets(oldSet, newSet), nil
+}
+
+// diffSets compares the symbols and module code sizes of two PDBs.
+func diffSets(oldSet, newSet *symbolSet) *SymbolReport {
+	report := &SymbolReport{OldCodeSize: oldSet.codeSize, NewCodeSize: newSet.codeSize}
+
+	// New symbols without a match by key, by name and module. A symbol
+	// lacking a decorated name on either side is matched through these, so
+	// that it is not reported as removed and added again.
+	unmatched := make(map[string][]string)
+	for _, key := range newSet.keys {
+		if oldSet.symbols[key] == nil {
+			n := newSet.symbols[key]
+			unmatched[n.nameKey()] = append(unmatched[n.nameKey()], key)
+		}
+	}
+	matched := make(map[string]bool)
+	match := func(o *sizedSymbol) *sizedSymbol {
+		keys := unmatched[o.nameKey()]
+		for i, key := range keys {
+			if n := newSet.symbols[key]; o.decorated == "" || n.decorated == "" {
+				unmatched[o.nameKey()] = append(keys[:i:i], keys[i+1:]...)
+				matched[key] = true
+				return n
+			}
+		}
+		return nil
+	}
+
+	for _, key := range oldSet.keys {
+		o := oldSet.symbols[key]
+		n := newSet.symbols[key]
+		if n == nil {
+			n = match(o)
+		}
+		switch {
+		case n == nil:
+			report.Symbols = append(report.Symbols, SymbolChange{Kind: SymbolRemoved, Category: o.category,
+				Name: o.name, Module: labelOf(o.module), OldSize: o.size, Delta: -o.size, Decorated: oldSet.overload(o)})
+		case o.size != n.size:
+			report.Symbols = append(report.Symbols, SymbolChange{Kind: SymbolSizeChanged, Category: n.category,
+				Name: n.name, Module: labelOf(n.module), OldSize: o.size, NewSize: n.size, Delta: n.size - o.size,
+				Decorated: newSet.overload(n)})
+		}
+	}
+	for _, key := range newSet.keys {
+		if oldSet.symbols[key] == nil && !matched[key] {
+			n := newSet.symbols[key]
+			report.Symbols = append(report.Symbols, SymbolChange{Kind: SymbolAdded, Category: n.category,
+				Name: n.name, Module: labelOf(n.module), NewSize: n.size, Delta: n.size, Decorated: newSet.overload(n)})
+		}
+	}
+
+	for key, oldSize := range oldSet.moduleSizes {
+		if newSize := newSet.moduleSizes[key]; newSize != oldSize {
+			report.Modules = append(report.Modules, ModuleSizeChange{Module: oldSet.moduleNames[key],
+				OldSize: oldSize, NewSize: newSize, Delta: newSize - oldSize})
+		}
+	}
+	for key, newSize := range newSet.moduleSizes {
+		if _, ok := oldSet.moduleSizes[key]; !ok && newSize != 0 {
+			report.Modules = append(report.Modules, ModuleSizeChange{Module: newSet.moduleNames[key],
+				NewSize: newSize, Delta: newSize})
+		}
+	}
+
+	report.Sort(SortByName)
+	return report
+}
+
+func collectSymbols(f *pdb.File) (*symbolSet, error) {
+	modules, err := f.Modules()
+	if err != nil {
+		return nil, fmt.Errorf("pdbdiff: failed to get modules: %w", err)
+	}
+	symTable, err := f.Symbols()
+	if err != nil {
+		return nil, fmt.Errorf("pdbdiff: failed to get symbols: %w", err)
+	}
+	// Data sizes come from the type table; without one they are reported as 0
+	types, _ := f.Types()
+	names := moduleNames(modules)
+
+	type address struct {
+		section uint16
+		offset  uint32
+	}
+	var found []*sizedSymbol
+	addresses := make(map[address]bool)
+
+	addData := func(sym *pdb.DataSymbol, module *moduleName) {
+		addr := address{sym.Section(), sym.Offset()}
+		if addresses[addr] {
+			return
+		}
+		addresses[addr] = true
+
+		var size int64
+		if types != nil {
+			if typ, err := types.ByIndex(pdb.TypeIndex(sym.TypeIndex())); err == nil {
+				size = int64(typ.Size())
+			}
+		}
+		found = append(found, &sizedSymbol{category: CategoryData, name: sym.Name(), module: module, size: size})
+	}
+
+	for i, mod := range modules {
+		for sym := range mod.Symbols() {
+			switch s := sym.(type) {
+			case *pdb.FunctionSymbol:
+				addr := address{s.Section(), s.Offset()}
+				if addresses[addr] {
+					continue
+				}
+				addresses[addr] = true
+				decorated, _ := s.MangledName()
+				found = append(found, &sizedSymbol{category: CategoryFunction, name: s.Name(),
+					decorated: decorated, module: names[i], size: int64(s.Length())})
+			case *pdb.DataSymbol:
+				addData(s, names[i])
+			}
+		}
+	}
+
+	for sym := range symTable.Globals() {
+		if s, ok := sym.(*pdb.DataSymbol); ok {
+			addData(s, nil)
+		}
+	}
+
+	for sym := range symTable.Public() {
+		if addresses[address{sym.Section(), sym.Offset()}] {
+			continue
+		}
+		found = append(found, &sizedSymbol{category: CategoryPublic, name: sym.Name()})
+	}
+
+	set := newSymbolSet(found)
+
+	contribs, err := f.SectionContributions()
+	if err != nil {
+		return nil, fmt.Errorf("pdbdiff: failed to get section contributions: %w", err)
+	}
+	for _, sc := range contribs {
+		if !sc.IsCode() || sc.ModuleIndex >= len(modules) {
+			continue
+		}
+		key := names[sc.ModuleIndex].key
+		set.moduleSizes[key] += int64(sc.Size)
+		set.moduleNames[key] = names[sc.ModuleIndex].label
+		set.codeSize += int64(sc.Size)
+	}
+
+	return set, nil
+}
+
+// newSymbolSet keys the symbols found in a PDB.
+func newSymbolSet(found []*sizedSymbol) *symbolSet {
+	set := &symbolSet{
+		symbols:     make(map[string]*sizedSymbol, len(found)),
+		overloaded:  make(map[string]bool),
+		moduleSizes: make(map[moduleKey]int64),
+		moduleNames: make(map[moduleKey]string),
+	}
+
+	// Symbols are keyed by decorated name where there is one, then by
+	// module if that is not unique, and by position within the module if
+	// neither is, so that no symbol is dropped
+	idKey := func(s *sizedSymbol) string {
+		if s.decorated != "" {
+			return s.category + "\x00" + s.decorated
+		}
+		return s.category + "\x00" + s.name
+	}
+	counts := make(map[string]int, len(found))
+	ids := make(map[string]string)
+	for _, s := range found {
+		key := idKey(s)
+		counts[key]++
+
+		name := s.category + "\x00" + s.name
+		if id, ok := ids[name]; ok && id != key {
+			set.overloaded[name] = true
+		}
+		ids[name] = key
+	}
+	for _, s := range found {
+		key := idKey(s)
+		if counts[key] > 1 && s.module != nil {
+			key += fmt.Sprintf("\x00%s\x00%s\x00%d", s.module.key.name, s.module.key.objFile, s.module.key.nth)
+		}
+		base := key
+		for n := 2; set.symbols[key] != nil; n++ {
+			key = fmt.Sprintf("%s\x00#%d", base, n)
+		}
+		set.symbols[key] = s
+		set.keys = append(set.keys, key)
+	}
+	return set
+}
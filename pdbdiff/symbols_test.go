@@ -0,0 +1,86 @@
+package pdbdiff
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+func TestDiffSymbolsModules(t *testing.T) {
+	open := func(name string) *pdb.File {
+		f, err := pdb.Open(filepath.Join("..", "testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+
+	report, err := DiffSymbols(open("x86.pdb"), open("x64.pdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OldCodeSize != 160 || report.NewCodeSize != 256 {
+		t.Errorf("code size %d -> %d, want 160 -> 256", report.OldCodeSize, report.NewCodeSize)
+	}
+	want := []ModuleSizeChange{
+		{Module: `c:\build\obj\main.obj`, OldSize: 144, NewSize: 160, Delta: 16},
+		{Module: `c:\build\obj\util.obj`, OldSize: 16, NewSize: 96, Delta: 80},
+	}
+	if !slices.Equal(report.Modules, want) {
+		t.Errorf("modules = %+v, want %+v", report.Modules, want)
+	}
+
+	report, err = DiffSymbols(open("x64.pdb"), open("x64.pdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Symbols) != 0 || len(report.Modules) != 0 {
+		t.Errorf("PDB differs from itself: %+v", report)
+	}
+}
+
+// TestDiffSetsMissingDecoration checks that a function whose decorated name
+// is known in only one PDB is matched by name and module.
+func TestDiffSetsMissingDecoration(t *testing.T) {
+	a := &moduleName{key: moduleKey{name: "a.obj", objFile: "a.obj"}, label: "a.obj"}
+	b := &moduleName{key: moduleKey{name: "b.obj", objFile: "b.obj"}, label: "b.obj"}
+
+	function := func(name, decorated string, module *moduleName, size int64) *sizedSymbol {
+		return &sizedSymbol{category: CategoryFunction, name: name, decorated: decorated, module: module, size: size}
+	}
+	oldSet := newSymbolSet([]*sizedSymbol{
+		function("Draw", "?Draw@@YAXH@Z", a, 10),
+		function("helper", "", a, 4),
+		function("f", "?f@@YAXH@Z", a, 8),
+		function("g", "", a, 8),
+	})
+	newSet := newSymbolSet([]*sizedSymbol{
+		function("Draw", "", a, 12),
+		function("helper", "_helper", a, 4),
+		function("f", "?f@@YAXN@Z", a, 8),
+		function("g", "?g@@YAXXZ", b, 8),
+	})
+
+	type change struct {
+		kind   SymbolChangeKind
+		name   string
+		module string
+	}
+	var got []change
+	for _, c := range diffSets(oldSet, newSet).Symbols {
+		got = append(got, change{c.Kind, c.Name, c.Module})
+	}
+	want := []change{
+		{SymbolSizeChanged, "Draw", "a.obj"},
+		{SymbolRemoved, "f", "a.obj"},
+		{SymbolAdded, "f", "a.obj"},
+		{SymbolRemoved, "g", "a.obj"},
+		{SymbolAdded, "g", "b.obj"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("changes = %+v, want %+v", got, want)
+	}
+}
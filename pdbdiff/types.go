@@ -0,0 +1,339 @@
+package pdbdiff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// udt is a complete class, struct, union or enum definition.
+type udt struct {
+	typ  pdb.Type
+	name string
+}
+
+// DiffTypes compares the user-defined types of two PDBs.
+//
+// Types are matched by unique (decorated) name, or by name when a type has
+// no unique name. Forward references and unnamed types are ignored. Changes
+// that alter the memory layout of an existing type (size, field offsets and
+// types, base classes, vtable slots, enumerator values) or remove a type are
+// marked as breaking; additions are not.
+func DiffTypes(oldTypes, newTypes *pdb.TypeTable) *Report {
+	oldUDTs, oldKeys := collectUDTs(oldTypes)
+	newUDTs, newKeys := collectUDTs(newTypes)
+
+	report := &Report{}
+	d := &typeDiffer{report: report, oldTypes: oldTypes, newTypes: newTypes}
+
+	for _, key := range oldKeys {
+		o := oldUDTs[key]
+		n, ok := newUDTs[key]
+		if !ok {
+			report.add(Change{Kind: TypeRemoved, Type: o.name, Old: o.typ.Kind().String(), Breaking: true})
+			continue
+		}
+		d.diffUDT(o, n)
+	}
+
+	for _, key := range newKeys {
+		if _, ok := oldUDTs[key]; !ok {
+			n := newUDTs[key]
+			report.add(Change{Kind: TypeAdded, Type: n.name, New: n.typ.Kind().String()})
+		}
+	}
+
+	report.sort()
+	return report
+}
+
+// collectUDTs indexes the complete UDT definitions of a type table.
+// The first definition wins when a key is defined more than once.
+func collectUDTs(tt *pdb.TypeTable) (map[string]*udt, []string) {
+	udts := make(map[string]*udt)
+	var keys []string
+
+	for typ := range tt.All() {
+		var uniqueName string
+		var forwardRef bool
+
+		switch t := typ.(type) {
+		case *pdb.ClassType:
+			uniqueName, forwardRef = t.UniqueName(), t.IsForwardRef()
+		case *pdb.StructType:
+			uniqueName, forwardRef = t.UniqueName(), t.IsForwardRef()
+		case *pdb.UnionType:
+			uniqueName, forwardRef = t.UniqueName(), t.IsForwardRef()
+		case *pdb.EnumType:
+			uniqueName, forwardRef = t.UniqueName(), t.IsForwardRef()
+		default:
+			continue
+		}
+
+		name := typ.Name()
+		if forwardRef || isUnnamed(name) {
+			continue
+		}
+
+		key := uniqueName
+		if key == "" {
+			key = name
+		}
+		if _, exists := udts[key]; exists {
+			continue
+		}
+
+		udts[key] = &udt{typ: typ, name: name}
+		keys = append(keys, key)
+	}
+
+	return udts, keys
+}
+
+// isUnnamed reports whether a name is a compiler-generated placeholder.
+func isUnnamed(name string) bool {
+	return name == "" ||
+		strings.Contains(name, "<unnamed-") ||
+		strings.Contains(name, "<anonymous-") ||
+		strings.HasPrefix(name, "__unnamed")
+}
+
+type typeDiffer struct {
+	report   *Report
+	oldTypes *pdb.TypeTable
+	newTypes *pdb.TypeTable
+}
+
+func (d *typeDiffer) diffUDT(o, n *udt) {
+	oldKind, newKind := o.typ.Kind(), n.typ.Kind()
+	if oldKind != newKind {
+		// class and struct only differ in default access
+		breaking := oldKind == pdb.TypeKindUnion || newKind == pdb.TypeKindUnion ||
+			oldKind == pdb.TypeKindEnum || newKind == pdb.TypeKindEnum
+		d.report.add(Change{Kind: TypeKindChanged, Type: o.name,
+			Old: oldKind.String(), New: newKind.String(), Breaking: breaking})
+		if (oldKind == pdb.TypeKindEnum) != (newKind == pdb.TypeKindEnum) {
+			return
+		}
+	}
+
+	if o.typ.Size() != n.typ.Size() {
+		d.report.add(Change{Kind: SizeChanged, Type: o.name,
+			Old: fmt.Sprint(o.typ.Size()), New: fmt.Sprint(n.typ.Size()), Breaking: true})
+	}
+
+	if oldKind == pdb.TypeKindEnum {
+		d.diffEnumerators(o, n)
+		return
+	}
+
+	d.diffFields(o, n)
+	d.diffBases(o, n)
+	d.diffVTables(o, n)
+}
+
+func (d *typeDiffer) diffFields(o, n *udt) {
+	oldMembers, _ := d.oldTypes.GetMembers(o.typ.Index())
+	newMembers, _ := d.newTypes.GetMembers(n.typ.Index())
+
+	newByName := make(map[string]*pdb.Member, len(newMembers))
+	for _, m := range newMembers {
+		if _, exists := newByName[m.Name]; !exists {
+			newByName[m.Name] = m
+		}
+	}
+
+	oldNames := make(map[string]bool, len(oldMembers))
+	for _, om := range oldMembers {
+		if oldNames[om.Name] {
+			continue
+		}
+		oldNames[om.Name] = true
+
+		oldType := formatType(d.oldTypes, om.Type)
+		nm, ok := newByName[om.Name]
+		if !ok {
+			d.report.add(Change{Kind: FieldRemoved, Type: o.name, Member: om.Name,
+				Old: fieldDetail(om, oldType), Breaking: true})
+			continue
+		}
+
+		if !om.IsStatic && !nm.IsStatic && om.Offset != nm.Offset {
+			d.report.add(Change{Kind: FieldOffsetChanged, Type: o.name, Member: om.Name,
+				Old: fmt.Sprintf("0x%X", om.Offset), New: fmt.Sprintf("0x%X", nm.Offset), Breaking: true})
+		}
+
+		newType := formatType(d.newTypes, nm.Type)
+		if oldType != newType || om.IsStatic != nm.IsStatic {
+			d.report.add(Change{Kind: FieldTypeChanged, Type: o.name, Member: om.Name,
+				Old: fieldDetail(om, oldType), New: fieldDetail(nm, newType), Breaking: true})
+		}
+
+		if om.Access != nm.Access {
+			d.report.add(Change{Kind: FieldAccessChanged, Type: o.name, Member: om.Name,
+				Old: om.Access, New: nm.Access})
+		}
+	}
+
+	for _, nm := range newMembers {
+		if !oldNames[nm.Name] {
+			oldNames[nm.Name] = true
+			d.report.add(Change{Kind: FieldAdded, Type: n.name, Member: nm.Name,
+				New: fieldDetail(nm, formatType(d.newTypes, nm.Type))})
+		}
+	}
+}
+
+func fieldDetail(m *pdb.Member, typeName string) string {
+	if m.IsStatic {
+		return "static " + typeName
+	}
+	return fmt.Sprintf("%s @ 0x%X", typeName, m.Offset)
+}
+
+func (d *typeDiffer) diffBases(o, n *udt) {
+	oldBases, _ := d.oldTypes.BaseClasses(o.typ.Index())
+	newBases, _ := d.newTypes.BaseClasses(n.typ.Index())
+
+	newByName := make(map[string]*pdb.BaseClass, len(newBases))
+	for _, b := range newBases {
+		newByName[b.Name] = b
+	}
+
+	oldNames := make(map[string]bool, len(oldBases))
+	for _, ob := range oldBases {
+		oldNames[ob.Name] = true
+
+		nb, ok := newByName[ob.Name]
+		if !ok {
+			d.report.add(Change{Kind: BaseClassRemoved, Type: o.name, Member: ob.Name,
+				Old: baseDetail(ob), Breaking: true})
+			continue
+		}
+
+		if oldDetail, newDetail := baseDetail(ob), baseDetail(nb); oldDetail != newDetail {
+			d.report.add(Change{Kind: BaseClassChanged, Type: o.name, Member: ob.Name,
+				Old: oldDetail, New: newDetail, Breaking: true})
+		}
+	}
+
+	for _, nb := range newBases {
+		if !oldNames[nb.Name] {
+			d.report.add(Change{Kind: BaseClassAdded, Type: n.name, Member: nb.Name,
+				New: baseDetail(nb), Breaking: true})
+		}
+	}
+}
+
+func baseDetail(b *pdb.BaseClass) string {
+	if b.IsVirtual {
+		return fmt.Sprintf("virtual (vbptr @ 0x%X, index %d)", b.VBPtrOffset, b.VBTableIndex)
+	}
+	return fmt.Sprintf("@ 0x%X", b.Offset)
+}
+
+func (d *typeDiffer) diffVTables(o, n *udt) {
+	oldTables, _ := d.oldTypes.VTable(o.typ.Index())
+	newTables, _ := d.newTypes.VTable(n.typ.Index())
+	if len(oldTables) == 0 && len(newTables) == 0 {
+		return
+	}
+
+	newByKey := make(map[string]*pdb.VTable, len(newTables))
+	for _, t := range newTables {
+		newByKey[vtableKey(t)] = t
+	}
+
+	oldKeys := make(map[string]bool, len(oldTables))
+	for _, ot := range oldTables {
+		key := vtableKey(ot)
+		oldKeys[key] = true
+
+		nt, ok := newByKey[key]
+		if !ok {
+			d.report.add(Change{Kind: VTableRemoved, Type: o.name, Member: key, Breaking: true})
+			continue
+		}
+
+		for i := 0; i < max(len(ot.Slots), len(nt.Slots)); i++ {
+			slotName := fmt.Sprintf("%s[%d]", key, i)
+			switch {
+			case i >= len(nt.Slots):
+				d.report.add(Change{Kind: VTableSlotRemoved, Type: o.name, Member: slotName,
+					Old: slotDetail(d.oldTypes, ot.Slots[i]), Breaking: true})
+			case i >= len(ot.Slots):
+				// Appending slots keeps existing ones in place
+				d.report.add(Change{Kind: VTableSlotAdded, Type: n.name, Member: slotName,
+					New: slotDetail(d.newTypes, nt.Slots[i])})
+			default:
+				oldDetail := slotDetail(d.oldTypes, ot.Slots[i])
+				newDetail := slotDetail(d.newTypes, nt.Slots[i])
+				if oldDetail != newDetail {
+					d.report.add(Change{Kind: VTableSlotChanged, Type: o.name, Member: slotName,
+						Old: oldDetail, New: newDetail, Breaking: true})
+				}
+			}
+		}
+	}
+
+	for _, nt := range newTables {
+		if key := vtableKey(nt); !oldKeys[key] {
+			d.report.add(Change{Kind: VTableAdded, Type: n.name, Member: key, Breaking: true})
+		}
+	}
+}
+
+// vtableKey identifies a vftable within a class by the base path it was
+// inherited through.
+func vtableKey(t *pdb.VTable) string {
+	parts := slices.Clone(t.Path)
+	if t.VirtualBase != "" {
+		parts = append(parts, "virtual "+t.VirtualBase)
+	}
+	if len(parts) == 0 {
+		return "vftable"
+	}
+	return "vftable{" + strings.Join(parts, ",") + "}"
+}
+
+func slotDetail(tt *pdb.TypeTable, slot *pdb.VTableSlot) string {
+	if slot.Name == "" {
+		return "<unknown>"
+	}
+	return slot.Name + ": " + formatType(tt, slot.Type)
+}
+
+func (d *typeDiffer) diffEnumerators(o, n *udt) {
+	oldEnums, _ := d.oldTypes.Enumerators(o.typ.Index())
+	newEnums, _ := d.newTypes.Enumerators(n.typ.Index())
+
+	newByName := make(map[string]*pdb.Enumerator, len(newEnums))
+	for _, e := range newEnums {
+		newByName[e.Name] = e
+	}
+
+	oldNames := make(map[string]bool, len(oldEnums))
+	for _, oe := range oldEnums {
+		oldNames[oe.Name] = true
+
+		ne, ok := newByName[oe.Name]
+		if !ok {
+			d.report.add(Change{Kind: EnumeratorRemoved, Type: o.name, Member: oe.Name,
+				Old: oe.ValueString(), Breaking: true})
+			continue
+		}
+		if oe.Value != ne.Value {
+			d.report.add(Change{Kind: EnumeratorValueChanged, Type: o.name, Member: oe.Name,
+				Old: oe.ValueString(), New: ne.ValueString(), Breaking: true})
+		}
+	}
+
+	for _, ne := range newEnums {
+		if !oldNames[ne.Name] {
+			d.report.add(Change{Kind: EnumeratorAdded, Type: n.name, Member: ne.Name,
+				New: ne.ValueString()})
+		}
+	}
+}
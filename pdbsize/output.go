@@ -0,0 +1,85 @@
+package pdbsize
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteText writes the report as an indented tree with sizes and their
+// share of the total.
+func (r *Report) WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%12s %7s  %s\n%s\n", "SIZE", "%", "NAME", strings.Repeat("-", 100)); err != nil {
+		return err
+	}
+	return r.writeNode(w, r.Root, 0)
+}
+
+func (r *Report) writeNode(w io.Writer, n *Node, depth int) error {
+	share := 100.0
+	if r.Root.Size > 0 {
+		share = float64(n.Size) * 100 / float64(r.Root.Size)
+	}
+	if _, err := fmt.Fprintf(w, "%12d %6.2f%%  %s%s\n", n.Size, share, strings.Repeat("  ", depth), n.Name); err != nil {
+		return err
+	}
+
+	for _, c := range n.Children {
+		if err := r.writeNode(w, c, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes one row per leaf of the hierarchy, with a column for each
+// level and the size in bytes. Collapsed nodes leave deeper columns empty.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	header := append(LevelNames[:r.Depth:r.Depth], "size")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	path := make([]string, r.Depth)
+	var walk func(n *Node, level int) error
+	walk = func(n *Node, level int) error {
+		if level > 0 {
+			path[level-1] = n.Name
+		}
+		if len(n.Children) == 0 {
+			if level == 0 {
+				return nil
+			}
+			row := make([]string, 0, r.Depth+1)
+			row = append(row, path[:level]...)
+			for i := level; i < r.Depth; i++ {
+				row = append(row, "")
+			}
+			return cw.Write(append(row, strconv.FormatInt(n.Size, 10)))
+		}
+		for _, c := range n.Children {
+			if err := walk(c, level+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(r.Root, 0); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,345 @@
+// Package pdbsize attributes the size of a PE image to sections, libraries,
+// object files and functions using the section contributions and symbols
+// recorded in its PDB.
+package pdbsize
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/skdltmxn/pdb-go/demangle"
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// Levels of the size hierarchy, from the root down.
+const (
+	LevelSection = iota + 1
+	LevelLibrary
+	LevelObject
+	LevelSymbol
+)
+
+// LevelNames names the columns of each level below the root.
+var LevelNames = []string{"section", "library", "object", "symbol"}
+
+// Names of nodes that do not correspond to a library or symbol.
+const (
+	NoLibrary = "[no library]"
+	Other     = "[other]"
+)
+
+// Options controls how a size report is built.
+type Options struct {
+	// Depth limits the hierarchy to the given level (LevelSection to
+	// LevelSymbol). Zero means LevelSymbol.
+	Depth int
+
+	// FoldTemplates merges all instantiations of a function template or of
+	// the member functions of a class template into one symbol.
+	FoldTemplates bool
+
+	// MaxChildren limits the children of each node to the largest ones,
+	// collapsing the rest into a single node. Zero means no limit.
+	MaxChildren int
+}
+
+// Node is a node of the size hierarchy.
+type Node struct {
+	Name     string  `json:"name"`
+	Size     int64   `json:"size"`
+	Children []*Node `json:"children,omitempty"`
+
+	index map[string]*Node
+}
+
+func (n *Node) child(name string) *Node {
+	if c, ok := n.index[name]; ok {
+		return c
+	}
+	if n.index == nil {
+		n.index = make(map[string]*Node)
+	}
+	c := &Node{Name: name}
+	n.index[name] = c
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// add adds size bytes to the node and the nodes along path below it.
+func (n *Node) add(path []string, size int64) {
+	n.Size += size
+	for _, name := range path {
+		n = n.child(name)
+		n.Size += size
+	}
+}
+
+// finish sorts children by descending size and collapses small children.
+func (n *Node) finish(maxChildren int) {
+	n.index = nil
+	sort.SliceStable(n.Children, func(i, j int) bool {
+		a, b := n.Children[i], n.Children[j]
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return a.Name < b.Name
+	})
+
+	if maxChildren > 0 && len(n.Children) > maxChildren {
+		rest := n.Children[maxChildren:]
+		collapsed := &Node{Name: fmt.Sprintf("[%d others]", len(rest))}
+		for _, c := range rest {
+			collapsed.Size += c.Size
+		}
+		n.Children = append(n.Children[:maxChildren:maxChildren], collapsed)
+	}
+
+	for _, c := range n.Children {
+		c.finish(maxChildren)
+	}
+}
+
+// Report is a size breakdown of an image.
+type Report struct {
+	Root  *Node `json:"root"`
+	Depth int   `json:"depth"`
+}
+
+// Analyze builds a size breakdown of the image described by a PDB:
+// section, then library, then object file, then function.
+//
+// Sizes come from the DBI section contributions. The bytes of each
+// contribution are attributed to the functions of the contributing module
+// that start within it; bytes not covered by a function are reported as
+// [other]. The library of a module is taken from its object file name when
+// that differs from the module name, as is the case for objects linked from
+// a static library.
+func Analyze(f *pdb.File, opts Options) (*Report, error) {
+	depth := opts.Depth
+	if depth <= 0 || depth > LevelSymbol {
+		depth = LevelSymbol
+	}
+
+	modules, err := f.Modules()
+	if err != nil {
+		return nil, fmt.Errorf("pdbsize: failed to get modules: %w", err)
+	}
+
+	contribs, err := f.SectionContributions()
+	if err != nil {
+		return nil, fmt.Errorf("pdbsize: failed to get section contributions: %w", err)
+	}
+
+	// Section names are optional; fall back to section numbers
+	sections, _ := f.Sections()
+
+	a := &analyzer{
+		modules:   make([]moduleInfo, len(modules)),
+		contribs:  make([]contribution, 0, len(contribs)),
+		functions: make(map[functionAddress]bool),
+	}
+	for i, mod := range modules {
+		library, object := moduleNames(mod.Name(), mod.ObjectFileName())
+		a.modules[i] = moduleInfo{library: library, object: object}
+	}
+	for _, sc := range contribs {
+		if sc.Size == 0 || sc.ModuleIndex >= len(modules) {
+			continue
+		}
+		a.contribs = append(a.contribs, contribution{
+			section: sc.Section,
+			start:   sc.Offset,
+			end:     sc.Offset + sc.Size,
+			module:  sc.ModuleIndex,
+			name:    sectionName(sections, sc.Section),
+		})
+	}
+	sort.Slice(a.contribs, func(i, j int) bool {
+		ci, cj := a.contribs[i], a.contribs[j]
+		if ci.section != cj.section {
+			return ci.section < cj.section
+		}
+		return ci.start < cj.start
+	})
+
+	var symTable *pdb.SymbolTable
+	if opts.FoldTemplates {
+		// Public symbols carry the decorated names needed for folding
+		symTable, _ = f.Symbols()
+	}
+
+	if depth >= LevelSymbol {
+		for m, mod := range modules {
+			for sym := range mod.Symbols() {
+				fn, ok := sym.(*pdb.FunctionSymbol)
+				if !ok || fn.Length() == 0 {
+					continue
+				}
+
+				name := fn.Name()
+				if opts.FoldTemplates {
+					name = foldedName(symTable, fn)
+				}
+				a.addFunction(m, fn.Section(), fn.Offset(), fn.Length(), name)
+			}
+		}
+	}
+
+	root := &Node{Name: "TOTAL"}
+	for i := range a.contribs {
+		c := &a.contribs[i]
+		mod := a.modules[c.module]
+		path := []string{c.name, mod.library, mod.object}[:min(depth, LevelObject)]
+
+		size := int64(c.end - c.start)
+		var covered int64
+		for _, fn := range c.functions {
+			root.add(append(path, fn.name), fn.size)
+			covered += fn.size
+		}
+		if rest := size - covered; rest > 0 {
+			if depth >= LevelSymbol {
+				root.add(append(path, Other), rest)
+			} else {
+				root.add(path, rest)
+			}
+		}
+	}
+	root.finish(opts.MaxChildren)
+
+	return &Report{Root: root, Depth: depth}, nil
+}
+
+type moduleInfo struct {
+	library string
+	object  string
+}
+
+type contribution struct {
+	section    uint16
+	start, end uint32
+	module     int
+	name       string
+	functions  []sizedFunction
+}
+
+type sizedFunction struct {
+	name string
+	size int64
+}
+
+type functionAddress struct {
+	section uint16
+	offset  uint32
+}
+
+type analyzer struct {
+	modules   []moduleInfo
+	contribs  []contribution
+	functions map[functionAddress]bool // Functions already attributed
+}
+
+// addFunction attributes a function of a module to the contribution of that
+// module it starts in, clamping its size to the end of the contribution.
+// Functions folded by the linker (ICF, COMDAT folding) have a procedure
+// record in each module that defined them, all at the same address; only
+// the first from the contributing module is counted.
+func (a *analyzer) addFunction(module int, section uint16, offset, length uint32, name string) {
+	i := sort.Search(len(a.contribs), func(i int) bool {
+		c := &a.contribs[i]
+		return c.section > section || (c.section == section && c.end > offset)
+	})
+	if i == len(a.contribs) {
+		return
+	}
+
+	c := &a.contribs[i]
+	if c.section != section || offset < c.start || c.module != module {
+		return
+	}
+	addr := functionAddress{section, offset}
+	if a.functions[addr] {
+		return
+	}
+	a.functions[addr] = true
+
+	size := min(length, c.end-offset)
+	c.functions = append(c.functions, sizedFunction{name: name, size: int64(size)})
+}
+
+// moduleNames derives the library and object file names of a module.
+func moduleNames(moduleName, objFileName string) (library, object string) {
+	object = baseName(moduleName)
+	if objFileName != "" && !strings.EqualFold(objFileName, moduleName) {
+		return baseName(objFileName), object
+	}
+	return NoLibrary, object
+}
+
+// baseName returns the last element of a Windows or POSIX path.
+func baseName(path string) string {
+	if i := strings.LastIndexAny(path, `\/`); i >= 0 && i < len(path)-1 {
+		return path[i+1:]
+	}
+	return path
+}
+
+func sectionName(sections *pdb.SectionHeaders, section uint16) string {
+	if sections != nil {
+		if hdr, err := sections.Get(int(section) - 1); err == nil {
+			if name := hdr.NameString(); name != "" {
+				return name
+			}
+		}
+	}
+	return fmt.Sprintf("section %d", section)
+}
+
+// foldedName returns the name of a function with template arguments folded.
+// The decorated name of the public symbol at the same address is parsed when
+// available; otherwise the template arguments are folded textually.
+func foldedName(symTable *pdb.SymbolTable, fn *pdb.FunctionSymbol) string {
+	if symTable != nil {
+		if sym, ok := symTable.ByAddress(fn.Section(), fn.Offset()); ok &&
+			sym.Section() == fn.Section() && sym.Offset() == fn.Offset() {
+			if folded, ok := demangle.FoldTemplates(sym.Name()); ok {
+				// Some names keep template arguments in plain identifiers
+				return foldTemplateText(folded)
+			}
+		}
+	}
+	return foldTemplateText(fn.Name())
+}
+
+// foldTemplateText replaces the contents of each outermost <...> in a name
+// with "...". Comparison operators such as operator< are left untouched.
+func foldTemplateText(name string) string {
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '<' && !isOperatorSuffix(name[:i]):
+			if depth == 0 {
+				b.WriteString("<...")
+			}
+			depth++
+		case c == '>' && depth > 0:
+			depth--
+			if depth == 0 {
+				b.WriteByte('>')
+			}
+		case depth == 0:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// isOperatorSuffix reports whether a '<' following prefix is part of an
+// operator name (operator<, operator<<, operator<=, operator<=>).
+func isOperatorSuffix(prefix string) bool {
+	prefix = strings.TrimRight(prefix, "<")
+	return strings.HasSuffix(prefix, "operator")
+}
@@ -0,0 +1,138 @@
+package pdbsize_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+	"github.com/skdltmxn/pdb-go/pdbsize"
+)
+
+// flatten lists the nodes of a report below the root as "path: size", with
+// the path elements separated by " > ".
+func flatten(n *pdbsize.Node, path []string, out []string) []string {
+	for _, c := range n.Children {
+		p := append(slices.Clone(path), c.Name)
+		out = append(out, fmt.Sprintf("%s: %d", strings.Join(p, " > "), c.Size))
+		out = flatten(c, p, out)
+	}
+	return out
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name  string
+		pdb   string
+		opts  pdbsize.Options
+		total int64
+		want  []string
+	}{
+		{
+			// Sum in util.obj is folded into Add in main.obj and counted there
+			name:  "x86",
+			pdb:   "x86.pdb",
+			total: 192,
+			want: []string{
+				".text: 160",
+				".text > [no library]: 144",
+				".text > [no library] > main.obj: 144",
+				".text > [no library] > main.obj > Widget::Draw: 64",
+				".text > [no library] > main.obj > main: 48",
+				".text > [no library] > main.obj > Add: 32",
+				".text > util.lib: 16",
+				".text > util.lib > util.obj: 16",
+				".text > util.lib > util.obj > helper: 16",
+				".data: 32",
+				".data > [no library]: 32",
+				".data > [no library] > main.obj: 32",
+				".data > [no library] > main.obj > [other]: 32",
+			},
+		},
+		{
+			name:  "x64/fold",
+			pdb:   "x64.pdb",
+			opts:  pdbsize.Options{FoldTemplates: true},
+			total: 376,
+			want: []string{
+				".text: 256",
+				".text > [no library]: 160",
+				".text > [no library] > main.obj: 160",
+				".text > [no library] > main.obj > Widget::Draw: 96",
+				".text > [no library] > main.obj > main: 64",
+				".text > util.lib: 96",
+				".text > util.lib > util.obj: 96",
+				".text > util.lib > util.obj > trap_handler: 48",
+				".text > util.lib > util.obj > std::max<...>: 32",
+				".text > util.lib > util.obj > helper: 16",
+				".data: 72",
+				".data > [no library]: 72",
+				".data > [no library] > main.obj: 72",
+				".data > [no library] > main.obj > [other]: 72",
+				".rdata: 48",
+				".rdata > [no library]: 48",
+				".rdata > [no library] > main.obj: 48",
+				".rdata > [no library] > main.obj > [other]: 48",
+			},
+		},
+		{
+			name:  "x64/depth",
+			pdb:   "x64.pdb",
+			opts:  pdbsize.Options{Depth: pdbsize.LevelLibrary, MaxChildren: 1},
+			total: 376,
+			want: []string{
+				".text: 256",
+				".text > [no library]: 160",
+				".text > [1 others]: 96",
+				"[2 others]: 120",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := pdb.Open(filepath.Join("..", "testdata", tt.pdb))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			report, err := pdbsize.Analyze(f, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Root.Size != tt.total {
+				t.Errorf("total = %d, want %d", report.Root.Size, tt.total)
+			}
+			if got := flatten(report.Root, nil, nil); !slices.Equal(got, tt.want) {
+				t.Errorf("tree:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+// TestAnalyzeUnfolded checks that without folding each instantiation of a
+// function template is its own symbol.
+func TestAnalyzeUnfolded(t *testing.T) {
+	f, err := pdb.Open(filepath.Join("..", "testdata", "x64.pdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	report, err := pdbsize.Analyze(f, pdbsize.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := flatten(report.Root, nil, nil)
+	for _, want := range []string{
+		".text > util.lib > util.obj > std::max<int>: 16",
+		".text > util.lib > util.obj > std::max<double>: 16",
+	} {
+		if !slices.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, strings.Join(got, "\n"))
+		}
+	}
+}
@@ -0,0 +1,610 @@
+//go:build ignore
+
+// Gen builds the fixtures in this directory from the YAML descriptions:
+//
+//	go run gen.go
+//
+// llvm-pdbutil yaml2pdb (LLVM 14 or later; set LLVM_PDBUTIL to override)
+// converts x86.yaml and x64.yaml, after which the parts it cannot express
+// are added here: section headers, section contributions, public symbols,
+// and the FPO and frame data streams. x64.exe is an image with the exception directory and unwind
+// information of the x64 fixture's functions; its code is all int3.
+package main
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+
+	"github.com/skdltmxn/pdb-go/msf"
+)
+
+const (
+	imageScnCntCode          = 0x00000020
+	imageScnCntInitData      = 0x00000040
+	imageScnMemExecute       = 0x20000000
+	imageScnMemRead          = 0x40000000
+	imageScnMemWrite         = 0x80000000
+	textCharacteristics      = imageScnCntCode | imageScnMemExecute | imageScnMemRead
+	rdataCharacteristics     = imageScnCntInitData | imageScnMemRead
+	dataCharacteristics      = imageScnCntInitData | imageScnMemRead | imageScnMemWrite
+	publicCode               = 0x1
+	publicFunction           = 0x2
+	symPub32                 = 0x110e
+	frameDataFunctionStart   = 0x4
+	optionalDbgFPO           = 0
+	optionalDbgSectionHeader = 5
+	optionalDbgNewFPO        = 9
+	sectionContribVer60      = 0xeffe0000 + 19970605
+)
+
+type section struct {
+	name            string
+	va, size        uint32
+	characteristics uint32
+}
+
+type public struct {
+	name    string
+	section uint16
+	offset  uint32
+	flags   uint32
+}
+
+type frameData struct {
+	rva, codeSize, localSize, paramsSize, maxStackSize uint32
+	program                                            string
+	prologSize, savedRegsSize                          uint16
+	flags                                              uint32
+}
+
+type contribution struct {
+	section      uint16
+	offset, size uint32
+	module       uint16
+}
+
+type fpoData struct {
+	rva, codeSize, locals uint32
+	params                uint16
+	prologSize, savedRegs uint8
+	usesBP                bool
+	frameType             uint8
+}
+
+type fixture struct {
+	name          string
+	sections      []section
+	contributions []contribution
+	publics       []public
+	frameData     []frameData
+	fpo           []fpoData
+}
+
+var x86 = fixture{
+	name: "x86",
+	sections: []section{
+		{".text", 0x1000, 0x100, textCharacteristics},
+		{".data", 0x2000, 0x100, dataCharacteristics},
+	},
+	// Sum in util.obj is folded into Add in main.obj
+	contributions: []contribution{
+		{1, 0x00, 0x90, 0},
+		{1, 0x90, 0x10, 1},
+		{2, 0x00, 0x20, 0},
+	},
+	publics: []public{
+		{"_main", 1, 0x00, publicCode | publicFunction},
+		{"?Draw@Widget@@QAEXHPBD@Z", 1, 0x30, publicCode | publicFunction},
+		{"_Add@8", 1, 0x70, publicCode | publicFunction},
+		{"?Sum@@YGHHH@Z", 1, 0x70, publicCode | publicFunction},
+		{"_helper", 1, 0x90, publicCode | publicFunction},
+		{"@Fast@8", 1, 0xa0, publicCode | publicFunction},
+		{"?Func@Outer@@SAXXZ", 1, 0xb0, publicCode | publicFunction},
+		{"??0Widget@@QAE@XZ", 1, 0xc0, publicCode | publicFunction},
+		{"_start", 1, 0xd0, publicCode | publicFunction},
+		{"_memcpy", 1, 0xe0, publicCode | publicFunction},
+		{"_memmove", 1, 0xe0, publicCode | publicFunction},
+		{"?g_count@@3HA", 2, 0x00, 0},
+		{"??_7Widget@@6B@", 2, 0x10, 0},
+	},
+	frameData: []frameData{
+		{rva: 0x1000, codeSize: 0x30, localSize: 8, paramsSize: 8, program: "$T0 $ebp = $eip $T0 4 + ^ = $ebp $T0 ^ = $esp $T0 8 + =",
+			prologSize: 3, flags: frameDataFunctionStart},
+		{rva: 0x1030, codeSize: 0x40, localSize: 0x10, paramsSize: 8, maxStackSize: 0x20,
+			program:    "$T0 .raSearch = $eip $T0 ^ = $esp $T0 4 + = $ebx $T0 12 - ^ = $esi $T0 16 - ^ =",
+			prologSize: 5, savedRegsSize: 8, flags: frameDataFunctionStart},
+		{rva: 0x1035, codeSize: 0x3b, localSize: 0x10, paramsSize: 8, maxStackSize: 0x20,
+			program:       "$T0 .raSearch = $eip $T0 ^ = $esp $T0 4 + = $ebx $T0 12 - ^ = $esi $T0 16 - ^ =",
+			savedRegsSize: 8},
+		{rva: 0x1070, codeSize: 0x20, paramsSize: 8, flags: frameDataFunctionStart},
+	},
+	fpo: []fpoData{
+		{rva: 0x1090, codeSize: 0x10, locals: 1, params: 2, prologSize: 1, savedRegs: 1},
+		{rva: 0x10a0, codeSize: 0x10, params: 2, prologSize: 3, usesBP: true, frameType: 3},
+	},
+}
+
+var x64 = fixture{
+	name: "x64",
+	sections: []section{
+		{".text", 0x1000, 0x300, textCharacteristics},
+		{".rdata", 0x2000, 0x100, rdataCharacteristics},
+		{".pdata", 0x3000, 0x30, rdataCharacteristics},
+		{".data", 0x4000, 0x100, dataCharacteristics},
+	},
+	// util.obj's std::max instantiations are separate COMDATs
+	contributions: []contribution{
+		{1, 0x000, 0xa0, 0},
+		{1, 0x0c0, 0x40, 1},
+		{1, 0x140, 0x10, 1},
+		{1, 0x240, 0x10, 1},
+		{2, 0x000, 0x30, 0},
+		{4, 0x000, 0x48, 0},
+	},
+	publics: append([]public{
+		{"main", 1, 0x00, publicCode | publicFunction},
+		{"?Draw@Widget@@QEAAXHPEBD@Z", 1, 0x40, publicCode | publicFunction},
+		{"helper", 1, 0xc0, publicCode | publicFunction},
+		{"trap_handler", 1, 0xd0, publicCode | publicFunction},
+		{"??$max@N@std@@YAAEBNAEBN0@Z", 1, 0x240, publicCode | publicFunction},
+		{"?g_count@@3HA", 4, 0x00, 0},
+		{"??_7Widget@@6B@", 4, 0x10, 0},
+		{"??_R0?AVWidget@@@8", 4, 0x20, 0},
+		{"?s_instance@Widget@@0PEAV1@EA", 4, 0x40, 0},
+	}, codePublics(0x100, 0x10,
+		"??0Widget@@QEAA@XZ",
+		"??1Widget@@UEAA@XZ",
+		"??4Widget@@QEAAAEAV0@AEBV0@@Z",
+		"?Resize@Widget@@QEAA_NAEBUSize@geom@@0@Z",
+		"??$max@H@std@@YAAEBHAEBH0@Z",
+		"?push_back@?$vector@HV?$allocator@H@std@@@std@@QEAAXAEBH@Z",
+		"?callback@@YAXP6AXH@Z@Z",
+		"??H@YA?AVPoint@@AEBV0@0@Z",
+		"?Get@?$Holder@$0A@@@QEBAHXZ",
+		"??$Apply@P6AHH@Z@@YAHP6AHH@Z@Z",
+		"?Name@Widget@@SAPEBDXZ",
+		"?Visit@@YAXAEAV?$map@V?$basic_string@DU?$char_traits@D@std@@V?$allocator@D@2@@std@@HU?$less@V?$basic_string@DU?$char_traits@D@std@@V?$allocator@D@2@@std@@@2@V?$allocator@U?$pair@$$CBV?$basic_string@DU?$char_traits@D@std@@V?$allocator@D@2@@std@@H@std@@@2@@std@@@Z",
+		"?Log@@YAXPEBDZZ",
+		"?Move@@YAX$$QEAVWidget@@@Z",
+		"??_GWidget@@UEAAPEAXI@Z",
+		"?Read@Stream@io@@QEAA_KPEAX_K@Z",
+		"?Member@@YAXPEQWidget@@H@Z",
+		"?Sort@@YAXPEAHP6A_NHH@Z@Z",
+		"?Anon@?A0x1234abcd@@YAXXZ",
+		"??$Cast@UFoo@@@@YAPEAUFoo@@PEAX@Z",
+	)...),
+}
+
+// x64 unwind information, laid out in .rdata and .pdata by writeImage
+var (
+	// push rbx; sub rsp, 20h
+	unwindMain = unwindInfo(0, 5, 0, 0,
+		0x05, 0x32, // UWOP_ALLOC_SMALL 20h
+		0x01, 0x30, // UWOP_PUSH_NONVOL rbx
+	)
+	// push rbp; push r12; sub rsp, 1000h; lea rbp, [rsp+20h];
+	// mov [rsp+1018h], rsi
+	unwindDraw = unwindInfo(0, 0x17, 5, 2,
+		0x17, 0x64, 0x03, 0x02, // UWOP_SAVE_NONVOL rsi, 203h*8
+		0x0f, 0x03, // UWOP_SET_FPREG
+		0x0a, 0x01, 0x00, 0x02, // UWOP_ALLOC_LARGE 200h*8
+		0x03, 0xc0, // UWOP_PUSH_NONVOL r12
+		0x01, 0x50, // UWOP_PUSH_NONVOL rbp
+	)
+	// Machine frame with error code; sub rsp, 12340h
+	unwindTrap = unwindInfo(0, 0x0e, 0, 0,
+		0x0e, 0x11, 0x40, 0x23, 0x01, 0x00, // UWOP_ALLOC_LARGE 12340h
+		0x00, 0x1a, // UWOP_PUSH_MACHFRAME with error code
+	)
+)
+
+// runtimeFunctions of x64.exe: begin, end and offset of the unwind
+// information in .rdata. The separated cold part of Widget::Draw has chained
+// unwind information.
+var runtimeFunctions = [][3]uint32{
+	{0x1000, 0x1040, 0x00},
+	{0x1040, 0x10a0, 0x10},
+	{0x10a0, 0x10c0, 0x30},
+	{0x10d0, 0x1100, 0x50},
+}
+
+func main() {
+	pdbutil := os.Getenv("LLVM_PDBUTIL")
+	if pdbutil == "" {
+		pdbutil = "llvm-pdbutil"
+	}
+
+	for _, fx := range []fixture{x86, x64} {
+		path := fx.name + ".pdb"
+		cmd := exec.Command(pdbutil, "yaml2pdb", "-pdb="+path, fx.name+".yaml")
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		if err := complete(path, fx); err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+	}
+
+	if err := writeImage("x64.exe", x64.sections); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func codePublics(offset, stride uint32, names ...string) []public {
+	publics := make([]public, len(names))
+	for i, name := range names {
+		publics[i] = public{name, 1, offset + uint32(i)*stride, publicCode | publicFunction}
+	}
+	return publics
+}
+
+// unwindInfo encodes an UNWIND_INFO with the given unwind code slots.
+func unwindInfo(flags, prologSize, frameRegister, frameOffset byte, codes ...byte) []byte {
+	const version = 1
+	b := []byte{version | flags<<3, prologSize, byte(len(codes) / 2), frameRegister | frameOffset<<4}
+	b = append(b, codes...)
+	if len(codes)%4 != 0 {
+		b = append(b, 0, 0)
+	}
+	return b
+}
+
+// complete adds the streams yaml2pdb cannot produce to a PDB.
+func complete(path string, fx fixture) error {
+	streams, err := readStreams(path)
+	if err != nil {
+		return err
+	}
+
+	names, err := stringTable(streams)
+	if err != nil {
+		return err
+	}
+	add := func(data []byte) uint16 {
+		streams = append(streams, data)
+		return uint16(len(streams) - 1)
+	}
+
+	dbg := make([]uint16, 11)
+	for i := range dbg {
+		dbg[i] = 0xffff
+	}
+	dbg[optionalDbgSectionHeader] = add(sectionHeaders(fx.sections))
+	if len(fx.fpo) > 0 {
+		dbg[optionalDbgFPO] = add(fpoStream(fx.fpo))
+	}
+	if len(fx.frameData) > 0 {
+		data, err := frameDataStream(fx.frameData, names)
+		if err != nil {
+			return err
+		}
+		dbg[optionalDbgNewFPO] = add(data)
+	}
+	symRecords := add(publicRecords(fx.publics))
+
+	streams[3], err = patchDBI(streams[3], sectionContributions(fx), symRecords, dbg)
+	if err != nil {
+		return err
+	}
+	return writeMSF(path, 4096, streams)
+}
+
+func readStreams(path string) ([][]byte, error) {
+	f, err := msf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	n, err := f.NumStreams()
+	if err != nil {
+		return nil, err
+	}
+	streams := make([][]byte, n)
+	for i := range n {
+		size, err := f.StreamSize(i)
+		if err != nil {
+			return nil, err
+		}
+		switch size {
+		case msf.NilStreamSize:
+		case 0:
+			streams[i] = []byte{}
+		default:
+			if streams[i], err = f.ReadStream(i); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return streams, nil
+}
+
+// stringTable returns the string buffer of the /names stream.
+func stringTable(streams [][]byte) ([]byte, error) {
+	for _, s := range streams {
+		if len(s) >= 12 && binary.LittleEndian.Uint32(s) == 0xeffeeffe {
+			size := binary.LittleEndian.Uint32(s[8:])
+			return s[12 : 12+size], nil
+		}
+	}
+	return nil, fmt.Errorf("no /names stream")
+}
+
+// patchDBI points the DBI stream at the symbol record stream and replaces
+// its section contribution substream and optional debug header.
+func patchDBI(dbi, contribs []byte, symRecords uint16, dbg []uint16) ([]byte, error) {
+	const headerSize = 64
+	le := binary.LittleEndian
+
+	var substreams uint32
+	for _, off := range []int{24, 28, 32, 36, 40, 52} {
+		substreams += le.Uint32(dbi[off:])
+	}
+	if int(headerSize+substreams) > len(dbi) {
+		return nil, fmt.Errorf("DBI substreams exceed the stream")
+	}
+
+	// Module info precedes the section contributions
+	scStart := headerSize + le.Uint32(dbi[24:])
+	scEnd := scStart + le.Uint32(dbi[28:])
+	out := bytes.Clone(dbi[:scStart])
+	out = append(out, contribs...)
+	out = append(out, dbi[scEnd:headerSize+substreams]...)
+	le.PutUint32(out[28:], uint32(len(contribs)))
+	le.PutUint16(out[20:], symRecords)
+	le.PutUint32(out[48:], uint32(len(dbg)*2))
+	for _, index := range dbg {
+		out = le.AppendUint16(out, index)
+	}
+	return out, nil
+}
+
+// sectionContributions encodes a version 6.0 section contribution
+// substream. Each contribution takes the characteristics of its section.
+func sectionContributions(fx fixture) []byte {
+	le := binary.LittleEndian
+	out := le.AppendUint32(nil, sectionContribVer60)
+	for _, c := range fx.contributions {
+		out = le.AppendUint16(out, c.section)
+		out = le.AppendUint16(out, 0)
+		out = le.AppendUint32(out, c.offset)
+		out = le.AppendUint32(out, c.size)
+		out = le.AppendUint32(out, fx.sections[c.section-1].characteristics)
+		out = le.AppendUint16(out, c.module)
+		out = le.AppendUint16(out, 0)
+		out = le.AppendUint32(out, 0) // Data CRC
+		out = le.AppendUint32(out, 0) // Relocation CRC
+	}
+	return out
+}
+
+func sectionHeaders(sections []section) []byte {
+	var buf bytes.Buffer
+	for _, s := range sections {
+		h := pe.SectionHeader32{
+			VirtualSize:     s.size,
+			VirtualAddress:  s.va,
+			Characteristics: s.characteristics,
+		}
+		copy(h.Name[:], s.name)
+		binary.Write(&buf, binary.LittleEndian, &h)
+	}
+	return buf.Bytes()
+}
+
+func publicRecords(publics []public) []byte {
+	le := binary.LittleEndian
+	var out []byte
+	for _, p := range publics {
+		body := le.AppendUint32(nil, p.flags)
+		body = le.AppendUint32(body, p.offset)
+		body = le.AppendUint16(body, p.section)
+		body = append(body, p.name...)
+		body = append(body, 0)
+		for (len(body)+4)%4 != 0 {
+			body = append(body, 0)
+		}
+		out = le.AppendUint16(out, uint16(len(body)+2))
+		out = le.AppendUint16(out, symPub32)
+		out = append(out, body...)
+	}
+	return out
+}
+
+func frameDataStream(records []frameData, names []byte) ([]byte, error) {
+	le := binary.LittleEndian
+	var out []byte
+	for _, fd := range records {
+		program := 0
+		if fd.program != "" {
+			program = bytes.Index(names, append(append([]byte{0}, fd.program...), 0)) + 1
+			if program == 0 {
+				return nil, fmt.Errorf("frame data program %q is not in the string table", fd.program)
+			}
+		}
+		for _, v := range []uint32{fd.rva, fd.codeSize, fd.localSize, fd.paramsSize, fd.maxStackSize, uint32(program)} {
+			out = le.AppendUint32(out, v)
+		}
+		out = le.AppendUint16(out, fd.prologSize)
+		out = le.AppendUint16(out, fd.savedRegsSize)
+		out = le.AppendUint32(out, fd.flags)
+	}
+	return out, nil
+}
+
+func fpoStream(records []fpoData) []byte {
+	le := binary.LittleEndian
+	var out []byte
+	for _, fd := range records {
+		bits := uint16(fd.prologSize) | uint16(fd.savedRegs&0x7)<<8 | uint16(fd.frameType&0x3)<<14
+		if fd.usesBP {
+			bits |= 0x1000
+		}
+		out = le.AppendUint32(out, fd.rva)
+		out = le.AppendUint32(out, fd.codeSize)
+		out = le.AppendUint32(out, fd.locals)
+		out = le.AppendUint16(out, fd.params)
+		out = le.AppendUint16(out, bits)
+	}
+	return out
+}
+
+// writeMSF writes streams to a new MSF file: the superblock and free block
+// maps, the stream data, the directory and finally the directory's block map.
+func writeMSF(path string, blockSize uint32, streams [][]byte) error {
+	le := binary.LittleEndian
+	blocks := [][]byte{nil, nil, nil}
+	place := func(data []byte) []uint32 {
+		var indexes []uint32
+		for off := 0; off < len(data); off += int(blockSize) {
+			indexes = append(indexes, uint32(len(blocks)))
+			blocks = append(blocks, data[off:min(off+int(blockSize), len(data))])
+		}
+		return indexes
+	}
+
+	dir := le.AppendUint32(nil, uint32(len(streams)))
+	for _, s := range streams {
+		if s == nil {
+			dir = le.AppendUint32(dir, msf.NilStreamSize)
+		} else {
+			dir = le.AppendUint32(dir, uint32(len(s)))
+		}
+	}
+	for _, s := range streams {
+		for _, b := range place(s) {
+			dir = le.AppendUint32(dir, b)
+		}
+	}
+	var blockMap []byte
+	for _, b := range place(dir) {
+		blockMap = le.AppendUint32(blockMap, b)
+	}
+	blockMapAddr := uint32(len(blocks))
+	blocks = append(blocks, blockMap)
+
+	numBlocks := uint32(len(blocks))
+	fpm := bytes.Repeat([]byte{0xff}, int(blockSize))
+	for i := range numBlocks {
+		fpm[i/8] &^= 1 << (i % 8)
+	}
+	sb := []byte(msf.Magic)
+	for _, v := range []uint32{blockSize, 1, numBlocks, uint32(len(dir)), 0, blockMapAddr} {
+		sb = le.AppendUint32(sb, v)
+	}
+	blocks[0], blocks[1], blocks[2] = sb, fpm, bytes.Repeat([]byte{0xff}, int(blockSize))
+
+	var out bytes.Buffer
+	for _, b := range blocks {
+		out.Write(b)
+		out.Write(make([]byte, int(blockSize)-len(b)))
+	}
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
+
+// writeImage writes a PE32+ image with the given sections, the unwind
+// information in .rdata and the function table in .pdata.
+func writeImage(path string, sections []section) error {
+	const (
+		fileAlignment    = 0x200
+		sectionAlignment = 0x1000
+		headersSize      = 0x400
+		timestamp        = 0x65f0a1b2
+	)
+	le := binary.LittleEndian
+
+	contents := make(map[string][]byte)
+	text := bytes.Repeat([]byte{0xcc}, int(sections[0].size))
+	contents[".text"] = text
+
+	rdata := make([]byte, 0x60)
+	copy(rdata[0x00:], unwindMain)
+	copy(rdata[0x10:], unwindDraw)
+	// Cold part of Widget::Draw: no codes of its own, chained to Draw's
+	chained := unwindInfo(0x4, 0, 0, 0)
+	chained = le.AppendUint32(chained, runtimeFunctions[1][0])
+	chained = le.AppendUint32(chained, runtimeFunctions[1][1])
+	chained = le.AppendUint32(chained, 0x2000+runtimeFunctions[1][2])
+	copy(rdata[0x30:], chained)
+	copy(rdata[0x50:], unwindTrap)
+	contents[".rdata"] = rdata
+
+	var pdata []byte
+	for _, fn := range runtimeFunctions {
+		pdata = le.AppendUint32(pdata, fn[0])
+		pdata = le.AppendUint32(pdata, fn[1])
+		pdata = le.AppendUint32(pdata, 0x2000+fn[2])
+	}
+	contents[".pdata"] = pdata
+
+	var headers []pe.SectionHeader32
+	var raw bytes.Buffer
+	var sizeOfImage uint32
+	for _, s := range sections {
+		data := contents[s.name]
+		h := pe.SectionHeader32{
+			VirtualSize:     s.size,
+			VirtualAddress:  s.va,
+			Characteristics: s.characteristics,
+		}
+		copy(h.Name[:], s.name)
+		if len(data) > 0 {
+			h.SizeOfRawData = uint32((len(data) + fileAlignment - 1) &^ (fileAlignment - 1))
+			h.PointerToRawData = headersSize + uint32(raw.Len())
+			raw.Write(data)
+			raw.Write(make([]byte, int(h.SizeOfRawData)-len(data)))
+		}
+		headers = append(headers, h)
+		sizeOfImage = (s.va + s.size + sectionAlignment - 1) &^ (sectionAlignment - 1)
+	}
+
+	oh := pe.OptionalHeader64{
+		Magic:                       0x20b,
+		SizeOfCode:                  headers[0].SizeOfRawData,
+		AddressOfEntryPoint:         sections[0].va,
+		BaseOfCode:                  sections[0].va,
+		ImageBase:                   0x140000000,
+		SectionAlignment:            sectionAlignment,
+		FileAlignment:               fileAlignment,
+		MajorOperatingSystemVersion: 6,
+		MajorSubsystemVersion:       6,
+		SizeOfImage:                 sizeOfImage,
+		SizeOfHeaders:               headersSize,
+		Subsystem:                   pe.IMAGE_SUBSYSTEM_WINDOWS_CUI,
+		SizeOfStackReserve:          0x100000,
+		SizeOfStackCommit:           0x1000,
+		SizeOfHeapReserve:           0x100000,
+		SizeOfHeapCommit:            0x1000,
+		NumberOfRvaAndSizes:         16,
+	}
+	oh.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXCEPTION] = pe.DataDirectory{
+		VirtualAddress: 0x3000,
+		Size:           uint32(len(pdata)),
+	}
+	fh := pe.FileHeader{
+		Machine:              pe.IMAGE_FILE_MACHINE_AMD64,
+		NumberOfSections:     uint16(len(headers)),
+		TimeDateStamp:        timestamp,
+		SizeOfOptionalHeader: uint16(binary.Size(oh)),
+		Characteristics:      pe.IMAGE_FILE_EXECUTABLE_IMAGE | pe.IMAGE_FILE_LARGE_ADDRESS_AWARE,
+	}
+
+	var out bytes.Buffer
+	dos := make([]byte, 0x40)
+	copy(dos, "MZ")
+	le.PutUint32(dos[0x3c:], 0x40)
+	out.Write(dos)
+	out.WriteString("PE\x00\x00")
+	binary.Write(&out, le, &fh)
+	binary.Write(&out, le, &oh)
+	binary.Write(&out, le, headers)
+	out.Write(make([]byte, headersSize-out.Len()))
+	out.Write(raw.Bytes())
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
@@ -0,0 +1,230 @@
+package unwind
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// x64 unwind operation codes (UWOP_*)
+const (
+	uwopPushNonvol    = 0
+	uwopAllocLarge    = 1
+	uwopAllocSmall    = 2
+	uwopSetFPReg      = 3
+	uwopSaveNonvol    = 4
+	uwopSaveNonvolFar = 5
+	uwopSaveXMM       = 6 // UWOP_EPILOG in version 2
+	uwopSaveXMMFar    = 7
+	uwopSaveXMM128    = 8
+	uwopSaveXMM128Far = 9
+	uwopPushMachFrame = 10
+)
+
+// unwFlagChainInfo marks UNWIND_INFO that continues in a chained entry.
+const unwFlagChainInfo = 0x4
+
+// unwindAMD64 recovers the caller of an x64 frame by undoing the prolog of
+// its function as described by the unwind codes, then popping the return
+// address. Functions without unwind information are leaf functions whose
+// return address is at the top of the stack.
+//
+// Frames stopped inside an epilog are unwound as if the epilog had not
+// started; Unwind falls back to scanning when the result is implausible.
+func (w *Walker) unwindAMD64(frame *Frame) (Frame, bool) {
+	m := frame.Module
+	if m == nil || !m.Contains(frame.Context.IP) {
+		return Frame{}, false
+	}
+
+	caller := Frame{Context: frame.Context, Trust: TrustUnwindInfo}
+	caller.Context.Valid &= calleeSaved(ArchAMD64) | 1<<RSP
+
+	// Return addresses belong to the call instruction before them
+	rva := uint32(frame.Context.IP - m.Base)
+	lookup := rva
+	if frame.Trust != TrustContext && lookup > 0 {
+		lookup--
+	}
+
+	fn, ok := m.function(lookup)
+	switch {
+	case ok:
+		done, err := w.applyUnwindCodes(m, &caller.Context, fn, rva-fn.Begin)
+		if err != nil {
+			return Frame{}, false
+		}
+		if done {
+			return caller, true
+		}
+	case len(m.Functions) == 0 || frame.Trust != TrustContext:
+		// Without a function table nothing is known about the frame, and
+		// only the innermost frame can be in a leaf function
+		return Frame{}, false
+	}
+
+	ret, err := w.readPointer(&caller.Context, caller.Context.SP())
+	if err != nil {
+		return Frame{}, false
+	}
+	caller.Context.IP = ret
+	caller.Context.set(RSP, caller.Context.SP()+8)
+	return caller, true
+}
+
+// applyUnwindCodes undoes the prolog of a function at an offset into it,
+// following chained unwind information. It returns true if a machine frame
+// already restored the instruction pointer.
+func (w *Walker) applyUnwindCodes(m *Module, ctx *Context, fn RuntimeFunction, pcOffset uint32) (bool, error) {
+	read := func(rva, size uint32) ([]byte, error) {
+		if m.Image != nil {
+			return m.Image.ReadRVA(rva, size)
+		}
+		return w.Memory.ReadMemory(m.Base+uint64(rva), uint64(size))
+	}
+	load := func(addr uint64) (uint64, error) { return w.readPointer(ctx, addr) }
+
+	// Only the codes of the primary entry can be partially executed
+	primary := true
+
+	unwindRVA := fn.UnwindInfo
+	for visited := 0; visited < 32; visited++ {
+		header, err := read(unwindRVA, 4)
+		if err != nil {
+			return false, err
+		}
+		version := header[0] & 0x7
+		flags := header[0] >> 3
+		prologSize := uint32(header[1])
+		count := uint32(header[2])
+		frameReg := int(header[3] & 0xf)
+		frameOffset := uint64(header[3]>>4) * 16
+
+		if version != 1 && version != 2 {
+			return false, fmt.Errorf("unwind: unsupported unwind info version %d at RVA 0x%x", version, unwindRVA)
+		}
+
+		codes, err := read(unwindRVA+4, count*2)
+		if err != nil {
+			return false, err
+		}
+		slot := func(i uint32) uint32 { return uint32(binary.LittleEndian.Uint16(codes[i*2:])) }
+		executed := func(i uint32) bool { return !primary || pcOffset >= prologSize || pcOffset >= uint32(codes[i*2]) }
+
+		// With a frame register the stack pointer may have moved after the
+		// prolog, so the frame is located through the register instead
+		if frameReg != 0 {
+			for i := uint32(0); i < count; i++ {
+				if codes[i*2+1]&0xf == uwopSetFPReg && executed(i) {
+					ctx.set(RSP, ctx.Regs[frameReg]-frameOffset)
+					break
+				}
+			}
+		}
+
+		for i := uint32(0); i < count; i++ {
+			op := codes[i*2+1] & 0xf
+			info := int(codes[i*2+1] >> 4)
+			run := executed(i)
+
+			switch op {
+			case uwopPushNonvol:
+				if run {
+					v, err := load(ctx.SP())
+					if err != nil {
+						return false, err
+					}
+					ctx.set(info, v)
+					ctx.set(RSP, ctx.SP()+8)
+				}
+			case uwopAllocLarge:
+				var size uint64
+				if info == 0 {
+					if i+1 < count {
+						size = uint64(slot(i+1)) * 8
+					}
+					i++
+				} else {
+					if i+2 < count {
+						size = uint64(slot(i+1) | slot(i+2)<<16)
+					}
+					i += 2
+				}
+				if run {
+					ctx.set(RSP, ctx.SP()+size)
+				}
+			case uwopAllocSmall:
+				if run {
+					ctx.set(RSP, ctx.SP()+uint64(info)*8+8)
+				}
+			case uwopSetFPReg:
+				// Handled above
+			case uwopSaveNonvol, uwopSaveNonvolFar:
+				var off uint64
+				if op == uwopSaveNonvol {
+					if i+1 < count {
+						off = uint64(slot(i+1)) * 8
+					}
+					i++
+				} else {
+					if i+2 < count {
+						off = uint64(slot(i+1) | slot(i+2)<<16)
+					}
+					i += 2
+				}
+				if run {
+					v, err := load(ctx.SP() + off)
+					if err != nil {
+						return false, err
+					}
+					ctx.set(info, v)
+				}
+			case uwopSaveXMM128:
+				i++
+			case uwopSaveXMM128Far:
+				i += 2
+			case uwopSaveXMM:
+				// Epilog codes take a single slot
+				if version < 2 {
+					i++
+				}
+			case uwopSaveXMMFar:
+				i += 2
+			case uwopPushMachFrame:
+				if !run {
+					continue
+				}
+				// The interrupt pushed SS, RSP, EFLAGS, CS and RIP, after an
+				// optional error code
+				sp := ctx.SP()
+				if info != 0 {
+					sp += 8
+				}
+				ip, err := load(sp)
+				if err != nil {
+					return false, err
+				}
+				rsp, err := load(sp + 24)
+				if err != nil {
+					return false, err
+				}
+				ctx.IP = ip
+				ctx.set(RSP, rsp)
+				return true, nil
+			}
+		}
+
+		if flags&unwFlagChainInfo == 0 {
+			break
+		}
+
+		// The chained RUNTIME_FUNCTION follows the codes, padded to an even count
+		chained, err := read(unwindRVA+4+((count+1)&^1)*2, 12)
+		if err != nil {
+			return false, err
+		}
+		unwindRVA = binary.LittleEndian.Uint32(chained[8:])
+		primary = false
+	}
+
+	return false, nil
+}
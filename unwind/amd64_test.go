@@ -0,0 +1,210 @@
+package unwind
+
+import (
+	"debug/pe"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The x64 fixture's functions and unwind information:
+//
+//	0x1000 main          push rbx; sub rsp, 20h
+//	0x1040 Widget::Draw  push rbp; push r12; sub rsp, 1000h; lea rbp, [rsp+20h];
+//	                     mov [rsp+1018h], rsi
+//	0x10a0 (cold part of Widget::Draw, chained to its unwind information)
+//	0x10c0 helper        leaf, no unwind information
+//	0x10d0 trap_handler  machine frame with error code; sub rsp, 12340h
+const imageBase = 0x140000000
+
+func x64PE(t *testing.T) *pe.File {
+	t.Helper()
+	f, err := pe.Open(filepath.Join("..", "testdata", "x64.exe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func x64Module(t *testing.T) *Module {
+	t.Helper()
+	m, err := NewPEModule("x64.exe", imageBase, x64PE(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// mapImage lays out the fixture executable in memory as the loader does.
+func mapImage(t *testing.T, mem *memory) {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("..", "testdata", "x64.exe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := x64PE(t)
+	oh := f.OptionalHeader.(*pe.OptionalHeader64)
+
+	mem.alloc(imageBase, uint64(oh.SizeOfImage))
+	mem.write(imageBase, raw[:oh.SizeOfHeaders])
+	for _, s := range f.Sections {
+		data, err := s.Data()
+		if err != nil {
+			t.Fatal(err)
+		}
+		mem.write(imageBase+uint64(s.VirtualAddress), data[:min(len(data), int(s.VirtualSize))])
+	}
+}
+
+// drawStack lays out the frames of helper, Widget::Draw and main at stack,
+// and returns the context in helper.
+func drawStack(mem *memory, stack uint64) Context {
+	s1 := stack + 8 // Widget::Draw's stack pointer after its prolog
+	s2 := s1 + 0x1018
+
+	// helper returns into Widget::Draw
+	mem.put64(stack, imageBase+0x1070)
+
+	mem.put64(s1+0x1000, 0x1212)           // r12
+	mem.put64(s1+0x1008, 0)                // rbp
+	mem.put64(s1+0x1010, imageBase+0x1030) // Return into main
+	mem.put64(s1+0x1018, 0x5151)           // rsi, in main's home area
+
+	mem.put64(s2+0x20, 0xbbbb) // rbx
+	mem.put64(s2+0x28, 0)      // main is the outermost frame
+
+	var regs [16]uint64
+	regs[RSP] = stack
+	regs[RBP] = s1 + 0x20
+	regs[RAX] = 0xaaaa
+	return NewContext(ArchAMD64, imageBase+0x10c4, regs)
+}
+
+func TestWalkAMD64(t *testing.T) {
+	const stack = 0x7ff000
+	for _, loaded := range []string{"pe", "memory"} {
+		t.Run(loaded, func(t *testing.T) {
+			mem := newMemory()
+			mem.alloc(stack, 0x2000)
+
+			var m *Module
+			if loaded == "pe" {
+				m = x64Module(t)
+			} else {
+				mapImage(t, mem)
+				var err error
+				if m, err = NewMemoryModule("x64.exe", imageBase, 0x5000, mem); err != nil {
+					t.Fatal(err)
+				}
+			}
+			w := &Walker{Memory: mem, Modules: []*Module{m}}
+
+			frames := w.Walk(drawStack(mem, stack))
+			checkFrames(t, frames, []wantFrame{
+				{imageBase + 0x10c4, stack, TrustContext},
+				{imageBase + 0x1070, stack + 8, TrustUnwindInfo},
+				{imageBase + 0x1030, stack + 8 + 0x1018, TrustUnwindInfo},
+			})
+			if len(frames) < 3 {
+				return
+			}
+
+			if _, ok := frames[1].Context.Register(RAX); ok {
+				t.Error("rax recovered for the caller of helper")
+			}
+			checkRegister(t, frames[2], RSI, 0x5151)
+			checkRegister(t, frames[2], R12, 0x1212)
+			checkRegister(t, frames[2], RBP, 0)
+		})
+	}
+}
+
+func TestUnwindAMD64(t *testing.T) {
+	const stack = 0x7f0000
+	m := x64Module(t)
+
+	tests := []struct {
+		name  string
+		ip    uint64
+		setup func(mem *memory, regs *[16]uint64)
+		want  wantFrame
+		reg   int
+		value uint64
+	}{
+		{
+			// The cold part has no codes of its own but chains to the
+			// unwind information of Widget::Draw
+			name: "chained",
+			ip:   imageBase + 0x10b0,
+			setup: func(mem *memory, regs *[16]uint64) {
+				regs[RBP] = stack + 0x20
+				mem.put64(stack+0x1000, 0x1212)
+				mem.put64(stack+0x1010, imageBase+0x1030)
+				mem.put64(stack+0x1018, 0x5151)
+			},
+			want: wantFrame{imageBase + 0x1030, stack + 0x1018, TrustUnwindInfo},
+			reg:  RSI, value: 0x5151,
+		},
+		{
+			// Within main's prolog, after the push and before the
+			// allocation
+			name: "prolog",
+			ip:   imageBase + 0x1001,
+			setup: func(mem *memory, regs *[16]uint64) {
+				mem.put64(stack, 0xbbbb)
+				mem.put64(stack+8, imageBase+0x1035)
+			},
+			want: wantFrame{imageBase + 0x1035, stack + 0x10, TrustUnwindInfo},
+			reg:  RBX, value: 0xbbbb,
+		},
+		{
+			// The machine frame holds the interrupted context, above the
+			// error code
+			name: "machine frame",
+			ip:   imageBase + 0x10e0,
+			setup: func(mem *memory, regs *[16]uint64) {
+				mem.put64(stack+0x12340, 0x0e)             // Error code
+				mem.put64(stack+0x12348, imageBase+0x1070) // RIP
+				mem.put64(stack+0x12348+24, stack+0x20000) // RSP
+				regs[RBX] = 0xbbbb
+			},
+			want: wantFrame{imageBase + 0x1070, stack + 0x20000, TrustUnwindInfo},
+			reg:  RBX, value: 0xbbbb,
+		},
+		{
+			// Outside the function table: neither the data nor the function
+			// start, which cannot follow a call, is a return address
+			name: "scan",
+			ip:   imageBase + 0x1100,
+			setup: func(mem *memory, regs *[16]uint64) {
+				mem.put64(stack, 0xdeadbeef)
+				mem.put64(stack+8, imageBase+0x1000)
+				mem.put64(stack+16, imageBase+0x1035)
+			},
+			want: wantFrame{imageBase + 0x1035, stack + 24, TrustScan},
+			reg:  -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := newMemory()
+			mem.alloc(stack, 0x12400)
+			var regs [16]uint64
+			regs[RSP] = stack
+			tt.setup(mem, &regs)
+
+			w := &Walker{Memory: mem, Modules: []*Module{m}}
+			frame := Frame{Context: NewContext(ArchAMD64, tt.ip, regs), Trust: TrustContext, Module: m}
+			caller, ok := w.Unwind(&frame)
+			if !ok {
+				t.Fatal("no caller")
+			}
+			checkFrames(t, []Frame{caller}, []wantFrame{tt.want})
+			if tt.reg >= 0 {
+				checkRegister(t, caller, tt.reg, tt.value)
+			}
+		})
+	}
+}
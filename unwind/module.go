@@ -0,0 +1,237 @@
+package unwind
+
+import (
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// RuntimeFunction is an x64 RUNTIME_FUNCTION entry of an image's
+// exception directory. All fields are RVAs.
+type RuntimeFunction struct {
+	Begin      uint32
+	End        uint32
+	UnwindInfo uint32
+}
+
+// ImageReader reads the contents of a loaded image by RVA.
+type ImageReader interface {
+	ReadRVA(rva, size uint32) ([]byte, error)
+}
+
+// Module is a loaded image and the unwind information for its code.
+type Module struct {
+	Name string
+	Base uint64
+	Size uint64
+
+	// x86 frame information from the PDB
+	FrameData []pdb.FrameData
+	FPO       []pdb.FPOData
+
+	// x64 function table, sorted by Begin. Unwind information is read
+	// through Image, or from process memory when Image is nil.
+	Functions []RuntimeFunction
+	Image     ImageReader
+
+	codeRanges [][2]uint32 // RVA ranges of executable sections
+}
+
+// Contains returns true if an address lies within the module.
+func (m *Module) Contains(addr uint64) bool {
+	return addr >= m.Base && addr-m.Base < m.Size
+}
+
+// isCode reports whether an address is in executable code of the module.
+// Without section information the whole module counts as code.
+func (m *Module) isCode(addr uint64) bool {
+	if !m.Contains(addr) {
+		return false
+	}
+	if len(m.codeRanges) == 0 {
+		return true
+	}
+	rva := uint32(addr - m.Base)
+	for _, r := range m.codeRanges {
+		if rva >= r[0] && rva < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// function returns the runtime function containing an RVA.
+func (m *Module) function(rva uint32) (RuntimeFunction, bool) {
+	i := sort.Search(len(m.Functions), func(i int) bool { return m.Functions[i].End > rva })
+	if i == len(m.Functions) || m.Functions[i].Begin > rva {
+		return RuntimeFunction{}, false
+	}
+	return m.Functions[i], true
+}
+
+// LoadPDB adds the x86 frame data and FPO records of the module's PDB. The
+// PDB's section headers tell code from data when the module has no others.
+func (m *Module) LoadPDB(f *pdb.File) error {
+	var err error
+	if m.FrameData, err = f.FrameData(); err != nil {
+		return err
+	}
+	if m.FPO, err = f.FPOData(); err != nil {
+		return err
+	}
+
+	sort.SliceStable(m.FrameData, func(i, j int) bool { return m.FrameData[i].RVA < m.FrameData[j].RVA })
+	sort.SliceStable(m.FPO, func(i, j int) bool { return m.FPO[i].RVA < m.FPO[j].RVA })
+
+	if len(m.codeRanges) == 0 {
+		if sections, err := f.Sections(); err == nil {
+			for _, s := range sections.All() {
+				m.addSection(s.VirtualAddress, s.VirtualSize, s.Characteristics)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Module) addSection(va, size, characteristics uint32) {
+	const imageScnMemExecute = 0x20000000
+	if characteristics&imageScnMemExecute != 0 && size > 0 {
+		m.codeRanges = append(m.codeRanges, [2]uint32{va, va + size})
+	}
+}
+
+// NewPEModule creates a module for an executable loaded at base, with the
+// function table and unwind information of its x64 exception directory.
+func NewPEModule(name string, base uint64, f *pe.File) (*Module, error) {
+	img := &peImage{file: f, data: make(map[*pe.Section][]byte)}
+
+	var size uint32
+	var dir pe.DataDirectory
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		size = oh.SizeOfImage
+	case *pe.OptionalHeader64:
+		size = oh.SizeOfImage
+		if oh.NumberOfRvaAndSizes > pe.IMAGE_DIRECTORY_ENTRY_EXCEPTION {
+			dir = oh.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXCEPTION]
+		}
+	default:
+		return nil, fmt.Errorf("unwind: PE file has no optional header")
+	}
+
+	m := &Module{Name: name, Base: base, Size: uint64(size), Image: img}
+	for _, s := range f.Sections {
+		m.addSection(s.VirtualAddress, max(s.VirtualSize, s.Size), s.Characteristics)
+	}
+
+	if dir.Size > 0 {
+		data, err := img.ReadRVA(dir.VirtualAddress, dir.Size)
+		if err != nil {
+			return nil, err
+		}
+		m.Functions = parseRuntimeFunctions(data)
+	}
+	return m, nil
+}
+
+// NewMemoryModule creates a module from the image headers captured in
+// process memory, as found in full minidumps.
+func NewMemoryModule(name string, base, size uint64, mem MemoryReader) (*Module, error) {
+	img := &memoryImage{mem: mem, base: base}
+	m := &Module{Name: name, Base: base, Size: size, Image: img}
+
+	dos, err := img.ReadRVA(0, 0x40)
+	if err != nil || binary.LittleEndian.Uint16(dos) != 0x5a4d {
+		return nil, fmt.Errorf("unwind: no image header in memory at 0x%x", base)
+	}
+	peOff := binary.LittleEndian.Uint32(dos[0x3c:])
+
+	header, err := img.ReadRVA(peOff, 24)
+	if err != nil || binary.LittleEndian.Uint32(header) != 0x4550 {
+		return nil, fmt.Errorf("unwind: invalid PE header in memory at 0x%x", base)
+	}
+	numSections := uint32(binary.LittleEndian.Uint16(header[6:]))
+	optSize := uint32(binary.LittleEndian.Uint16(header[20:]))
+
+	opt, err := img.ReadRVA(peOff+24, optSize)
+	if err != nil || len(opt) < 2 {
+		return nil, fmt.Errorf("unwind: invalid optional header in memory at 0x%x", base)
+	}
+
+	// PE32+ headers carry the exception directory at a fixed offset
+	const pe32PlusMagic = 0x20b
+	if binary.LittleEndian.Uint16(opt) == pe32PlusMagic && len(opt) >= 112+8*(pe.IMAGE_DIRECTORY_ENTRY_EXCEPTION+1) {
+		d := opt[112+8*pe.IMAGE_DIRECTORY_ENTRY_EXCEPTION:]
+		rva, dirSize := binary.LittleEndian.Uint32(d), binary.LittleEndian.Uint32(d[4:])
+		if dirSize > 0 {
+			if data, err := img.ReadRVA(rva, dirSize); err == nil {
+				m.Functions = parseRuntimeFunctions(data)
+			}
+		}
+	}
+
+	if sections, err := img.ReadRVA(peOff+24+optSize, numSections*40); err == nil {
+		for i := uint32(0); i < numSections; i++ {
+			s := sections[i*40:]
+			m.addSection(binary.LittleEndian.Uint32(s[12:]), binary.LittleEndian.Uint32(s[8:]), binary.LittleEndian.Uint32(s[36:]))
+		}
+	}
+
+	return m, nil
+}
+
+func parseRuntimeFunctions(data []byte) []RuntimeFunction {
+	funcs := make([]RuntimeFunction, 0, len(data)/12)
+	for i := 0; i+12 <= len(data); i += 12 {
+		fn := RuntimeFunction{
+			Begin:      binary.LittleEndian.Uint32(data[i:]),
+			End:        binary.LittleEndian.Uint32(data[i+4:]),
+			UnwindInfo: binary.LittleEndian.Uint32(data[i+8:]),
+		}
+		if fn.End > fn.Begin {
+			funcs = append(funcs, fn)
+		}
+	}
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].Begin < funcs[j].Begin })
+	return funcs
+}
+
+// peImage reads an image from its PE file.
+type peImage struct {
+	file *pe.File
+	data map[*pe.Section][]byte
+}
+
+func (img *peImage) ReadRVA(rva, size uint32) ([]byte, error) {
+	for _, s := range img.file.Sections {
+		if rva < s.VirtualAddress || rva-s.VirtualAddress >= max(s.VirtualSize, s.Size) {
+			continue
+		}
+
+		data, ok := img.data[s]
+		if !ok {
+			data, _ = s.Data()
+			img.data[s] = data
+		}
+
+		off := uint64(rva - s.VirtualAddress)
+		if off+uint64(size) > uint64(len(data)) {
+			break
+		}
+		return data[off : off+uint64(size)], nil
+	}
+	return nil, fmt.Errorf("unwind: RVA 0x%x not in image", rva)
+}
+
+// memoryImage reads an image from process memory.
+type memoryImage struct {
+	mem  MemoryReader
+	base uint64
+}
+
+func (img *memoryImage) ReadRVA(rva, size uint32) ([]byte, error) {
+	return img.mem.ReadMemory(img.base+uint64(rva), uint64(size))
+}
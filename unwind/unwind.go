@@ -0,0 +1,331 @@
+// Package unwind walks x86 and x64 call stacks. Given the register context
+// of a thread and access to its memory, it recovers the caller frames from
+// PDB frame data (x86) or the unwind codes of the image (x64), falling back
+// to frame pointers and stack scanning when no unwind information applies.
+package unwind
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// MemoryReader reads the memory of the unwound process. minidump.File
+// implements it.
+type MemoryReader interface {
+	ReadMemory(addr, size uint64) ([]byte, error)
+}
+
+// Arch is the processor architecture of a context.
+type Arch int
+
+const (
+	ArchX86 Arch = iota
+	ArchAMD64
+)
+
+// Register numbers, in the order of the x86 instruction encoding and of
+// x64 unwind codes. x86 contexts use the first eight.
+const (
+	RAX = iota
+	RCX
+	RDX
+	RBX
+	RSP
+	RBP
+	RSI
+	RDI
+	R8
+	R9
+	R10
+	R11
+	R12
+	R13
+	R14
+	R15
+)
+
+var registerNames = [2][16]string{
+	{"eax", "ecx", "edx", "ebx", "esp", "ebp", "esi", "edi"},
+	{"rax", "rcx", "rdx", "rbx", "rsp", "rbp", "rsi", "rdi", "r8", "r9", "r10", "r11", "r12", "r13", "r14", "r15"},
+}
+
+// Context is the register state of a frame.
+type Context struct {
+	Arch  Arch
+	IP    uint64
+	Regs  [16]uint64
+	Valid uint16 // Bit n is set if Regs[n] is known
+}
+
+// NewContext returns a context with all general-purpose registers valid.
+func NewContext(arch Arch, ip uint64, regs [16]uint64) Context {
+	return Context{Arch: arch, IP: ip, Regs: regs, Valid: 0xffff}
+}
+
+// SP returns the stack pointer.
+func (c *Context) SP() uint64 { return c.Regs[RSP] }
+
+// FP returns the frame pointer (EBP or RBP).
+func (c *Context) FP() uint64 { return c.Regs[RBP] }
+
+// Register returns a register and whether its value is known.
+func (c *Context) Register(n int) (uint64, bool) {
+	return c.Regs[n], c.Valid&(1<<n) != 0
+}
+
+func (c *Context) set(n int, v uint64) {
+	c.Regs[n] = v
+	c.Valid |= 1 << n
+}
+
+// RegisterName returns the name of a register on the context's architecture.
+func (c *Context) RegisterName(n int) string {
+	return registerNames[c.Arch][n]
+}
+
+// PointerSize returns the size of a pointer on the context's architecture.
+func (c *Context) PointerSize() int {
+	if c.Arch == ArchX86 {
+		return 4
+	}
+	return 8
+}
+
+// Trust tells how a frame was found, from most to least reliable.
+type Trust int
+
+const (
+	TrustContext      Trust = iota // Instruction pointer of the thread context
+	TrustUnwindInfo                // Frame data, FPO or x64 unwind codes
+	TrustFramePointer              // Frame pointer chain
+	TrustScan                      // Stack scanning
+)
+
+func (t Trust) String() string {
+	switch t {
+	case TrustContext:
+		return "context"
+	case TrustUnwindInfo:
+		return "cfi"
+	case TrustFramePointer:
+		return "frame pointer"
+	case TrustScan:
+		return "scan"
+	}
+	return fmt.Sprintf("Trust(%d)", int(t))
+}
+
+// Frame is a stack frame. Every frame but the first has a return address
+// as its instruction pointer.
+type Frame struct {
+	Context Context
+	Trust   Trust
+	Module  *Module // Module containing the instruction pointer, or nil
+
+	calleeParams uint32 // x86: parameter bytes of the callee on top of the stack
+}
+
+// Defaults of a Walker.
+const (
+	DefaultMaxFrames = 256
+	DefaultScanWords = 40
+)
+
+// Walker unwinds stacks within a process.
+type Walker struct {
+	Memory  MemoryReader
+	Modules []*Module
+
+	MaxFrames int // Frames per stack; DefaultMaxFrames if 0
+	ScanWords int // Stack words searched for a return address; DefaultScanWords if 0
+}
+
+// Walk returns the frames of the stack, starting with the context itself.
+// Walking stops at the first frame that cannot be unwound, at a null
+// instruction pointer, or when the stack pointer does not move up.
+func (w *Walker) Walk(ctx Context) []Frame {
+	maxFrames := w.MaxFrames
+	if maxFrames <= 0 {
+		maxFrames = DefaultMaxFrames
+	}
+
+	frame := Frame{Context: ctx, Trust: TrustContext, Module: w.ModuleAt(ctx.IP)}
+	frames := []Frame{frame}
+	for len(frames) < maxFrames {
+		caller, ok := w.Unwind(&frame)
+		if !ok {
+			break
+		}
+		frames = append(frames, caller)
+		frame = caller
+	}
+	return frames
+}
+
+// Unwind returns the caller of a frame. It tries the unwind information of
+// the frame's module, then the frame pointer chain (x86 only), then a stack
+// scan.
+func (w *Walker) Unwind(frame *Frame) (Frame, bool) {
+	var caller Frame
+	var ok bool
+
+	switch frame.Context.Arch {
+	case ArchX86:
+		if caller, ok = w.unwindX86(frame); !ok || !w.plausible(frame, &caller) {
+			caller, ok = w.unwindFramePointer(frame)
+		}
+	case ArchAMD64:
+		caller, ok = w.unwindAMD64(frame)
+	}
+
+	if !ok || !w.plausible(frame, &caller) {
+		if caller, ok = w.scan(frame); !ok {
+			return Frame{}, false
+		}
+	}
+
+	caller.Module = w.ModuleAt(caller.Context.IP)
+	return caller, true
+}
+
+// ModuleAt returns the module containing an address, or nil.
+func (w *Walker) ModuleAt(addr uint64) *Module {
+	for _, m := range w.Modules {
+		if m.Contains(addr) {
+			return m
+		}
+	}
+	return nil
+}
+
+// plausible checks a recovered caller: it must return into code and its
+// stack pointer must be above the callee's.
+func (w *Walker) plausible(callee, caller *Frame) bool {
+	return caller.Context.IP != 0 &&
+		caller.Context.SP() > callee.Context.SP() &&
+		w.isReturnAddress(caller.Context.Arch, caller.Context.IP)
+}
+
+// isReturnAddress reports whether a value could be a return address. On
+// x64 the address must also be covered by the function table, since a
+// function that calls others always has unwind information.
+func (w *Walker) isReturnAddress(arch Arch, addr uint64) bool {
+	m := w.ModuleAt(addr)
+	if m == nil || !m.isCode(addr) {
+		return false
+	}
+	if arch == ArchAMD64 && len(m.Functions) > 0 && addr > m.Base {
+		_, ok := m.function(uint32(addr - m.Base - 1))
+		return ok
+	}
+	return true
+}
+
+// unwindFramePointer follows the frame pointer: [ebp] holds the caller's
+// ebp and [ebp+4] the return address.
+func (w *Walker) unwindFramePointer(frame *Frame) (Frame, bool) {
+	ctx := frame.Context
+	bp, ok := ctx.Register(RBP)
+	if !ok || bp == 0 {
+		return Frame{}, false
+	}
+
+	size := uint64(ctx.PointerSize())
+	savedBP, err1 := w.readPointer(&ctx, bp)
+	ret, err2 := w.readPointer(&ctx, bp+size)
+	if err1 != nil || err2 != nil {
+		return Frame{}, false
+	}
+
+	caller := Frame{Context: ctx, Trust: TrustFramePointer}
+	caller.Context.IP = ret
+	caller.Context.set(RBP, savedBP)
+	caller.Context.set(RSP, bp+2*size)
+	return caller, true
+}
+
+// scan searches the stack above the stack pointer for a return address.
+// The first frame is scanned further since its function may not have
+// pushed anything yet or may keep large locals.
+func (w *Walker) scan(frame *Frame) (Frame, bool) {
+	ctx := frame.Context
+	words := w.scanWords()
+	if frame.Trust == TrustContext {
+		words *= 4
+	}
+
+	sp := ctx.SP()
+	if frame.Trust != TrustContext {
+		// Skip the callee's parameters popped on return
+		sp += uint64(frame.calleeParams)
+	}
+
+	addr, ret, ok := w.scanForReturnAddress(&ctx, sp, words)
+	if !ok {
+		return Frame{}, false
+	}
+
+	caller := Frame{Context: ctx, Trust: TrustScan}
+	caller.Context.IP = ret
+	caller.Context.set(RSP, addr+uint64(ctx.PointerSize()))
+
+	// Without unwind information nothing but the callee-saved registers
+	// survive the call, and those only on a best-effort basis
+	caller.Context.Valid &= calleeSaved(ctx.Arch)
+	caller.Context.Valid |= 1 << RSP
+	return caller, true
+}
+
+// scanForReturnAddress returns the first of count stack words at start that
+// is a plausible return address, and its location.
+func (w *Walker) scanForReturnAddress(ctx *Context, start uint64, count int) (addr, ret uint64, ok bool) {
+	size := ctx.PointerSize()
+	data, err := w.Memory.ReadMemory(start, uint64(count*size))
+	for err != nil && count > 1 {
+		// Read what is left of the stack when it ends early
+		count /= 2
+		data, err = w.Memory.ReadMemory(start, uint64(count*size))
+	}
+	if err != nil {
+		return 0, 0, false
+	}
+
+	for i := 0; i+size <= len(data); i += size {
+		v := pointerAt(data[i:], size)
+		if v != 0 && w.isReturnAddress(ctx.Arch, v) {
+			return start + uint64(i), v, true
+		}
+	}
+	return 0, 0, false
+}
+
+func (w *Walker) scanWords() int {
+	if w.ScanWords > 0 {
+		return w.ScanWords
+	}
+	return DefaultScanWords
+}
+
+func (w *Walker) readPointer(ctx *Context, addr uint64) (uint64, error) {
+	size := ctx.PointerSize()
+	data, err := w.Memory.ReadMemory(addr, uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	return pointerAt(data, size), nil
+}
+
+func pointerAt(data []byte, size int) uint64 {
+	if size == 4 {
+		return uint64(binary.LittleEndian.Uint32(data))
+	}
+	return binary.LittleEndian.Uint64(data)
+}
+
+// calleeSaved returns the registers a function preserves for its caller.
+func calleeSaved(arch Arch) uint16 {
+	if arch == ArchX86 {
+		return 1<<RBX | 1<<RBP | 1<<RSI | 1<<RDI
+	}
+	return 1<<RBX | 1<<RBP | 1<<RSI | 1<<RDI | 1<<R12 | 1<<R13 | 1<<R14 | 1<<R15
+}
@@ -0,0 +1,129 @@
+package unwind
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+// memory is a synthetic process memory image made of separate regions.
+type memory struct {
+	regions map[uint64][]byte // Region contents by start address
+}
+
+func newMemory() *memory {
+	return &memory{regions: make(map[uint64][]byte)}
+}
+
+// alloc adds a zeroed region.
+func (m *memory) alloc(addr, size uint64) {
+	m.regions[addr] = make([]byte, size)
+}
+
+func (m *memory) ReadMemory(addr, size uint64) ([]byte, error) {
+	for start, data := range m.regions {
+		if addr >= start && addr+size <= start+uint64(len(data)) {
+			return data[addr-start : addr-start+size], nil
+		}
+	}
+	return nil, fmt.Errorf("no memory at 0x%x", addr)
+}
+
+func (m *memory) write(addr uint64, b []byte) {
+	for start, data := range m.regions {
+		if addr >= start && addr+uint64(len(b)) <= start+uint64(len(data)) {
+			copy(data[addr-start:], b)
+			return
+		}
+	}
+	panic(fmt.Sprintf("no memory at 0x%x", addr))
+}
+
+func (m *memory) put32(addr uint64, v uint32) {
+	m.write(addr, binary.LittleEndian.AppendUint32(nil, v))
+}
+
+func (m *memory) put64(addr uint64, v uint64) {
+	m.write(addr, binary.LittleEndian.AppendUint64(nil, v))
+}
+
+// wantFrame is the expected instruction pointer, stack pointer and trust of
+// a frame.
+type wantFrame struct {
+	ip, sp uint64
+	trust  Trust
+}
+
+func checkFrames(t *testing.T, frames []Frame, want []wantFrame) {
+	t.Helper()
+	for i := range max(len(frames), len(want)) {
+		switch {
+		case i >= len(frames):
+			t.Errorf("frame %d: missing, want ip 0x%x sp 0x%x (%v)", i, want[i].ip, want[i].sp, want[i].trust)
+		case i >= len(want):
+			t.Errorf("frame %d: unexpected ip 0x%x sp 0x%x (%v)", i, frames[i].Context.IP, frames[i].Context.SP(), frames[i].Trust)
+		default:
+			got := wantFrame{frames[i].Context.IP, frames[i].Context.SP(), frames[i].Trust}
+			if got != want[i] {
+				t.Errorf("frame %d: ip 0x%x sp 0x%x (%v), want ip 0x%x sp 0x%x (%v)",
+					i, got.ip, got.sp, got.trust, want[i].ip, want[i].sp, want[i].trust)
+			}
+		}
+	}
+}
+
+func checkRegister(t *testing.T, frame Frame, reg int, want uint64) {
+	t.Helper()
+	v, ok := frame.Context.Register(reg)
+	if !ok {
+		t.Errorf("%s not recovered, want 0x%x", frame.Context.RegisterName(reg), want)
+	} else if v != want {
+		t.Errorf("%s = 0x%x, want 0x%x", frame.Context.RegisterName(reg), v, want)
+	}
+}
+
+func TestScanSkipsNonReturnAddresses(t *testing.T) {
+	const base, stack = 0x400000, 0x10000
+	mem := newMemory()
+	mem.alloc(stack, 0x100)
+	m := &Module{Name: "a.exe", Base: base, Size: 0x3000}
+	m.addSection(0x1000, 0x1000, 0x60000020)
+	w := &Walker{Memory: mem, Modules: []*Module{m}, ScanWords: 8}
+
+	// Neither data nor addresses outside the module's code are taken for
+	// return addresses
+	mem.put32(stack+0x00, 0x12345678)
+	mem.put32(stack+0x04, base+0x2100) // Outside .text
+	mem.put32(stack+0x08, base+0x1234)
+
+	frames := w.Walk(NewContext(ArchX86, base+0x1800, [16]uint64{RSP: stack}))
+	checkFrames(t, frames, []wantFrame{
+		{base + 0x1800, stack, TrustContext},
+		{base + 0x1234, stack + 0x0c, TrustScan},
+	})
+
+	// Only the callee-saved registers survive a scanned frame
+	if _, ok := frames[1].Context.Register(RAX); ok {
+		t.Error("eax recovered by a stack scan")
+	}
+}
+
+func TestScanLimit(t *testing.T) {
+	const base, stack = 0x400000, 0x10000
+	mem := newMemory()
+	mem.alloc(stack, 0x100)
+	m := &Module{Name: "a.exe", Base: base, Size: 0x3000}
+	w := &Walker{Memory: mem, Modules: []*Module{m}, ScanWords: 4}
+
+	// The first frame is scanned four times further than the others
+	mem.put32(stack+15*4, base+0x1000)
+	if frames := w.Walk(NewContext(ArchX86, base+0x1800, [16]uint64{RSP: stack})); len(frames) != 2 {
+		t.Errorf("found %d frames scanning 16 words, want 2", len(frames))
+	}
+
+	mem.put32(stack+15*4, 0)
+	mem.put32(stack+16*4, base+0x1000)
+	if frames := w.Walk(NewContext(ArchX86, base+0x1800, [16]uint64{RSP: stack})); len(frames) != 1 {
+		t.Errorf("found %d frames beyond the scanned words, want 1", len(frames))
+	}
+}
@@ -0,0 +1,268 @@
+package unwind
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Programs for functions whose frame data has no program of its own
+const (
+	// The return address is found past the locals and saved registers
+	programRASearch = "$eip .raSearch ^ = $esp .raSearch 4 + ="
+
+	// A standard frame: push ebp; mov ebp, esp
+	programFramePointer = "$eip $ebp 4 + ^ = $esp $ebp 8 + = $ebp $ebp ^ ="
+)
+
+// FPO frame types
+const (
+	fpoFrameFPO    = 0
+	fpoFrameNonFPO = 3
+)
+
+// x86FrameInfo is the frame layout of an x86 function at an address.
+type x86FrameInfo struct {
+	program   string
+	locals    uint32
+	savedRegs uint32
+	params    uint32
+}
+
+// x86Registers maps program register names to context registers.
+var x86Registers = map[string]int{
+	"$eax": RAX, "$ecx": RCX, "$edx": RDX, "$ebx": RBX,
+	"$esp": RSP, "$ebp": RBP, "$esi": RSI, "$edi": RDI,
+}
+
+// frameInfo returns the frame layout at an RVA. Frame data takes precedence
+// over FPO records.
+func (m *Module) frameInfo(rva uint32) (x86FrameInfo, bool) {
+	// Frame data entries may nest, so take the innermost one covering rva
+	i := sort.Search(len(m.FrameData), func(i int) bool { return m.FrameData[i].RVA > rva })
+	for j := i - 1; j >= 0 && j >= i-16; j-- {
+		fd := &m.FrameData[j]
+		if rva-fd.RVA >= fd.CodeSize {
+			continue
+		}
+		info := x86FrameInfo{
+			program:   fd.Program,
+			locals:    fd.LocalSize,
+			savedRegs: uint32(fd.SavedRegsSize),
+			params:    fd.ParamsSize,
+		}
+		if info.program == "" {
+			info.program = programRASearch
+		}
+		return info, true
+	}
+
+	i = sort.Search(len(m.FPO), func(i int) bool { return m.FPO[i].RVA > rva })
+	if i > 0 {
+		fpo := &m.FPO[i-1]
+		if rva-fpo.RVA < fpo.CodeSize && (fpo.FrameType == fpoFrameFPO || fpo.FrameType == fpoFrameNonFPO) {
+			info := x86FrameInfo{
+				program:   programRASearch,
+				locals:    fpo.Locals * 4,
+				savedRegs: uint32(fpo.SavedRegs) * 4,
+				params:    uint32(fpo.Params) * 4,
+			}
+			if fpo.UsesBP || fpo.FrameType == fpoFrameNonFPO {
+				info.program = programFramePointer
+			}
+			return info, true
+		}
+	}
+
+	return x86FrameInfo{}, false
+}
+
+// unwindX86 recovers the caller of an x86 frame from the frame data or FPO
+// record covering its instruction pointer.
+func (w *Walker) unwindX86(frame *Frame) (Frame, bool) {
+	ctx := frame.Context
+	m := frame.Module
+	if m == nil || !m.Contains(ctx.IP) {
+		return Frame{}, false
+	}
+
+	// Return addresses belong to the call instruction before them
+	rva := uint32(ctx.IP - m.Base)
+	if frame.Trust != TrustContext && rva > 0 {
+		rva--
+	}
+
+	info, ok := m.frameInfo(rva)
+	if !ok {
+		return Frame{}, false
+	}
+
+	vars := map[string]uint32{
+		"$eip":            uint32(ctx.IP),
+		".cbLocals":       info.locals,
+		".cbSavedRegs":    info.savedRegs,
+		".cbParams":       info.params,
+		".cbCalleeParams": frame.calleeParams,
+	}
+	for name, reg := range x86Registers {
+		if v, ok := ctx.Register(reg); ok {
+			vars[name] = uint32(v)
+		}
+	}
+
+	// The return address is expected right above the locals and saved
+	// registers; look further up when it is not found there
+	start := uint32(ctx.SP()) + frame.calleeParams + info.locals + info.savedRegs
+	vars[".raSearchStart"] = start
+	vars[".raSearch"] = start
+	if addr, _, ok := w.scanForReturnAddress(&ctx, uint64(start), w.scanWords()); ok {
+		vars[".raSearch"] = uint32(addr)
+	}
+
+	e := &evaluator{mem: w.Memory, vars: vars}
+	if err := e.run(info.program); err != nil {
+		return Frame{}, false
+	}
+
+	eip, ok1 := vars["$eip"]
+	esp, ok2 := vars["$esp"]
+	if !ok1 || !ok2 || !e.assigned["$eip"] || !e.assigned["$esp"] {
+		return Frame{}, false
+	}
+
+	caller := Frame{Context: Context{Arch: ArchX86, IP: uint64(eip)}, Trust: TrustUnwindInfo, calleeParams: info.params}
+	caller.Context.set(RSP, uint64(esp))
+	for name, reg := range x86Registers {
+		if reg == RSP {
+			continue
+		}
+		// Registers the program does not recover are kept if the callee
+		// preserves them
+		if v, ok := vars[name]; ok && (e.assigned[name] || calleeSaved(ArchX86)&(1<<reg) != 0) {
+			caller.Context.set(reg, uint64(v))
+		}
+	}
+	return caller, true
+}
+
+// evaluator runs the postfix programs of x86 frame data, such as
+//
+//	$T0 .raSearch = $eip $T0 ^ = $esp $T0 4 + =
+//
+// Operands are variables ($eip, $T0, .raSearch, ...) and numbers. The
+// operators are the binary + - * / % and @ (align down), ^ (dereference)
+// and = (assignment).
+type evaluator struct {
+	mem      MemoryReader
+	vars     map[string]uint32
+	assigned map[string]bool
+	stack    []string
+}
+
+func (e *evaluator) run(program string) error {
+	e.assigned = make(map[string]bool)
+	e.stack = e.stack[:0]
+
+	for _, tok := range strings.Fields(program) {
+		switch tok {
+		case "+", "-", "*", "/", "%", "@":
+			b, err := e.pop()
+			if err != nil {
+				return err
+			}
+			a, err := e.pop()
+			if err != nil {
+				return err
+			}
+			var v uint32
+			switch tok {
+			case "+":
+				v = a + b
+			case "-":
+				v = a - b
+			case "*":
+				v = a * b
+			case "/", "%", "@":
+				if b == 0 {
+					return fmt.Errorf("unwind: division by zero in %q", program)
+				}
+				switch tok {
+				case "/":
+					v = a / b
+				case "%":
+					v = a % b
+				case "@":
+					v = a &^ (b - 1)
+				}
+			}
+			e.push(v)
+
+		case "^":
+			addr, err := e.pop()
+			if err != nil {
+				return err
+			}
+			data, err := e.mem.ReadMemory(uint64(addr), 4)
+			if err != nil {
+				return err
+			}
+			e.push(binary.LittleEndian.Uint32(data))
+
+		case "=":
+			v, err := e.pop()
+			if err != nil {
+				return err
+			}
+			if len(e.stack) == 0 {
+				return fmt.Errorf("unwind: stack underflow in %q", program)
+			}
+			name := e.stack[len(e.stack)-1]
+			e.stack = e.stack[:len(e.stack)-1]
+			if !isVariable(name) {
+				return fmt.Errorf("unwind: assignment to %q in %q", name, program)
+			}
+			e.vars[name] = v
+			e.assigned[name] = true
+
+		default:
+			e.stack = append(e.stack, tok)
+		}
+	}
+
+	if len(e.stack) != 0 {
+		return fmt.Errorf("unwind: unbalanced program %q", program)
+	}
+	return nil
+}
+
+func (e *evaluator) push(v uint32) {
+	e.stack = append(e.stack, strconv.FormatUint(uint64(v), 10))
+}
+
+// pop returns the value of the top operand.
+func (e *evaluator) pop() (uint32, error) {
+	if len(e.stack) == 0 {
+		return 0, fmt.Errorf("unwind: stack underflow")
+	}
+	tok := e.stack[len(e.stack)-1]
+	e.stack = e.stack[:len(e.stack)-1]
+
+	if isVariable(tok) {
+		v, ok := e.vars[tok]
+		if !ok {
+			return 0, fmt.Errorf("unwind: undefined variable %s", tok)
+		}
+		return v, nil
+	}
+	v, err := strconv.ParseInt(tok, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unwind: invalid operand %q", tok)
+	}
+	return uint32(v), nil
+}
+
+func isVariable(tok string) bool {
+	return strings.HasPrefix(tok, "$") || strings.HasPrefix(tok, ".")
+}
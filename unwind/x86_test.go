@@ -0,0 +1,144 @@
+package unwind
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/skdltmxn/pdb-go/pdb"
+)
+
+// x86Module loads the frame data and FPO records of the x86 fixture:
+//
+//	0x1000 main          frame data, $ebp-based program
+//	0x1030 Widget::Draw  frame data, .raSearch program restoring ebx and esi
+//	0x1090 helper        FPO, no frame pointer
+//	0x10a0 Fast          FPO, standard frame
+func x86Module(t *testing.T, base uint64) *Module {
+	t.Helper()
+	f, err := pdb.Open(filepath.Join("..", "testdata", "x86.pdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	m := &Module{Name: "x86.exe", Base: base, Size: 0x3000}
+	if err := m.LoadPDB(f); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestWalkX86(t *testing.T) {
+	const (
+		base  = 0x400000
+		stack = 0x120000
+	)
+	mem := newMemory()
+	mem.alloc(stack, 0x200)
+	w := &Walker{Memory: mem, Modules: []*Module{x86Module(t, base)}}
+
+	// helper: 4 bytes of locals and one saved register below the return
+	// address, 8 bytes of parameters above it
+	mem.put32(stack+0x08, base+0x1040)
+
+	// Widget::Draw, after its prolog: the return address follows the
+	// callee's parameters, 0x10 bytes of locals and 8 of saved registers
+	const t0 = stack + 0x0c + 8 + 0x10 + 8
+	mem.put32(stack+0x1c, 0x51515151) // $esi at $T0 - 16
+	mem.put32(stack+0x20, 0xb0b0b0b0) // $ebx at $T0 - 12
+	mem.put32(t0, base+0x1020)
+
+	// main: ebp frame
+	const ebp0, ebp1 = stack + 0x48, stack + 0x60
+	mem.put32(ebp0, ebp1)
+	mem.put32(ebp0+4, base+0x10d5)
+
+	// start has no frame information; its ebp chain leads on
+	mem.put32(ebp1+4, base+0x10e5)
+
+	// Neither has the function at 0x10e0, and ebp is now 0
+	mem.put32(ebp1+8, 0x12345678)
+	mem.put32(ebp1+12, base+0x2000) // Outside .text
+	mem.put32(ebp1+16, base+0x10a5)
+
+	var regs [16]uint64
+	regs[RSP] = stack
+	regs[RBP] = ebp0
+	regs[RBX] = 0xbbbb
+	regs[RAX] = 0xaaaa
+	frames := w.Walk(NewContext(ArchX86, base+0x1095, regs))
+
+	checkFrames(t, frames, []wantFrame{
+		{base + 0x1095, stack, TrustContext},
+		{base + 0x1040, stack + 0x0c, TrustUnwindInfo},
+		{base + 0x1020, t0 + 4, TrustUnwindInfo},
+		{base + 0x10d5, ebp0 + 8, TrustUnwindInfo},
+		{base + 0x10e5, ebp1 + 8, TrustFramePointer},
+		{base + 0x10a5, ebp1 + 20, TrustScan},
+	})
+	if len(frames) < 4 {
+		return
+	}
+
+	// helper preserves the callee-saved registers and clobbers the others
+	checkRegister(t, frames[1], RBX, 0xbbbb)
+	checkRegister(t, frames[1], RBP, ebp0)
+	if _, ok := frames[1].Context.Register(RAX); ok {
+		t.Error("eax recovered for the caller of helper")
+	}
+
+	// Widget::Draw's program restores ebx and esi
+	checkRegister(t, frames[2], RBX, 0xb0b0b0b0)
+	checkRegister(t, frames[2], RSI, 0x51515151)
+
+	// main's program restores ebp
+	checkRegister(t, frames[3], RBP, ebp1)
+}
+
+func TestEvaluator(t *testing.T) {
+	mem := newMemory()
+	mem.alloc(0x1000, 0x10)
+	mem.put32(0x1004, 0xcafe)
+
+	tests := []struct {
+		program string
+		want    map[string]uint32
+		err     string
+	}{
+		{program: "$T0 4096 4 + ^ =", want: map[string]uint32{"$T0": 0xcafe}},
+		{program: "$T0 $esp 8 - = $T1 $T0 16 @ =", want: map[string]uint32{"$T0": 0x1ff8, "$T1": 0x1ff0}},
+		{program: "$T0 7 2 / = $T1 7 2 % = $T2 3 5 * =", want: map[string]uint32{"$T0": 3, "$T1": 1, "$T2": 15}},
+		{program: "$T0 0x10 1 - =", want: map[string]uint32{"$T0": 15}},
+		{program: "$T0 1 0 / =", err: "division by zero"},
+		{program: "$T0 1 0 @ =", err: "division by zero"},
+		{program: "$T0 $T9 =", err: "undefined variable"},
+		{program: "4 1 =", err: "assignment"},
+		{program: "$T0 1 = 2", err: "unbalanced"},
+		{program: "1 + $T0 =", err: "underflow"},
+		{program: "1 =", err: "underflow"},
+		{program: "$T0 x =", err: "invalid operand"},
+		{program: "4096 ^", err: "unbalanced"},
+		{program: "$T0 16 ^ =", err: "no memory"},
+	}
+
+	for _, tt := range tests {
+		e := &evaluator{mem: mem, vars: map[string]uint32{"$esp": 0x2000}}
+		err := e.run(tt.program)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: error %v, want %q", tt.program, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.program, err)
+			continue
+		}
+		for name, want := range tt.want {
+			if got := e.vars[name]; got != want || !e.assigned[name] {
+				t.Errorf("%q: %s = 0x%x (assigned %v), want 0x%x", tt.program, name, got, e.assigned[name], want)
+			}
+		}
+	}
+}